@@ -0,0 +1,21 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package handlers
+
+import (
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+// withErrorCode returns err with the error code of its cause, like a full disk, or with
+// code when its cause is unknown.
+func withErrorCode(err error, code fleetapi.ErrorCode, retryable bool) error {
+	if err == nil {
+		return nil
+	}
+	if ackErr := fleetapi.AckErrorFrom(err); ackErr.Code != fleetapi.ErrorCodeUnknown {
+		return fleetapi.NewAckError(ackErr.Code, ackErr.Retryable, err)
+	}
+	return fleetapi.NewAckError(code, retryable, err)
+}
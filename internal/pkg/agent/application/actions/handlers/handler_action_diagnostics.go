@@ -30,7 +30,7 @@ import (
 // This may occur if the user sends multiple diagnostics actions to an agent in a short duration
 // or if the agent goes offline and retrieves multiple diagnostics actions.
 // In either case the 1st action will succeed and the others will ack with an the error.
-var ErrRateLimit = fmt.Errorf("rate limit exceeded")
+var ErrRateLimit error = fleetapi.NewAckError(fleetapi.ErrorCodeRateLimited, true, fmt.Errorf("rate limit exceeded"))
 
 // getCPUDiag is a wrapper around diagnostics.CreateCPUProfile so it can be replaced in unit-tests.
 var getCPUDiag = func(ctx context.Context, d time.Duration) ([]byte, error) {
@@ -135,10 +135,10 @@ func (h *Diagnostics) collectDiag(ctx context.Context, action *fleetapi.ActionDi
 	h.log.Debug("Gathering agent diagnostics.")
 	aDiag, err := h.runHooks(ctx, action)
 	if err != nil {
-		action.Err = err
 		h.log.Errorw("diagnostics action handler failed to run diagnostics hooks",
 			"error.message", err,
 			"action", action)
+		action.Err = withErrorCode(err, fleetapi.ErrorCodeDiagnosticsFailed, true)
 		return
 	}
 	h.log.Debug("Gathering unit diagnostics.")
@@ -168,7 +168,7 @@ func (h *Diagnostics) collectDiag(ctx context.Context, action *fleetapi.ActionDi
 				"error.message", err,
 				"action", action,
 			)
-			action.Err = err
+			action.Err = withErrorCode(err, fleetapi.ErrorCodeDiagnosticsFailed, true)
 			return
 		}
 		r = &b
@@ -184,7 +184,7 @@ func (h *Diagnostics) collectDiag(ctx context.Context, action *fleetapi.ActionDi
 	uploadID, err := h.uploader.UploadDiagnostics(ctx, action.ActionID, ts.Format("2006-01-02T15-04-05Z07-00"), s, r) // RFC3339 format that uses - instead of : so it works on Windows
 	action.UploadID = uploadID
 	if err != nil {
-		action.Err = err
+		action.Err = withErrorCode(err, fleetapi.ErrorCodeUploadFailed, true)
 		h.log.Errorw(
 			"diagnostics action handler failed to upload diagnostics",
 			"error.message", err,
@@ -63,7 +63,7 @@ func (h *Migrate) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker
 	// if endpoint is present do not proceed
 	if h.tamperProtectionFn() && h.coord.HasEndpoint() {
 		err := errors.New("unsupported action: tamper protected agent")
-		h.ackFailure(ctx, err, action, ack)
+		h.ackFailure(ctx, fleetapi.NewAckError(fleetapi.ErrorCodeMigrateNotSupported, false, err), action, ack)
 		return err
 	}
 
@@ -75,7 +75,11 @@ func (h *Migrate) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker
 		}
 
 		// ack failure
-		h.ackFailure(ctx, err, action, ack)
+		if errors.Is(err, coordinator.ErrFleetServer) {
+			h.ackFailure(ctx, fleetapi.NewAckError(fleetapi.ErrorCodeMigrateNotSupported, false, err), action, ack)
+		} else {
+			h.ackFailure(ctx, withErrorCode(err, fleetapi.ErrorCodeMigrateFailed, false), action, ack)
+		}
 
 		if errors.Is(err, coordinator.ErrFleetServer) {
 			return errors.New("action not available for agents running Fleet Server")
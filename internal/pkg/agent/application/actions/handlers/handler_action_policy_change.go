@@ -102,12 +102,17 @@ func (h *PolicyChangeHandler) Handle(ctx context.Context, a fleetapi.Action, ack
 
 	c, err := config.NewConfigFrom(action.Data.Policy)
 	if err != nil {
-		return errors.New(err, "could not parse the configuration from the policy", errors.TypeConfig)
+		err = fleetapi.NewAckError(fleetapi.ErrorCodePolicyInvalid, false,
+			errors.New(err, "could not parse the configuration from the policy", errors.TypeConfig))
+		h.ackFailure(ctx, err, action, acker)
+		return err
 	}
 
 	h.log.Debugf("handlerPolicyChange: emit configuration for action %+v", a)
 	err = h.handlePolicyChange(ctx, c)
 	if err != nil {
+		err = withErrorCode(err, fleetapi.ErrorCodePolicyApplyFailed, true)
+		h.ackFailure(ctx, err, action, acker)
 		return err
 	}
 
@@ -115,6 +120,23 @@ func (h *PolicyChangeHandler) Handle(ctx context.Context, a fleetapi.Action, ack
 	return nil
 }
 
+// ackFailure acks the action with the error it failed with, so Fleet gets its code.
+func (h *PolicyChangeHandler) ackFailure(ctx context.Context, err error, action *fleetapi.ActionPolicyChange, acker acker.Acker) {
+	action.Err = err
+
+	if err := acker.Ack(ctx, action); err != nil {
+		h.log.Errorw("failed to ack policy change action",
+			"error.message", err,
+			"action", action)
+	}
+
+	if err := acker.Commit(ctx); err != nil {
+		h.log.Errorw("failed to commit policy change action",
+			"error.message", err,
+			"action", action)
+	}
+}
+
 // Watch returns the channel for configuration change notifications.
 func (h *PolicyChangeHandler) Watch() <-chan coordinator.ConfigChange {
 	return h.ch
@@ -258,7 +280,7 @@ func (h *PolicyChangeHandler) handlePolicyChange(ctx context.Context, c *config.
 	}
 
 	if validationErr != nil {
-		return validationErr
+		return fleetapi.NewAckError(fleetapi.ErrorCodePolicyInvalid, false, validationErr)
 	}
 
 	// apply logging configuration
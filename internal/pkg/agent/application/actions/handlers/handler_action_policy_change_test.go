@@ -8,6 +8,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"encoding/json"
 	"fmt"
 	"net/http"
 	"net/http/httptest"
@@ -132,6 +133,44 @@ func TestPolicyAcked(t *testing.T) {
 		assert.EqualValues(t, 1, len(actions))
 		assert.Equal(t, actionID, actions[0])
 	})
+
+	t.Run("Invalid policy should ACK with the error code", func(t *testing.T) {
+		ch := make(chan coordinator.ConfigChange, 1)
+		macker := &MockAcker{}
+
+		action := &fleetapi.ActionPolicyChange{
+			ActionID:   "abc123",
+			ActionType: "POLICY_CHANGE",
+			Data: fleetapi.ActionPolicyChangeData{
+				Policy: map[string]interface{}{"agent.logging.level": "not-a-level"},
+			},
+		}
+
+		cfg := configuration.DefaultConfiguration()
+		handler := NewPolicyChangeHandler(log, agentInfo, cfg, nullStore, ch, mockhandlers.NewLogLevelSetter(t), &coordinator.Coordinator{})
+
+		err := handler.Handle(context.Background(), action, macker)
+		require.Error(t, err)
+		assert.Equal(t, fleetapi.ErrorCodePolicyInvalid, fleetapi.AckErrorFrom(err).Code)
+		assert.Empty(t, ch, "an invalid policy must not be emitted")
+
+		require.Len(t, macker.Acked, 1)
+		raw, err := json.Marshal(macker.Acked[0].AckEvent())
+		require.NoError(t, err)
+		var event struct {
+			Error   string `json:"error"`
+			Payload struct {
+				Error struct {
+					Code      string `json:"code"`
+					Retryable bool   `json:"retryable"`
+				} `json:"error"`
+			} `json:"payload"`
+		}
+		require.NoError(t, json.Unmarshal(raw, &event))
+		assert.Contains(t, event.Error, "validating logging config")
+		assert.Equal(t, string(fleetapi.ErrorCodePolicyInvalid), event.Payload.Error.Code)
+		assert.False(t, event.Payload.Error.Retryable)
+	})
 }
 
 func TestPolicyChangeHandler_handlePolicyChange_FleetClientSettings(t *testing.T) {
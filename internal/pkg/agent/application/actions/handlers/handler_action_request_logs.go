@@ -0,0 +1,183 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package handlers
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
+)
+
+// DefaultRequestLogsMaxSize is the maximum uncompressed size of the logs collected by a
+// RequestLogs action. Actions can only request a smaller size.
+const DefaultRequestLogsMaxSize int64 = 100 * 1024 * 1024 // 100MiB
+
+// LogsUploader is the interface used to upload a logs bundle to fleet-server.
+type LogsUploader interface {
+	UploadLogs(context.Context, string, string, int64, io.Reader) (string, error)
+}
+
+// RequestLogs is the handler to process RequestLogs actions.
+// When a RequestLogs action is received the agent and component logs selected by the
+// action are bundled, redacted and uploaded to fleet-server.
+type RequestLogs struct {
+	log      abstractLogger
+	limiter  *rate.Limiter
+	uploader LogsUploader
+	topPath  string
+	maxSize  int64
+}
+
+// NewRequestLogs returns a new RequestLogs handler.
+func NewRequestLogs(log abstractLogger, topPath string, cfg config.Limit, uploader LogsUploader) *RequestLogs {
+	if topPath == "" {
+		topPath = paths.Top()
+	}
+	return &RequestLogs{
+		log:      log,
+		limiter:  rate.NewLimiter(rate.Every(cfg.Interval), cfg.Burst),
+		uploader: uploader,
+		topPath:  topPath,
+		maxSize:  DefaultRequestLogsMaxSize,
+	}
+}
+
+// Handle processes the passed RequestLogs action asynchronously.
+//
+// The handler shares the rate limit configuration of the diagnostics handler, but has its own limiter.
+func (h *RequestLogs) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker) error {
+	h.log.Debugf("handlerRequestLogs: action '%+v' received", a)
+	action, ok := a.(*fleetapi.ActionRequestLogs)
+	if !ok {
+		return fmt.Errorf("invalid type, expected ActionRequestLogs and received %T", a)
+	}
+	go h.collectLogs(ctx, action, ack)
+	return nil
+}
+
+// filter returns the logs filter of the action, capped to the handler maximum size.
+func (h *RequestLogs) filter(action *fleetapi.ActionRequestLogs) diagnostics.LogsFilter {
+	maxSize := h.maxSize
+	if action.Data.MaxSize > 0 && action.Data.MaxSize < maxSize {
+		maxSize = action.Data.MaxSize
+	}
+	return diagnostics.LogsFilter{
+		Since:         action.Data.Since,
+		Until:         action.Data.Until,
+		Components:    action.Data.Components,
+		ExcludeEvents: action.Data.ExcludeEventsLog,
+		MaxSize:       maxSize,
+	}
+}
+
+// collectLogs assembles the logs bundle and uploads it with the file upload APIs on fleet-server.
+//
+// The bundle is assembled on disk, however if it encounters any errors an in-memory-buffer is used.
+func (h *RequestLogs) collectLogs(ctx context.Context, action *fleetapi.ActionRequestLogs, ack acker.Acker) {
+	ts := time.Now().UTC()
+	defer func() {
+		if r := recover(); r != nil {
+			err := fmt.Errorf("panic detected: %v", r)
+			action.Err = err
+			h.log.Errorw("request logs handler panicked", "error.message", err)
+		}
+	}()
+	defer func() {
+		err := ack.Ack(ctx, action)
+		if err != nil {
+			h.log.Errorw("failed to ack request logs action",
+				"error.message", err,
+				"action", action)
+		}
+		err = ack.Commit(ctx)
+		if err != nil {
+			h.log.Errorw("failed to commit request logs action",
+				"error.message", err,
+				"action", action)
+		}
+	}()
+
+	if !h.limiter.Allow() {
+		action.Err = ErrRateLimit
+		h.log.Infof("request logs action handler rate limited: %v", ErrRateLimit)
+		return
+	}
+
+	filter := h.filter(action)
+	var wBuf bytes.Buffer
+	defer func() {
+		if str := wBuf.String(); str != "" {
+			h.log.Warn(str)
+		}
+	}()
+
+	var r io.Reader
+	var s int64
+	f, err := os.CreateTemp(paths.TempDir(), "elastic-agent-logs")
+	if err == nil {
+		defer func() {
+			f.Close()
+			os.Remove(f.Name())
+		}()
+		s, err = zipLogsToFile(&wBuf, f, h.topPath, filter)
+		r = f
+	}
+	if err != nil {
+		h.log.Warnw("Request logs action unable to use temporary file, using buffer instead.", "error.message", err)
+		var b bytes.Buffer
+		if err := diagnostics.ZipLogs(&wBuf, &b, h.topPath, filter); err != nil {
+			h.log.Errorw(
+				"request logs action handler failed generate zip archive",
+				"error.message", err,
+				"action", action,
+			)
+			action.Err = withErrorCode(err, fleetapi.ErrorCodeDiagnosticsFailed, true)
+			return
+		}
+		r = &b
+		s = int64(b.Len())
+	}
+
+	h.log.Debug("Sending logs archive.")
+	uploadID, err := h.uploader.UploadLogs(ctx, action.ActionID, ts.Format("2006-01-02T15-04-05Z07-00"), s, r) // RFC3339 format that uses - instead of : so it works on Windows
+	action.UploadID = uploadID
+	if err != nil {
+		action.Err = withErrorCode(err, fleetapi.ErrorCodeUploadFailed, true)
+		h.log.Errorw(
+			"request logs action handler failed to upload logs",
+			"error.message", err,
+			"action", action)
+		return
+	}
+	elapsed := time.Since(ts)
+	h.log.Debugw(fmt.Sprintf("Request logs action complete. Took %s", elapsed), "action", action, "elapsed", elapsed)
+}
+
+// zipLogsToFile writes the logs bundle to f and returns its size, with f positioned at its start.
+func zipLogsToFile(errOut io.Writer, f *os.File, topPath string, filter diagnostics.LogsFilter) (int64, error) {
+	if err := diagnostics.ZipLogs(errOut, f, topPath, filter); err != nil {
+		return 0, err
+	}
+	_ = f.Sync()
+	if _, err := f.Seek(0, 0); err != nil {
+		return 0, err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		return 0, err
+	}
+	return fi.Size(), nil
+}
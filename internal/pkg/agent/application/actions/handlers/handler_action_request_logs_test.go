@@ -0,0 +1,135 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+	mockackers "github.com/elastic/elastic-agent/testing/mocks/internal_/pkg/fleetapi/acker"
+)
+
+type fakeLogsUploader struct {
+	actionID string
+	data     []byte
+	err      error
+}
+
+func (u *fakeLogsUploader) UploadLogs(_ context.Context, actionID string, _ string, _ int64, r io.Reader) (string, error) {
+	if u.err != nil {
+		return "", u.err
+	}
+	u.actionID = actionID
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return "", err
+	}
+	u.data = data
+	return "upload-id", nil
+}
+
+func TestRequestLogsHandler(t *testing.T) {
+	topPath := t.TempDir()
+	logsDir := filepath.Join(paths.HomeFrom(topPath), "logs")
+	require.NoError(t, os.MkdirAll(logsDir, 0o700))
+	require.NoError(t, os.WriteFile(
+		filepath.Join(logsDir, "elastic-agent.ndjson"),
+		[]byte(`{"@timestamp":"2024-05-01T09:00:00.000Z","log.level":"info","message":"agent starting"}`+"\n"),
+		0o600))
+
+	t.Run("happy path", func(t *testing.T) {
+		log, _ := loggertest.New(t.Name())
+		uploader := &fakeLogsUploader{}
+		handler := NewRequestLogs(log, topPath, defaultRateLimit, uploader)
+
+		mockAcker := mockackers.NewAcker(t)
+		mockAcker.EXPECT().Ack(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, a fleetapi.Action) error {
+			require.IsType(t, &fleetapi.ActionRequestLogs{}, a)
+			action := a.(*fleetapi.ActionRequestLogs)
+			assert.NoError(t, action.Err)
+			assert.Equal(t, "upload-id", action.UploadID)
+			return nil
+		})
+		mockAcker.EXPECT().Commit(mock.Anything).Return(nil)
+
+		handler.collectLogs(context.Background(), &fleetapi.ActionRequestLogs{ActionID: "action-1"}, mockAcker)
+
+		assert.Equal(t, "action-1", uploader.actionID)
+		r, err := zip.NewReader(bytes.NewReader(uploader.data), int64(len(uploader.data)))
+		require.NoError(t, err)
+		var names []string
+		for _, f := range r.File {
+			names = append(names, f.Name)
+		}
+		assert.Contains(t, names, diagnostics.LogsManifestFilename)
+		assert.Contains(t, names, "logs/"+filepath.Base(paths.HomeFrom(topPath))+"/elastic-agent.ndjson")
+	})
+
+	t.Run("upload failure", func(t *testing.T) {
+		log, _ := loggertest.New(t.Name())
+		uploadErr := errors.New("upload failed")
+		handler := NewRequestLogs(log, topPath, defaultRateLimit, &fakeLogsUploader{err: uploadErr})
+
+		mockAcker := mockackers.NewAcker(t)
+		mockAcker.EXPECT().Ack(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, a fleetapi.Action) error {
+			assert.ErrorIs(t, a.(*fleetapi.ActionRequestLogs).Err, uploadErr)
+			return nil
+		})
+		mockAcker.EXPECT().Commit(mock.Anything).Return(nil)
+
+		handler.collectLogs(context.Background(), &fleetapi.ActionRequestLogs{ActionID: "action-1"}, mockAcker)
+	})
+
+	t.Run("rate limited", func(t *testing.T) {
+		log, _ := loggertest.New(t.Name())
+		uploader := &fakeLogsUploader{}
+		handler := NewRequestLogs(log, topPath, config.Limit{Interval: 1, Burst: 0}, uploader)
+
+		mockAcker := mockackers.NewAcker(t)
+		mockAcker.EXPECT().Ack(mock.Anything, mock.Anything).RunAndReturn(func(_ context.Context, a fleetapi.Action) error {
+			assert.ErrorIs(t, a.(*fleetapi.ActionRequestLogs).Err, ErrRateLimit)
+			return nil
+		})
+		mockAcker.EXPECT().Commit(mock.Anything).Return(nil)
+
+		handler.collectLogs(context.Background(), &fleetapi.ActionRequestLogs{ActionID: "action-1"}, mockAcker)
+		assert.Nil(t, uploader.data)
+	})
+}
+
+func TestRequestLogsFilter(t *testing.T) {
+	log, _ := loggertest.New(t.Name())
+	handler := NewRequestLogs(log, t.TempDir(), defaultRateLimit, &fakeLogsUploader{})
+
+	filter := handler.filter(&fleetapi.ActionRequestLogs{Data: fleetapi.ActionRequestLogsData{
+		Components:       []string{"filestream-default"},
+		ExcludeEventsLog: true,
+	}})
+	assert.Equal(t, DefaultRequestLogsMaxSize, filter.MaxSize)
+	assert.Equal(t, []string{"filestream-default"}, filter.Components)
+	assert.True(t, filter.ExcludeEvents)
+
+	filter = handler.filter(&fleetapi.ActionRequestLogs{Data: fleetapi.ActionRequestLogsData{MaxSize: 1024}})
+	assert.Equal(t, int64(1024), filter.MaxSize)
+
+	// actions cannot raise the cap
+	filter = handler.filter(&fleetapi.ActionRequestLogs{Data: fleetapi.ActionRequestLogsData{MaxSize: 2 * DefaultRequestLogsMaxSize}})
+	assert.Equal(t, DefaultRequestLogsMaxSize, filter.MaxSize)
+}
@@ -79,6 +79,8 @@ func (h *Unenroll) handle(ctx context.Context, a fleetapi.Action, acker acker.Ac
 		if len(ucs) > 0 {
 			err := notifyUnitsOfProxiedAction(ctx, h.log, action, ucs, h.coord.PerformAction)
 			if err != nil {
+				err = withErrorCode(err, fleetapi.ErrorCodeUnenrollFailed, true)
+				h.ackFailure(ctx, err, action, acker)
 				return err
 			}
 		} else {
@@ -114,3 +116,20 @@ func (h *Unenroll) handle(ctx context.Context, a fleetapi.Action, acker acker.Ac
 
 	return nil
 }
+
+// ackFailure acks the action with the error it failed with, so Fleet gets its code.
+func (h *Unenroll) ackFailure(ctx context.Context, err error, action *fleetapi.ActionUnenroll, acker acker.Acker) {
+	action.Err = err
+
+	if err := acker.Ack(ctx, action); err != nil {
+		h.log.Errorw("failed to ack unenroll action",
+			"error.message", err,
+			"action", action)
+	}
+
+	if err := acker.Commit(ctx); err != nil {
+		h.log.Errorw("failed to commit unenroll action",
+			"error.message", err,
+			"action", action)
+	}
+}
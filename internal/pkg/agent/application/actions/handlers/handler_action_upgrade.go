@@ -10,6 +10,8 @@ import (
 	"fmt"
 	"sync"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
 	"github.com/elastic/elastic-agent/pkg/core/logger"
@@ -50,6 +52,10 @@ func (h *Upgrade) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker
 		return fmt.Errorf("invalid type, expected ActionUpgrade and received %T", a)
 	}
 
+	if action.Data.Rollback {
+		return h.handleRollback(ctx, action, ack)
+	}
+
 	asyncCtx, runAsync := h.getAsyncContext(ctx, a, ack)
 	if !runAsync {
 		return nil
@@ -79,7 +85,13 @@ func (h *Upgrade) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker
 			h.log.Errorf("upgrade to version %s failed: %v", action.Data.Version, err)
 			// If context is cancelled in getAsyncContext, the actions are acked there
 			if !errors.Is(asyncCtx.Err(), context.Canceled) {
+				err = upgradeErrorWithCode(err)
 				h.bkgMutex.Lock()
+				for _, bkgAction := range h.bkgActions {
+					if upgradeAction, ok := bkgAction.(*fleetapi.ActionUpgrade); ok {
+						upgradeAction.Err = err
+					}
+				}
 				h.ackActions(asyncCtx, ack)
 				h.bkgMutex.Unlock()
 			}
@@ -88,6 +100,24 @@ func (h *Upgrade) Handle(ctx context.Context, a fleetapi.Action, ack acker.Acker
 	return nil
 }
 
+// handleRollback rolls the agent back to the retained install of the action version, acks
+// the action and re-executes the agent to run the install rolled back to.
+func (h *Upgrade) handleRollback(ctx context.Context, action *fleetapi.ActionUpgrade, ack acker.Acker) error {
+	h.log.Infof("rolling back to version %q", action.Data.Version)
+	version, err := h.coord.Rollback(ctx, action.Data.Version)
+	if err != nil {
+		h.log.Errorf("rollback to version %q failed: %v", action.Data.Version, err)
+		action.Err = rollbackErrorWithCode(err)
+		h.ackAction(ctx, ack, action, true)
+		return fmt.Errorf("rollback to version %q failed: %w", action.Data.Version, err)
+	}
+
+	h.log.Infof("rolled back to version %s, restarting", version)
+	h.ackAction(ctx, ack, action, true)
+	h.coord.ReExec(nil)
+	return nil
+}
+
 // ackActions Acks all the actions in bkgActions, and deletes entries from bkgActions.
 // User is responsible for obtaining and releasing bkgMutex lock
 func (h *Upgrade) ackActions(ctx context.Context, ack acker.Acker) {
@@ -169,3 +199,27 @@ func (h *Upgrade) getAsyncContext(ctx context.Context, action fleetapi.Action, a
 	h.bkgCancel = cancel
 	return c, true
 }
+
+// upgradeErrorWithCode returns the error of a failed upgrade with its error code.
+func upgradeErrorWithCode(err error) error {
+	switch {
+	case errors.Is(err, coordinator.ErrUpgradeInProgress):
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeInProgress, true, err)
+	case errors.Is(err, coordinator.ErrNotUpgradable):
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeNotAllowed, false, err)
+	}
+	return upgrade.ErrorWithCode(err, fleetapi.ErrorCodeUpgradeFailed)
+}
+
+// rollbackErrorWithCode returns the error of a failed rollback with its error code.
+func rollbackErrorWithCode(err error) error {
+	switch {
+	case errors.Is(err, coordinator.ErrUpgradeInProgress), errors.Is(err, upgrade.ErrRollbackUpgradeWatched):
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeInProgress, true, err)
+	case errors.Is(err, coordinator.ErrNotUpgradable):
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeNotAllowed, false, err)
+	case errors.Is(err, upgrade.ErrNoRetainedInstall):
+		return fleetapi.NewAckError(fleetapi.ErrorCodeRollbackNotAvailable, false, err)
+	}
+	return withErrorCode(err, fleetapi.ErrorCodeRollbackFailed, false)
+}
@@ -18,6 +18,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/reexec"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
@@ -69,6 +70,14 @@ func (u *mockUpgradeManager) Upgrade(
 		pgpBytes...)
 }
 
+func (u *mockUpgradeManager) Rollback(_ context.Context, version string) (string, error) {
+	return version, nil
+}
+
+func (u *mockUpgradeManager) Preflight(_ context.Context, _ string, _ string, _ *details.Details, _ bool, _ bool, _ ...string) *upgrade.PreflightReport {
+	return &upgrade.PreflightReport{}
+}
+
 func (u *mockUpgradeManager) Ack(_ context.Context, _ acker.Acker) error {
 	return nil
 }
@@ -381,3 +390,46 @@ func (f *fakeAcker) Commit(ctx context.Context) error {
 	args := f.Called(ctx)
 	return args.Error(0)
 }
+
+func TestUpgradeErrorWithCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code fleetapi.ErrorCode
+	}{
+		{"in progress", coordinator.ErrUpgradeInProgress, fleetapi.ErrorCodeUpgradeInProgress},
+		{"not upgradable", coordinator.ErrNotUpgradable, fleetapi.ErrorCodeUpgradeNotAllowed},
+		{"same version", upgrade.ErrUpgradeSameVersion, fleetapi.ErrorCodeUpgradeNotAllowed},
+		{"checksum mismatch", &download.ChecksumMismatchError{File: "elastic-agent.tar.gz"}, fleetapi.ErrorCodeUpgradeChecksumMismatch},
+		{"already coded", fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeDownloadFailed, true, errors.New("download failed")), fleetapi.ErrorCodeUpgradeDownloadFailed},
+		{"other", errors.New("failed"), fleetapi.ErrorCodeUpgradeFailed},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := upgradeErrorWithCode(tc.err)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.code, fleetapi.AckErrorFrom(err).Code)
+		})
+	}
+}
+
+func TestRollbackErrorWithCode(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		code fleetapi.ErrorCode
+	}{
+		{"in progress", coordinator.ErrUpgradeInProgress, fleetapi.ErrorCodeUpgradeInProgress},
+		{"watched", upgrade.ErrRollbackUpgradeWatched, fleetapi.ErrorCodeUpgradeInProgress},
+		{"not upgradable", coordinator.ErrNotUpgradable, fleetapi.ErrorCodeUpgradeNotAllowed},
+		{"not retained", upgrade.ErrNoRetainedInstall, fleetapi.ErrorCodeRollbackNotAvailable},
+		{"other", errors.New("failed"), fleetapi.ErrorCodeRollbackFailed},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			err := rollbackErrorWithCode(tc.err)
+			require.ErrorIs(t, err, tc.err)
+			require.Equal(t, tc.code, fleetapi.AckErrorFrom(err).Code)
+		})
+	}
+}
@@ -29,7 +29,9 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/composable/providers/kubernetes"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
+	fileacker "github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/file"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/fleet"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/journal"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/lazy"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/retrier"
 	fleetclient "github.com/elastic/elastic-agent/internal/pkg/fleetapi/client"
@@ -140,6 +142,7 @@ func New(
 
 	var configMgr coordinator.ConfigManager
 	var managed *managedConfigManager
+	var local *localActionsConfigManager
 	var compModifiers = []coordinator.ComponentsModifier{InjectAPMConfig}
 	var composableManaged bool
 	var isManaged bool
@@ -167,6 +170,31 @@ func New(
 			log.Debugf("Reloading of configuration is on, frequency is set to %s", cfg.Settings.Reload.Period)
 			configMgr = newPeriodic(log, cfg.Settings.Reload.Period, discover, loader)
 		}
+
+		if cfg.Settings.LocalActions != nil && cfg.Settings.LocalActions.Enabled {
+			log.Info("Local actions are enabled, actions are accepted from the local actions directory")
+
+			localStore, err := storage.NewEncryptedDiskStore(ctx, paths.LocalActionsStateFile())
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error instantiating local actions store: %w", err)
+			}
+			stateStorage, err := stateStore.NewStateStore(log, localStore)
+			if err != nil {
+				return nil, nil, nil, errors.New(err, fmt.Sprintf("fail to read local actions store '%s'", paths.LocalActionsStateFile()))
+			}
+			resultsFile := cfg.Settings.LocalActions.ResultsFile
+			if resultsFile == "" {
+				resultsFile = paths.LocalActionResultsFile()
+			}
+			actionAcker = fileacker.NewAcker(log, agentInfo, resultsFile)
+
+			// TODO: stop using global state
+			local, err = newLocalActionsConfigManager(log, agentInfo, cfg, configMgr, stateStorage, actionAcker, paths.Top())
+			if err != nil {
+				return nil, nil, nil, err
+			}
+			configMgr = local
+		}
 	} else {
 		isManaged = true
 		var store storage.Store
@@ -203,13 +231,27 @@ func New(
 				return nil, nil, nil, errors.New(err, fmt.Sprintf("fail to read state store '%s'", paths.AgentStateStoreFile()))
 			}
 
-			fleetAcker, err := fleet.NewAcker(log, agentInfo, client)
+			ackJournalStore, err := storage.NewEncryptedDiskStore(ctx, paths.AckJournalFile())
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("error instantiating ack journal store: %w", err)
+			}
+			ackJournal, err := journal.New(ackJournalStore, log)
+			if err != nil {
+				return nil, nil, nil, fmt.Errorf("failed to create ack journal: %w", err)
+			}
+
+			fleetAcker, err := fleet.NewAcker(log, agentInfo, client, fleet.WithJournal(ackJournal))
 			if err != nil {
 				return nil, nil, nil, fmt.Errorf("failed to create acker: %w", err)
 			}
 
 			retrier := retrier.New(fleetAcker, log)
-			batchedAcker := lazy.NewAcker(fleetAcker, log, lazy.WithRetrier(retrier))
+			batchedAcker := lazy.NewAcker(fleetAcker, log, lazy.WithRetrier(retrier), lazy.WithJournal(ackJournal))
+			if pending := ackJournal.Pending(); len(pending) > 0 {
+				// acks that were not delivered before the agent stopped, the retrier sends them once running
+				log.Infof("Replaying %d acks from the ack journal", len(pending))
+				retrier.Enqueue(pending)
+			}
 			actionAcker = stateStore.NewStateStoreActionAcker(batchedAcker, stateStorage)
 
 			// TODO: stop using global state
@@ -235,6 +277,12 @@ func New(
 		// the coordinator requires the config manager as well as in managed-mode the config manager requires the
 		// coordinator, so it must be set here once the coordinator is created
 		managed.coord = coord
+		coord.SetActionsManager(managed.dispatcher)
+	}
+	if local != nil {
+		// same as in managed-mode, the local actions handlers require the coordinator
+		local.coord = coord
+		coord.SetActionsManager(local.dispatcher)
 	}
 
 	// every time we change the limits we'll see the log message
@@ -26,6 +26,7 @@ import (
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent-libs/logp"
 
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/dispatcher"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/enroll"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
@@ -58,6 +59,9 @@ const (
 
 var ErrNotManaged = errors.New("unmanaged agent")
 
+// ErrActionsNotManaged is returned when actions are requested from a standalone agent without local actions.
+var ErrActionsNotManaged = errors.New("actions are only available when the Elastic Agent is managed by Fleet or local actions are enabled")
+
 var ErrFleetServer = errors.New("unsupported action: agent runs Fleet server")
 
 // ErrNotUpgradable error is returned when upgrade cannot be performed.
@@ -86,6 +90,12 @@ type UpgradeManager interface {
 	// Upgrade upgrades running agent.
 	Upgrade(ctx context.Context, version string, sourceURI string, action *fleetapi.ActionUpgrade, details *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) (_ reexec.ShutdownCallbackFn, err error)
 
+	// Rollback relinks the agent to a retained install, the agent must be re-executed to run it.
+	Rollback(ctx context.Context, version string) (string, error)
+
+	// Preflight runs the pre-flight checks of an upgrade without upgrading.
+	Preflight(ctx context.Context, version string, sourceURI string, details *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) *upgrade.PreflightReport
+
 	// Ack is used on startup to check if the agent has upgraded and needs to send an ack for the action
 	Ack(ctx context.Context, acker acker.Acker) error
 
@@ -146,6 +156,18 @@ type RuntimeManager interface {
 	// PerformComponentDiagnostics executes the diagnostic action for the provided components. If no components are provided,
 	// then it performs the diagnostics for all current units.
 	PerformComponentDiagnostics(ctx context.Context, additionalMetrics []cproto.AdditionalDiagnosticRequest, req ...component.Component) ([]runtime.ComponentDiagnostic, error)
+
+	// PauseComponent stops a running component while keeping it in the components model.
+	PauseComponent(componentID string) error
+
+	// ResumeComponent starts a previously paused component.
+	ResumeComponent(componentID string) error
+
+	// RestartComponent stops and starts a running component.
+	RestartComponent(componentID string) error
+
+	// SetLogLevelOverride temporarily overrides the log level of the matching components and units.
+	SetLogLevelOverride(componentID string, unitID string, level client.UnitLogLevel, ttl time.Duration) error
 }
 
 // OTelManager provides an interface to run components and plain otel configurations in an otel collector.
@@ -218,6 +240,20 @@ type VarsManager interface {
 	Watch() <-chan []*transpiler.Vars
 }
 
+// ActionsManager provides an interface to inspect and cancel the actions handled by the
+// Fleet action dispatcher.
+type ActionsManager interface {
+	// QueuedActions returns the scheduled actions waiting in the queue.
+	QueuedActions() []fleetapi.ScheduledAction
+
+	// CancelQueuedAction removes a scheduled action from the queue, acks it as cancelled
+	// and clears the upgrade details when it was the scheduled upgrade.
+	CancelQueuedAction(ctx context.Context, detailsSetter details.Observer, acker acker.Acker, actionID string) (int, error)
+
+	// ActionHistory returns the last handled actions.
+	ActionHistory() []dispatcher.HandledAction
+}
+
 // ComponentsModifier is a function that takes the computed components model and modifies it before
 // passing it into the components runtime manager.
 type ComponentsModifier func(comps []component.Component, cfg map[string]interface{}) ([]component.Component, error)
@@ -252,6 +288,9 @@ type Coordinator struct {
 	configMgr  ConfigManager
 	varsMgr    VarsManager
 
+	// actionsMgr is only set when the Elastic Agent is managed by Fleet or accepts local actions.
+	actionsMgr ActionsManager
+
 	otelMgr OTelManager
 	otelCfg *confmap.Conf
 
@@ -359,6 +398,14 @@ type Coordinator struct {
 	// run a ticker that checks to see if we have a new PID.
 	componentPIDTicker         *time.Ticker
 	componentPidRequiresUpdate *atomic.Bool
+
+	// preflightRunning is set while the pre-flight checks of an upgrade run, they download
+	// the artifact to the same path as an upgrade.
+	preflightRunning atomic.Bool
+
+	// stateJournal records the component and unit state transitions.
+	// Written by watchRuntimeComponents, read by external goroutines.
+	stateJournal *StateJournal
 }
 
 // The channels Coordinator reads to receive updates from the various managers.
@@ -479,6 +526,8 @@ func New(
 		componentPidRequiresUpdate: &atomic.Bool{},
 
 		fleetAcker: fleetAcker,
+
+		stateJournal: newStateJournal(logger, cfg),
 	}
 	// Setup communication channels for any non-nil components. This pattern
 	// lets us transparently accept nil managers / simulated events during
@@ -520,6 +569,29 @@ func New(
 	return c
 }
 
+// newStateJournal creates the journal of state transitions from the configuration,
+// falling back to a journal kept only in memory when the on-disk journal can't be used.
+func newStateJournal(log *logger.Logger, cfg *configuration.Configuration) *StateJournal {
+	journalCfg := configuration.DefaultStateJournalConfig()
+	if cfg != nil && cfg.Settings != nil && cfg.Settings.StateJournal != nil {
+		journalCfg = cfg.Settings.StateJournal
+	}
+	size := journalCfg.Size
+	if size <= 0 {
+		size = configuration.DefaultStateJournalConfig().Size
+	}
+	if journalCfg.Persist {
+		journal, err := NewStateJournal(size, paths.StateJournalFile())
+		if err == nil {
+			return journal
+		}
+		log.Warnf("Failed to load the state journal from disk, keeping it in memory only: %s", err)
+	}
+	// can't fail with a positive size and no path
+	journal, _ := NewStateJournal(size, "")
+	return journal
+}
+
 // State returns the current state for the coordinator.
 // Called by external goroutines.
 func (c *Coordinator) State() State {
@@ -546,6 +618,12 @@ func (c *Coordinator) RegisterMonitoringServer(s configReloader) {
 	c.monitoringServerReloader = s
 }
 
+// SetActionsManager sets the manager used to inspect and cancel Fleet actions.
+// Must be called before Run.
+func (c *Coordinator) SetActionsManager(m ActionsManager) {
+	c.actionsMgr = m
+}
+
 // StateSubscribe returns a channel that reports changes in Coordinator state.
 //
 // bufferLen specifies how many state changes should be queued in addition to
@@ -593,6 +671,19 @@ func (c *Coordinator) ReExec(callback reexec.ShutdownCallbackFn, argOverrides ..
 	c.reexecMgr.ReExec(callback, argOverrides...)
 }
 
+// Rollback rolls the agent back to the retained install of version, the most recent
+// retained install when version is empty, and returns the version rolled back to. The
+// agent must be re-executed to run it.
+func (c *Coordinator) Rollback(ctx context.Context, version string) (string, error) {
+	if !c.upgradeMgr.Upgradeable() {
+		return "", ErrNotUpgradable
+	}
+	if c.State().State == agentclient.Upgrading {
+		return "", ErrUpgradeInProgress
+	}
+	return c.upgradeMgr.Rollback(ctx, version)
+}
+
 // Migrate migrates agent to a new cluster and ACKs success to the old one.
 // In case of failure no ack is performed and error is returned.
 func (c *Coordinator) Migrate(ctx context.Context, action *fleetapi.ActionMigrate, backoffFactory func(done <-chan struct{}) backoff.Backoff) error {
@@ -728,6 +819,10 @@ func (c *Coordinator) Upgrade(ctx context.Context, version string, sourceURI str
 
 	// override the overall state to upgrading until the re-execution is complete
 	c.SetOverrideState(agentclient.Upgrading, fmt.Sprintf("Upgrading to version %s", version))
+	if c.preflightRunning.Load() {
+		c.ClearOverrideState()
+		return ErrUpgradeInProgress
+	}
 
 	// initialize upgrade details
 	actionID := ""
@@ -745,6 +840,7 @@ func (c *Coordinator) Upgrade(ctx context.Context, version string, sourceURI str
 			det.SetState(details.StateCompleted)
 			return c.upgradeMgr.AckAction(ctx, c.fleetAcker, action)
 		}
+		err = upgrade.ErrorWithCode(err, fleetapi.ErrorCodeUpgradeFailed)
 		det.Fail(err)
 		return err
 	}
@@ -755,6 +851,57 @@ func (c *Coordinator) Upgrade(ctx context.Context, version string, sourceURI str
 	return nil
 }
 
+// UpgradePreflight runs the pre-flight checks of the upgrade to version without upgrading
+// and returns their results. The checks the upgrade would fail on are reported as failed.
+func (c *Coordinator) UpgradePreflight(ctx context.Context, version string, sourceURI string, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) *upgrade.PreflightReport {
+	report := &upgrade.PreflightReport{}
+	if !c.upgradeMgr.Upgradeable() {
+		report.Add(upgrade.PreflightCheckUpgradeable, ErrNotUpgradable, "")
+		return report
+	}
+	// set before checking the state, so an upgrade starting concurrently sees it
+	if !c.preflightRunning.CompareAndSwap(false, true) {
+		report.Add(upgrade.PreflightCheckUpgradeable, ErrUpgradeInProgress, "")
+		return report
+	}
+	defer c.preflightRunning.Store(false)
+	if err := c.upgradeInProgress(); err != nil {
+		report.Add(upgrade.PreflightCheckUpgradeable, err, "")
+		return report
+	}
+	report.Add(upgrade.PreflightCheckUpgradeable, nil, "")
+
+	if c.caps != nil && !c.caps.AllowUpgrade(version, sourceURI) {
+		report.Add(upgrade.PreflightCheckCapabilities, fmt.Errorf("upgrade to %s is not allowed by the capabilities", version), "")
+		return report
+	}
+	report.Add(upgrade.PreflightCheckCapabilities, nil, "")
+
+	// the details of the dry run are not reported, no upgrade is running
+	det := details.NewDetails(version, details.StateRequested, "")
+	upgradeReport := c.upgradeMgr.Preflight(ctx, version, sourceURI, det, skipVerifyOverride, skipDefaultPgp, pgpBytes...)
+	report.Checks = append(report.Checks, upgradeReport.Checks...)
+	return report
+}
+
+// upgradeInProgress returns ErrUpgradeInProgress when an upgrade is running or, on Fleet
+// managed agents, when an upgrade action from Fleet is scheduled or running.
+func (c *Coordinator) upgradeInProgress() error {
+	s := c.State()
+	if s.State == agentclient.Upgrading {
+		return ErrUpgradeInProgress
+	}
+	if c.isManaged && s.UpgradeDetails != nil {
+		switch s.UpgradeDetails.State {
+		case details.StateCompleted, details.StateFailed:
+		default:
+			return fmt.Errorf("%w: upgrade action %q from Fleet is %s", ErrUpgradeInProgress,
+				s.UpgradeDetails.ActionID, s.UpgradeDetails.State)
+		}
+	}
+	return nil
+}
+
 func (c *Coordinator) logUpgradeDetails(details *details.Details) {
 	c.logger.Infow("updated upgrade details", "upgrade_details", details)
 }
@@ -800,6 +947,71 @@ func (c *Coordinator) PerformComponentDiagnostics(ctx context.Context, additiona
 	return diags, err
 }
 
+// PauseComponent stops a running component until ResumeComponent is called.
+// Called from external goroutines.
+func (c *Coordinator) PauseComponent(componentID string) error {
+	return c.runtimeMgr.PauseComponent(componentID)
+}
+
+// ResumeComponent starts a component previously stopped by PauseComponent.
+// Called from external goroutines.
+func (c *Coordinator) ResumeComponent(componentID string) error {
+	return c.runtimeMgr.ResumeComponent(componentID)
+}
+
+// RestartComponent stops and starts a running component.
+// Called from external goroutines.
+func (c *Coordinator) RestartComponent(componentID string) error {
+	return c.runtimeMgr.RestartComponent(componentID)
+}
+
+// StateTransitions returns the component and unit state transitions recorded after since, oldest first.
+// Called from external goroutines.
+func (c *Coordinator) StateTransitions(since time.Time) []StateTransition {
+	return c.stateJournal.Transitions(since)
+}
+
+// SubscribeStateTransitions returns the component and unit state transitions recorded after since
+// and a channel that receives the following transitions until ctx is done.
+// Called from external goroutines.
+func (c *Coordinator) SubscribeStateTransitions(ctx context.Context, since time.Time) ([]StateTransition, <-chan StateTransition) {
+	return c.stateJournal.Subscribe(ctx, since)
+}
+
+// SetComponentLogLevel temporarily overrides the log level of the matching components and units,
+// reverting it once ttl has elapsed. Empty componentID or unitID match all components or units.
+// Called from external goroutines.
+func (c *Coordinator) SetComponentLogLevel(componentID string, unitID string, level client.UnitLogLevel, ttl time.Duration) error {
+	return c.runtimeMgr.SetLogLevelOverride(componentID, unitID, level, ttl)
+}
+
+// QueuedActions returns the scheduled Fleet actions waiting to be handled.
+// Called from external goroutines.
+func (c *Coordinator) QueuedActions() ([]fleetapi.ScheduledAction, error) {
+	if c.actionsMgr == nil {
+		return nil, ErrActionsNotManaged
+	}
+	return c.actionsMgr.QueuedActions(), nil
+}
+
+// CancelQueuedAction removes the scheduled Fleet action from the queue and acks it to Fleet.
+// Called from external goroutines.
+func (c *Coordinator) CancelQueuedAction(ctx context.Context, actionID string) (int, error) {
+	if c.actionsMgr == nil {
+		return 0, ErrActionsNotManaged
+	}
+	return c.actionsMgr.CancelQueuedAction(ctx, c.SetUpgradeDetails, c.fleetAcker, actionID)
+}
+
+// ActionHistory returns the last Fleet actions handled, oldest first.
+// Called from external goroutines.
+func (c *Coordinator) ActionHistory() ([]dispatcher.HandledAction, error) {
+	if c.actionsMgr == nil {
+		return nil, ErrActionsNotManaged
+	}
+	return c.actionsMgr.ActionHistory(), nil
+}
+
 // SetLogLevel changes the entire log level for the running Elastic Agent.
 // Called from external goroutines.
 func (c *Coordinator) SetLogLevel(ctx context.Context, lvl *logp.Level) error {
@@ -834,6 +1046,7 @@ func (c *Coordinator) watchRuntimeComponents(
 		case <-ctx.Done():
 			return
 		case componentState := <-runtimeComponentStates:
+			c.recordStateTransitions(state, &componentState)
 			logComponentStateChange(c.logger, state, &componentState)
 			// Forward the final changes back to Coordinator, unless our context
 			// has ended.
@@ -844,6 +1057,7 @@ func (c *Coordinator) watchRuntimeComponents(
 			}
 		case componentStates := <-otelComponentStates:
 			for _, componentState := range componentStates {
+				c.recordStateTransitions(state, &componentState)
 				logComponentStateChange(c.logger, state, &componentState)
 				// Forward the final changes back to Coordinator, unless our context
 				// has ended.
@@ -857,6 +1071,20 @@ func (c *Coordinator) watchRuntimeComponents(
 	}
 }
 
+// recordStateTransitions adds the transitions of the new component state to the state journal.
+func (c *Coordinator) recordStateTransitions(
+	coordinatorState map[string]runtime.ComponentState,
+	componentState *runtime.ComponentComponentState) {
+	if c.stateJournal == nil {
+		// unit tests may not initialize the journal
+		return
+	}
+	oldState, ok := coordinatorState[componentState.Component.ID]
+	for _, t := range componentStateTransitions(time.Now().UTC(), oldState, ok, componentState) {
+		c.stateJournal.Add(t)
+	}
+}
+
 // logComponentStateChange emits a log message based on the new component state.
 func logComponentStateChange(
 	logger *logger.Logger,
@@ -954,6 +1182,9 @@ func (c *Coordinator) Run(ctx context.Context) error {
 	// context ends, so test listeners and such can collect Coordinator's
 	// shutdown state.
 	defer close(c.stateBroadcaster.InputChan)
+	if c.stateJournal != nil {
+		defer c.stateJournal.Close()
+	}
 
 	if c.varsMgr != nil {
 		c.setCoordinatorState(agentclient.Starting, "Waiting for initial configuration and composable variables")
@@ -166,9 +166,10 @@ func (c *Coordinator) applyComponentState(state runtime.ComponentComponentState)
 		}
 	}
 
-	// In the case that the component has stopped, it is now removed.
+	// In the case that the component has stopped, it is now removed, unless it has been paused,
+	// in which case it is kept to report it as paused.
 	// Broadcast its stopped state immediately, so subscribers get notified of stopped before removal
-	if state.State.State == client.UnitStateStopped {
+	if state.State.State == client.UnitStateStopped && !state.Paused {
 		c.refreshState()
 		for i, other := range c.state.Components {
 			if other.Component.ID == state.Component.ID {
@@ -670,6 +670,115 @@ func TestCoordinator_StateSubscribe(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func TestCoordinator_PausedComponentStaysStoppedOnPolicyChange(t *testing.T) {
+	t.Cleanup(func() {
+		// the other tests must not load the paused component
+		_ = os.Remove(paths.PausedComponentsFile())
+	})
+
+	coordCh := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	coord, cfgMgr, varsMgr := createCoordinator(t, ctx)
+	go func() {
+		err := coord.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			// allowed error
+			err = nil
+		}
+		coordCh <- err
+	}()
+
+	policy := func(message string) *config.Config {
+		cfg, err := config.NewConfigFrom(map[string]interface{}{
+			"outputs": map[string]interface{}{
+				"default": map[string]interface{}{
+					"type": "fake-output",
+				},
+			},
+			"inputs": []interface{}{
+				map[string]interface{}{
+					"type":       "fake",
+					"use_output": "default",
+					"state":      client.UnitStateHealthy,
+					"message":    message,
+				},
+			},
+		})
+		require.NoError(t, err)
+		return cfg
+	}
+	unitKey := runtime.ComponentUnitKey{UnitType: client.UnitTypeInput, UnitID: "fake-default-fake"}
+	healthy := func(compState *runtime.ComponentComponentState, message string) bool {
+		unit, ok := compState.State.Units[unitKey]
+		return ok && unit.State == client.UnitStateHealthy && unit.Message == message
+	}
+	// configuredMessage returns the message the input unit of the component is configured with
+	configuredMessage := func(compState *runtime.ComponentComponentState) string {
+		for _, unit := range compState.Component.Units {
+			if unit.ID == unitKey.UnitID && unit.Config != nil && unit.Config.Source != nil {
+				message, _ := unit.Config.Source.AsMap()["message"].(string)
+				return message
+			}
+		}
+		return ""
+	}
+
+	subCtx, subCancel := context.WithTimeout(ctx, 30*time.Second)
+	defer subCancel()
+	subChan := coord.StateSubscribe(subCtx, 32)
+	var resumed bool
+	waitForComponent := func(match func(*runtime.ComponentComponentState) bool, msg string) {
+		t.Helper()
+		for {
+			select {
+			case <-subCtx.Done():
+				require.FailNow(t, msg)
+			case state := <-subChan:
+				compState := getComponentState(state.Components, "fake-default")
+				if compState == nil {
+					continue
+				}
+				require.False(t, !resumed && healthy(compState, "Healthy After Policy Change"),
+					"paused component must not run the new policy")
+				if match(compState) {
+					return
+				}
+			}
+		}
+	}
+
+	// no vars used by the config
+	varsMgr.Vars(ctx, []*transpiler.Vars{{}})
+	cfgMgr.Config(ctx, policy("Healthy From Fake Config"))
+	waitForComponent(func(compState *runtime.ComponentComponentState) bool {
+		return healthy(compState, "Healthy From Fake Config")
+	}, "component did not become healthy")
+
+	require.NoError(t, coord.PauseComponent("fake-default"))
+	waitForComponent(func(compState *runtime.ComponentComponentState) bool {
+		return compState.Paused && compState.State.State == client.UnitStateStopped
+	}, "component was not paused")
+
+	// the paused component is kept in the state with the new policy, but it is not started
+	cfgMgr.Config(ctx, policy("Healthy After Policy Change"))
+	waitForComponent(func(compState *runtime.ComponentComponentState) bool {
+		return compState.Paused && compState.State.State == client.UnitStateStopped &&
+			configuredMessage(compState) == "Healthy After Policy Change"
+	}, "paused component was not updated with the new policy")
+
+	resumed = true
+	require.NoError(t, coord.ResumeComponent("fake-default"))
+	waitForComponent(func(compState *runtime.ComponentComponentState) bool {
+		return !compState.Paused && healthy(compState, "Healthy After Policy Change")
+	}, "resumed component did not run the new policy")
+
+	cancel()
+	err := <-coordCh
+	require.NoError(t, err)
+}
+
 func TestCoordinator_StateSubscribeIsolatedUnits(t *testing.T) {
 	coordCh := make(chan error)
 	ctx, cancel := context.WithCancel(context.Background())
@@ -957,7 +1066,7 @@ func TestCoordinator_UpgradeDetails(t *testing.T) {
 	cfgMgr.Config(ctx, cfg)
 
 	err = coord.Upgrade(ctx, "9.0.0", "", nil, true, false)
-	require.ErrorIs(t, expectedErr, err)
+	require.ErrorIs(t, err, expectedErr)
 	cancel()
 
 	err = <-coordCh
@@ -968,6 +1077,55 @@ func TestCoordinator_UpgradeDetails(t *testing.T) {
 	require.Equal(t, expectedErr.Error(), coord.state.UpgradeDetails.Metadata.ErrorMsg)
 }
 
+func TestCoordinator_UpgradePreflight(t *testing.T) {
+	coordCh := make(chan error)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	upgradeManager := &fakeUpgradeManager{upgradeable: true}
+	coord, cfgMgr, varsMgr := createCoordinator(t, ctx, WithUpgradeManager(upgradeManager))
+	coord.isManaged = true
+	go func() {
+		err := coord.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			// allowed error
+			err = nil
+		}
+		coordCh <- err
+	}()
+
+	// no vars used by the config
+	varsMgr.Vars(ctx, []*transpiler.Vars{{}})
+
+	// no need for anything to really run
+	cfg, err := config.NewConfigFrom(nil)
+	require.NoError(t, err)
+	cfgMgr.Config(ctx, cfg)
+
+	report := coord.UpgradePreflight(ctx, "9.0.0", "", true, false)
+	require.NoError(t, report.Err())
+
+	// an upgrade action from Fleet is scheduled
+	scheduled := details.NewDetails("9.0.0", details.StateScheduled, "action-id")
+	coord.SetUpgradeDetails(scheduled)
+	require.Eventually(t, func() bool {
+		return coord.State().UpgradeDetails != nil
+	}, 5*time.Second, 10*time.Millisecond)
+	report = coord.UpgradePreflight(ctx, "9.0.0", "", true, false)
+	require.ErrorIs(t, report.Err(), ErrUpgradeInProgress)
+
+	// an upgrade does not start while the pre-flight checks run
+	coord.preflightRunning.Store(true)
+	err = coord.Upgrade(ctx, "9.0.0", "", nil, true, false)
+	require.ErrorIs(t, err, ErrUpgradeInProgress)
+	assert.False(t, upgradeManager.upgradeCalled)
+	coord.preflightRunning.Store(false)
+
+	cancel()
+	err = <-coordCh
+	require.NoError(t, err)
+}
+
 func BenchmarkCoordinator_generateComponentModel(b *testing.B) {
 	// load variables
 	varsMaps := []map[string]any{}
@@ -1170,6 +1328,16 @@ func (f *fakeUpgradeManager) Upgrade(ctx context.Context, version string, source
 	return func() error { return nil }, nil
 }
 
+func (f *fakeUpgradeManager) Rollback(ctx context.Context, version string) (string, error) {
+	return version, f.upgradeErr
+}
+
+func (f *fakeUpgradeManager) Preflight(ctx context.Context, version string, sourceURI string, details *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) *upgrade.PreflightReport {
+	report := &upgrade.PreflightReport{}
+	report.Add(upgrade.PreflightCheckVersion, f.upgradeErr, "")
+	return report
+}
+
 func (f *fakeUpgradeManager) Ack(ctx context.Context, acker acker.Acker) error {
 	if acker != nil {
 		return acker.Ack(ctx, fleetapi.NewAction(fleetapi.ActionTypeUnknown))
@@ -1461,6 +1629,26 @@ func (r *fakeRuntimeManager) PerformComponentDiagnostics(ctx context.Context, ad
 	return nil, nil
 }
 
+// PauseComponent stops a running component.
+func (r *fakeRuntimeManager) PauseComponent(_ string) error {
+	return nil
+}
+
+// ResumeComponent starts a paused component.
+func (r *fakeRuntimeManager) ResumeComponent(_ string) error {
+	return nil
+}
+
+// RestartComponent restarts a running component.
+func (r *fakeRuntimeManager) RestartComponent(_ string) error {
+	return nil
+}
+
+// SetLogLevelOverride overrides the log level of running components.
+func (r *fakeRuntimeManager) SetLogLevelOverride(_ string, _ string, _ client.UnitLogLevel, _ time.Duration) error {
+	return nil
+}
+
 func testBinary(t testing.TB, name string) string {
 	t.Helper()
 
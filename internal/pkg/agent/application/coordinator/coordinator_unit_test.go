@@ -38,6 +38,7 @@ import (
 
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent-libs/logp"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/dispatcher"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/monitoring/reload"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
@@ -53,6 +54,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/core/backoff"
 	monitoringCfg "github.com/elastic/elastic-agent/internal/pkg/core/monitoring/config"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
 	"github.com/elastic/elastic-agent/internal/pkg/testutils/fipsutils"
 	"github.com/elastic/elastic-agent/pkg/component"
 	"github.com/elastic/elastic-agent/pkg/component/runtime"
@@ -1509,7 +1511,7 @@ func TestCoordinatorInitiatesUpgrade(t *testing.T) {
 	// Call upgrade and make sure the upgrade manager receives an Upgrade call
 	err := coord.Upgrade(ctx, "1.2.3", "", nil, false, false)
 	assert.True(t, upgradeMgr.upgradeCalled, "Coordinator Upgrade should call upgrade manager Upgrade")
-	assert.Equal(t, upgradeMgr.upgradeErr, err, "Upgrade should report upgrade manager error")
+	assert.ErrorIs(t, err, upgradeMgr.upgradeErr, "Upgrade should report upgrade manager error")
 
 	// Make sure the expected override states were set
 	select {
@@ -1962,3 +1964,57 @@ func TestHasEndpoint(t *testing.T) {
 		})
 	}
 }
+
+type fakeActionsManager struct {
+	queued    []fleetapi.ScheduledAction
+	history   []dispatcher.HandledAction
+	cancelled []string
+}
+
+func (f *fakeActionsManager) QueuedActions() []fleetapi.ScheduledAction {
+	return f.queued
+}
+
+func (f *fakeActionsManager) CancelQueuedAction(_ context.Context, _ details.Observer, _ acker.Acker, actionID string) (int, error) {
+	f.cancelled = append(f.cancelled, actionID)
+	return 1, nil
+}
+
+func (f *fakeActionsManager) ActionHistory() []dispatcher.HandledAction {
+	return f.history
+}
+
+func TestCoordinatorActions(t *testing.T) {
+	t.Run("not managed", func(t *testing.T) {
+		c := &Coordinator{}
+
+		_, err := c.QueuedActions()
+		assert.ErrorIs(t, err, ErrActionsNotManaged)
+		_, err = c.CancelQueuedAction(context.Background(), "action-1")
+		assert.ErrorIs(t, err, ErrActionsNotManaged)
+		_, err = c.ActionHistory()
+		assert.ErrorIs(t, err, ErrActionsNotManaged)
+	})
+
+	t.Run("managed", func(t *testing.T) {
+		mgr := &fakeActionsManager{
+			queued:  []fleetapi.ScheduledAction{&fleetapi.ActionUpgrade{ActionID: "action-1", ActionType: fleetapi.ActionTypeUpgrade}},
+			history: []dispatcher.HandledAction{{ID: "action-0", Result: dispatcher.ActionResultSuccess}},
+		}
+		c := &Coordinator{}
+		c.SetActionsManager(mgr)
+
+		queued, err := c.QueuedActions()
+		require.NoError(t, err)
+		assert.Equal(t, mgr.queued, queued)
+
+		n, err := c.CancelQueuedAction(context.Background(), "action-1")
+		require.NoError(t, err)
+		assert.Equal(t, 1, n)
+		assert.Equal(t, []string{"action-1"}, mgr.cancelled)
+
+		history, err := c.ActionHistory()
+		require.NoError(t, err)
+		assert.Equal(t, mgr.history, history)
+	})
+}
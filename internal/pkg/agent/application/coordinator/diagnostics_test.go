@@ -91,14 +91,19 @@ func TestDiagnosticLocalConfig(t *testing.T) {
 	// The YAML we expect to see from the preceding config
 	expectedCfg := `
 agent:
+  actions: null
   download: null
+  fleet_checkin: null
   grpc: null
   id: ""
+  local_actions: null
   path: ""
   process: null
   reload: null
+  state_journal: null
   upgrade: null
   v1_monitoring_enabled: false
+  vault: null
   monitoring:
     enabled: false
     http: null
@@ -0,0 +1,391 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package coordinator
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+)
+
+const (
+	// number of state transitions buffered for each journal subscriber
+	stateJournalSubscriberBuffer = 64
+	// number of state transitions buffered for the writer of the on-disk journal
+	stateJournalWriteBuffer = 256
+)
+
+// StateSeverity is the severity of a state transition.
+type StateSeverity int
+
+const (
+	// StateSeverityInfo is the severity of transitions to starting, configuring, healthy, stopping and stopped.
+	StateSeverityInfo StateSeverity = iota
+	// StateSeverityWarning is the severity of transitions to degraded.
+	StateSeverityWarning
+	// StateSeverityError is the severity of transitions to failed.
+	StateSeverityError
+)
+
+// StateTransition is a component or unit state transition recorded in the StateJournal.
+type StateTransition struct {
+	Timestamp   time.Time `json:"@timestamp"`
+	ComponentID string    `json:"component_id"`
+	// UnitID is empty for transitions of the component itself.
+	UnitID   string           `json:"unit_id,omitempty"`
+	UnitType client.UnitType  `json:"unit_type,omitempty"`
+	OldState client.UnitState `json:"old_state"`
+	State    client.UnitState `json:"state"`
+	Message  string           `json:"message"`
+}
+
+// Severity returns the severity of the state the transition moved to.
+func (t StateTransition) Severity() StateSeverity {
+	switch t.State {
+	case client.UnitStateDegraded:
+		return StateSeverityWarning
+	case client.UnitStateFailed:
+		return StateSeverityError
+	default:
+		return StateSeverityInfo
+	}
+}
+
+// StateJournal is a bounded journal of component and unit state transitions.
+//
+// When created with a path the journal is also written to disk, so it is kept across
+// restarts of the Elastic Agent. The transitions are written by a separate goroutine,
+// so adding them never waits on the disk.
+type StateJournal struct {
+	mx      sync.Mutex
+	size    int
+	entries []StateTransition
+	subs    map[chan StateTransition]struct{}
+	// seq is the sequence number of the last added transition
+	seq uint64
+
+	path string
+	// writes buffers the transitions to write to disk, nil when the journal is not written to disk
+	writes chan journalWrite
+	// dropped is set when a transition did not fit in writes, the on-disk journal is then
+	// rewritten from the transitions in memory
+	dropped bool
+	closed  bool
+	done    chan struct{}
+
+	// owned by the writer goroutine once started
+	file      *os.File
+	written   int
+	persisted uint64
+	closeErr  error
+}
+
+// journalWrite is a transition to write to disk and its sequence number.
+type journalWrite struct {
+	seq        uint64
+	transition StateTransition
+}
+
+// NewStateJournal creates a state journal that keeps the last size transitions.
+//
+// When path is not empty the journal previously written at path is loaded and new
+// transitions are appended to it.
+func NewStateJournal(size int, path string) (*StateJournal, error) {
+	if size <= 0 {
+		return nil, fmt.Errorf("state journal size must be positive, got %d", size)
+	}
+	j := &StateJournal{
+		size: size,
+		subs: make(map[chan StateTransition]struct{}),
+		path: path,
+	}
+	if path == "" {
+		return j, nil
+	}
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	if err := j.compact(j.entries); err != nil {
+		return nil, err
+	}
+	j.writes = make(chan journalWrite, stateJournalWriteBuffer)
+	j.done = make(chan struct{})
+	go j.runWriter()
+	return j, nil
+}
+
+// Add records the state transition and sends it to the subscribers.
+//
+// Subscribers that are not keeping up have their channel closed.
+func (j *StateJournal) Add(t StateTransition) {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	j.entries = append(j.entries, t)
+	if len(j.entries) > j.size {
+		j.entries = j.entries[len(j.entries)-j.size:]
+	}
+	j.seq++
+	if j.writes != nil && !j.closed {
+		select {
+		case j.writes <- journalWrite{seq: j.seq, transition: t}:
+		default:
+			// the writer is not keeping up, it rewrites the journal once it catches up
+			j.dropped = true
+		}
+	}
+
+	for ch := range j.subs {
+		select {
+		case ch <- t:
+		default:
+			// subscriber is too slow, drop it instead of blocking the coordinator
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}
+}
+
+// Transitions returns the recorded transitions that happened after since, oldest first.
+func (j *StateJournal) Transitions(since time.Time) []StateTransition {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+	return j.transitionsSince(since)
+}
+
+// Subscribe returns the recorded transitions that happened after since and a channel
+// that receives the following transitions until ctx is done.
+//
+// The channel is closed when ctx is done or when the subscriber does not keep up.
+func (j *StateJournal) Subscribe(ctx context.Context, since time.Time) ([]StateTransition, <-chan StateTransition) {
+	ch := make(chan StateTransition, stateJournalSubscriberBuffer)
+	j.mx.Lock()
+	history := j.transitionsSince(since)
+	j.subs[ch] = struct{}{}
+	j.mx.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		j.mx.Lock()
+		defer j.mx.Unlock()
+		if _, ok := j.subs[ch]; ok {
+			delete(j.subs, ch)
+			close(ch)
+		}
+	}()
+	return history, ch
+}
+
+// Close writes the pending transitions and closes the on-disk journal.
+func (j *StateJournal) Close() error {
+	j.mx.Lock()
+	if j.writes == nil || j.closed {
+		j.mx.Unlock()
+		return nil
+	}
+	j.closed = true
+	close(j.writes)
+	j.mx.Unlock()
+
+	<-j.done
+	return j.closeErr
+}
+
+func (j *StateJournal) transitionsSince(since time.Time) []StateTransition {
+	i := sort.Search(len(j.entries), func(i int) bool {
+		return j.entries[i].Timestamp.After(since)
+	})
+	result := make([]StateTransition, len(j.entries)-i)
+	copy(result, j.entries[i:])
+	return result
+}
+
+// load reads the transitions previously written to disk.
+func (j *StateJournal) load() error {
+	f, err := os.Open(j.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open state journal: %w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var t StateTransition
+		if err := json.Unmarshal(scanner.Bytes(), &t); err != nil {
+			// skip lines that are corrupted, for example by a partial write
+			continue
+		}
+		j.entries = append(j.entries, t)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read state journal: %w", err)
+	}
+	sort.SliceStable(j.entries, func(a, b int) bool {
+		return j.entries[a].Timestamp.Before(j.entries[b].Timestamp)
+	})
+	if len(j.entries) > j.size {
+		j.entries = j.entries[len(j.entries)-j.size:]
+	}
+	return nil
+}
+
+// compact rewrites the on-disk journal with entries and opens it for appending.
+func (j *StateJournal) compact(entries []StateTransition) error {
+	if j.file != nil {
+		_ = j.file.Close()
+		j.file = nil
+	}
+	if err := os.MkdirAll(filepath.Dir(j.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create state journal directory: %w", err)
+	}
+	tmp := j.path + ".tmp"
+	f, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to create state journal: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	enc := json.NewEncoder(w)
+	for _, t := range entries {
+		if err := enc.Encode(t); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("failed to write state journal: %w", err)
+		}
+	}
+	if err := w.Flush(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("failed to write state journal: %w", err)
+	}
+	if err := f.Close(); err != nil {
+		return fmt.Errorf("failed to write state journal: %w", err)
+	}
+	if err := os.Rename(tmp, j.path); err != nil {
+		return fmt.Errorf("failed to replace state journal: %w", err)
+	}
+
+	j.file, err = os.OpenFile(j.path, os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open state journal: %w", err)
+	}
+	j.written = len(entries)
+	return nil
+}
+
+// runWriter appends the added transitions to the on-disk journal until the journal is
+// closed. The journal is compacted once it holds twice the number of kept transitions,
+// or rewritten when transitions were dropped because the writer did not keep up.
+func (j *StateJournal) runWriter() {
+	defer close(j.done)
+	for w := range j.writes {
+		if w.seq <= j.persisted {
+			// already written by a compaction
+			continue
+		}
+		j.mx.Lock()
+		dropped := j.dropped
+		j.mx.Unlock()
+		if dropped || j.written >= 2*j.size {
+			j.compactFromMemory()
+			continue
+		}
+		j.persist(w)
+	}
+	if j.file != nil {
+		j.closeErr = j.file.Close()
+		j.file = nil
+	}
+}
+
+// compactFromMemory rewrites the on-disk journal with the transitions kept in memory.
+func (j *StateJournal) compactFromMemory() {
+	j.mx.Lock()
+	entries := make([]StateTransition, len(j.entries))
+	copy(entries, j.entries)
+	seq := j.seq
+	j.dropped = false
+	j.mx.Unlock()
+
+	// on failure the journal stays in memory only
+	if err := j.compact(entries); err == nil {
+		j.persisted = seq
+	}
+}
+
+// persist appends the transition to the on-disk journal.
+func (j *StateJournal) persist(w journalWrite) {
+	if j.file == nil {
+		return
+	}
+	data, err := json.Marshal(w.transition)
+	if err != nil {
+		return
+	}
+	if _, err := j.file.Write(append(data, '\n')); err == nil {
+		j.written++
+		j.persisted = w.seq
+	}
+}
+
+// componentStateTransitions returns the transitions between the previous state of the
+// component and its new state.
+func componentStateTransitions(now time.Time, oldState runtime.ComponentState, existed bool, componentState *runtime.ComponentComponentState) []StateTransition {
+	var transitions []StateTransition
+	oldCompState := client.UnitStateStopped
+	if existed {
+		oldCompState = oldState.State
+	}
+	if !existed || oldState.State != componentState.State.State {
+		transitions = append(transitions, StateTransition{
+			Timestamp:   now,
+			ComponentID: componentState.Component.ID,
+			OldState:    oldCompState,
+			State:       componentState.State.State,
+			Message:     componentState.State.Message,
+		})
+	}
+	keys := make([]runtime.ComponentUnitKey, 0, len(componentState.State.Units))
+	for key := range componentState.State.Units {
+		keys = append(keys, key)
+	}
+	sort.Slice(keys, func(a, b int) bool {
+		if keys[a].UnitType != keys[b].UnitType {
+			return keys[a].UnitType < keys[b].UnitType
+		}
+		return keys[a].UnitID < keys[b].UnitID
+	})
+	for _, key := range keys {
+		us := componentState.State.Units[key]
+		oldUnitState := client.UnitStateStopped
+		oldUS, ok := oldState.Units[key]
+		if existed && ok {
+			if oldUS.State == us.State {
+				continue
+			}
+			oldUnitState = oldUS.State
+		}
+		transitions = append(transitions, StateTransition{
+			Timestamp:   now,
+			ComponentID: componentState.Component.ID,
+			UnitID:      key.UnitID,
+			UnitType:    key.UnitType,
+			OldState:    oldUnitState,
+			State:       us.State,
+			Message:     us.Message,
+		})
+	}
+	return transitions
+}
@@ -0,0 +1,185 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package coordinator
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+)
+
+func testTransition(ts time.Time, compID string, state client.UnitState) StateTransition {
+	return StateTransition{
+		Timestamp:   ts,
+		ComponentID: compID,
+		OldState:    client.UnitStateHealthy,
+		State:       state,
+		Message:     state.String(),
+	}
+}
+
+func TestStateJournalBounded(t *testing.T) {
+	j, err := NewStateJournal(3, "")
+	require.NoError(t, err)
+
+	start := time.Now()
+	for i := 0; i < 5; i++ {
+		j.Add(testTransition(start.Add(time.Duration(i)*time.Second), "comp", client.UnitStateDegraded))
+	}
+
+	all := j.Transitions(time.Time{})
+	require.Len(t, all, 3)
+	assert.Equal(t, start.Add(2*time.Second), all[0].Timestamp)
+	assert.Equal(t, start.Add(4*time.Second), all[2].Timestamp)
+
+	recent := j.Transitions(start.Add(3 * time.Second))
+	require.Len(t, recent, 1)
+	assert.Equal(t, start.Add(4*time.Second), recent[0].Timestamp)
+}
+
+func TestStateJournalSubscribe(t *testing.T) {
+	j, err := NewStateJournal(10, "")
+	require.NoError(t, err)
+
+	start := time.Now()
+	j.Add(testTransition(start, "comp", client.UnitStateFailed))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	history, ch := j.Subscribe(ctx, time.Time{})
+	require.Len(t, history, 1)
+
+	next := testTransition(start.Add(time.Second), "comp", client.UnitStateHealthy)
+	j.Add(next)
+	select {
+	case got := <-ch:
+		assert.Equal(t, next, got)
+	case <-time.After(5 * time.Second):
+		t.Fatal("transition not received")
+	}
+
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-ch:
+			return !ok
+		default:
+			return false
+		}
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestStateJournalSlowSubscriber(t *testing.T) {
+	j, err := NewStateJournal(10, "")
+	require.NoError(t, err)
+
+	_, ch := j.Subscribe(context.Background(), time.Time{})
+	start := time.Now()
+	for i := 0; i <= stateJournalSubscriberBuffer; i++ {
+		j.Add(testTransition(start.Add(time.Duration(i)*time.Second), "comp", client.UnitStateHealthy))
+	}
+
+	received := 0
+	for range ch {
+		received++
+	}
+	assert.Equal(t, stateJournalSubscriberBuffer, received)
+}
+
+func TestStateJournalPersist(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state_journal.ndjson")
+	j, err := NewStateJournal(2, path)
+	require.NoError(t, err)
+
+	start := time.Now().UTC().Truncate(time.Second)
+	for i := 0; i < 7; i++ {
+		j.Add(testTransition(start.Add(time.Duration(i)*time.Second), "comp", client.UnitStateFailed))
+	}
+	require.NoError(t, j.Close())
+
+	reloaded, err := NewStateJournal(2, path)
+	require.NoError(t, err)
+	defer reloaded.Close()
+
+	all := reloaded.Transitions(time.Time{})
+	require.Len(t, all, 2)
+	assert.True(t, start.Add(5*time.Second).Equal(all[0].Timestamp))
+	assert.True(t, start.Add(6*time.Second).Equal(all[1].Timestamp))
+	assert.Equal(t, StateSeverityError, all[1].Severity())
+}
+
+func TestStateJournalPersistWriterBehind(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "state_journal.ndjson")
+	const size = 10
+	j, err := NewStateJournal(size, path)
+	require.NoError(t, err)
+
+	// more transitions than the writer buffers, some are dropped and rewritten from memory
+	start := time.Now().UTC().Truncate(time.Second)
+	total := 2 * stateJournalWriteBuffer
+	for i := 0; i < total; i++ {
+		j.Add(testTransition(start.Add(time.Duration(i)*time.Second), "comp", client.UnitStateHealthy))
+	}
+	require.NoError(t, j.Close())
+	require.NoError(t, j.Close(), "closing twice should succeed")
+
+	reloaded, err := NewStateJournal(size, path)
+	require.NoError(t, err)
+	defer reloaded.Close()
+
+	all := reloaded.Transitions(time.Time{})
+	require.Len(t, all, size)
+	for i, tr := range all {
+		assert.Truef(t, start.Add(time.Duration(total-size+i)*time.Second).Equal(tr.Timestamp),
+			"transition %d is at %s, written twice or out of order", i, tr.Timestamp)
+	}
+}
+
+func TestComponentStateTransitions(t *testing.T) {
+	now := time.Now()
+	inputKey := runtime.ComponentUnitKey{UnitType: client.UnitTypeInput, UnitID: "comp-input"}
+	outputKey := runtime.ComponentUnitKey{UnitType: client.UnitTypeOutput, UnitID: "comp"}
+	newState := &runtime.ComponentComponentState{
+		Component: component.Component{ID: "comp"},
+		State: runtime.ComponentState{
+			State:   client.UnitStateHealthy,
+			Message: "Healthy",
+			Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+				inputKey:  {State: client.UnitStateDegraded, Message: "Degraded"},
+				outputKey: {State: client.UnitStateHealthy, Message: "Healthy"},
+			},
+		},
+	}
+
+	// new component reports every state as a transition from stopped
+	transitions := componentStateTransitions(now, runtime.ComponentState{}, false, newState)
+	require.Len(t, transitions, 3)
+	assert.Equal(t, "", transitions[0].UnitID)
+	assert.Equal(t, client.UnitStateStopped, transitions[0].OldState)
+	assert.Equal(t, "comp-input", transitions[1].UnitID)
+	assert.Equal(t, StateSeverityWarning, transitions[1].Severity())
+
+	// only the units that changed are reported
+	oldState := runtime.ComponentState{
+		State: client.UnitStateHealthy,
+		Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+			inputKey:  {State: client.UnitStateHealthy},
+			outputKey: {State: client.UnitStateHealthy},
+		},
+	}
+	transitions = componentStateTransitions(now, oldState, true, newState)
+	require.Len(t, transitions, 1)
+	assert.Equal(t, "comp-input", transitions[0].UnitID)
+	assert.Equal(t, client.UnitStateHealthy, transitions[0].OldState)
+	assert.Equal(t, client.UnitStateDegraded, transitions[0].State)
+	assert.Equal(t, "Degraded", transitions[0].Message)
+}
@@ -8,13 +8,16 @@ import (
 	"context"
 	"fmt"
 	"reflect"
+	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"go.elastic.co/apm/v2"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
@@ -23,9 +26,40 @@ import (
 
 type actionHandlers map[reflect.Type]actions.Handler
 
+// orderedActionTypes are the action types changing the policy or the enrollment of the
+// agent, they are handled one after the other in the order they were received.
+var orderedActionTypes = map[string]bool{
+	fleetapi.ActionTypePolicyChange:   true,
+	fleetapi.ActionTypePolicyReassign: true,
+	fleetapi.ActionTypeSettings:       true,
+	fleetapi.ActionTypeUnenroll:       true,
+	fleetapi.ActionTypeMigrate:        true,
+}
+
+// ActionTimeoutError is the error of an action whose handler did not complete in time.
+type ActionTimeoutError struct {
+	ActionID   string
+	ActionType string
+	Timeout    time.Duration
+}
+
+func (e *ActionTimeoutError) Error() string {
+	return fmt.Sprintf("action %q of type %q timed out after %s", e.ActionID, e.ActionType, e.Timeout)
+}
+
+// Unwrap returns context.DeadlineExceeded.
+func (e *ActionTimeoutError) Unwrap() error {
+	return context.DeadlineExceeded
+}
+
+// errActionCancelled is the error a queued action cancelled from the control server is acked with.
+var errActionCancelled = fleetapi.NewAckError(fleetapi.ErrorCodeCancelled, false, errors.New("action cancelled on the agent"))
+
 type priorityQueue interface {
 	Add(fleetapi.ScheduledAction, int64)
 	DequeueActions() []fleetapi.ScheduledAction
+	Actions() []fleetapi.ScheduledAction
+	Cancel(string) int
 	CancelType(string) int
 	Save() error
 }
@@ -45,12 +79,37 @@ type ActionDispatcher struct {
 	rt       *retryConfig
 	errCh    chan error
 	topPath  string
+	history  history
+	cfg      *configuration.ActionsConfig
+	// guards the queue and lastUpgradeDetails, they are updated by the retries of the
+	// actions handled concurrently and by the actions cancelled from the control server
+	queueMx sync.Mutex
 
 	lastUpgradeDetails *details.Details
 }
 
+// Option is an action dispatcher option.
+type Option func(*ActionDispatcher)
+
+// WithActionsConfig sets the concurrency and the timeouts of the handled actions.
+func WithActionsConfig(cfg *configuration.ActionsConfig) Option {
+	return func(ad *ActionDispatcher) {
+		if cfg != nil {
+			ad.cfg = cfg
+		}
+	}
+}
+
+// WithHistoryStore persists the history of the handled actions to the store, usually the
+// state store the action queue is persisted to.
+func WithHistoryStore(store historyStore) Option {
+	return func(ad *ActionDispatcher) {
+		ad.history.store = store
+	}
+}
+
 // New creates a new action dispatcher.
-func New(log *logger.Logger, topPath string, def actions.Handler, queue priorityQueue) (*ActionDispatcher, error) {
+func New(log *logger.Logger, topPath string, def actions.Handler, queue priorityQueue, opts ...Option) (*ActionDispatcher, error) {
 	var err error
 	if log == nil {
 		log, err = logger.New("action_dispatcher", false)
@@ -63,7 +122,7 @@ func New(log *logger.Logger, topPath string, def actions.Handler, queue priority
 		return nil, errors.New("missing default handler")
 	}
 
-	return &ActionDispatcher{
+	ad := &ActionDispatcher{
 		log:      log,
 		handlers: make(actionHandlers),
 		def:      def,
@@ -71,7 +130,14 @@ func New(log *logger.Logger, topPath string, def actions.Handler, queue priority
 		rt:       defaultRetryConfig(),
 		errCh:    make(chan error),
 		topPath:  topPath,
-	}, nil
+		cfg:      configuration.DefaultActionsConfig(),
+	}
+	ad.history.log = log
+	for _, opt := range opts {
+		opt(ad)
+	}
+	ad.history.load()
+	return ad, nil
 }
 
 func (ad *ActionDispatcher) Errors() <-chan error {
@@ -114,6 +180,39 @@ func (ad *ActionDispatcher) Dispatch(ctx context.Context, detailsSetter details.
 		span.End()
 	}()
 
+	actions = ad.updateQueue(ctx, detailsSetter, acker, actions)
+	if len(actions) == 0 {
+		ad.log.Debug("No action to dispatch")
+		return
+	}
+
+	ad.log.Debugf(
+		"Dispatch %d actions of types: %s",
+		len(actions),
+		strings.Join(detectTypes(actions), ", "),
+	)
+
+	reportedErr := ad.dispatchActions(ctx, acker, actions)
+	if err = ctx.Err(); err != nil {
+		ad.errCh <- err
+		return
+	}
+
+	if err = acker.Commit(ctx); err != nil {
+		reportedErr = err
+	}
+
+	if len(actions) > 0 {
+		ad.errCh <- reportedErr
+	}
+}
+
+// updateQueue queues the scheduled actions, dispatches the cancel actions and returns the
+// actions to dispatch, including the queued actions which start time is reached.
+func (ad *ActionDispatcher) updateQueue(ctx context.Context, detailsSetter details.Observer, acker acker.Acker, actions []fleetapi.Action) []fleetapi.Action {
+	ad.queueMx.Lock()
+	defer ad.queueMx.Unlock()
+
 	ad.removeQueuedUpgrades(actions)
 
 	// set scheduled action as soon as it's received
@@ -132,46 +231,242 @@ func (ad *ActionDispatcher) Dispatch(ctx context.Context, detailsSetter details.
 	if err := ad.queue.Save(); err != nil {
 		ad.log.Errorf("failed to persist action_queue: %v", err)
 	}
+	return actions
+}
 
-	if len(actions) == 0 {
-		ad.log.Debug("No action to dispatch")
-		return
+// QueuedActions returns the scheduled actions waiting in the queue, ordered by start time.
+func (ad *ActionDispatcher) QueuedActions() []fleetapi.ScheduledAction {
+	ad.queueMx.Lock()
+	actions := ad.queue.Actions()
+	ad.queueMx.Unlock()
+
+	sort.SliceStable(actions, func(i, j int) bool {
+		a, _ := actions[i].StartTime()
+		b, _ := actions[j].StartTime()
+		return a.Before(b)
+	})
+	return actions
+}
+
+// CancelQueuedAction removes the scheduled action from the queue and returns the number
+// of entries cancelled. The cancelled action is acked as failed, so Fleet does not wait
+// for it, and the upgrade details are cleared when it was the scheduled upgrade.
+func (ad *ActionDispatcher) CancelQueuedAction(ctx context.Context, detailsSetter details.Observer, acker acker.Acker, actionID string) (int, error) {
+	ad.queueMx.Lock()
+	defer ad.queueMx.Unlock()
+
+	var cancelled []fleetapi.ScheduledAction
+	for _, action := range ad.queue.Actions() {
+		if action.ID() == actionID {
+			cancelled = append(cancelled, action)
+		}
+	}
+	n := ad.queue.Cancel(actionID)
+	if n == 0 {
+		return 0, nil
+	}
+	if err := ad.queue.Save(); err != nil {
+		return n, fmt.Errorf("failed to persist action_queue: %w", err)
 	}
 
-	ad.log.Debugf(
-		"Dispatch %d actions of types: %s",
-		len(actions),
-		strings.Join(detectTypes(actions), ", "),
-	)
+	if ad.lastUpgradeDetails != nil && ad.lastUpgradeDetails.ActionID == actionID &&
+		ad.lastUpgradeDetails.State == details.StateScheduled {
+		ad.lastUpgradeDetails = nil
+		detailsSetter(nil)
+	}
 
-	var reportedErr error
+	for _, action := range cancelled {
+		ad.history.add(action, ActionResultCancelled, nil)
+		if err := acker.Ack(ctx, &failedAction{Action: action, err: errActionCancelled}); err != nil {
+			ad.log.Errorf("Unable to ack cancelled action (id %s) to fleet-server: %v", action.ID(), err)
+		}
+	}
+	if err := acker.Commit(ctx); err != nil {
+		ad.log.Errorf("Unable to commit cancelled action (id %s) to fleet-server: %v", actionID, err)
+	}
+	return n, nil
+}
+
+// ActionHistory returns the last actions handled by the dispatcher, oldest first.
+func (ad *ActionDispatcher) ActionHistory() []HandledAction {
+	return ad.history.list()
+}
+
+// dispatchActions handles the actions concurrently, at most cfg.MaxConcurrency at the same
+// time. The actions of the same type, or of the ordered types, are handled one after the
+// other in the order they were received. It returns the error of the last failed action.
+func (ad *ActionDispatcher) dispatchActions(ctx context.Context, acker acker.Acker, actions []fleetapi.Action) error {
+	var lanes [][]fleetapi.Action
+	laneIdx := make(map[string]int)
 	for _, action := range actions {
-		if err = ctx.Err(); err != nil {
-			ad.errCh <- err
-			return
+		key := action.Type()
+		if orderedActionTypes[key] {
+			key = fleetapi.ActionTypePolicyChange
 		}
+		i, ok := laneIdx[key]
+		if !ok {
+			i = len(lanes)
+			laneIdx[key] = i
+			lanes = append(lanes, nil)
+		}
+		lanes[i] = append(lanes[i], action)
+	}
 
-		if err := ad.dispatchAction(ctx, action, acker); err != nil {
-			rAction, ok := action.(fleetapi.RetryableAction)
-			if ok {
-				rAction.SetError(err) // set the retryable action error to what the dispatcher returned
-				ad.scheduleRetry(ctx, rAction, acker)
-				continue
+	maxConcurrency := ad.cfg.MaxConcurrency
+	if maxConcurrency <= 0 {
+		maxConcurrency = 1
+	}
+	sem := make(chan struct{}, maxConcurrency)
+
+	var wg sync.WaitGroup
+	// the errors are reported by lane, so the error of the last failed action of
+	// a lane wins over the errors of the previous ones
+	laneErrs := make([]error, len(lanes))
+	for i, lane := range lanes {
+		wg.Add(1)
+		go func(i int, lane []fleetapi.Action) {
+			defer wg.Done()
+			for _, action := range lane {
+				select {
+				case <-ctx.Done():
+					return
+				case sem <- struct{}{}:
+				}
+				if ctx.Err() != nil {
+					<-sem
+					return
+				}
+				if err := ad.handleAction(ctx, action, acker); err != nil {
+					laneErrs[i] = err
+				}
+				<-sem
 			}
-			ad.log.Errorf("Failed to dispatch action id %q of type %q, error: %+v", action.ID(), action.Type(), err)
+		}(i, lane)
+	}
+	wg.Wait()
+
+	var reportedErr error
+	for _, err := range laneErrs {
+		if err != nil {
 			reportedErr = err
-			continue
 		}
+	}
+	return reportedErr
+}
+
+// handleAction dispatches the action and records its result. It returns the error of
+// the action when it failed and is not retried.
+func (ad *ActionDispatcher) handleAction(ctx context.Context, action fleetapi.Action, acker acker.Acker) error {
+	err := ad.dispatchActionWithTimeout(ctx, action, acker)
+	if err == nil {
+		ad.history.add(action, ActionResultSuccess, nil)
 		ad.log.Debugf("Successfully dispatched action: '%+v'", action)
+		return nil
 	}
 
-	if err = acker.Commit(ctx); err != nil {
-		reportedErr = err
+	if rAction, ok := action.(fleetapi.RetryableAction); ok {
+		rAction.SetError(err) // set the retryable action error to what the dispatcher returned
+		ad.history.add(action, ActionResultRetry, err)
+		ad.queueMx.Lock()
+		ad.scheduleRetry(ctx, rAction, acker)
+		ad.queueMx.Unlock()
+		return nil
+	}
+	ad.log.Errorf("Failed to dispatch action id %q of type %q, error: %+v", action.ID(), action.Type(), err)
+	var timeoutErr *ActionTimeoutError
+	if errors.As(err, &timeoutErr) {
+		ad.history.add(action, ActionResultTimedOut, err)
+	} else {
+		ad.history.add(action, ActionResultFailed, err)
 	}
+	return err
+}
 
-	if len(actions) > 0 {
-		ad.errCh <- reportedErr
+// dispatchActionWithTimeout dispatches the action with the timeout of its type. The handler
+// context is not cancelled when the handler returns: the handlers completing the action in
+// the background, like upgrade or diagnostics, keep the deadline of the action. When the
+// handler does not return in time the action is acked as failed with an ActionTimeoutError,
+// unless the handler already acked it or the action is retried, and the acks of the handler
+// are then dropped. The timed out handler is not waited for, the next actions of its lane
+// are handled while it returns.
+func (ad *ActionDispatcher) dispatchActionWithTimeout(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
+	timeout := ad.cfg.TimeoutFor(a.Type())
+	if timeout <= 0 {
+		return ad.dispatchAction(ctx, a, acker)
+	}
+
+	hCtx, cancel := context.WithTimeout(ctx, timeout)
+	// released when the deadline expires, after the background work of the handler
+	time.AfterFunc(timeout, cancel)
+
+	guard := &timeoutAcker{Acker: acker}
+	done := make(chan error, 1)
+	go func() {
+		done <- ad.dispatchAction(hCtx, a, guard)
+	}()
+	select {
+	case err := <-done:
+		return err
+	case <-hCtx.Done():
+	}
+
+	acked := guard.timeout()
+	go func() {
+		if err := <-done; err != nil {
+			ad.log.Debugf("Timed out action id %q of type %q returned: %v", a.ID(), a.Type(), err)
+		}
+	}()
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	timeoutErr := &ActionTimeoutError{ActionID: a.ID(), ActionType: a.Type(), Timeout: timeout}
+	if _, retryable := a.(fleetapi.RetryableAction); !retryable && !acked {
+		// the handler did not get to ack the action, ack it as failed
+		if err := acker.Ack(ctx, &failedAction{Action: a, err: timeoutErr}); err != nil {
+			ad.log.Errorf("Unable to ack action timeout (id %s): %v", a.ID(), err)
+		}
+	}
+	return timeoutErr
+}
+
+// timeoutAcker drops the acks of a handler once its action timed out, so the action is
+// not acked twice.
+type timeoutAcker struct {
+	acker.Acker
+
+	mx       sync.Mutex
+	acked    bool
+	timedOut bool
+}
+
+func (a *timeoutAcker) Ack(ctx context.Context, action fleetapi.Action) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	if a.timedOut {
+		return nil
 	}
+	a.acked = true
+	return a.Acker.Ack(ctx, action)
+}
+
+// timeout marks the action as timed out and returns whether the handler acked it already.
+func (a *timeoutAcker) timeout() bool {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	a.timedOut = true
+	return a.acked
+}
+
+// failedAction is an action acked with the error that made it fail.
+type failedAction struct {
+	fleetapi.Action
+	err error
+}
+
+func (a *failedAction) AckEvent() fleetapi.AckEvent {
+	event := a.Action.AckEvent()
+	fleetapi.SetAckEventError(&event, a.err)
+	return event
 }
 
 func (ad *ActionDispatcher) dispatchAction(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
@@ -223,7 +518,10 @@ func (ad *ActionDispatcher) dispatchCancelActions(ctx context.Context, actions [
 			actions = append(actions[:i], actions[i+1:]...)
 			if err := ad.dispatchAction(ctx, action, acker); err != nil {
 				ad.log.Errorf("Unable to dispatch cancel action id %s: %v", action.ID(), err)
+				ad.history.add(action, ActionResultFailed, err)
+				continue
 			}
+			ad.history.add(action, ActionResultSuccess, nil)
 		}
 	}
 	return actions
@@ -295,6 +593,7 @@ func (ad *ActionDispatcher) handleExpired(
 	upgradeDetailsSetter details.Observer) {
 
 	for _, e := range expired {
+		ad.history.add(e, ActionResultExpired, nil)
 		if e.Type() == fleetapi.ActionTypeUpgrade {
 			// there is a scheduled upgrade set, if it isn't the same actions as
 			// the expired, the current status take precedence
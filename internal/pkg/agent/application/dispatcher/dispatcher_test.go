@@ -6,7 +6,10 @@ package dispatcher
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
@@ -17,6 +20,7 @@ import (
 	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker/noop"
@@ -32,6 +36,14 @@ func (h *mockHandler) Handle(ctx context.Context, a fleetapi.Action, acker acker
 	return args.Error(0)
 }
 
+// handlerFunc is a handler for the handlers still running after their action timed out,
+// mockHandler would format its arguments while the dispatcher updates the acker guard.
+type handlerFunc func(ctx context.Context, a fleetapi.Action, acker acker.Acker) error
+
+func (f handlerFunc) Handle(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
+	return f(ctx, a, acker)
+}
+
 // need various action structs as the dispather uses type reflection for routing, not action.Type()
 type mockAction struct {
 	mock.Mock
@@ -101,6 +113,16 @@ func (m *mockQueue) DequeueActions() []fleetapi.ScheduledAction {
 	return args.Get(0).([]fleetapi.ScheduledAction)
 }
 
+func (m *mockQueue) Actions() []fleetapi.ScheduledAction {
+	args := m.Called()
+	return args.Get(0).([]fleetapi.ScheduledAction)
+}
+
+func (m *mockQueue) Cancel(id string) int {
+	args := m.Called(id)
+	return args.Int(0)
+}
+
 func (m *mockQueue) CancelType(t string) int {
 	args := m.Called(t)
 	return args.Int(0)
@@ -663,6 +685,357 @@ func Test_ActionDispatcher_scheduleRetry(t *testing.T) {
 	})
 }
 
+func Test_ActionDispatcher_CancelQueuedAction(t *testing.T) {
+	def := &mockHandler{}
+	action := &mockScheduledAction{}
+	action.On("ID").Return("id")
+	action.On("Type").Return("action")
+	action.On("AckEvent").Return(fleetapi.AckEvent{ActionID: "id"})
+
+	queue := &mockQueue{}
+	queue.On("Actions").Return([]fleetapi.ScheduledAction{action})
+	queue.On("Cancel", "id").Return(1).Once()
+	queue.On("Cancel", "unknown").Return(0).Once()
+	queue.On("Save").Return(nil).Once()
+	d, err := New(nil, t.TempDir(), def, queue)
+	require.NoError(t, err)
+
+	ack := &recordingAcker{}
+	detailsSetter := func(*details.Details) { t.Error("the upgrade details must not change") }
+	n, err := d.CancelQueuedAction(context.Background(), detailsSetter, ack, "unknown")
+	require.NoError(t, err)
+	assert.Zero(t, n)
+	assert.Empty(t, ack.acked)
+
+	n, err = d.CancelQueuedAction(context.Background(), detailsSetter, ack, "id")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	require.Len(t, ack.acked, 1, "the cancelled action is acked to fleet")
+	assert.Equal(t, "id", ack.acked[0].ActionID)
+	assert.Contains(t, string(ack.acked[0].Payload), string(fleetapi.ErrorCodeCancelled))
+
+	history := d.ActionHistory()
+	require.Len(t, history, 1)
+	assert.Equal(t, "id", history[0].ID)
+	assert.Equal(t, ActionResultCancelled, history[0].Result)
+	queue.AssertExpectations(t)
+}
+
+func Test_ActionDispatcher_CancelQueuedUpgrade(t *testing.T) {
+	def := &mockHandler{}
+	upgrade := &fleetapi.ActionUpgrade{
+		ActionID:        "upgrade-id",
+		ActionType:      fleetapi.ActionTypeUpgrade,
+		ActionStartTime: time.Now().Add(time.Hour).Format(time.RFC3339),
+		Data:            fleetapi.ActionUpgradeData{Version: "9.0.0"},
+	}
+
+	queue := &mockQueue{}
+	queue.On("CancelType", fleetapi.ActionTypeUpgrade).Return(0).Once()
+	queue.On("Add", upgrade, mock.Anything).Once()
+	queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{}).Once()
+	queue.On("Actions").Return([]fleetapi.ScheduledAction{upgrade}).Once()
+	queue.On("Cancel", "upgrade-id").Return(1).Once()
+	queue.On("Save").Return(nil).Twice()
+	d, err := New(nil, t.TempDir(), def, queue)
+	require.NoError(t, err)
+
+	var gotDetails *details.Details
+	detailsSetter := func(upgradeDetails *details.Details) {
+		gotDetails = upgradeDetails
+	}
+	d.Dispatch(context.Background(), detailsSetter, noop.New(), upgrade)
+	require.NotNil(t, gotDetails)
+	require.Equal(t, details.StateScheduled, gotDetails.State)
+
+	n, err := d.CancelQueuedAction(context.Background(), detailsSetter, &recordingAcker{}, "upgrade-id")
+	require.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Nil(t, gotDetails, "the cancelled upgrade is no longer reported as scheduled")
+	queue.AssertExpectations(t)
+}
+
+func Test_ActionDispatcher_PersistedActionHistory(t *testing.T) {
+	store := &memoryHistoryStore{}
+	def := &mockHandler{}
+	action := &mockScheduledAction{}
+	action.On("ID").Return("id")
+	action.On("Type").Return("action")
+	action.On("AckEvent").Return(fleetapi.AckEvent{ActionID: "id"})
+
+	queue := &mockQueue{}
+	queue.On("Actions").Return([]fleetapi.ScheduledAction{action})
+	queue.On("Cancel", "id").Return(1).Once()
+	queue.On("Save").Return(nil).Once()
+	d, err := New(nil, t.TempDir(), def, queue, WithHistoryStore(store))
+	require.NoError(t, err)
+
+	_, err = d.CancelQueuedAction(context.Background(), func(*details.Details) {}, noop.New(), "id")
+	require.NoError(t, err)
+	assert.Equal(t, 1, store.saved)
+
+	// a new dispatcher, like after a restart, loads the history from the store
+	d, err = New(nil, t.TempDir(), def, &mockQueue{}, WithHistoryStore(store))
+	require.NoError(t, err)
+	history := d.ActionHistory()
+	require.Len(t, history, 1)
+	assert.Equal(t, "id", history[0].ID)
+	assert.Equal(t, "action", history[0].Type)
+	assert.Equal(t, ActionResultCancelled, history[0].Result)
+}
+
+type memoryHistoryStore struct {
+	history json.RawMessage
+	saved   int
+}
+
+func (s *memoryHistoryStore) SetActionHistory(history json.RawMessage) {
+	s.history = history
+}
+
+func (s *memoryHistoryStore) ActionHistory() json.RawMessage {
+	return s.history
+}
+
+func (s *memoryHistoryStore) Save() error {
+	s.saved++
+	return nil
+}
+
+func Test_ActionDispatcher_ActionHistory(t *testing.T) {
+	def := &mockHandler{}
+	def.On("Handle", mock.Anything, mock.Anything, mock.Anything).Return(errors.New("test error")).Once()
+	success := &mockHandler{}
+	success.On("Handle", mock.Anything, mock.Anything, mock.Anything).Return(nil).Once()
+
+	queue := &mockQueue{}
+	queue.On("Save").Return(nil).Once()
+	queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{}).Once()
+	d, err := New(nil, t.TempDir(), def, queue)
+	require.NoError(t, err)
+	require.NoError(t, d.Register(&mockAction{}, success))
+
+	action1 := &mockAction{}
+	action1.On("Type").Return("action")
+	action1.On("ID").Return("id1")
+	action2 := &mockOtherAction{}
+	action2.On("Type").Return("other")
+	action2.On("ID").Return("id2")
+
+	go d.Dispatch(context.Background(), func(*details.Details) {}, noop.New(), action1, action2)
+	require.Error(t, <-d.Errors())
+
+	// actions of different types are handled concurrently, in any order
+	history := d.ActionHistory()
+	require.Len(t, history, 2)
+	byID := map[string]HandledAction{history[0].ID: history[0], history[1].ID: history[1]}
+	require.Contains(t, byID, "id1")
+	require.Contains(t, byID, "id2")
+	assert.Equal(t, ActionResultSuccess, byID["id1"].Result)
+	assert.Equal(t, "other", byID["id2"].Type)
+	assert.Equal(t, ActionResultFailed, byID["id2"].Result)
+	assert.Equal(t, "test error", byID["id2"].Error)
+}
+
+type recordingAcker struct {
+	mx    sync.Mutex
+	acked []fleetapi.AckEvent
+}
+
+func (a *recordingAcker) Ack(_ context.Context, action fleetapi.Action) error {
+	a.mx.Lock()
+	defer a.mx.Unlock()
+	a.acked = append(a.acked, action.AckEvent())
+	return nil
+}
+
+func (a *recordingAcker) Commit(context.Context) error {
+	return nil
+}
+
+func newTestAction(actionType, id string) *mockAction {
+	action := &mockAction{}
+	action.On("Type").Return(actionType)
+	action.On("ID").Return(id)
+	action.On("AckEvent").Return(fleetapi.AckEvent{ActionID: id}).Maybe()
+	return action
+}
+
+func newTestQueue() *mockQueue {
+	queue := &mockQueue{}
+	queue.On("Save").Return(nil)
+	queue.On("DequeueActions").Return([]fleetapi.ScheduledAction{})
+	return queue
+}
+
+func Test_ActionDispatcher_Concurrency(t *testing.T) {
+	t.Run("a slow action does not block other types", func(t *testing.T) {
+		release := make(chan struct{})
+		started := make(chan struct{})
+		def := &mockHandler{}
+		def.On("Handle", mock.Anything, mock.MatchedBy(func(a fleetapi.Action) bool { return a.Type() == fleetapi.ActionTypeDiagnostics }), mock.Anything).
+			Run(func(mock.Arguments) {
+				close(started)
+				<-release
+			}).Return(nil).Once()
+		def.On("Handle", mock.Anything, mock.MatchedBy(func(a fleetapi.Action) bool { return a.Type() == fleetapi.ActionTypePolicyChange }), mock.Anything).
+			Run(func(mock.Arguments) {
+				<-started
+				// the policy change completes while the diagnostics are still running
+				close(release)
+			}).Return(nil).Once()
+
+		// with the default configuration
+		d, err := New(nil, t.TempDir(), def, newTestQueue())
+		require.NoError(t, err)
+
+		go d.Dispatch(context.Background(), func(*details.Details) {}, noop.New(),
+			newTestAction(fleetapi.ActionTypeDiagnostics, "diag"),
+			newTestAction(fleetapi.ActionTypePolicyChange, "policy"))
+		select {
+		case err := <-d.Errors():
+			require.NoError(t, err)
+		case <-time.After(10 * time.Second):
+			t.Fatal("dispatch blocked on the slow action")
+		}
+		def.AssertExpectations(t)
+	})
+
+	t.Run("ordered actions are handled in order", func(t *testing.T) {
+		var mx sync.Mutex
+		var handled []string
+		def := &mockHandler{}
+		def.On("Handle", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			a := args.Get(1).(fleetapi.Action)
+			if a.ID() == "policy-1" {
+				// give the other actions a chance to overtake the first one
+				time.Sleep(50 * time.Millisecond)
+			}
+			mx.Lock()
+			handled = append(handled, a.ID())
+			mx.Unlock()
+		}).Return(nil)
+
+		d, err := New(nil, t.TempDir(), def, newTestQueue(), WithActionsConfig(&configuration.ActionsConfig{MaxConcurrency: 4}))
+		require.NoError(t, err)
+
+		go d.Dispatch(context.Background(), func(*details.Details) {}, noop.New(),
+			newTestAction(fleetapi.ActionTypePolicyChange, "policy-1"),
+			newTestAction(fleetapi.ActionTypeInputAction, "input"),
+			newTestAction(fleetapi.ActionTypePolicyChange, "policy-2"),
+			newTestAction(fleetapi.ActionTypeUnenroll, "unenroll"))
+		require.NoError(t, <-d.Errors())
+
+		require.Len(t, handled, 4)
+		var ordered []string
+		for _, id := range handled {
+			if id != "input" {
+				ordered = append(ordered, id)
+			}
+		}
+		assert.Equal(t, []string{"policy-1", "policy-2", "unenroll"}, ordered)
+	})
+
+	t.Run("timed out action is acked as failed", func(t *testing.T) {
+		def := handlerFunc(func(ctx context.Context, _ fleetapi.Action, _ acker.Acker) error {
+			<-ctx.Done()
+			return nil
+		})
+
+		d, err := New(nil, t.TempDir(), def, newTestQueue(), WithActionsConfig(&configuration.ActionsConfig{
+			MaxConcurrency: 1,
+			Timeout:        time.Hour,
+			Timeouts:       map[string]time.Duration{fleetapi.ActionTypeDiagnostics: 10 * time.Millisecond},
+		}))
+		require.NoError(t, err)
+
+		ack := &recordingAcker{}
+		go d.Dispatch(context.Background(), func(*details.Details) {}, ack, newTestAction(fleetapi.ActionTypeDiagnostics, "diag"))
+		err = <-d.Errors()
+		var timeoutErr *ActionTimeoutError
+		require.ErrorAs(t, err, &timeoutErr)
+		assert.Equal(t, "diag", timeoutErr.ActionID)
+		assert.Equal(t, 10*time.Millisecond, timeoutErr.Timeout)
+		assert.ErrorIs(t, err, context.DeadlineExceeded)
+
+		require.Len(t, ack.acked, 1)
+		assert.Equal(t, "diag", ack.acked[0].ActionID)
+		assert.Equal(t, timeoutErr.Error(), ack.acked[0].Error)
+
+		history := d.ActionHistory()
+		require.Len(t, history, 1)
+		assert.Equal(t, ActionResultTimedOut, history[0].Result)
+	})
+
+	t.Run("timed out action does not block its lane and is acked once", func(t *testing.T) {
+		release := make(chan struct{})
+		returned := make(chan struct{})
+		var handled atomic.Bool
+		def := handlerFunc(func(ctx context.Context, a fleetapi.Action, acker acker.Acker) error {
+			if a.ID() == "diag-2" {
+				handled.Store(true)
+				return nil
+			}
+			<-ctx.Done()
+			<-release
+			// late ack of the handler, dropped as the action is acked as timed out
+			_ = acker.Ack(context.Background(), a)
+			close(returned)
+			return nil
+		})
+
+		d, err := New(nil, t.TempDir(), def, newTestQueue(), WithActionsConfig(&configuration.ActionsConfig{
+			MaxConcurrency: 1,
+			Timeout:        10 * time.Millisecond,
+		}))
+		require.NoError(t, err)
+
+		ack := &recordingAcker{}
+		go d.Dispatch(context.Background(), func(*details.Details) {}, ack,
+			newTestAction(fleetapi.ActionTypeDiagnostics, "diag-1"),
+			newTestAction(fleetapi.ActionTypeDiagnostics, "diag-2"))
+		select {
+		case err := <-d.Errors():
+			// the error of the last failed action of the lane
+			var timeoutErr *ActionTimeoutError
+			require.ErrorAs(t, err, &timeoutErr)
+		case <-time.After(10 * time.Second):
+			t.Fatal("dispatch blocked on the timed out handler")
+		}
+		assert.True(t, handled.Load(), "the next action of the lane must be handled while the timed out one runs")
+
+		close(release)
+		<-returned
+		ack.mx.Lock()
+		defer ack.mx.Unlock()
+		require.Len(t, ack.acked, 1)
+		assert.Equal(t, "diag-1", ack.acked[0].ActionID)
+	})
+
+	t.Run("background work of the handler keeps the action deadline", func(t *testing.T) {
+		asyncCtx := make(chan context.Context, 1)
+		def := &mockHandler{}
+		def.On("Handle", mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+			asyncCtx <- args.Get(0).(context.Context)
+		}).Return(nil).Once()
+
+		d, err := New(nil, t.TempDir(), def, newTestQueue(), WithActionsConfig(&configuration.ActionsConfig{
+			Timeouts: map[string]time.Duration{fleetapi.ActionTypeDiagnostics: time.Hour},
+		}))
+		require.NoError(t, err)
+
+		go d.Dispatch(context.Background(), func(*details.Details) {}, noop.New(), newTestAction(fleetapi.ActionTypeDiagnostics, "diag"))
+		require.NoError(t, <-d.Errors())
+
+		ctx := <-asyncCtx
+		assert.NoError(t, ctx.Err(), "the handler context should not be cancelled when the handler returns")
+		deadline, ok := ctx.Deadline()
+		require.True(t, ok)
+		assert.WithinDuration(t, time.Now().Add(time.Hour), deadline, time.Minute)
+	})
+}
+
 func TestReportNextScheduledUpgrade(t *testing.T) {
 	now := time.Now().UTC()
 	later := now.Add(3 * time.Hour)
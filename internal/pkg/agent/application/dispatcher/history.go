@@ -0,0 +1,126 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package dispatcher
+
+import (
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// number of handled actions kept in the history
+const historySize = 100
+
+// ActionResult is the result of handling an action.
+type ActionResult string
+
+const (
+	// ActionResultSuccess is the result of an action successfully handled.
+	ActionResultSuccess ActionResult = "success"
+	// ActionResultFailed is the result of an action that failed.
+	ActionResultFailed ActionResult = "failed"
+	// ActionResultTimedOut is the result of an action whose handler did not complete in time.
+	ActionResultTimedOut ActionResult = "timed_out"
+	// ActionResultRetry is the result of an action that failed and was scheduled for a retry.
+	ActionResultRetry ActionResult = "retry"
+	// ActionResultExpired is the result of a scheduled action that expired before being handled.
+	ActionResultExpired ActionResult = "expired"
+	// ActionResultCancelled is the result of a queued action cancelled locally.
+	ActionResultCancelled ActionResult = "cancelled"
+)
+
+// HandledAction is an action handled by the dispatcher.
+type HandledAction struct {
+	ID     string       `json:"id"`
+	Type   string       `json:"type"`
+	Time   time.Time    `json:"time"`
+	Result ActionResult `json:"result"`
+	Error  string       `json:"error,omitempty"`
+	// ErrorCode is the machine-readable code of the error, see fleetapi.ErrorCode.
+	ErrorCode string `json:"error_code,omitempty"`
+}
+
+// historyStore persists the history along with the action queue, the dispatcher owns the
+// format of the stored history.
+type historyStore interface {
+	SetActionHistory(json.RawMessage)
+	ActionHistory() json.RawMessage
+	Save() error
+}
+
+// history is a bounded history of the handled actions, persisted to the store when one is set.
+type history struct {
+	mx      sync.Mutex
+	entries []HandledAction
+	store   historyStore
+	log     *logger.Logger
+}
+
+// load reads the history persisted in the store.
+func (h *history) load() {
+	if h.store == nil {
+		return
+	}
+	data := h.store.ActionHistory()
+	if len(data) == 0 {
+		return
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	if err := json.Unmarshal(data, &h.entries); err != nil {
+		h.log.Warnf("failed to load the action history, starting with an empty one: %v", err)
+		h.entries = nil
+	}
+}
+
+func (h *history) add(action fleetapi.Action, result ActionResult, err error) {
+	entry := HandledAction{
+		ID:     action.ID(),
+		Type:   action.Type(),
+		Time:   time.Now().UTC(),
+		Result: result,
+	}
+	if err != nil {
+		entry.Error = err.Error()
+		entry.ErrorCode = string(fleetapi.AckErrorFrom(err).Code)
+	}
+
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.entries = append(h.entries, entry)
+	if len(h.entries) > historySize {
+		h.entries = h.entries[len(h.entries)-historySize:]
+	}
+	h.save()
+}
+
+// save persists the history, h.mx must be held.
+func (h *history) save() {
+	if h.store == nil {
+		return
+	}
+	data, err := json.Marshal(h.entries)
+	if err != nil {
+		h.log.Errorf("failed to marshal the action history: %v", err)
+		return
+	}
+	h.store.SetActionHistory(data)
+	if err := h.store.Save(); err != nil {
+		h.log.Errorf("failed to persist the action history: %v", err)
+	}
+}
+
+// list returns the handled actions, oldest first.
+func (h *history) list() []HandledAction {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	entries := make([]HandledAction, len(h.entries))
+	copy(entries, h.entries)
+	return entries
+}
@@ -0,0 +1,117 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package fleet
+
+import (
+	"reflect"
+	"sort"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+// checkinDelta tracks the components sent in the last acknowledged check-in, so the
+// following check-ins only send what changed.
+//
+// A full snapshot is sent when Fleet Server did not advertise support for delta
+// check-ins, after a failed check-in and every fullInterval.
+type checkinDelta struct {
+	fullInterval time.Duration
+	supported    bool
+
+	// components of the last acknowledged check-in by ID, nil until one was acknowledged
+	last     map[string]fleetapi.CheckinComponent
+	lastFull time.Time
+}
+
+// prepare returns the components to send in the check-in, the IDs of the removed
+// components and whether the check-in is a delta.
+func (d *checkinDelta) prepare(now time.Time, components []fleetapi.CheckinComponent) ([]fleetapi.CheckinComponent, []string, bool) {
+	if !d.supported || d.last == nil || d.fullInterval <= 0 || now.Sub(d.lastFull) >= d.fullInterval {
+		return components, nil, false
+	}
+	changed, removed := deltaCheckinComponents(d.last, components)
+	return changed, removed, true
+}
+
+// acknowledged records the components of a successful check-in.
+func (d *checkinDelta) acknowledged(now time.Time, components []fleetapi.CheckinComponent, delta bool, supported bool) {
+	d.supported = supported
+	if !supported {
+		d.last = nil
+		return
+	}
+	d.last = make(map[string]fleetapi.CheckinComponent, len(components))
+	for _, c := range components {
+		d.last[c.ID] = c
+	}
+	if !delta {
+		d.lastFull = now
+	}
+}
+
+// reset forces the next check-in to be a full snapshot.
+func (d *checkinDelta) reset() {
+	d.last = nil
+}
+
+// deltaCheckinComponents returns the components that changed compared to last, with only
+// their changed units, and the IDs of the components that are no longer present.
+func deltaCheckinComponents(last map[string]fleetapi.CheckinComponent, components []fleetapi.CheckinComponent) ([]fleetapi.CheckinComponent, []string) {
+	var changed []fleetapi.CheckinComponent
+	current := make(map[string]struct{}, len(components))
+	for _, c := range components {
+		current[c.ID] = struct{}{}
+		prev, ok := last[c.ID]
+		if !ok {
+			changed = append(changed, c)
+			continue
+		}
+		units, removedUnits := deltaCheckinUnits(prev.Units, c.Units)
+		if prev.Type == c.Type && prev.Status == c.Status && prev.Message == c.Message && len(units) == 0 && len(removedUnits) == 0 {
+			continue
+		}
+		c.Units = units
+		c.RemovedUnits = removedUnits
+		changed = append(changed, c)
+	}
+
+	var removed []string
+	for id := range last {
+		if _, ok := current[id]; !ok {
+			removed = append(removed, id)
+		}
+	}
+	sort.Strings(removed)
+	return changed, removed
+}
+
+func deltaCheckinUnits(last []fleetapi.CheckinUnit, units []fleetapi.CheckinUnit) ([]fleetapi.CheckinUnit, []fleetapi.CheckinRemovedUnit) {
+	prev := make(map[fleetapi.CheckinRemovedUnit]fleetapi.CheckinUnit, len(last))
+	for _, u := range last {
+		prev[fleetapi.CheckinRemovedUnit{Type: u.Type, ID: u.ID}] = u
+	}
+
+	var changed []fleetapi.CheckinUnit
+	for _, u := range units {
+		key := fleetapi.CheckinRemovedUnit{Type: u.Type, ID: u.ID}
+		if p, ok := prev[key]; !ok || !reflect.DeepEqual(p, u) {
+			changed = append(changed, u)
+		}
+		delete(prev, key)
+	}
+
+	var removed []fleetapi.CheckinRemovedUnit
+	for key := range prev {
+		removed = append(removed, key)
+	}
+	sort.Slice(removed, func(i, j int) bool {
+		if removed[i].Type != removed[j].Type {
+			return removed[i].Type < removed[j].Type
+		}
+		return removed[i].ID < removed[j].ID
+	})
+	return changed, removed
+}
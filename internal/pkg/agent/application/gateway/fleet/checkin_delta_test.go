@@ -0,0 +1,83 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package fleet
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+func TestDeltaCheckinComponents(t *testing.T) {
+	last := map[string]fleetapi.CheckinComponent{
+		"unchanged": {ID: "unchanged", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+			{ID: "unchanged-input", Type: "input", Status: "HEALTHY"},
+		}},
+		"changed": {ID: "changed", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+			{ID: "changed-input-1", Type: "input", Status: "HEALTHY"},
+			{ID: "changed-input-2", Type: "input", Status: "HEALTHY", Payload: map[string]interface{}{"events": 1}},
+			{ID: "changed-input-3", Type: "input", Status: "HEALTHY"},
+			{ID: "changed", Type: "output", Status: "HEALTHY"},
+		}},
+		"removed": {ID: "removed", Status: "HEALTHY"},
+	}
+	components := []fleetapi.CheckinComponent{
+		{ID: "unchanged", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+			{ID: "unchanged-input", Type: "input", Status: "HEALTHY"},
+		}},
+		{ID: "changed", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+			{ID: "changed-input-1", Type: "input", Status: "HEALTHY"},
+			{ID: "changed-input-2", Type: "input", Status: "HEALTHY", Payload: map[string]interface{}{"events": 2}},
+			{ID: "changed", Type: "input", Status: "HEALTHY"},
+		}},
+		{ID: "added", Status: "STARTING"},
+	}
+
+	changed, removed := deltaCheckinComponents(last, components)
+	assert.Equal(t, []fleetapi.CheckinComponent{
+		{ID: "changed", Status: "HEALTHY", Units: []fleetapi.CheckinUnit{
+			{ID: "changed-input-2", Type: "input", Status: "HEALTHY", Payload: map[string]interface{}{"events": 2}},
+			{ID: "changed", Type: "input", Status: "HEALTHY"},
+		}, RemovedUnits: []fleetapi.CheckinRemovedUnit{
+			{Type: "input", ID: "changed-input-3"},
+			{Type: "output", ID: "changed"},
+		}},
+		{ID: "added", Status: "STARTING"},
+	}, changed)
+	assert.Equal(t, []string{"removed"}, removed)
+}
+
+func TestCheckinDelta(t *testing.T) {
+	now := time.Now()
+	components := []fleetapi.CheckinComponent{{ID: "comp", Status: "HEALTHY"}}
+	d := checkinDelta{fullInterval: 10 * time.Minute}
+
+	// full snapshot until the server advertises support
+	sent, _, delta := d.prepare(now, components)
+	assert.False(t, delta)
+	d.acknowledged(now, sent, delta, false)
+	_, _, delta = d.prepare(now, components)
+	assert.False(t, delta)
+	d.acknowledged(now, components, delta, true)
+
+	sent, removed, delta := d.prepare(now.Add(time.Minute), components)
+	assert.True(t, delta)
+	assert.Empty(t, sent)
+	assert.Empty(t, removed)
+	d.acknowledged(now.Add(time.Minute), components, delta, true)
+
+	// periodic full snapshot
+	sent, _, delta = d.prepare(now.Add(10*time.Minute), components)
+	assert.False(t, delta)
+	assert.Equal(t, components, sent)
+
+	// full snapshot after a failure
+	d.reset()
+	_, _, delta = d.prepare(now.Add(2*time.Minute), components)
+	assert.False(t, delta)
+}
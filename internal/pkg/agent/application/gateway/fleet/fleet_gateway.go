@@ -15,6 +15,7 @@ import (
 	eaclient "github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/core/backoff"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
@@ -56,6 +57,7 @@ type fleetGatewaySettings struct {
 	Jitter                       time.Duration    `config:"jitter"`
 	Backoff                      *backoffSettings `config:"backoff"`
 	ErrConsecutiveUnauthDuration time.Duration
+	FullCheckinInterval          time.Duration // time between full snapshots when delta check-ins are supported
 }
 
 type backoffSettings struct {
@@ -86,6 +88,8 @@ type FleetGateway struct {
 	stateStore         stateStore
 	errCh              chan error
 	actionCh           chan []fleetapi.Action
+	checkinDelta       checkinDelta
+	checkinGzip        bool
 }
 
 // New creates a new fleet gateway
@@ -96,11 +100,14 @@ func New(
 	acker acker.Acker,
 	stateFetcher func() coordinator.State,
 	stateStore stateStore,
+	checkinCfg *configuration.FleetCheckinConfig,
 ) (*FleetGateway, error) {
-	scheduler := scheduler.NewPeriodicJitter(defaultGatewaySettings.Duration, defaultGatewaySettings.Jitter)
+	settings := *defaultGatewaySettings
+	settings.FullCheckinInterval = checkinCfg.FullCheckinInterval
+	scheduler := scheduler.NewPeriodicJitter(settings.Duration, settings.Jitter)
 	return newFleetGatewayWithScheduler(
 		log,
-		defaultGatewaySettings,
+		&settings,
 		agentInfo,
 		client,
 		scheduler,
@@ -131,6 +138,7 @@ func newFleetGatewayWithScheduler(
 		stateStore:   stateStore,
 		errCh:        make(chan error),
 		actionCh:     make(chan []fleetapi.Action, 1),
+		checkinDelta: checkinDelta{fullInterval: settings.FullCheckinInterval},
 	}, nil
 }
 
@@ -191,6 +199,7 @@ func (f *FleetGateway) doExecute(ctx context.Context, bo backoff.Backoff) (*flee
 	for ctx.Err() == nil {
 		f.log.Debugf("Checking started")
 		resp, took, err := f.execute(ctx)
+		recordCheckin(took, err)
 		if err != nil {
 			f.checkinFailCounter++
 
@@ -356,18 +365,34 @@ func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse,
 	// Fix loglevel with the current log level used by coordinator
 	ecsMeta.Elastic.Agent.LogLevel = state.LogLevel.String()
 
+	// only send the changes since the last check-in when Fleet Server supports it
+	now := time.Now()
+	checkinComponents, removedComponents, delta := f.checkinDelta.prepare(now, components)
+
 	// checkin
-	cmd := fleetapi.NewCheckinCmd(f.agentInfo, f.client)
+	var opts []fleetapi.CheckinCmdOption
+	if f.checkinGzip {
+		opts = append(opts, fleetapi.WithCheckinGzip())
+	}
+	cmd := fleetapi.NewCheckinCmd(f.agentInfo, f.client, opts...)
 	req := &fleetapi.CheckinRequest{
-		AckToken:       ackToken,
-		Metadata:       ecsMeta,
-		Status:         agentStateToString(state.State),
-		Message:        state.Message,
-		Components:     components,
-		UpgradeDetails: state.UpgradeDetails,
+		AckToken:          ackToken,
+		Metadata:          ecsMeta,
+		Status:            agentStateToString(state.State),
+		Message:           state.Message,
+		Components:        checkinComponents,
+		UpgradeDetails:    state.UpgradeDetails,
+		ComponentsDelta:   delta,
+		RemovedComponents: removedComponents,
 	}
 
 	resp, took, err := cmd.Execute(ctx, req)
+	if err != nil {
+		// fall back to a full uncompressed check-in, the server handling the retry
+		// might not support them
+		f.checkinDelta.reset()
+		f.checkinGzip = false
+	}
 	if isUnauth(err) {
 		f.unauthCounter++
 		if f.shouldUseLongSched() {
@@ -386,6 +411,9 @@ func (f *FleetGateway) execute(ctx context.Context) (*fleetapi.CheckinResponse,
 		return nil, took, err
 	}
 
+	f.checkinDelta.acknowledged(now, components, delta, resp.Supports(fleetapi.CheckinCapabilityDelta))
+	f.checkinGzip = resp.Supports(fleetapi.CheckinCapabilityGzip)
+
 	// Save the latest ackToken
 	if resp.AckToken != "" {
 		f.stateStore.SetAckToken(resp.AckToken)
@@ -22,6 +22,7 @@ import (
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/storage/store"
@@ -464,6 +465,62 @@ func TestRetriesOnFailures(t *testing.T) {
 		}))
 }
 
+func TestFleetGatewayFullCheckinInterval(t *testing.T) {
+	t.Run("configured interval is used by the gateway", func(t *testing.T) {
+		log, _ := logger.New("fleet_gateway", false)
+		gateway, err := New(log, &testAgentInfo{}, newTestingClient(), noop.New(), emptyStateFetcher, newStateStore(t, log),
+			&configuration.FleetCheckinConfig{FullCheckinInterval: time.Hour})
+		require.NoError(t, err)
+		assert.Equal(t, time.Hour, gateway.checkinDelta.fullInterval)
+		assert.Zero(t, defaultGatewaySettings.FullCheckinInterval, "default settings must not be modified")
+	})
+
+	testcases := []struct {
+		name          string
+		fullInterval  time.Duration
+		expectedDelta bool
+	}{
+		{name: "delta check-ins", fullInterval: time.Hour, expectedDelta: true},
+		{name: "full check-ins only", fullInterval: 0, expectedDelta: false},
+	}
+	for _, tc := range testcases {
+		settings := &fleetGatewaySettings{
+			Duration:            5 * time.Second,
+			Backoff:             &backoffSettings{Init: 1 * time.Second, Max: 5 * time.Second},
+			FullCheckinInterval: tc.fullInterval,
+		}
+		t.Run(tc.name, withGateway(&testAgentInfo{}, settings, func(
+			t *testing.T,
+			gateway coordinator.FleetGateway,
+			client *testingClient,
+			scheduler *scheduler.Stepper,
+		) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			errCh := runFleetGateway(ctx, gateway)
+
+			checkin := func() fleetapi.CheckinRequest {
+				var req fleetapi.CheckinRequest
+				waitFn := ackSeq(client.Answer(func(headers http.Header, body io.Reader) (*http.Response, error) {
+					require.NoError(t, json.NewDecoder(body).Decode(&req))
+					resp := wrapStrToResp(http.StatusOK, `{ "actions": [] }`)
+					resp.Header.Set("Elastic-Checkin-Capabilities", fleetapi.CheckinCapabilityDelta)
+					return resp, nil
+				}))
+				scheduler.Next()
+				waitFn()
+				return req
+			}
+
+			assert.False(t, checkin().ComponentsDelta, "first check-in must be a full snapshot")
+			assert.Equal(t, tc.expectedDelta, checkin().ComponentsDelta)
+
+			cancel()
+			require.NoError(t, <-errCh)
+		}))
+	}
+}
+
 type testAgentInfo struct{}
 
 func (testAgentInfo) AgentID() string { return "agent-secret" }
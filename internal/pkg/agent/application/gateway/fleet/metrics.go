@@ -0,0 +1,33 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package fleet
+
+import (
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// MetricsNamespace is the monitoring namespace holding the metrics of the check-ins with Fleet Server.
+const MetricsNamespace = "fleet"
+
+var (
+	checkinRegistry = monitoring.GetNamespace(MetricsNamespace).GetRegistry().NewRegistry("checkin")
+
+	checkinTotal    = monitoring.NewUint(checkinRegistry, "total")
+	checkinFailures = monitoring.NewUint(checkinRegistry, "failures")
+	// time the last check-in request was held by Fleet Server long polling for changes, it
+	// measures the poll time until a change or the poll timeout, not the latency of Fleet Server
+	checkinLongPoll = monitoring.NewInt(checkinRegistry, "long_poll_ns")
+)
+
+// recordCheckin updates the check-in metrics with the result of a check-in request.
+func recordCheckin(took time.Duration, err error) {
+	checkinTotal.Inc()
+	if err != nil {
+		checkinFailures.Inc()
+	}
+	checkinLongPoll.Set(int64(took))
+}
@@ -0,0 +1,340 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package application
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/actions/handlers"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/dispatcher"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage/store"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi/acker"
+	"github.com/elastic/elastic-agent/internal/pkg/queue"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const (
+	// suffix of the local action files that could not be parsed
+	invalidLocalActionSuffix = ".invalid"
+	// suffix of the local action files rejected because of their owner or permissions
+	rejectedLocalActionSuffix = ".rejected"
+)
+
+// localActionTypes are the action types a standalone agent accepts from the local actions directory.
+var localActionTypes = map[string]bool{
+	fleetapi.ActionTypeUpgrade:     true,
+	fleetapi.ActionTypeSettings:    true,
+	fleetapi.ActionTypeDiagnostics: true,
+	fleetapi.ActionTypeRequestLogs: true,
+	fleetapi.ActionTypeInputAction: true,
+	fleetapi.ActionTypeCancel:      true,
+}
+
+// localActionsConfigManager is the config manager of standalone agents accepting actions
+// from the host. It dispatches the actions written to the local actions directory and
+// writes their results to a local results file instead of acking them to Fleet.
+type localActionsConfigManager struct {
+	coordinator.ConfigManager
+
+	log         *logger.Logger
+	agentInfo   info.Agent
+	cfg         *configuration.Configuration
+	coord       *coordinator.Coordinator
+	actionQueue *queue.ActionQueue
+	dispatcher  *dispatcher.ActionDispatcher
+	acker       acker.Acker
+	dir         string
+	period      time.Duration
+}
+
+func newLocalActionsConfigManager(
+	log *logger.Logger,
+	agentInfo info.Agent,
+	cfg *configuration.Configuration,
+	configMgr coordinator.ConfigManager,
+	stateStore *store.StateStore,
+	actionAcker acker.Acker,
+	topPath string,
+) (*localActionsConfigManager, error) {
+	actionQueue, err := queue.NewActionQueue(stateStore.Queue(), stateStore)
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize local action queue: %w", err)
+	}
+
+	actionDispatcher, err := dispatcher.New(log, topPath, handlers.NewDefault(log), actionQueue,
+		dispatcher.WithActionsConfig(cfg.Settings.Actions), dispatcher.WithHistoryStore(stateStore))
+	if err != nil {
+		return nil, fmt.Errorf("unable to initialize local action dispatcher: %w", err)
+	}
+
+	localCfg := cfg.Settings.LocalActions
+	dir := localCfg.Path
+	if dir == "" {
+		dir = paths.LocalActionsDir()
+	}
+	period := localCfg.Period
+	if period <= 0 {
+		period = configuration.DefaultLocalActionsConfig().Period
+	}
+
+	return &localActionsConfigManager{
+		ConfigManager: configMgr,
+		log:           log,
+		agentInfo:     agentInfo,
+		cfg:           cfg,
+		actionQueue:   actionQueue,
+		dispatcher:    actionDispatcher,
+		acker:         actionAcker,
+		dir:           dir,
+		period:        period,
+	}, nil
+}
+
+func (m *localActionsConfigManager) Run(ctx context.Context) error {
+	// Check setup correctly in application (the coord must be set manually)
+	if m.coord == nil {
+		return errors.New("coord must be set before calling Run")
+	}
+
+	if err := os.MkdirAll(m.dir, 0o750); err != nil {
+		return fmt.Errorf("failed to create local actions directory %s: %w", m.dir, err)
+	}
+	if err := m.checkDir(); err != nil {
+		// not fatal, the directory is checked again before every scan
+		m.log.Errorf("Local actions are not read: %v", err)
+	}
+
+	m.initDispatcher()
+
+	if err := m.coord.AckUpgrade(ctx, m.acker); err != nil {
+		m.log.Warnf("Failed to ack upgrade: %v", err)
+	}
+
+	cfgErr := make(chan error, 1)
+	go func() {
+		cfgErr <- m.ConfigManager.Run(ctx)
+	}()
+
+	m.log.Infof("Watching %s for local actions", m.dir)
+	t := time.NewTimer(m.period)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return <-cfgErr
+		case err := <-cfgErr:
+			return err
+		case <-t.C:
+			// dispatch also runs the queued scheduled actions when no new action was written.
+			m.dispatcher.Dispatch(ctx, m.coord.SetUpgradeDetails, m.acker, m.readActions()...)
+			t.Reset(m.period)
+		}
+	}
+}
+
+// ActionErrors returns the error channel for actions.
+func (m *localActionsConfigManager) ActionErrors() <-chan error {
+	return m.dispatcher.Errors()
+}
+
+func (m *localActionsConfigManager) initDispatcher() {
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionUpgrade{},
+		handlers.NewUpgrade(m.log, m.coord),
+	)
+
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionSettings{},
+		handlers.NewSettings(m.log, m.agentInfo, m.coord),
+	)
+
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionCancel{},
+		handlers.NewCancel(m.log, m.actionQueue),
+	)
+
+	fileUploader := &localUploader{dir: paths.LocalDiagnosticsDir()}
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionDiagnostics{},
+		handlers.NewDiagnostics(
+			m.log,
+			paths.Top(), // TODO: stop using global state
+			m.coord,
+			m.cfg.Settings.MonitoringConfig.Diagnostics.Limit,
+			fileUploader,
+		),
+	)
+
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionRequestLogs{},
+		handlers.NewRequestLogs(
+			m.log,
+			paths.Top(), // TODO: stop using global state
+			m.cfg.Settings.MonitoringConfig.Diagnostics.Limit,
+			fileUploader,
+		),
+	)
+
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionApp{},
+		handlers.NewAppAction(m.log, m.coord, m.agentInfo.AgentID()),
+	)
+
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionUnknown{},
+		handlers.NewUnknown(m.log),
+	)
+}
+
+// checkDir returns an error when the local actions directory can be written by other users
+// than root and the user the agent runs as, the actions written to it are then not trusted.
+func (m *localActionsConfigManager) checkDir() error {
+	fi, err := os.Stat(m.dir)
+	if err != nil {
+		return fmt.Errorf("failed to get local actions directory info: %w", err)
+	}
+	if !fi.IsDir() {
+		return fmt.Errorf("local actions path %s is not a directory", m.dir)
+	}
+	return checkLocalActionsOwner(m.dir, fi)
+}
+
+// readActions reads and removes the action files from the local actions directory, in
+// name order. Files that cannot be parsed are renamed with the .invalid suffix, files
+// that are not regular files owned by root or the agent user with the .rejected suffix.
+// No file is read when the directory itself is not secure.
+func (m *localActionsConfigManager) readActions() []fleetapi.Action {
+	if err := m.checkDir(); err != nil {
+		m.log.Errorf("Local actions are not read: %v", err)
+		return nil
+	}
+
+	files, err := filepath.Glob(filepath.Join(m.dir, "*.json"))
+	if err != nil {
+		m.log.Errorf("Failed to list local actions in %s: %v", m.dir, err)
+		return nil
+	}
+	sort.Strings(files)
+
+	var actions []fleetapi.Action
+	for _, file := range files {
+		if err := checkLocalActionFile(file); err != nil {
+			m.log.Errorf("Rejected local action file %s: %v", file, err)
+			if err := os.Rename(file, file+rejectedLocalActionSuffix); err != nil {
+				m.log.Errorf("Failed to rename rejected local action file %s: %v", file, err)
+			}
+			continue
+		}
+		data, err := os.ReadFile(file)
+		if err != nil {
+			m.log.Errorf("Failed to read local action file %s: %v", file, err)
+			continue
+		}
+		parsed, err := parseLocalActions(data)
+		if err != nil {
+			m.log.Errorf("Failed to parse local action file %s: %v", file, err)
+			if err := os.Rename(file, file+invalidLocalActionSuffix); err != nil {
+				m.log.Errorf("Failed to rename invalid local action file %s: %v", file, err)
+			}
+			continue
+		}
+		// the file is removed before dispatching so an action is never handled twice
+		if err := os.Remove(file); err != nil {
+			m.log.Errorf("Failed to remove local action file %s, skipping it: %v", file, err)
+			continue
+		}
+		m.log.Infof("Read %d local actions from %s", len(parsed), file)
+		actions = append(actions, parsed...)
+	}
+	return actions
+}
+
+// checkLocalActionFile returns an error unless file is a regular file, not a link, owned
+// by root or the user the agent runs as.
+func checkLocalActionFile(file string) error {
+	fi, err := os.Lstat(file)
+	if err != nil {
+		return err
+	}
+	if !fi.Mode().IsRegular() {
+		return fmt.Errorf("%s is not a regular file", file)
+	}
+	return checkLocalActionsOwner(file, fi)
+}
+
+// parseLocalActions parses a single action or an array of actions, in the format Fleet
+// sends them on checkin. Actions of types that standalone agents do not accept are
+// turned into unknown actions, so they are acked with an error.
+func parseLocalActions(data []byte) ([]fleetapi.Action, error) {
+	data = bytes.TrimSpace(data)
+	if bytes.HasPrefix(data, []byte("{")) {
+		data = append(append([]byte("["), data...), ']')
+	}
+
+	var actions fleetapi.Actions
+	if err := actions.UnmarshalJSON(data); err != nil {
+		return nil, err
+	}
+
+	for i, a := range actions {
+		if a.ID() == "" {
+			return nil, fmt.Errorf("action %d of type %s has no id", i, a.Type())
+		}
+		if !localActionTypes[a.Type()] {
+			actions[i] = &fleetapi.ActionUnknown{
+				ActionID:     a.ID(),
+				ActionType:   fleetapi.ActionTypeUnknown,
+				OriginalType: a.Type(),
+			}
+		}
+	}
+	return actions, nil
+}
+
+// localUploader writes the diagnostics and logs bundles to a local directory instead of
+// uploading them to Fleet.
+type localUploader struct {
+	dir string
+}
+
+// UploadDiagnostics writes the diagnostics bundle to the directory and returns its path.
+func (u *localUploader) UploadDiagnostics(_ context.Context, actionID string, timestamp string, _ int64, r io.Reader) (string, error) {
+	return u.writeZip(fmt.Sprintf("elastic-agent-diagnostics-%s-%s.zip", timestamp, actionID), r)
+}
+
+// UploadLogs writes the logs bundle to the directory and returns its path.
+func (u *localUploader) UploadLogs(_ context.Context, actionID string, timestamp string, _ int64, r io.Reader) (string, error) {
+	return u.writeZip(fmt.Sprintf("elastic-agent-logs-%s-%s.zip", timestamp, actionID), r)
+}
+
+func (u *localUploader) writeZip(name string, r io.Reader) (string, error) {
+	if err := os.MkdirAll(u.dir, 0o750); err != nil {
+		return "", fmt.Errorf("failed to create diagnostics directory %s: %w", u.dir, err)
+	}
+	path := filepath.Join(u.dir, strings.ReplaceAll(name, string(filepath.Separator), "_"))
+	f, err := os.OpenFile(path, os.O_CREATE|os.O_EXCL|os.O_WRONLY, 0o600)
+	if err != nil {
+		return "", fmt.Errorf("failed to create bundle %s: %w", path, err)
+	}
+	defer f.Close()
+	if _, err := io.Copy(f, r); err != nil {
+		return "", fmt.Errorf("failed to write bundle %s: %w", path, err)
+	}
+	return path, nil
+}
@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package application
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func TestParseLocalActions(t *testing.T) {
+	t.Run("single action", func(t *testing.T) {
+		actions, err := parseLocalActions([]byte(`{"id": "action-1", "type": "UPGRADE", "data": {"version": "9.1.0"}}`))
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		upgrade, ok := actions[0].(*fleetapi.ActionUpgrade)
+		require.True(t, ok, "expected an upgrade action, got %T", actions[0])
+		assert.Equal(t, "action-1", upgrade.ActionID)
+		assert.Equal(t, "9.1.0", upgrade.Data.Version)
+	})
+
+	t.Run("array of actions", func(t *testing.T) {
+		actions, err := parseLocalActions([]byte(`[
+			{"id": "action-1", "type": "SETTINGS", "data": {"log_level": "debug"}},
+			{"id": "action-2", "type": "REQUEST_DIAGNOSTICS"}
+		]`))
+		require.NoError(t, err)
+		require.Len(t, actions, 2)
+		assert.IsType(t, &fleetapi.ActionSettings{}, actions[0])
+		assert.IsType(t, &fleetapi.ActionDiagnostics{}, actions[1])
+	})
+
+	t.Run("unsupported type", func(t *testing.T) {
+		actions, err := parseLocalActions([]byte(`{"id": "action-1", "type": "UNENROLL"}`))
+		require.NoError(t, err)
+		require.Len(t, actions, 1)
+		unknown, ok := actions[0].(*fleetapi.ActionUnknown)
+		require.True(t, ok, "expected an unknown action, got %T", actions[0])
+		assert.Equal(t, "action-1", unknown.ActionID)
+		assert.Equal(t, fleetapi.ActionTypeUnenroll, unknown.OriginalType)
+	})
+
+	t.Run("missing id", func(t *testing.T) {
+		_, err := parseLocalActions([]byte(`{"type": "SETTINGS"}`))
+		assert.Error(t, err)
+	})
+
+	t.Run("invalid JSON", func(t *testing.T) {
+		_, err := parseLocalActions([]byte(`{"id": `))
+		assert.Error(t, err)
+	})
+}
+
+func TestLocalActionsConfigManager_readActions(t *testing.T) {
+	log, _ := loggertest.New(t.Name())
+	dir := t.TempDir()
+	m := &localActionsConfigManager{log: log, dir: dir}
+
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "2.json"), []byte(`{"id": "action-2", "type": "SETTINGS"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "1.json"), []byte(`{"id": "action-1", "type": "SETTINGS"}`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "3.json"), []byte(`not json`), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "ignored.txt"), []byte(`{"id": "action-4", "type": "SETTINGS"}`), 0o600))
+
+	actions := m.readActions()
+	require.Len(t, actions, 2)
+	assert.Equal(t, "action-1", actions[0].ID())
+	assert.Equal(t, "action-2", actions[1].ID())
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for _, e := range entries {
+		names = append(names, e.Name())
+	}
+	assert.ElementsMatch(t, []string{"3.json" + invalidLocalActionSuffix, "ignored.txt"}, names)
+
+	// actions are read only once
+	assert.Empty(t, m.readActions())
+}
+
+func TestLocalUploader(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "diagnostics")
+	u := &localUploader{dir: dir}
+
+	path, err := u.UploadDiagnostics(context.Background(), "action-1", "2024-05-01T12-00-00Z", 4, strings.NewReader("data"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "elastic-agent-diagnostics-2024-05-01T12-00-00Z-action-1.zip"), path)
+
+	data, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "data", string(data))
+
+	// an existing bundle is never overwritten
+	_, err = u.UploadDiagnostics(context.Background(), "action-1", "2024-05-01T12-00-00Z", 4, strings.NewReader("other"))
+	assert.Error(t, err)
+
+	path, err = u.UploadLogs(context.Background(), "action-2", "2024-05-01T12-00-00Z", 4, strings.NewReader("logs"))
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, "elastic-agent-logs-2024-05-01T12-00-00Z-action-2.zip"), path)
+}
@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build !windows
+
+package application
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+)
+
+// checkLocalActionsOwner returns an error unless the local actions directory or action file
+// is owned by root or by the user the agent runs as, and is not writable by the group or
+// other users, so only they can write actions.
+func checkLocalActionsOwner(path string, fi os.FileInfo) error {
+	stat, ok := fi.Sys().(*syscall.Stat_t)
+	if !ok {
+		return fmt.Errorf("failed to get the owner of %s", path)
+	}
+	if stat.Uid != 0 && int(stat.Uid) != os.Geteuid() {
+		return fmt.Errorf("%s is owned by uid %d, not by root or the user the agent runs as", path, stat.Uid)
+	}
+	if fi.Mode().Perm()&0o022 != 0 {
+		return fmt.Errorf("%s is writable by the group or other users, mode %s", path, fi.Mode().Perm())
+	}
+	return nil
+}
@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build !windows
+
+package application
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func TestLocalActionsConfigManager_readActionsOwnership(t *testing.T) {
+	const action = `{"id": "action-1", "type": "SETTINGS"}`
+
+	t.Run("group writable directory", func(t *testing.T) {
+		log, obs := loggertest.New(t.Name())
+		dir := t.TempDir()
+		require.NoError(t, os.Chmod(dir, 0o770))
+		require.NoError(t, os.WriteFile(filepath.Join(dir, "1.json"), []byte(action), 0o600))
+		m := &localActionsConfigManager{log: log, dir: dir}
+
+		assert.Empty(t, m.readActions())
+		assert.FileExists(t, filepath.Join(dir, "1.json"), "files of an insecure directory must be left alone")
+		assert.NotZero(t, obs.FilterMessageSnippet("Local actions are not read").Len())
+	})
+
+	t.Run("world writable file", func(t *testing.T) {
+		log, _ := loggertest.New(t.Name())
+		dir := t.TempDir()
+		file := filepath.Join(dir, "1.json")
+		require.NoError(t, os.WriteFile(file, []byte(action), 0o600))
+		require.NoError(t, os.Chmod(file, 0o666))
+		m := &localActionsConfigManager{log: log, dir: dir}
+
+		assert.Empty(t, m.readActions())
+		assert.FileExists(t, file+rejectedLocalActionSuffix)
+	})
+
+	t.Run("symlink", func(t *testing.T) {
+		log, _ := loggertest.New(t.Name())
+		dir := t.TempDir()
+		target := filepath.Join(t.TempDir(), "action")
+		require.NoError(t, os.WriteFile(target, []byte(action), 0o600))
+		require.NoError(t, os.Symlink(target, filepath.Join(dir, "1.json")))
+		m := &localActionsConfigManager{log: log, dir: dir}
+
+		assert.Empty(t, m.readActions())
+		assert.FileExists(t, target)
+	})
+
+	t.Run("file of another user", func(t *testing.T) {
+		if os.Geteuid() != 0 {
+			t.Skip("changing the owner of a file requires root")
+		}
+		log, _ := loggertest.New(t.Name())
+		dir := t.TempDir()
+		file := filepath.Join(dir, "1.json")
+		require.NoError(t, os.WriteFile(file, []byte(action), 0o600))
+		require.NoError(t, os.Chown(file, 65534, 65534))
+		m := &localActionsConfigManager{log: log, dir: dir}
+
+		assert.Empty(t, m.readActions())
+		assert.FileExists(t, file+rejectedLocalActionSuffix)
+	})
+}
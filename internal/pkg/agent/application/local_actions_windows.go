@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build windows
+
+package application
+
+import (
+	"fmt"
+	"os"
+
+	"golang.org/x/sys/windows"
+)
+
+// checkLocalActionsOwner returns an error unless the local actions directory or action file
+// is owned by SYSTEM, the Administrators group or the user the agent runs as. Who can write
+// to the directory is left to its ACL, set by install on the data path.
+func checkLocalActionsOwner(path string, _ os.FileInfo) error {
+	sd, err := windows.GetNamedSecurityInfo(path, windows.SE_FILE_OBJECT, windows.OWNER_SECURITY_INFORMATION)
+	if err != nil {
+		return fmt.Errorf("failed to get the security info of %s: %w", path, err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return fmt.Errorf("failed to get the owner of %s: %w", path, err)
+	}
+	if owner.IsWellKnown(windows.WinLocalSystemSid) || owner.IsWellKnown(windows.WinBuiltinAdministratorsSid) {
+		return nil
+	}
+	user, err := windows.GetCurrentProcessToken().GetTokenUser()
+	if err != nil {
+		return fmt.Errorf("failed to get the user the agent runs as: %w", err)
+	}
+	if !owner.Equals(user.User.Sid) {
+		return fmt.Errorf("%s is owned by %s, not by SYSTEM, Administrators or the user the agent runs as", path, owner)
+	}
+	return nil
+}
@@ -79,7 +79,8 @@ func newManagedConfigManager(
 		return nil, fmt.Errorf("unable to initialize action queue: %w", err)
 	}
 
-	actionDispatcher, err := dispatcher.New(log, topPath, handlers.NewDefault(log), actionQueue)
+	actionDispatcher, err := dispatcher.New(log, topPath, handlers.NewDefault(log), actionQueue,
+		dispatcher.WithActionsConfig(cfg.Settings.Actions), dispatcher.WithHistoryStore(stateStore))
 	if err != nil {
 		return nil, fmt.Errorf("unable to initialize action dispatcher: %w", err)
 	}
@@ -179,6 +180,7 @@ func (m *managedConfigManager) Run(ctx context.Context) error {
 		m.actionAcker,
 		m.coord.State,
 		m.stateStore,
+		m.cfg.Settings.FleetCheckin,
 	)
 	if err != nil {
 		return err
@@ -372,6 +374,7 @@ func (m *managedConfigManager) initDispatcher(canceller context.CancelFunc) *han
 		),
 	)
 
+	fileUploader := uploader.New(m.agentInfo.AgentID(), m.client, m.cfg.Settings.MonitoringConfig.Diagnostics.Uploader)
 	m.dispatcher.MustRegister(
 		&fleetapi.ActionDiagnostics{},
 		handlers.NewDiagnostics(
@@ -379,7 +382,17 @@ func (m *managedConfigManager) initDispatcher(canceller context.CancelFunc) *han
 			paths.Top(), // TODO: stop using global state
 			m.coord,
 			m.cfg.Settings.MonitoringConfig.Diagnostics.Limit,
-			uploader.New(m.agentInfo.AgentID(), m.client, m.cfg.Settings.MonitoringConfig.Diagnostics.Uploader),
+			fileUploader,
+		),
+	)
+
+	m.dispatcher.MustRegister(
+		&fleetapi.ActionRequestLogs{},
+		handlers.NewRequestLogs(
+			m.log,
+			paths.Top(), // TODO: stop using global state
+			m.cfg.Settings.MonitoringConfig.Diagnostics.Limit,
+			fileUploader,
 		),
 	)
 
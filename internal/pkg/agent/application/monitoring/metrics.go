@@ -0,0 +1,331 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package monitoring
+
+import (
+	"bytes"
+	"fmt"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	sysprocess "github.com/elastic/elastic-agent-system-metrics/metric/system/process"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	fleetgateway "github.com/elastic/elastic-agent/internal/pkg/agent/application/gateway/fleet"
+	"github.com/elastic/elastic-agent/internal/pkg/release"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+)
+
+const openMetricsContentType = "application/openmetrics-text; version=1.0.0; charset=utf-8"
+
+// unitStates are all the states a component or unit can report.
+var unitStates = []client.UnitState{
+	client.UnitStateStarting,
+	client.UnitStateConfiguring,
+	client.UnitStateHealthy,
+	client.UnitStateDegraded,
+	client.UnitStateFailed,
+	client.UnitStateStopping,
+	client.UnitStateStopped,
+}
+
+// metricsHandler exposes the state of the Elastic Agent and of its components in the
+// OpenMetrics text format.
+//
+// The metric names and labels are part of the API consumed by Prometheus scrapers,
+// existing ones must not be renamed.
+func metricsHandler(coord CoordinatorState, ns func(string) *monitoring.Namespace, procStats processStatsFunc) func(http.ResponseWriter, *http.Request) error {
+	return func(w http.ResponseWriter, r *http.Request) error {
+		var stats, fleet monitoring.FlatSnapshot
+		if ns != nil {
+			stats = monitoring.CollectFlatSnapshot(ns("stats").GetRegistry(), monitoring.Full, false)
+			fleet = monitoring.CollectFlatSnapshot(ns(fleetgateway.MetricsNamespace).GetRegistry(), monitoring.Full, false)
+		}
+
+		state := coord.State()
+		m := &metricsWriter{}
+		writeAgentMetrics(m, state)
+		writeComponentMetrics(m, state)
+		writeComponentProcessMetrics(m, state, procStats)
+		writeFleetMetrics(m, fleet)
+		writeProcessMetrics(m, stats)
+		m.buf.WriteString("# EOF\n")
+
+		w.Header().Set("Content-Type", openMetricsContentType)
+		_, err := w.Write(m.buf.Bytes())
+		return err
+	}
+}
+
+func writeAgentMetrics(m *metricsWriter, state coordinator.State) {
+	info := release.Info()
+	m.family("elastic_agent", "info", "Version information of the Elastic Agent.")
+	m.sample("elastic_agent_info", 1,
+		"version", info.Version,
+		"commit", info.Commit,
+		"snapshot", strconv.FormatBool(info.Snapshot),
+		"fips", strconv.FormatBool(info.FIPSDistribution))
+
+	m.family("elastic_agent_state", "stateset", "Overall state of the Elastic Agent.")
+	writeStateSet(m, "elastic_agent_state", int32(state.State), nil)
+
+	m.family("elastic_agent_fleet_state", "stateset", "State of the connection of the Elastic Agent to Fleet.")
+	writeStateSet(m, "elastic_agent_fleet_state", int32(state.FleetState), nil)
+
+	d := state.UpgradeDetails
+	m.family("elastic_agent_upgrade", "info", "Details of the upgrade in progress.")
+	if d != nil {
+		m.sample("elastic_agent_upgrade_info", 1,
+			"target_version", d.TargetVersion,
+			"state", string(d.State),
+			"action_id", d.ActionID)
+	}
+	m.family("elastic_agent_upgrade_download_ratio", "gauge", "Ratio of the upgrade artifact that has been downloaded.")
+	if d != nil {
+		m.sample("elastic_agent_upgrade_download_ratio", d.Metadata.DownloadPercent,
+			"target_version", d.TargetVersion)
+	}
+}
+
+func writeComponentMetrics(m *metricsWriter, state coordinator.State) {
+	components := make([]int, len(state.Components))
+	for i := range components {
+		components[i] = i
+	}
+	sort.Slice(components, func(a, b int) bool {
+		return state.Components[components[a]].Component.ID < state.Components[components[b]].Component.ID
+	})
+
+	m.family("elastic_agent_component_state", "stateset", "State of the components run by the Elastic Agent.")
+	for _, i := range components {
+		comp := state.Components[i]
+		writeUnitStateSet(m, "elastic_agent_component_state", comp.State.State, "component_id", comp.Component.ID)
+	}
+
+	m.family("elastic_agent_component_restarts", "counter", "Number of times the component process exited unexpectedly and was restarted.")
+	for _, i := range components {
+		comp := state.Components[i]
+		m.sample("elastic_agent_component_restarts_total", float64(comp.State.Restarts), "component_id", comp.Component.ID)
+	}
+
+	m.family("elastic_agent_unit_state", "stateset", "State of the units run by the components of the Elastic Agent.")
+	for _, i := range components {
+		comp := state.Components[i]
+		keys := make([]runtime.ComponentUnitKey, 0, len(comp.State.Units))
+		for key := range comp.State.Units {
+			keys = append(keys, key)
+		}
+		sort.Slice(keys, func(a, b int) bool {
+			if keys[a].UnitID != keys[b].UnitID {
+				return keys[a].UnitID < keys[b].UnitID
+			}
+			return keys[a].UnitType < keys[b].UnitType
+		})
+		for _, key := range keys {
+			writeUnitStateSet(m, "elastic_agent_unit_state", comp.State.Units[key].State,
+				"component_id", comp.Component.ID,
+				"unit_id", key.UnitID,
+				"unit_type", strings.ToLower(key.UnitType.String()))
+		}
+	}
+}
+
+// writeComponentProcessMetrics writes the resource usage of the processes of the components, components
+// without a running process or whose process cannot be inspected are skipped.
+func writeComponentProcessMetrics(m *metricsWriter, state coordinator.State, procStats processStatsFunc) {
+	type componentProcess struct {
+		id  string
+		cpu float64
+		rss float64
+	}
+	var procs []componentProcess
+	for _, comp := range state.Components {
+		pid, err := strconv.Atoi(comp.LegacyPID)
+		if err != nil || pid <= 0 || procStats == nil {
+			continue
+		}
+		cpu, rss, err := procStats(pid)
+		if err != nil {
+			// the process exited since the state was reported
+			continue
+		}
+		procs = append(procs, componentProcess{id: comp.Component.ID, cpu: cpu, rss: rss})
+	}
+	sort.Slice(procs, func(a, b int) bool { return procs[a].id < procs[b].id })
+
+	m.family("elastic_agent_component_cpu_seconds", "counter", "Total CPU time spent by the process of the component.")
+	for _, p := range procs {
+		m.sample("elastic_agent_component_cpu_seconds_total", p.cpu, "component_id", p.id)
+	}
+	m.family("elastic_agent_component_resident_memory_bytes", "gauge", "Resident memory size of the process of the component.")
+	for _, p := range procs {
+		m.sample("elastic_agent_component_resident_memory_bytes", p.rss, "component_id", p.id)
+	}
+}
+
+func writeFleetMetrics(m *metricsWriter, fleet monitoring.FlatSnapshot) {
+	// no samples when not managed by Fleet
+	_, managed := fleet.Ints["checkin.total"]
+
+	m.family("elastic_agent_fleet_checkins", "counter", "Number of check-ins with Fleet Server.")
+	if managed {
+		m.sample("elastic_agent_fleet_checkins_total", float64(fleet.Ints["checkin.total"]))
+	}
+	m.family("elastic_agent_fleet_checkin_failures", "counter", "Number of failed check-ins with Fleet Server.")
+	if managed {
+		m.sample("elastic_agent_fleet_checkin_failures_total", float64(fleet.Ints["checkin.failures"]))
+	}
+	m.family("elastic_agent_fleet_checkin_long_poll_seconds", "gauge",
+		"Time the last check-in was held by Fleet Server long polling for changes, until a change or the poll timeout. It is not the latency of Fleet Server.")
+	if managed {
+		m.sample("elastic_agent_fleet_checkin_long_poll_seconds", float64(fleet.Ints["checkin.long_poll_ns"])/1e9)
+	}
+}
+
+func writeProcessMetrics(m *metricsWriter, stats monitoring.FlatSnapshot) {
+	metrics := []struct {
+		name  string
+		typ   string
+		help  string
+		key   string
+		scale float64
+	}{
+		{"elastic_agent_process_cpu_seconds", "counter", "Total CPU time spent by the Elastic Agent process.", "beat.cpu.total.time.ms", 1e-3},
+		{"elastic_agent_process_resident_memory_bytes", "gauge", "Resident memory size of the Elastic Agent process.", "beat.memstats.rss", 1},
+		{"elastic_agent_process_open_fds", "gauge", "Number of open file descriptors of the Elastic Agent process.", "beat.handles.open", 1},
+		{"elastic_agent_process_goroutines", "gauge", "Number of goroutines of the Elastic Agent process.", "beat.runtime.goroutines", 1},
+		{"elastic_agent_process_uptime_seconds", "gauge", "Uptime of the Elastic Agent process.", "beat.info.uptime.ms", 1e-3},
+	}
+	for _, metric := range metrics {
+		m.family(metric.name, metric.typ, metric.help)
+		v, ok := flatNumber(stats, metric.key)
+		if !ok {
+			continue
+		}
+		name := metric.name
+		if metric.typ == "counter" {
+			name += "_total"
+		}
+		m.sample(name, v*metric.scale)
+	}
+}
+
+func flatNumber(snapshot monitoring.FlatSnapshot, key string) (float64, bool) {
+	if v, ok := snapshot.Ints[key]; ok {
+		return float64(v), true
+	}
+	v, ok := snapshot.Floats[key]
+	return v, ok
+}
+
+// writeStateSet writes a sample for every agent state, set to 1 for the current one. As required by
+// OpenMetrics the state is held by a label named after the family.
+func writeStateSet(m *metricsWriter, name string, current int32, labels []string) {
+	states := make([]int32, 0, len(cproto.State_name))
+	for s := range cproto.State_name {
+		states = append(states, s)
+	}
+	sort.Slice(states, func(a, b int) bool { return states[a] < states[b] })
+	for _, s := range states {
+		m.sample(name, boolValue(s == current), append(labels, name, strings.ToLower(cproto.State_name[s]))...)
+	}
+}
+
+// writeUnitStateSet writes a sample for every unit state, set to 1 for the current one.
+func writeUnitStateSet(m *metricsWriter, name string, current client.UnitState, labels ...string) {
+	for _, s := range unitStates {
+		m.sample(name, boolValue(s == current), append(labels[:len(labels):len(labels)], name, strings.ToLower(s.String()))...)
+	}
+}
+
+// processStatsFunc returns the total CPU time in seconds and the resident memory in bytes of a process.
+type processStatsFunc func(pid int) (cpuSeconds float64, rssBytes float64, err error)
+
+// systemProcessStats reads the resource usage of the processes from the operating system.
+func systemProcessStats() processStatsFunc {
+	var mx sync.Mutex
+	stats := &sysprocess.Stats{Procs: []string{".*"}, CPUTicks: true}
+	initErr := stats.Init()
+	return func(pid int) (float64, float64, error) {
+		if initErr != nil {
+			return 0, 0, fmt.Errorf("failed to initialize the process metrics: %w", initErr)
+		}
+
+		mx.Lock()
+		defer mx.Unlock()
+		event, err := stats.GetOne(pid)
+		if err != nil {
+			return 0, 0, err
+		}
+		cpuMs, err := event.GetValue("cpu.total.ticks")
+		if err != nil {
+			return 0, 0, err
+		}
+		rss, err := event.GetValue("memory.rss.bytes")
+		if err != nil {
+			return 0, 0, err
+		}
+		return numberValue(cpuMs) / 1e3, numberValue(rss), nil
+	}
+}
+
+func numberValue(v interface{}) float64 {
+	switch n := v.(type) {
+	case float64:
+		return n
+	case int64:
+		return float64(n)
+	case uint64:
+		return float64(n)
+	case int:
+		return float64(n)
+	}
+	return 0
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+// metricsWriter writes metric families in the OpenMetrics text format.
+type metricsWriter struct {
+	buf bytes.Buffer
+}
+
+func (m *metricsWriter) family(name string, typ string, help string) {
+	fmt.Fprintf(&m.buf, "# TYPE %s %s\n# HELP %s %s\n", name, typ, name, help)
+}
+
+// sample writes a sample of the family, labels are pairs of label names and values.
+func (m *metricsWriter) sample(name string, value float64, labels ...string) {
+	m.buf.WriteString(name)
+	if len(labels) > 0 {
+		m.buf.WriteByte('{')
+		for i := 0; i+1 < len(labels); i += 2 {
+			if i > 0 {
+				m.buf.WriteByte(',')
+			}
+			fmt.Fprintf(&m.buf, "%s=\"%s\"", labels[i], escapeLabelValue(labels[i+1]))
+		}
+		m.buf.WriteByte('}')
+	}
+	m.buf.WriteByte(' ')
+	m.buf.WriteString(strconv.FormatFloat(value, 'g', -1, 64))
+	m.buf.WriteByte('\n')
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(v string) string {
+	return labelValueEscaper.Replace(v)
+}
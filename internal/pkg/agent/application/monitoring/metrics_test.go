@@ -0,0 +1,189 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package monitoring
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/prometheus/prometheus/model/labels"
+	"github.com/prometheus/prometheus/model/textparse"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	fleetgateway "github.com/elastic/elastic-agent/internal/pkg/agent/application/gateway/fleet"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/component/runtime"
+	agentclient "github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestMetricsHandler(t *testing.T) {
+	registry := monitoring.NewRegistry()
+	checkin := registry.NewRegistry("checkin")
+	monitoring.NewUint(checkin, "total").Set(10)
+	monitoring.NewUint(checkin, "failures").Set(2)
+	monitoring.NewInt(checkin, "long_poll_ns").Set(1500000000)
+	stats := monitoring.NewRegistry()
+	monitoring.NewInt(stats.NewRegistry("beat").NewRegistry("memstats"), "rss").Set(1024)
+	ns := func(name string) *monitoring.Namespace {
+		n := &monitoring.Namespace{}
+		switch name {
+		case fleetgateway.MetricsNamespace:
+			n.SetRegistry(registry)
+		case "stats":
+			n.SetRegistry(stats)
+		}
+		return n
+	}
+
+	upgradeDetails := details.NewDetails("9.1.0", details.StateDownloading, "action-1")
+	upgradeDetails.SetDownloadProgress(0.5, 100)
+	coord := mockCoordinator{
+		state: coordinator.State{
+			State:      agentclient.Healthy,
+			FleetState: agentclient.Degraded,
+			Components: []runtime.ComponentComponentState{
+				{
+					Component: component.Component{ID: "filestream-default"},
+					LegacyPID: "1234",
+					State: runtime.ComponentState{
+						State:    client.UnitStateDegraded,
+						Restarts: 3,
+						Units: map[runtime.ComponentUnitKey]runtime.ComponentUnitState{
+							{UnitType: client.UnitTypeInput, UnitID: "filestream-default-\"quoted\""}: {State: client.UnitStateFailed},
+						},
+					},
+				},
+				{
+					Component: component.Component{ID: "system-metrics-default"},
+					LegacyPID: "5678",
+					State:     runtime.ComponentState{State: client.UnitStateHealthy},
+				},
+			},
+			UpgradeDetails: upgradeDetails,
+		},
+	}
+
+	procStats := func(pid int) (float64, float64, error) {
+		if pid != 1234 {
+			return 0, 0, errors.New("process exited")
+		}
+		return 2.5, 4096, nil
+	}
+
+	rec := httptest.NewRecorder()
+	err := metricsHandler(coord, ns, procStats)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+	assert.Equal(t, openMetricsContentType, rec.Header().Get("Content-Type"))
+
+	body := rec.Body.String()
+	assert.True(t, strings.HasSuffix(body, "# EOF\n"), "must end with # EOF")
+	requireOpenMetrics(t, rec.Body.Bytes())
+	requireFamilyGrouping(t, body)
+	for _, line := range []string{
+		"# TYPE elastic_agent info",
+		`elastic_agent_state{elastic_agent_state="healthy"} 1`,
+		`elastic_agent_state{elastic_agent_state="failed"} 0`,
+		`elastic_agent_fleet_state{elastic_agent_fleet_state="degraded"} 1`,
+		`elastic_agent_component_state{component_id="filestream-default",elastic_agent_component_state="degraded"} 1`,
+		`elastic_agent_component_state{component_id="filestream-default",elastic_agent_component_state="healthy"} 0`,
+		"# TYPE elastic_agent_component_restarts counter",
+		`elastic_agent_component_restarts_total{component_id="filestream-default"} 3`,
+		`elastic_agent_component_cpu_seconds_total{component_id="filestream-default"} 2.5`,
+		`elastic_agent_component_resident_memory_bytes{component_id="filestream-default"} 4096`,
+		`elastic_agent_unit_state{component_id="filestream-default",unit_id="filestream-default-\"quoted\"",unit_type="input",elastic_agent_unit_state="failed"} 1`,
+		"# TYPE elastic_agent_upgrade info",
+		`elastic_agent_upgrade_info{target_version="9.1.0",state="UPG_DOWNLOADING",action_id="action-1"} 1`,
+		`elastic_agent_upgrade_download_ratio{target_version="9.1.0"} 0.5`,
+		"elastic_agent_fleet_checkins_total 10",
+		"elastic_agent_fleet_checkin_failures_total 2",
+		"elastic_agent_fleet_checkin_long_poll_seconds 1.5",
+		"elastic_agent_process_resident_memory_bytes 1024",
+	} {
+		assert.Contains(t, body, line+"\n")
+	}
+	assert.NotContains(t, body, `elastic_agent_component_cpu_seconds_total{component_id="system-metrics-default"}`, "components whose process cannot be read must be skipped")
+}
+
+func TestMetricsHandlerStandalone(t *testing.T) {
+	rec := httptest.NewRecorder()
+	err := metricsHandler(mockCoordinator{}, nil, nil)(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	require.NoError(t, err)
+
+	body := rec.Body.String()
+	assert.NotContains(t, body, "elastic_agent_fleet_checkins_total")
+	assert.NotContains(t, body, "elastic_agent_upgrade_info{")
+	assert.Contains(t, body, "# TYPE elastic_agent info\n")
+	assert.Contains(t, body, "elastic_agent_info{")
+	requireOpenMetrics(t, rec.Body.Bytes())
+	requireFamilyGrouping(t, body)
+}
+
+func TestSystemProcessStats(t *testing.T) {
+	cpu, rss, err := systemProcessStats()(os.Getpid())
+	require.NoError(t, err)
+	assert.GreaterOrEqual(t, cpu, float64(0))
+	assert.Greater(t, rss, float64(0))
+}
+
+// requireOpenMetrics parses body with the OpenMetrics parser of Prometheus and checks that
+// the samples of every family directly follow its metadata, which the parser does not.
+func requireOpenMetrics(t *testing.T, body []byte) {
+	t.Helper()
+	p := textparse.NewOpenMetricsParser(body, labels.NewSymbolTable())
+	for {
+		_, err := p.Next()
+		if errors.Is(err, io.EOF) {
+			return
+		}
+		require.NoError(t, err, "metrics must be valid OpenMetrics")
+	}
+}
+
+// sampleSuffixes are the suffixes of the sample names of the families by type.
+var sampleSuffixes = map[string][]string{
+	"counter":  {"_total", "_created"},
+	"gauge":    {""},
+	"info":     {"_info"},
+	"stateset": {""},
+}
+
+// requireFamilyGrouping requires every sample to belong to the family of the metadata
+// preceding it, and every family to be described once.
+func requireFamilyGrouping(t *testing.T, body string) {
+	t.Helper()
+	seen := make(map[string]bool)
+	var family, typ string
+	for _, line := range strings.Split(strings.TrimSuffix(body, "\n"), "\n") {
+		switch {
+		case line == "# EOF":
+			continue
+		case strings.HasPrefix(line, "# TYPE "):
+			fields := strings.Fields(line)
+			require.Len(t, fields, 4, "invalid metadata %q", line)
+			family, typ = fields[2], fields[3]
+			require.False(t, seen[family], "family %s must be described once", family)
+			seen[family] = true
+			continue
+		case strings.HasPrefix(line, "#"):
+			continue
+		}
+		name, _, _ := strings.Cut(line, " ")
+		name, _, _ = strings.Cut(name, "{")
+		require.NotEmpty(t, family, "sample %q must follow the metadata of its family", line)
+		suffix, ok := strings.CutPrefix(name, family)
+		require.True(t, ok, "sample %q must follow the metadata of its family, not %s", line, family)
+		require.Contains(t, sampleSuffixes[typ], suffix, "sample %q does not belong to the %s family %s", line, typ, family)
+	}
+}
@@ -66,6 +66,7 @@ func exposeMetricsEndpoint(
 
 		statsHandler := statsHandler(statNs)
 		r.Handle("/stats", createHandler(statsHandler))
+		r.Handle("/metrics", createHandler(metricsHandler(coord, ns, systemProcessStats())))
 
 		if isProcessStatsEnabled(cfg) {
 			log.Infof("process monitoring is enabled, creating monitoring endpoints")
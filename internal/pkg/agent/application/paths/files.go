@@ -42,6 +42,36 @@ const defaultAgentStateStoreFile = "state.enc"
 // defaultInputDPath return the location of the inputs.d.
 const defaultInputsDPath = "inputs.d"
 
+// defaultPausedComponentsFile is the file that contains the components paused
+// over the control protocol.
+const defaultPausedComponentsFile = "paused_components.yml"
+
+// defaultStateJournalFile is the file that contains the journal of component
+// and unit state transitions, when it is persisted.
+const defaultStateJournalFile = "state_journal.ndjson"
+
+// defaultAckJournalFile is the file that contains the encrypted journal of the
+// acks not delivered to Fleet yet.
+const defaultAckJournalFile = "ack_journal.enc"
+
+// defaultLocalActionsDir is the directory watched for the actions of standalone agents.
+const defaultLocalActionsDir = "actions"
+
+// defaultLocalActionsStateFile is the file that contains the encrypted queue of
+// the scheduled local actions.
+const defaultLocalActionsStateFile = "local_action_store.enc"
+
+// defaultSecretsStoreFile is the file that contains the encrypted secrets
+// managed by the secrets command.
+const defaultSecretsStoreFile = "secrets.enc"
+
+// defaultLocalActionResultsFile is the file the results of the local actions are written to.
+const defaultLocalActionResultsFile = "local_action_results.ndjson"
+
+// defaultLocalDiagnosticsDir is the directory the diagnostics bundles requested by
+// local actions are written to.
+const defaultLocalDiagnosticsDir = "diagnostics"
+
 // AgentConfigYmlFile is a name of file used to store agent information
 func AgentConfigYmlFile() string {
 	return filepath.Join(Config(), defaultAgentFleetYmlFile)
@@ -97,3 +127,49 @@ func AgentStateStoreFile() string {
 func AgentInputsDPath() string {
 	return filepath.Join(Config(), defaultInputsDPath)
 }
+
+// PausedComponentsFile is the file that contains the components paused over the control protocol.
+func PausedComponentsFile() string {
+	return filepath.Join(Data(), defaultPausedComponentsFile)
+}
+
+// StateJournalFile is the file that contains the journal of component and unit state transitions.
+func StateJournalFile() string {
+	return filepath.Join(Data(), defaultStateJournalFile)
+}
+
+// AckJournalFile is the file that contains the encrypted journal of the acks not delivered to Fleet yet.
+func AckJournalFile() string {
+	return filepath.Join(Data(), defaultAckJournalFile)
+}
+
+// LocalActionsDir is the directory watched for the actions of standalone agents.
+func LocalActionsDir() string {
+	return filepath.Join(Data(), defaultLocalActionsDir)
+}
+
+// EncryptedStoreFiles are the files encrypted with the agent key, they are re-encrypted
+// when the agent key is rotated.
+func EncryptedStoreFiles() []string {
+	return []string{AgentConfigFile(), AgentStateStoreFile(), AckJournalFile(), LocalActionsStateFile(), SecretsStoreFile()}
+}
+
+// SecretsStoreFile is the file that contains the encrypted secrets managed by the secrets command.
+func SecretsStoreFile() string {
+	return filepath.Join(Config(), defaultSecretsStoreFile)
+}
+
+// LocalActionsStateFile is the file that contains the encrypted queue of the scheduled local actions.
+func LocalActionsStateFile() string {
+	return filepath.Join(Data(), defaultLocalActionsStateFile)
+}
+
+// LocalActionResultsFile is the file the results of the local actions are written to.
+func LocalActionResultsFile() string {
+	return filepath.Join(Data(), defaultLocalActionResultsFile)
+}
+
+// LocalDiagnosticsDir is the directory the diagnostics bundles requested by local actions are written to.
+func LocalDiagnosticsDir() string {
+	return filepath.Join(Data(), defaultLocalDiagnosticsDir)
+}
@@ -6,6 +6,7 @@
 package secret
 
 import (
+	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -18,6 +19,10 @@ import (
 
 const AgentSecretKey = "secret"
 
+// AgentSecretPreviousKey is the key of the agent secret being rotated, it is kept to
+// decrypt the data not re-encrypted yet until the rotation completes.
+const AgentSecretPreviousKey = "secret.previous"
+
 // mutex for secret create calls
 var mxCreate sync.Mutex
 
@@ -53,18 +58,105 @@ func Create(ctx context.Context, key string, opts ...vault.OptionFunc) error {
 		return nil
 	}
 
-	// Create new AES256 key
-	k, err := aesgcm.NewKey(aesgcm.AES256)
+	secret, err := newSecret()
 	if err != nil {
 		return err
 	}
 
-	secret := Secret{
+	return set(ctx, v, key, secret)
+}
+
+// newSecret creates a new AES256 key
+func newSecret() (Secret, error) {
+	k, err := aesgcm.NewKey(aesgcm.AES256)
+	if err != nil {
+		return Secret{}, err
+	}
+
+	return Secret{
 		Value:     k,
 		CreatedOn: time.Now().UTC(),
+	}, nil
+}
+
+// StartAgentSecretRotation replaces the agent secret with a new one and keeps the
+// current one as the previous agent secret, returns the new agent secret.
+// A rotation interrupted before it completes is resumed: the agent secret is only
+// replaced when it is still the previous one.
+func StartAgentSecretRotation(ctx context.Context, opts ...vault.OptionFunc) (Secret, error) {
+	v, err := vault.New(ctx, opts...)
+	if err != nil {
+		return Secret{}, fmt.Errorf("could not create new vault: %w", err)
 	}
+	defer v.Close()
 
-	return set(ctx, v, key, secret)
+	mxCreate.Lock()
+	defer mxCreate.Unlock()
+
+	current, err := get(ctx, v, AgentSecretKey)
+	if err != nil {
+		return Secret{}, fmt.Errorf("could not read the agent secret: %w", err)
+	}
+
+	exists, err := v.Exists(ctx, AgentSecretPreviousKey)
+	if err != nil {
+		return Secret{}, err
+	}
+	if exists {
+		previous, err := get(ctx, v, AgentSecretPreviousKey)
+		if err != nil {
+			return Secret{}, fmt.Errorf("could not read the previous agent secret: %w", err)
+		}
+		if !bytes.Equal(previous.Value, current.Value) {
+			// the agent secret was already replaced
+			return current, nil
+		}
+	} else if err := set(ctx, v, AgentSecretPreviousKey, current); err != nil {
+		return Secret{}, fmt.Errorf("could not save the previous agent secret: %w", err)
+	}
+
+	secret, err := newSecret()
+	if err != nil {
+		return Secret{}, err
+	}
+	if err := set(ctx, v, AgentSecretKey, secret); err != nil {
+		return Secret{}, fmt.Errorf("could not save the agent secret: %w", err)
+	}
+	return secret, nil
+}
+
+// GetPreviousAgentSecret reads the previous agent secret from the vault, returns false
+// when the agent secret is not being rotated.
+func GetPreviousAgentSecret(ctx context.Context, opts ...vault.OptionFunc) (Secret, bool, error) {
+	opts = append(opts, vault.WithReadonly(true))
+	v, err := vault.New(ctx, opts...)
+	if err != nil {
+		return Secret{}, false, err
+	}
+	defer v.Close()
+
+	exists, err := v.Exists(ctx, AgentSecretPreviousKey)
+	if err != nil || !exists {
+		return Secret{}, false, err
+	}
+	secret, err := get(ctx, v, AgentSecretPreviousKey)
+	return secret, err == nil, err
+}
+
+// CompleteAgentSecretRotation removes the previous agent secret once all the data is
+// encrypted with the agent secret.
+func CompleteAgentSecretRotation(ctx context.Context, opts ...vault.OptionFunc) error {
+	v, err := vault.New(ctx, opts...)
+	if err != nil {
+		return fmt.Errorf("could not create new vault: %w", err)
+	}
+	defer v.Close()
+
+	exists, err := v.Exists(ctx, AgentSecretPreviousKey)
+	if err != nil || !exists {
+		return err
+	}
+	return v.Remove(ctx, AgentSecretPreviousKey)
 }
 
 // GetAgentSecret read the agent secret from the vault
@@ -88,6 +180,10 @@ func Get(ctx context.Context, key string, opts ...vault.OptionFunc) (secret Secr
 	}
 	defer v.Close()
 
+	return get(ctx, v, key)
+}
+
+func get(ctx context.Context, v vault.Vault, key string) (secret Secret, err error) {
 	b, err := v.Get(ctx, key)
 	if err != nil {
 		return secret, err
@@ -11,6 +11,8 @@ import (
 	"time"
 
 	"github.com/google/go-cmp/cmp"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/vault/aesgcm"
@@ -69,3 +71,63 @@ func TestCreate(t *testing.T) {
 		}
 	}
 }
+
+func TestAgentSecretRotation(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "secret storage does not use NewGCMWithRandomNonce.")
+	opts := getTestOptions(t)
+	ctx := context.Background()
+
+	require.NoError(t, CreateAgentSecret(ctx, opts...))
+	original, err := GetAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+
+	_, rotating, err := GetPreviousAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+	assert.False(t, rotating)
+
+	rotated, err := StartAgentSecretRotation(ctx, opts...)
+	require.NoError(t, err)
+	assert.NotEqual(t, original.Value, rotated.Value)
+
+	current, err := GetAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Value, current.Value)
+	previous, rotating, err := GetPreviousAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+	require.True(t, rotating)
+	assert.Equal(t, original.Value, previous.Value)
+
+	// resuming the rotation keeps the new agent secret
+	resumed, err := StartAgentSecretRotation(ctx, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Value, resumed.Value)
+
+	require.NoError(t, CompleteAgentSecretRotation(ctx, opts...))
+	_, rotating, err = GetPreviousAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+	assert.False(t, rotating)
+	current, err = GetAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+	assert.Equal(t, rotated.Value, current.Value)
+}
+
+func TestAgentSecretRotation_InterruptedBeforeReplace(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "secret storage does not use NewGCMWithRandomNonce.")
+	opts := getTestOptions(t)
+	ctx := context.Background()
+
+	require.NoError(t, CreateAgentSecret(ctx, opts...))
+	original, err := GetAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+
+	// the rotation stopped once the previous agent secret was saved
+	require.NoError(t, Set(ctx, AgentSecretPreviousKey, original, opts...))
+
+	rotated, err := StartAgentSecretRotation(ctx, opts...)
+	require.NoError(t, err)
+	assert.NotEqual(t, original.Value, rotated.Value)
+	previous, rotating, err := GetPreviousAgentSecret(ctx, opts...)
+	require.NoError(t, err)
+	require.True(t, rotating)
+	assert.Equal(t, original.Value, previous.Value)
+}
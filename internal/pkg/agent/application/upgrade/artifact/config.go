@@ -61,6 +61,24 @@ type configWithoutHTTPTransportSettings struct {
 	// will increase for subsequent retry attempts in a randomized exponential backoff manner.
 	// This key is, for some reason, problematic
 	RetrySleepInitDuration time.Duration `yaml:"retry_sleep_init_duration" config:"retry_sleep_init_duration"`
+
+	// RateLimit: maximum rate, in bytes per second, at which artifacts are downloaded.
+	// Downloads are not limited when 0.
+	RateLimit int64 `yaml:"rate_limit" config:"rate_limit"`
+
+	// Windows: times of day during which artifacts can be downloaded. Downloads are
+	// paused outside of the windows and resumed once a window opens, artifacts can be
+	// downloaded at any time when empty.
+	Windows []DownloadWindow `yaml:"windows" config:"windows"`
+
+	// PeerCache: sharing of the downloaded artifacts with the other agents of the network.
+	PeerCache PeerCacheConfig `yaml:"peer_cache" config:"peer_cache"`
+
+	// Verification: verification of the signature of the downloaded artifacts.
+	Verification VerificationConfig `yaml:"verification" config:"verification"`
+
+	// OCI: authentication to the OCI registry of oci:// source URIs.
+	OCI OCIConfig `yaml:"oci" config:"oci"`
 }
 
 // Config is a configuration used for verifier and downloader
@@ -91,6 +109,24 @@ type Config struct {
 	// will increase for subsequent retry attempts in a randomized exponential backoff manner.
 	RetrySleepInitDuration time.Duration `yaml:"retry_sleep_init_duration" config:"retry_sleep_init_duration"`
 
+	// RateLimit: maximum rate, in bytes per second, at which artifacts are downloaded.
+	// Downloads are not limited when 0.
+	RateLimit int64 `yaml:"rate_limit" config:"rate_limit"`
+
+	// Windows: times of day during which artifacts can be downloaded. Downloads are
+	// paused outside of the windows and resumed once a window opens, artifacts can be
+	// downloaded at any time when empty.
+	Windows []DownloadWindow `yaml:"windows" config:"windows"`
+
+	// PeerCache: sharing of the downloaded artifacts with the other agents of the network.
+	PeerCache PeerCacheConfig `yaml:"peer_cache" config:"peer_cache"`
+
+	// Verification: verification of the signature of the downloaded artifacts.
+	Verification VerificationConfig `yaml:"verification" config:"verification"`
+
+	// OCI: authentication to the OCI registry of oci:// source URIs.
+	OCI OCIConfig `yaml:"oci" config:"oci"`
+
 	httpcommon.HTTPTransportSettings `config:",inline" yaml:",inline"` // Note: use anonymous struct for json inline
 }
 
@@ -144,6 +180,11 @@ func (r *Reloader) reloadConfig(rawConfig *config.Config) error {
 		TargetDirectory:       tmp.C.TargetDirectory,
 		InstallPath:           tmp.C.InstallPath,
 		DropPath:              tmp.C.DropPath,
+		RateLimit:             tmp.C.RateLimit,
+		Windows:               tmp.C.Windows,
+		PeerCache:             tmp.C.PeerCache,
+		Verification:          tmp.C.Verification,
+		OCI:                   tmp.C.OCI,
 		HTTPTransportSettings: tmp.C.HTTPTransportSettings,
 	}
 
@@ -198,6 +239,7 @@ func DefaultConfig() *Config {
 		TargetDirectory:        paths.Downloads(),
 		InstallPath:            paths.Install(),
 		RetrySleepInitDuration: 30 * time.Second,
+		PeerCache:              DefaultPeerCacheConfig(),
 		HTTPTransportSettings:  transport,
 	}
 }
@@ -0,0 +1,96 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package composed
+
+import (
+	"context"
+	goerrors "errors"
+	"fmt"
+
+	"go.elastic.co/apm/v2"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/http"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/peer"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/pkg/version"
+)
+
+// ErrNoPeers is returned by PeerDownloader when no peer is configured or discovered.
+var ErrNoPeers = goerrors.New("no peer to download the artifact from")
+
+// PeerDownloader downloads the artifacts from the other agents of the network serving
+// their downloads directory, see artifact.PeerCacheConfig. It tries the peers one after
+// the other and verifies the SHA-512 hash of the artifact once downloaded. Peers are not
+// trusted: like for any other source, the PGP signature of the artifact is verified
+// before use, with a signature and keys that are not fetched from the peers.
+type PeerDownloader struct {
+	log            *logger.Logger
+	config         *artifact.Config
+	upgradeDetails *details.Details
+}
+
+// NewPeerDownloader creates a downloader of the artifacts of the peers of the config.
+func NewPeerDownloader(log *logger.Logger, config *artifact.Config, upgradeDetails *details.Details) *PeerDownloader {
+	return &PeerDownloader{
+		log:            log,
+		config:         config,
+		upgradeDetails: upgradeDetails,
+	}
+}
+
+// Download fetches the package from the first peer having it.
+// Returns absolute path to downloaded package and an error.
+func (e *PeerDownloader) Download(ctx context.Context, a artifact.Artifact, version *version.ParsedSemVer) (string, error) {
+	span, ctx := apm.StartSpan(ctx, "downloadFromPeers", "app.internal")
+	defer span.End()
+
+	peers := peer.Peers(ctx, e.log, e.config.PeerCache)
+	if len(peers) == 0 {
+		return "", ErrNoPeers
+	}
+
+	var errs []error
+	for _, p := range peers {
+		path, err := e.downloadFrom(ctx, p, a, version)
+		if err == nil {
+			e.log.Infof("Downloaded %s from peer %s", path, p)
+			return path, nil
+		}
+		e.log.Warnf("Failed to download %s %s from peer %s: %v", a.Name, version, p, err)
+		errs = append(errs, fmt.Errorf("peer %s: %w", p, err))
+	}
+
+	return "", goerrors.Join(errs...)
+}
+
+func (e *PeerDownloader) downloadFrom(ctx context.Context, peerURI string, a artifact.Artifact, version *version.ParsedSemVer) (string, error) {
+	config := *e.config
+	config.SourceURI = peerURI
+	// peers are in the same network, the download is not limited
+	config.RateLimit = 0
+	config.Windows = nil
+
+	downloader, err := http.NewDownloader(e.log, &config, e.upgradeDetails)
+	if err != nil {
+		return "", err
+	}
+	path, err := downloader.Download(ctx, a, version)
+	if err != nil {
+		return "", err
+	}
+	if err := download.VerifySHA512HashWithCleanup(e.log, path); err != nil {
+		return "", err
+	}
+	return path, nil
+}
+
+// Reload reloads the config of the downloader.
+func (e *PeerDownloader) Reload(c *artifact.Config) error {
+	e.config = c
+	return nil
+}
@@ -0,0 +1,98 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package composed
+
+import (
+	"context"
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/peer"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+	agtversion "github.com/elastic/elastic-agent/pkg/version"
+)
+
+func TestPeerDownloader(t *testing.T) {
+	a := artifact.Artifact{Name: "Elastic Agent", Cmd: "elastic-agent", Artifact: "beats/elastic-agent"}
+	version := agtversion.NewParsedSemVer(9, 0, 0, "", "")
+	filename, err := artifact.GetArtifactName(a, *version, "linux", "64")
+	require.NoError(t, err)
+	content := []byte("artifact content")
+	hash := sha512.Sum512(content)
+
+	// agent serving the verified artifact
+	peerDir := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(peerDir, filename), content, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(peerDir, filename+".sha512"),
+		[]byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash[:]), filename)), 0o600))
+	log, _ := loggertest.New(t.Name())
+	goodPeer := httptest.NewServer(peer.NewServer(log, &artifact.Config{TargetDirectory: peerDir}))
+	defer goodPeer.Close()
+
+	// peer serving an artifact not matching its hash
+	badPeer := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if filepath.Ext(r.URL.Path) == ".sha512" {
+			_, _ = fmt.Fprintf(w, "%s  %s\n", hex.EncodeToString(hash[:]), filename)
+			return
+		}
+		_, _ = w.Write([]byte("tampered content"))
+	}))
+	defer badPeer.Close()
+
+	testCases := map[string]struct {
+		peers         []string
+		expectedError bool
+	}{
+		"good peer": {
+			peers: []string{goodPeer.URL},
+		},
+		"bad peer first": {
+			peers: []string{badPeer.URL, goodPeer.URL},
+		},
+		"bad peer only": {
+			peers:         []string{badPeer.URL},
+			expectedError: true,
+		},
+		"no peers": {
+			expectedError: true,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			targetDir := t.TempDir()
+			config := &artifact.Config{
+				OperatingSystem: "linux",
+				Architecture:    "64",
+				TargetDirectory: targetDir,
+				PeerCache:       artifact.PeerCacheConfig{Peers: tc.peers},
+			}
+			downloader := NewPeerDownloader(log, config, details.NewDetails("9.0.0", details.StateRequested, ""))
+
+			path, err := downloader.Download(context.Background(), a, version)
+			if tc.expectedError {
+				require.Error(t, err)
+				assert.NoFileExists(t, filepath.Join(targetDir, filename))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(targetDir, filename), path)
+			downloaded, err := os.ReadFile(path)
+			require.NoError(t, err)
+			assert.Equal(t, content, downloaded)
+		})
+	}
+}
@@ -0,0 +1,378 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package download
+
+import (
+	"bytes"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+)
+
+// CosignBundleSuffix is the suffix of the cosign signature bundle of an artifact, as
+// written by cosign sign-blob --bundle.
+const CosignBundleSuffix = ".cosign.bundle"
+
+var (
+	// oidIssuer and oidIssuerV2 are the extensions of the Fulcio certificates holding
+	// the OIDC issuer of the identity, as a raw string and as an UTF8String.
+	oidIssuer   = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 1}
+	oidIssuerV2 = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 57264, 1, 8}
+
+	errNoTransparencyLogEntry = goerrors.New("bundle has no transparency log entry")
+)
+
+// cosignBundle is the signature bundle written by cosign sign-blob --bundle.
+type cosignBundle struct {
+	Base64Signature string       `json:"base64Signature"`
+	Cert            string       `json:"cert,omitempty"`
+	RekorBundle     *rekorBundle `json:"rekorBundle,omitempty"`
+}
+
+// rekorBundle is the entry of the signature in the Rekor transparency log, with the
+// signed entry timestamp (SET) promising its inclusion.
+type rekorBundle struct {
+	SignedEntryTimestamp []byte       `json:"SignedEntryTimestamp"`
+	Payload              rekorPayload `json:"Payload"`
+}
+
+// rekorPayload is the payload signed by the SET. Its fields are in the order of their
+// canonical JSON encoding.
+type rekorPayload struct {
+	Body           string `json:"body"`
+	IntegratedTime int64  `json:"integratedTime"`
+	LogID          string `json:"logID"`
+	LogIndex       int64  `json:"logIndex"`
+}
+
+// hashedRekord is the body of the Rekor entries of signed artifact digests.
+type hashedRekord struct {
+	Kind string `json:"kind"`
+	Spec struct {
+		Data struct {
+			Hash struct {
+				Algorithm string `json:"algorithm"`
+				Value     string `json:"value"`
+			} `json:"hash"`
+		} `json:"data"`
+		Signature struct {
+			Content   string `json:"content"`
+			PublicKey struct {
+				Content string `json:"content"`
+			} `json:"publicKey"`
+		} `json:"signature"`
+	} `json:"spec"`
+}
+
+// CosignVerifier verifies cosign signature bundles offline, either with a public key or,
+// for keyless signatures, with the Fulcio CAs and Rekor public key of a trust root.
+// Signatures with ECDSA and RSA keys are supported.
+type CosignVerifier struct {
+	publicKey     crypto.PublicKey
+	roots         *x509.CertPool
+	intermediates *x509.CertPool
+	rekorKey      crypto.PublicKey
+	identity      string
+	issuer        string
+}
+
+// NewCosignVerifier creates a cosign verifier out of the keys and certificates of cfg.
+func NewCosignVerifier(cfg artifact.CosignConfig) (*CosignVerifier, error) {
+	v := &CosignVerifier{
+		identity: cfg.CertificateIdentity,
+		issuer:   cfg.CertificateOIDCIssuer,
+	}
+
+	var err error
+	if cfg.PublicKey != "" {
+		if v.publicKey, err = loadPublicKey(cfg.PublicKey); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.RekorPublicKey != "" {
+		if v.rekorKey, err = loadPublicKey(cfg.RekorPublicKey); err != nil {
+			return nil, err
+		}
+	}
+	if cfg.CertificateAuthorities != "" {
+		if v.roots, v.intermediates, err = loadCertificateAuthorities(cfg.CertificateAuthorities); err != nil {
+			return nil, err
+		}
+	}
+	if v.publicKey == nil && (v.roots == nil || v.rekorKey == nil) {
+		return nil, errors.New("cosign verification requires a public key or a trust root", errors.TypeSecurity)
+	}
+	return v, nil
+}
+
+// VerifyBundle verifies the cosign signature bundle of file. If the signature is not
+// valid then a *download.InvalidSignatureError is returned.
+func (v *CosignVerifier) VerifyBundle(file string, bundleBytes []byte) error {
+	var bundle cosignBundle
+	if err := json.Unmarshal(bundleBytes, &bundle); err != nil {
+		return &InvalidSignatureError{File: file, Err: fmt.Errorf("invalid cosign bundle: %w", err)}
+	}
+	signature, err := base64.StdEncoding.DecodeString(bundle.Base64Signature)
+	if err != nil {
+		return &InvalidSignatureError{File: file, Err: fmt.Errorf("invalid cosign signature: %w", err)}
+	}
+
+	digest, err := sha256File(file)
+	if err != nil {
+		return err
+	}
+
+	if err := v.verify(bundle, signature, digest); err != nil {
+		return &InvalidSignatureError{File: file, Err: err}
+	}
+	return nil
+}
+
+func (v *CosignVerifier) verify(bundle cosignBundle, signature, digest []byte) error {
+	if bundle.Cert == "" {
+		if v.publicKey == nil {
+			return goerrors.New("signature has no certificate and no public key is configured")
+		}
+		if err := verifyDigestSignature(v.publicKey, digest, signature); err != nil {
+			return err
+		}
+		// the log entry is optional for key-based signatures, but must be valid
+		if bundle.RekorBundle != nil && v.rekorKey != nil {
+			if _, err := v.verifyLogEntry(bundle.RekorBundle, signature, digest, nil); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	if v.roots == nil || v.rekorKey == nil {
+		return goerrors.New("signature has a certificate and no trust root is configured")
+	}
+	cert, err := parseBundleCertificate(bundle.Cert)
+	if err != nil {
+		return err
+	}
+	if bundle.RekorBundle == nil {
+		return errNoTransparencyLogEntry
+	}
+	// the certificate is short-lived, it must have been valid when the signature was
+	// recorded in the transparency log
+	integratedTime, err := v.verifyLogEntry(bundle.RekorBundle, signature, digest, cert)
+	if err != nil {
+		return err
+	}
+	if err := v.verifyCertificate(cert, integratedTime); err != nil {
+		return err
+	}
+	return verifyDigestSignature(cert.PublicKey, digest, signature)
+}
+
+// verifyLogEntry verifies the SET of the log entry and that the entry records signature,
+// digest and cert. It returns when the entry was recorded.
+func (v *CosignVerifier) verifyLogEntry(entry *rekorBundle, signature, digest []byte, cert *x509.Certificate) (time.Time, error) {
+	payload, err := json.Marshal(entry.Payload)
+	if err != nil {
+		return time.Time{}, err
+	}
+	payloadDigest := sha256.Sum256(payload)
+	if err := verifyDigestSignature(v.rekorKey, payloadDigest[:], entry.SignedEntryTimestamp); err != nil {
+		return time.Time{}, fmt.Errorf("invalid signed entry timestamp: %w", err)
+	}
+
+	body, err := base64.StdEncoding.DecodeString(entry.Payload.Body)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid transparency log entry: %w", err)
+	}
+	var rekord hashedRekord
+	if err := json.Unmarshal(body, &rekord); err != nil {
+		return time.Time{}, fmt.Errorf("invalid transparency log entry: %w", err)
+	}
+	if rekord.Kind != "hashedrekord" || rekord.Spec.Data.Hash.Algorithm != "sha256" {
+		return time.Time{}, fmt.Errorf("unsupported transparency log entry %s with %s digest", rekord.Kind, rekord.Spec.Data.Hash.Algorithm)
+	}
+	if rekord.Spec.Data.Hash.Value != hex.EncodeToString(digest) {
+		return time.Time{}, goerrors.New("transparency log entry is for another artifact")
+	}
+	if entrySignature, err := base64.StdEncoding.DecodeString(rekord.Spec.Signature.Content); err != nil || !bytes.Equal(entrySignature, signature) {
+		return time.Time{}, goerrors.New("transparency log entry is for another signature")
+	}
+	if cert != nil {
+		entryCert, err := parseBundleCertificate(rekord.Spec.Signature.PublicKey.Content)
+		if err != nil || !entryCert.Equal(cert) {
+			return time.Time{}, goerrors.New("transparency log entry is for another certificate")
+		}
+	}
+	return time.Unix(entry.Payload.IntegratedTime, 0), nil
+}
+
+// verifyCertificate verifies that cert was issued by the trusted CAs to the expected
+// identity, authenticated by the expected issuer, and was valid at signedOn.
+func (v *CosignVerifier) verifyCertificate(cert *x509.Certificate, signedOn time.Time) error {
+	_, err := cert.Verify(x509.VerifyOptions{
+		Roots:         v.roots,
+		Intermediates: v.intermediates,
+		CurrentTime:   signedOn,
+		KeyUsages:     []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+	})
+	if err != nil {
+		return fmt.Errorf("untrusted certificate: %w", err)
+	}
+
+	var identities []string
+	identities = append(identities, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		identities = append(identities, uri.String())
+	}
+	found := false
+	for _, identity := range identities {
+		if identity == v.identity {
+			found = true
+			break
+		}
+	}
+	if !found {
+		return fmt.Errorf("certificate identities %v do not match %q", identities, v.identity)
+	}
+
+	if issuer := certificateIssuer(cert); issuer != v.issuer {
+		return fmt.Errorf("certificate OIDC issuer %q does not match %q", issuer, v.issuer)
+	}
+	return nil
+}
+
+// certificateIssuer returns the OIDC issuer recorded in a Fulcio certificate.
+func certificateIssuer(cert *x509.Certificate) string {
+	for _, ext := range cert.Extensions {
+		switch {
+		case ext.Id.Equal(oidIssuerV2):
+			var issuer string
+			if _, err := asn1.Unmarshal(ext.Value, &issuer); err == nil {
+				return issuer
+			}
+		case ext.Id.Equal(oidIssuer):
+			return string(ext.Value)
+		}
+	}
+	return ""
+}
+
+func verifyDigestSignature(key crypto.PublicKey, digest, signature []byte) error {
+	switch k := key.(type) {
+	case *ecdsa.PublicKey:
+		if !ecdsa.VerifyASN1(k, digest, signature) {
+			return goerrors.New("signature verification failed")
+		}
+		return nil
+	case *rsa.PublicKey:
+		return rsa.VerifyPKCS1v15(k, crypto.SHA256, digest, signature)
+	default:
+		return fmt.Errorf("unsupported public key type %T", key)
+	}
+}
+
+// parseBundleCertificate parses a base64 encoded PEM certificate.
+func parseBundleCertificate(content string) (*x509.Certificate, error) {
+	pemBytes, err := base64.StdEncoding.DecodeString(content)
+	if err != nil {
+		return nil, fmt.Errorf("invalid certificate: %w", err)
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "CERTIFICATE" {
+		return nil, goerrors.New("invalid certificate: no PEM certificate found")
+	}
+	return x509.ParseCertificate(block.Bytes)
+}
+
+func loadPublicKey(path string) (crypto.PublicKey, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, errors.New(err, "reading public key", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, path))
+	}
+	block, _ := pem.Decode(pemBytes)
+	if block == nil || block.Type != "PUBLIC KEY" {
+		return nil, errors.New(fmt.Sprintf("no PEM public key found in %s", path), errors.TypeSecurity)
+	}
+	key, err := x509.ParsePKIXPublicKey(block.Bytes)
+	if err != nil {
+		return nil, errors.New(err, fmt.Sprintf("parsing public key %s", path), errors.TypeSecurity)
+	}
+	return key, nil
+}
+
+// loadCertificateAuthorities loads the self-signed root and the intermediate CAs of a
+// PEM file.
+func loadCertificateAuthorities(path string) (*x509.CertPool, *x509.CertPool, error) {
+	pemBytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, nil, errors.New(err, "reading certificate authorities", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, path))
+	}
+
+	roots := x509.NewCertPool()
+	intermediates := x509.NewCertPool()
+	hasRoot := false
+	for block, rest := pem.Decode(pemBytes); block != nil; block, rest = pem.Decode(rest) {
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, nil, errors.New(err, fmt.Sprintf("parsing certificate authorities %s", path), errors.TypeSecurity)
+		}
+		if bytes.Equal(cert.RawIssuer, cert.RawSubject) && cert.CheckSignatureFrom(cert) == nil {
+			roots.AddCert(cert)
+			hasRoot = true
+		} else {
+			intermediates.AddCert(cert)
+		}
+	}
+	if !hasRoot {
+		return nil, nil, errors.New(fmt.Sprintf("no root certificate authority found in %s", path), errors.TypeSecurity)
+	}
+	return roots, intermediates, nil
+}
+
+func sha256File(file string) ([]byte, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, errors.New(err, errors.TypeFilesystem, errors.M(errors.MetaKeyPath, file))
+	}
+	defer f.Close()
+
+	hasher := sha256.New()
+	if _, err := io.Copy(hasher, f); err != nil {
+		return nil, fmt.Errorf("failed to read file to calculate hash: %w", err)
+	}
+	return hasher.Sum(nil), nil
+}
+
+// VerifyCosignSignature verifies the cosign signature bundle of file with the keys and
+// certificates of cfg.
+func VerifyCosignSignature(log infoWarnLogger, cfg artifact.CosignConfig, file string, bundle []byte) error {
+	v, err := NewCosignVerifier(cfg)
+	if err != nil {
+		return fmt.Errorf("could not create cosign verifier: %w", err)
+	}
+	if err := v.VerifyBundle(file, bundle); err != nil {
+		log.Warnf("Verification with cosign failed: %v", err)
+		return fmt.Errorf("could not verify cosign signature of %q: %w", file, err)
+	}
+	log.Infof("Verification with cosign successful")
+	return nil
+}
@@ -0,0 +1,265 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package download
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+)
+
+const (
+	testIdentity = "release@example.com"
+	testIssuer   = "https://accounts.example.com"
+)
+
+// cosignTestEnv is a signing key, a Fulcio-like CA and a Rekor-like log key.
+type cosignTestEnv struct {
+	dir      string
+	artifact string
+	digest   []byte
+
+	signingKey *ecdsa.PrivateKey
+	caKey      *ecdsa.PrivateKey
+	caCert     *x509.Certificate
+	rekorKey   *ecdsa.PrivateKey
+}
+
+func newCosignTestEnv(t *testing.T) *cosignTestEnv {
+	env := &cosignTestEnv{dir: t.TempDir()}
+	env.artifact = filepath.Join(env.dir, "elastic-agent-1.2.3-linux-x86_64.tar.gz")
+	content := []byte("artifact content")
+	require.NoError(t, os.WriteFile(env.artifact, content, 0o600))
+	digest := sha256.Sum256(content)
+	env.digest = digest[:]
+
+	var err error
+	env.signingKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	env.rekorKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	env.caKey, err = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "test-fulcio"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		KeyUsage:              x509.KeyUsageCertSign,
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+	}
+	caDER, err := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &env.caKey.PublicKey, env.caKey)
+	require.NoError(t, err)
+	env.caCert, err = x509.ParseCertificate(caDER)
+	require.NoError(t, err)
+	return env
+}
+
+func (env *cosignTestEnv) writePEM(t *testing.T, name, blockType string, der []byte) string {
+	path := filepath.Join(env.dir, name)
+	require.NoError(t, os.WriteFile(path, pem.EncodeToMemory(&pem.Block{Type: blockType, Bytes: der}), 0o600))
+	return path
+}
+
+func (env *cosignTestEnv) publicKeyPath(t *testing.T, name string, key crypto.PublicKey) string {
+	der, err := x509.MarshalPKIXPublicKey(key)
+	require.NoError(t, err)
+	return env.writePEM(t, name, "PUBLIC KEY", der)
+}
+
+func (env *cosignTestEnv) keylessConfig(t *testing.T) artifact.CosignConfig {
+	return artifact.CosignConfig{
+		CertificateAuthorities: env.writePEM(t, "fulcio.pem", "CERTIFICATE", env.caCert.Raw),
+		RekorPublicKey:         env.publicKeyPath(t, "rekor.pub", &env.rekorKey.PublicKey),
+		CertificateIdentity:    testIdentity,
+		CertificateOIDCIssuer:  testIssuer,
+	}
+}
+
+// leafCert issues a short-lived code signing certificate for the signing key.
+func (env *cosignTestEnv) leafCert(t *testing.T, email string) []byte {
+	issuer, err := asn1.Marshal(testIssuer)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber:   big.NewInt(2),
+		NotBefore:      time.Now().Add(-5 * time.Minute),
+		NotAfter:       time.Now().Add(5 * time.Minute),
+		KeyUsage:       x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:    []x509.ExtKeyUsage{x509.ExtKeyUsageCodeSigning},
+		EmailAddresses: []string{email},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidIssuerV2, Value: issuer},
+		},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, env.caCert, &env.signingKey.PublicKey, env.caKey)
+	require.NoError(t, err)
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+}
+
+func (env *cosignTestEnv) sign(t *testing.T) []byte {
+	signature, err := ecdsa.SignASN1(rand.Reader, env.signingKey, env.digest)
+	require.NoError(t, err)
+	return signature
+}
+
+// logEntry records the signature in the transparency log.
+func (env *cosignTestEnv) logEntry(t *testing.T, signature, certPEM []byte, integratedTime time.Time) *rekorBundle {
+	var rekord hashedRekord
+	rekord.Kind = "hashedrekord"
+	rekord.Spec.Data.Hash.Algorithm = "sha256"
+	rekord.Spec.Data.Hash.Value = hex.EncodeToString(env.digest)
+	rekord.Spec.Signature.Content = base64.StdEncoding.EncodeToString(signature)
+	rekord.Spec.Signature.PublicKey.Content = base64.StdEncoding.EncodeToString(certPEM)
+	body, err := json.Marshal(rekord)
+	require.NoError(t, err)
+
+	entry := &rekorBundle{Payload: rekorPayload{
+		Body:           base64.StdEncoding.EncodeToString(body),
+		IntegratedTime: integratedTime.Unix(),
+		LogID:          "c0d23d6ad406973f9559f3ba2d1ca01f84147d8ffc5b8445c224f98b9591801d",
+		LogIndex:       42,
+	}}
+	payload, err := json.Marshal(entry.Payload)
+	require.NoError(t, err)
+	payloadDigest := sha256.Sum256(payload)
+	entry.SignedEntryTimestamp, err = ecdsa.SignASN1(rand.Reader, env.rekorKey, payloadDigest[:])
+	require.NoError(t, err)
+	return entry
+}
+
+func marshalBundle(t *testing.T, bundle cosignBundle) []byte {
+	b, err := json.Marshal(bundle)
+	require.NoError(t, err)
+	return b
+}
+
+func TestCosignVerifier_PublicKey(t *testing.T) {
+	env := newCosignTestEnv(t)
+	v, err := NewCosignVerifier(artifact.CosignConfig{PublicKey: env.publicKeyPath(t, "cosign.pub", &env.signingKey.PublicKey)})
+	require.NoError(t, err)
+
+	signature := env.sign(t)
+	bundle := marshalBundle(t, cosignBundle{Base64Signature: base64.StdEncoding.EncodeToString(signature)})
+	require.NoError(t, v.VerifyBundle(env.artifact, bundle))
+
+	t.Run("tampered artifact", func(t *testing.T) {
+		require.NoError(t, os.WriteFile(env.artifact+".tampered", []byte("tampered content"), 0o600))
+		err := v.VerifyBundle(env.artifact+".tampered", bundle)
+		var invalidSignatureErr *InvalidSignatureError
+		assert.ErrorAs(t, err, &invalidSignatureErr)
+	})
+
+	t.Run("other key", func(t *testing.T) {
+		otherKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+		require.NoError(t, err)
+		other, err := NewCosignVerifier(artifact.CosignConfig{PublicKey: env.publicKeyPath(t, "other.pub", &otherKey.PublicKey)})
+		require.NoError(t, err)
+		var invalidSignatureErr *InvalidSignatureError
+		assert.ErrorAs(t, other.VerifyBundle(env.artifact, bundle), &invalidSignatureErr)
+	})
+
+	t.Run("invalid bundle", func(t *testing.T) {
+		var invalidSignatureErr *InvalidSignatureError
+		assert.ErrorAs(t, v.VerifyBundle(env.artifact, []byte("not a bundle")), &invalidSignatureErr)
+	})
+}
+
+func TestCosignVerifier_Keyless(t *testing.T) {
+	env := newCosignTestEnv(t)
+	v, err := NewCosignVerifier(env.keylessConfig(t))
+	require.NoError(t, err)
+
+	signature := env.sign(t)
+	certPEM := env.leafCert(t, testIdentity)
+	keylessBundle := func(entry *rekorBundle, cert []byte) []byte {
+		return marshalBundle(t, cosignBundle{
+			Base64Signature: base64.StdEncoding.EncodeToString(signature),
+			Cert:            base64.StdEncoding.EncodeToString(cert),
+			RekorBundle:     entry,
+		})
+	}
+
+	require.NoError(t, v.VerifyBundle(env.artifact, keylessBundle(env.logEntry(t, signature, certPEM, time.Now()), certPEM)))
+
+	tests := map[string]struct {
+		bundle []byte
+		errMsg string
+	}{
+		"no log entry": {
+			bundle: keylessBundle(nil, certPEM),
+			errMsg: errNoTransparencyLogEntry.Error(),
+		},
+		"signed after the certificate expired": {
+			bundle: keylessBundle(env.logEntry(t, signature, certPEM, time.Now().Add(time.Hour)), certPEM),
+			errMsg: "untrusted certificate",
+		},
+		"other identity": {
+			bundle: func() []byte {
+				otherCert := env.leafCert(t, "someone@example.com")
+				return keylessBundle(env.logEntry(t, signature, otherCert, time.Now()), otherCert)
+			}(),
+			errMsg: "do not match",
+		},
+		"log entry of another certificate": {
+			bundle: keylessBundle(env.logEntry(t, signature, env.leafCert(t, testIdentity), time.Now()), certPEM),
+			errMsg: "transparency log entry is for another certificate",
+		},
+		"forged signed entry timestamp": {
+			bundle: func() []byte {
+				entry := env.logEntry(t, signature, certPEM, time.Now())
+				entry.Payload.LogIndex++
+				return keylessBundle(entry, certPEM)
+			}(),
+			errMsg: "invalid signed entry timestamp",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := v.VerifyBundle(env.artifact, tc.bundle)
+			var invalidSignatureErr *InvalidSignatureError
+			require.ErrorAs(t, err, &invalidSignatureErr)
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestNewCosignVerifier_Errors(t *testing.T) {
+	env := newCosignTestEnv(t)
+
+	_, err := NewCosignVerifier(artifact.CosignConfig{})
+	assert.Error(t, err, "a public key or a trust root is required")
+
+	_, err = NewCosignVerifier(artifact.CosignConfig{PublicKey: filepath.Join(env.dir, "missing.pub")})
+	assert.Error(t, err)
+
+	// a trust root without a self-signed root CA is rejected
+	intermediateOnly := artifact.CosignConfig{
+		CertificateAuthorities: env.writePEM(t, "not-ca.pem", "CERTIFICATE", []byte("invalid")),
+		RekorPublicKey:         env.publicKeyPath(t, "rekor.pub", &env.rekorKey.PublicKey),
+	}
+	_, err = NewCosignVerifier(intermediateOnly)
+	assert.Error(t, err)
+}
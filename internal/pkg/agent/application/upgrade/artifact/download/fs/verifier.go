@@ -78,20 +78,34 @@ func (v *Verifier) Verify(ctx context.Context, a artifact.Artifact, version agtv
 		return fmt.Errorf("failed to verify SHA512 hash: %w", err)
 	}
 
-	if err = v.verifyAsc(artifactPath, skipDefaultPgp, pgpBytes...); err != nil {
-		var invalidSignatureErr *download.InvalidSignatureError
-		if errors.As(err, &invalidSignatureErr) {
-			if err := os.Remove(artifactPath + ".asc"); err != nil {
-				v.log.Warnf("failed clean up after signature verification: failed to remove %q: %v",
-					artifactPath+".asc", err)
-			}
+	if v.config.Verification.PGPEnabled() {
+		if err = v.verifyAsc(artifactPath, skipDefaultPgp, pgpBytes...); err != nil {
+			v.removeOnInvalidSignature(err, artifactPath+ascSuffix)
+			return err
+		}
+	}
+
+	if v.config.Verification.CosignEnabled() {
+		if err = v.verifyCosign(artifactPath); err != nil {
+			v.removeOnInvalidSignature(err, artifactPath+download.CosignBundleSuffix)
+			return err
 		}
-		return err
 	}
 
 	return nil
 }
 
+// removeOnInvalidSignature removes the signature file when err is an invalid signature.
+func (v *Verifier) removeOnInvalidSignature(err error, signaturePath string) {
+	var invalidSignatureErr *download.InvalidSignatureError
+	if errors.As(err, &invalidSignatureErr) {
+		if err := os.Remove(signaturePath); err != nil {
+			v.log.Warnf("failed clean up after signature verification: failed to remove %q: %v",
+				signaturePath, err)
+		}
+	}
+}
+
 func (v *Verifier) Reload(c *artifact.Config) error {
 	// reload client
 	client, err := c.HTTPTransportSettings.Client(
@@ -126,6 +140,16 @@ func (v *Verifier) verifyAsc(fullPath string, skipDefaultKey bool, pgpSources ..
 	return download.VerifyPGPSignatureWithKeys(v.log, fullPath, ascBytes, pgpBytes)
 }
 
+func (v *Verifier) verifyCosign(fullPath string) error {
+	bundlePath := fullPath + download.CosignBundleSuffix
+	bundle, err := os.ReadFile(bundlePath)
+	if err != nil {
+		return errors.New(err, fmt.Sprintf("fetching cosign bundle from '%s'", bundlePath), errors.TypeFilesystem, errors.M(errors.MetaKeyPath, bundlePath))
+	}
+
+	return download.VerifyCosignSignature(v.log, v.config.Verification.Cosign, fullPath, bundle)
+}
+
 func (v *Verifier) getPublicAsc(fullPath string) ([]byte, error) {
 	fullPath = fmt.Sprintf("%s%s", fullPath, ascSuffix)
 	b, err := os.ReadFile(fullPath)
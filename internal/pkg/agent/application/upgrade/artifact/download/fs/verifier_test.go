@@ -7,7 +7,14 @@ package fs
 import (
 	"bytes"
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
 	"crypto/sha512"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
 	"fmt"
 	"os"
 	"path/filepath"
@@ -250,6 +257,61 @@ func TestVerify(t *testing.T) {
 // its corresponding checksum (.sha512) and signature (.asc) files.
 // It creates the necessary key to sing the artifact and returns the public key
 // to verify the signature.
+func TestVerifyCosign(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "test case preparation uses an OpenPGP key which results in a SHA-1 violation.")
+	ctx := context.Background()
+	log, _ := loggertest.New("TestVerifyCosign")
+	targetDir := t.TempDir()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	require.NoError(t, err)
+	keyPath := filepath.Join(t.TempDir(), "cosign.pub")
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "PUBLIC KEY", Bytes: keyDER}), 0o600))
+
+	config := &artifact.Config{
+		TargetDirectory: targetDir,
+		DropPath:        filepath.Join(targetDir, "drop"),
+		OperatingSystem: "linux",
+		Architecture:    "32",
+		Verification: artifact.VerificationConfig{
+			Methods: []string{artifact.VerificationCosign},
+			Cosign:  artifact.CosignConfig{PublicKey: keyPath},
+		},
+	}
+
+	pgpKey := prepareTestCase(t, agentSpec, testVersion, config)
+	artifactPath, err := NewDownloader(config).Download(ctx, agentSpec, testVersion)
+	require.NoError(t, err, "fs.Downloader could not download artifacts")
+
+	content, err := os.ReadFile(artifactPath)
+	require.NoError(t, err)
+	digest := sha256.Sum256(content)
+	signature, err := ecdsa.SignASN1(rand.Reader, key, digest[:])
+	require.NoError(t, err)
+	bundlePath := artifactPath + download.CosignBundleSuffix
+	bundle := fmt.Sprintf(`{"base64Signature":%q}`, base64.StdEncoding.EncodeToString(signature))
+	require.NoError(t, os.WriteFile(bundlePath, []byte(bundle), 0o600))
+
+	testVerifier, err := NewVerifier(log, config, pgpKey)
+	require.NoError(t, err)
+
+	// the PGP signature is not verified, the .asc file was not downloaded
+	require.NoError(t, testVerifier.Verify(ctx, agentSpec, *testVersion, false))
+
+	// an invalid bundle is removed
+	otherSignature, err := ecdsa.SignASN1(rand.Reader, key, digest[1:])
+	require.NoError(t, err)
+	bundle = fmt.Sprintf(`{"base64Signature":%q}`, base64.StdEncoding.EncodeToString(otherSignature))
+	require.NoError(t, os.WriteFile(bundlePath, []byte(bundle), 0o600))
+
+	err = testVerifier.Verify(ctx, agentSpec, *testVersion, false)
+	var invalidSignatureErr *download.InvalidSignatureError
+	require.ErrorAs(t, err, &invalidSignatureErr)
+	assertFileNotExists(t, bundlePath)
+}
+
 func prepareTestCase(t *testing.T, a artifact.Artifact, version *agtversion.ParsedSemVer, cfg *artifact.Config) []byte {
 	filename, err := artifact.GetArtifactName(a, *version, cfg.OperatingSystem, cfg.Architecture)
 	require.NoErrorf(t, err, "could not get artifact name")
@@ -17,6 +17,8 @@ import (
 	"strings"
 	"time"
 
+	"github.com/docker/go-units"
+
 	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
@@ -28,19 +30,6 @@ import (
 
 const (
 	packagePermissions = 0o660
-
-	// downloadProgressIntervalPercentage defines how often to report the current download progress when percentage
-	// of time has passed in the overall interval for the complete download to complete. 5% is a good default, as
-	// the default timeout is 10 minutes and this will have it log every 30 seconds.
-	downloadProgressIntervalPercentage = 0.05
-
-	// downloadProgressDefaultInterval defines the default interval at which the current download progress will be reported.
-	// This value is used if the timeout is not specified (and therefore equal to 0).
-	downloadProgressMinInterval = 10 * time.Second
-
-	// warningProgressIntervalPercentage defines how often to log messages as a warning once the amount of time
-	// passed is this percentage or more of the total allotted time to download.
-	warningProgressIntervalPercentage = 0.75
 )
 
 // Downloader is a downloader able to fetch artifacts from elastic.co web page.
@@ -61,7 +50,7 @@ func NewDownloader(log *logger.Logger, config *artifact.Config, upgradeDetails *
 		return nil, err
 	}
 
-	client.Transport = download.WithHeaders(client.Transport, download.Headers)
+	client.Transport = download.WithThrottle(download.WithHeaders(client.Transport, download.Headers), config)
 	return NewDownloaderWithClient(log, config, *client, upgradeDetails), nil
 }
 
@@ -84,7 +73,7 @@ func (e *Downloader) Reload(c *artifact.Config) error {
 		return errors.New(err, "http.downloader: failed to generate client out of config")
 	}
 
-	client.Transport = download.WithHeaders(client.Transport, download.Headers)
+	client.Transport = download.WithThrottle(download.WithHeaders(client.Transport, download.Headers), c)
 
 	e.client = *client
 	e.config = c
@@ -100,6 +89,9 @@ func (e *Downloader) Download(ctx context.Context, a artifact.Artifact, version
 	defer func() {
 		if err != nil {
 			for _, path := range downloadedFiles {
+				if path == "" {
+					continue
+				}
 				if err := os.Remove(path); err != nil {
 					e.log.Warnf("failed to cleanup %s: %v", path, err)
 				}
@@ -167,6 +159,10 @@ func (e *Downloader) downloadHash(ctx context.Context, remoteArtifact string, op
 	return e.downloadFile(ctx, remoteArtifact, filename, fullPath)
 }
 
+// downloadFile downloads the file to fullPath. The file is written to a partial file
+// that is renamed once complete. When the server supports byte ranges the partial file
+// is kept on failure, and the next download of the same URI resumes from its end if the
+// file did not change on the server.
 func (e *Downloader) downloadFile(ctx context.Context, artifactName, filename, fullPath string) (string, error) {
 	sourceURI, err := e.composeURI(artifactName, filename)
 	if err != nil {
@@ -184,41 +180,97 @@ func (e *Downloader) downloadFile(ctx context.Context, artifactName, filename, f
 		}
 	}
 
-	destinationFile, err := os.OpenFile(fullPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, packagePermissions)
-	if err != nil {
-		return "", errors.New(err, "creating package file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, fullPath))
+	partial, offset := loadPartialDownload(fullPath, sourceURI)
+	if partial != nil {
+		req.Header.Set("Range", fmt.Sprintf("bytes=%d-", offset))
+		req.Header.Set("If-Range", partial.validator())
 	}
-	defer destinationFile.Close()
 
 	resp, err := e.client.Do(req.WithContext(ctx))
 	if err != nil {
-		// return path, file already exists and needs to be cleaned up
-		return fullPath, errors.New(err, "fetching package failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
+		return "", errors.New(err, "fetching package failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
 	}
 	defer resp.Body.Close()
 
-	if resp.StatusCode != 200 {
-		// return path, file already exists and needs to be cleaned up
-		return fullPath, errors.New(fmt.Sprintf("call to '%s' returned unsuccessful status code: %d", sourceURI, resp.StatusCode), errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
-	}
-
 	fileSize := -1
-	if contentLength := resp.Header.Get("Content-Length"); contentLength != "" {
-		if length, err := strconv.Atoi(contentLength); err == nil {
-			fileSize = length
+	flags := os.O_CREATE | os.O_TRUNC | os.O_WRONLY
+	switch resp.StatusCode {
+	case http.StatusPartialContent:
+		if partial == nil {
+			return "", errors.New(fmt.Sprintf("call to '%s' returned unrequested partial content", sourceURI), errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
+		}
+		start, total, err := parseContentRange(resp.Header.Get("Content-Range"))
+		if err == nil && start != offset {
+			err = fmt.Errorf("range starts at %d instead of %d", start, offset)
+		}
+		if err == nil && partial.ETag != "" && resp.Header.Get("ETag") != "" && resp.Header.Get("ETag") != partial.ETag {
+			err = fmt.Errorf("ETag changed from %s to %s", partial.ETag, resp.Header.Get("ETag"))
+		}
+		if err != nil {
+			// the next attempt downloads the whole file
+			removePartialDownload(fullPath)
+			return "", errors.New(err, fmt.Sprintf("call to '%s' returned an invalid partial content", sourceURI), errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
 		}
+		e.log.Infof("Resuming download from %s at %s", sourceURI, units.HumanSize(float64(offset)))
+		fileSize = int(total)
+		flags = os.O_WRONLY | os.O_APPEND
+	case http.StatusOK:
+		// the server does not support ranges or the file changed, download all of it
+		offset = 0
+		if contentLength := resp.Header.Get("Content-Length"); contentLength != "" {
+			if length, err := strconv.Atoi(contentLength); err == nil {
+				fileSize = length
+			}
+		}
+		removePartialDownload(fullPath)
+		partial = newPartialDownload(sourceURI, resp)
+		if partial != nil {
+			if err := partial.save(fullPath); err != nil {
+				e.log.Warnf("Failed to save the state of the download from %s, it cannot be resumed: %v", sourceURI, err)
+				partial = nil
+			}
+		}
+	case http.StatusRequestedRangeNotSatisfiable:
+		// the partial file is invalid, download the whole file
+		removePartialDownload(fullPath)
+		resp.Body.Close()
+		return e.downloadFile(ctx, artifactName, filename, fullPath)
+	default:
+		return "", errors.New(fmt.Sprintf("call to '%s' returned unsuccessful status code: %d", sourceURI, resp.StatusCode), errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
 	}
 
-	loggingObserver := newLoggingProgressObserver(e.log, e.config.HTTPTransportSettings.Timeout)
-	detailsObserver := newDetailsProgressObserver(e.upgradeDetails)
-	dp := newDownloadProgressReporter(sourceURI, e.config.HTTPTransportSettings.Timeout, fileSize, loggingObserver, detailsObserver)
+	partialPath := fullPath + partialSuffix
+	destinationFile, err := os.OpenFile(partialPath, flags, packagePermissions)
+	if err != nil {
+		return "", errors.New(err, "creating package file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, partialPath))
+	}
+	defer destinationFile.Close()
+
+	loggingObserver := download.NewLoggingProgressObserver(e.log, e.config.HTTPTransportSettings.Timeout)
+	detailsObserver := download.NewDetailsProgressObserver(e.upgradeDetails)
+	dp := download.NewDownloadProgressReporter(sourceURI, e.config.HTTPTransportSettings.Timeout, fileSize, loggingObserver, detailsObserver)
+	dp.ResumeFrom(offset)
 	dp.Report(ctx)
 	_, err = io.Copy(destinationFile, io.TeeReader(resp.Body, dp))
 	if err != nil {
 		dp.ReportFailed(err)
-		// return path, file already exists and needs to be cleaned up
-		return fullPath, errors.New(err, "copying fetched package failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
+		if partial == nil {
+			// cannot be resumed
+			removePartialDownload(fullPath)
+		}
+		return "", errors.New(err, "copying fetched package failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, sourceURI))
+	}
+	if err := destinationFile.Close(); err != nil {
+		dp.ReportFailed(err)
+		removePartialDownload(fullPath)
+		return "", errors.New(err, "writing package file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, partialPath))
+	}
+	if err := os.Rename(partialPath, fullPath); err != nil {
+		dp.ReportFailed(err)
+		removePartialDownload(fullPath)
+		return "", errors.New(err, "renaming package file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, fullPath))
 	}
+	removePartialDownload(fullPath)
 	dp.ReportComplete()
 
 	return fullPath, nil
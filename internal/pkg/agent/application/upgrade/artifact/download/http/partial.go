@@ -0,0 +1,122 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package http
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+)
+
+const (
+	// partialSuffix is the suffix of a file being downloaded, it is renamed once complete.
+	partialSuffix = ".part"
+	// partialStateSuffix is the suffix of the file describing a partial download.
+	partialStateSuffix = ".part.json"
+)
+
+// partialDownload describes a partially downloaded file, it is persisted next to the
+// file so its download can be resumed with a Range request, even after a restart.
+type partialDownload struct {
+	URI          string `json:"uri"`
+	ETag         string `json:"etag,omitempty"`
+	LastModified string `json:"last_modified,omitempty"`
+}
+
+// newPartialDownload returns the description of the download of the response, nil when
+// the server does not support byte ranges or the response has no validator to make sure
+// the resumed download is of the same file.
+func newPartialDownload(sourceURI string, resp *http.Response) *partialDownload {
+	if resp.Header.Get("Accept-Ranges") != "bytes" {
+		return nil
+	}
+	p := &partialDownload{
+		URI:          sourceURI,
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+	}
+	if p.validator() == "" {
+		return nil
+	}
+	return p
+}
+
+// validator returns the value of the If-Range header. Weak ETags cannot be used with
+// ranges, the Last-Modified date is used instead.
+func (p *partialDownload) validator() string {
+	if p.ETag != "" && !strings.HasPrefix(p.ETag, "W/") {
+		return p.ETag
+	}
+	return p.LastModified
+}
+
+// loadPartialDownload returns the partial download of sourceURI to fullPath and the
+// number of bytes already downloaded. It removes the partial download and returns nil
+// when it cannot be resumed.
+func loadPartialDownload(fullPath, sourceURI string) (*partialDownload, int64) {
+	data, err := os.ReadFile(fullPath + partialStateSuffix)
+	if err != nil {
+		removePartialDownload(fullPath)
+		return nil, 0
+	}
+	var p partialDownload
+	if err := json.Unmarshal(data, &p); err != nil || p.URI != sourceURI || p.validator() == "" {
+		removePartialDownload(fullPath)
+		return nil, 0
+	}
+	fi, err := os.Stat(fullPath + partialSuffix)
+	if err != nil || fi.Size() == 0 {
+		removePartialDownload(fullPath)
+		return nil, 0
+	}
+	return &p, fi.Size()
+}
+
+func (p *partialDownload) save(fullPath string) error {
+	data, err := json.Marshal(p)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(fullPath+partialStateSuffix, data, packagePermissions)
+}
+
+// removePartialDownload removes the partial download of fullPath, if any.
+func removePartialDownload(fullPath string) {
+	_ = os.Remove(fullPath + partialSuffix)
+	_ = os.Remove(fullPath + partialStateSuffix)
+}
+
+// parseContentRange parses the Content-Range header of a 206 response, e.g.
+// "bytes 100-199/200", and returns the first byte of the range and the size of the
+// file, -1 when unknown.
+func parseContentRange(header string) (int64, int64, error) {
+	rng, ok := strings.CutPrefix(header, "bytes ")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	span, size, ok := strings.Cut(rng, "/")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	first, _, ok := strings.Cut(span, "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q", header)
+	}
+	start, err := strconv.ParseInt(first, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", header, err)
+	}
+	if size == "*" {
+		return start, -1, nil
+	}
+	total, err := strconv.ParseInt(size, 10, 64)
+	if err != nil {
+		return 0, 0, fmt.Errorf("invalid Content-Range %q: %w", header, err)
+	}
+	return start, total, nil
+}
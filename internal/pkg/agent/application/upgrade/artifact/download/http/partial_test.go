@@ -0,0 +1,160 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package http
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func TestDownloadFileResume(t *testing.T) {
+	content := bytes.Repeat([]byte("0123456789"), 1000)
+	const etag = `"v1"`
+
+	testCases := map[string]struct {
+		handler       func(t *testing.T) http.HandlerFunc
+		partialETag   string
+		expectedRange string
+	}{
+		"server supports ranges": {
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("ETag", etag)
+					http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(content))
+				}
+			},
+			partialETag:   etag,
+			expectedRange: "bytes=4000-",
+		},
+		"file changed on the server": {
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					w.Header().Set("ETag", etag)
+					http.ServeContent(w, r, "artifact", time.Time{}, bytes.NewReader(content))
+				}
+			},
+			partialETag:   `"v0"`,
+			expectedRange: "bytes=4000-",
+		},
+		"server does not support ranges": {
+			handler: func(t *testing.T) http.HandlerFunc {
+				return func(w http.ResponseWriter, r *http.Request) {
+					_, _ = w.Write(content)
+				}
+			},
+			partialETag:   etag,
+			expectedRange: "bytes=4000-",
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			var requestedRange string
+			handler := tc.handler(t)
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requestedRange = r.Header.Get("Range")
+				handler(w, r)
+			}))
+			defer srv.Close()
+
+			targetDir := t.TempDir()
+			fullPath := filepath.Join(targetDir, "artifact.tar.gz")
+			sourceURI := srv.URL + "/artifact/artifact.tar.gz"
+
+			// previous attempt stopped after 4000 bytes, with garbage in the partial file
+			// when the file changed on the server
+			part := content[:4000]
+			if tc.partialETag != etag {
+				part = bytes.Repeat([]byte("x"), 4000)
+			}
+			require.NoError(t, os.WriteFile(fullPath+partialSuffix, part, 0o600))
+			require.NoError(t, (&partialDownload{URI: sourceURI, ETag: tc.partialETag}).save(fullPath))
+
+			log, _ := loggertest.New("downloader")
+			config := &artifact.Config{SourceURI: srv.URL, TargetDirectory: targetDir}
+			d := NewDownloaderWithClient(log, config, *srv.Client(), details.NewDetails("9.0.0", details.StateRequested, ""))
+
+			path, err := d.downloadFile(context.Background(), "artifact", "artifact.tar.gz", fullPath)
+			require.NoError(t, err)
+			assert.Equal(t, fullPath, path)
+			assert.Equal(t, tc.expectedRange, requestedRange)
+
+			downloaded, err := os.ReadFile(fullPath)
+			require.NoError(t, err)
+			assert.Equal(t, content, downloaded)
+			assert.NoFileExists(t, fullPath+partialSuffix)
+			assert.NoFileExists(t, fullPath+partialStateSuffix)
+		})
+	}
+}
+
+func TestDownloadFileKeepsResumablePartial(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		w.Header().Set("ETag", `"v1"`)
+		w.Header().Set("Content-Length", "10000")
+		_, _ = w.Write(bytes.Repeat([]byte("0"), 4000))
+		// the connection is closed before the whole body is sent
+	}))
+	defer srv.Close()
+
+	targetDir := t.TempDir()
+	fullPath := filepath.Join(targetDir, "artifact.tar.gz")
+
+	log, _ := loggertest.New("downloader")
+	config := &artifact.Config{SourceURI: srv.URL, TargetDirectory: targetDir}
+	d := NewDownloaderWithClient(log, config, *srv.Client(), details.NewDetails("9.0.0", details.StateRequested, ""))
+
+	path, err := d.downloadFile(context.Background(), "artifact", "artifact.tar.gz", fullPath)
+	require.Error(t, err)
+	assert.Empty(t, path)
+	assert.NoFileExists(t, fullPath)
+
+	partial, offset := loadPartialDownload(fullPath, srv.URL+"/artifact/artifact.tar.gz")
+	require.NotNil(t, partial)
+	assert.Equal(t, `"v1"`, partial.ETag)
+	assert.Equal(t, int64(4000), offset)
+}
+
+func TestParseContentRange(t *testing.T) {
+	testCases := []struct {
+		header        string
+		start, total  int64
+		expectedError bool
+	}{
+		{header: "bytes 100-199/200", start: 100, total: 200},
+		{header: "bytes 0-0/*", start: 0, total: -1},
+		{header: "bytes */200", expectedError: true},
+		{header: "items 0-1/2", expectedError: true},
+		{header: "bytes 100-199", expectedError: true},
+		{header: "", expectedError: true},
+	}
+
+	for _, tc := range testCases {
+		t.Run(tc.header, func(t *testing.T) {
+			start, total, err := parseContentRange(tc.header)
+			if tc.expectedError {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.start, start)
+			assert.Equal(t, tc.total, total)
+		})
+	}
+}
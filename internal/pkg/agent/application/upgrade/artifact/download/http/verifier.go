@@ -101,24 +101,39 @@ func (v *Verifier) Verify(ctx context.Context, a artifact.Artifact, version agtv
 		return fmt.Errorf("failed to verify SHA512 hash: %w", err)
 	}
 
-	if err = v.verifyAsc(ctx, a, version, skipDefaultPgp, pgpBytes...); err != nil {
-		var invalidSignatureErr *download.InvalidSignatureError
-		if errors.As(err, &invalidSignatureErr) {
-			if err := os.Remove(artifactPath); err != nil {
-				v.log.Warnf("failed clean up after signature verification: failed to remove %q: %v",
-					artifactPath, err)
-			}
-			if err := os.Remove(artifactPath + ascSuffix); err != nil {
-				v.log.Warnf("failed clean up after sha512 check: failed to remove %q: %v",
-					artifactPath+ascSuffix, err)
-			}
+	if v.config.Verification.PGPEnabled() {
+		if err = v.verifyAsc(ctx, a, version, skipDefaultPgp, pgpBytes...); err != nil {
+			v.removeOnInvalidSignature(err, artifactPath, ascSuffix)
+			return err
+		}
+	}
+
+	if v.config.Verification.CosignEnabled() {
+		if err = v.verifyCosign(ctx, a, version); err != nil {
+			v.removeOnInvalidSignature(err, artifactPath, download.CosignBundleSuffix)
+			return err
 		}
-		return err
 	}
 
 	return nil
 }
 
+// removeOnInvalidSignature removes the artifact and its signature file when err is an
+// invalid signature.
+func (v *Verifier) removeOnInvalidSignature(err error, artifactPath, signatureSuffix string) {
+	var invalidSignatureErr *download.InvalidSignatureError
+	if errors.As(err, &invalidSignatureErr) {
+		if err := os.Remove(artifactPath); err != nil {
+			v.log.Warnf("failed clean up after signature verification: failed to remove %q: %v",
+				artifactPath, err)
+		}
+		if err := os.Remove(artifactPath + signatureSuffix); err != nil {
+			v.log.Warnf("failed clean up after signature verification: failed to remove %q: %v",
+				artifactPath+signatureSuffix, err)
+		}
+	}
+}
+
 func (v *Verifier) verifyAsc(ctx context.Context, a artifact.Artifact, version agtversion.ParsedSemVer, skipDefaultKey bool, pgpSources ...string) error {
 	filename, err := artifact.GetArtifactName(a, version, v.config.OS(), v.config.Arch())
 	if err != nil {
@@ -130,7 +145,7 @@ func (v *Verifier) verifyAsc(ctx context.Context, a artifact.Artifact, version a
 		return errors.New(err, "retrieving package path")
 	}
 
-	ascURI, err := v.composeURI(filename, a.Artifact)
+	ascURI, err := v.composeURI(filename, a.Artifact, ascSuffix)
 	if err != nil {
 		return errors.New(err, "composing URI for fetching asc file", errors.TypeNetwork)
 	}
@@ -149,7 +164,31 @@ func (v *Verifier) verifyAsc(ctx context.Context, a artifact.Artifact, version a
 	return download.VerifyPGPSignatureWithKeys(v.log, fullPath, ascBytes, pgpBytes)
 }
 
-func (v *Verifier) composeURI(filename, artifactName string) (string, error) {
+func (v *Verifier) verifyCosign(ctx context.Context, a artifact.Artifact, version agtversion.ParsedSemVer) error {
+	filename, err := artifact.GetArtifactName(a, version, v.config.OS(), v.config.Arch())
+	if err != nil {
+		return errors.New(err, "retrieving package name")
+	}
+
+	fullPath, err := artifact.GetArtifactPath(a, version, v.config.OS(), v.config.Arch(), v.config.TargetDirectory)
+	if err != nil {
+		return errors.New(err, "retrieving package path")
+	}
+
+	bundleURI, err := v.composeURI(filename, a.Artifact, download.CosignBundleSuffix)
+	if err != nil {
+		return errors.New(err, "composing URI for fetching cosign bundle", errors.TypeNetwork)
+	}
+
+	bundle, err := v.getPublicAsc(ctx, bundleURI)
+	if err != nil {
+		return errors.New(err, fmt.Sprintf("fetching cosign bundle from %s", bundleURI), errors.TypeNetwork, errors.M(errors.MetaKeyURI, bundleURI))
+	}
+
+	return download.VerifyCosignSignature(v.log, v.config.Verification.Cosign, fullPath, bundle)
+}
+
+func (v *Verifier) composeURI(filename, artifactName, suffix string) (string, error) {
 	upstream := v.config.SourceURI
 	if !strings.HasPrefix(upstream, "http") && !strings.HasPrefix(upstream, "file") && !strings.HasPrefix(upstream, "/") {
 		// always default to https
@@ -162,7 +201,7 @@ func (v *Verifier) composeURI(filename, artifactName string) (string, error) {
 		return "", errors.New(err, "invalid upstream URI", errors.TypeNetwork, errors.M(errors.MetaKeyURI, upstream))
 	}
 
-	uri.Path = path.Join(uri.Path, artifactName, filename+ascSuffix)
+	uri.Path = path.Join(uri.Path, artifactName, filename+suffix)
 	return uri.String(), nil
 }
 
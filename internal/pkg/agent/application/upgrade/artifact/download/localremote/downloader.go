@@ -10,6 +10,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/composed"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/fs"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/http"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/oci"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/snapshot"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
@@ -19,9 +20,25 @@ import (
 // NewDownloader creates a downloader which first checks local directory
 // and then fallbacks to remote if configured.
 func NewDownloader(log *logger.Logger, config *artifact.Config, upgradeDetails *details.Details) (download.Downloader, error) {
-	downloaders := make([]download.Downloader, 0, 3)
+	downloaders := make([]download.Downloader, 0, 4)
 	downloaders = append(downloaders, fs.NewDownloader(config))
 
+	// try the other agents of the network before the remote sources
+	if config.PeerCache.HasPeers() {
+		downloaders = append(downloaders, composed.NewPeerDownloader(log, config, upgradeDetails))
+	}
+
+	// artifacts of an OCI registry are only downloaded from the registry
+	if artifact.IsOCISourceURI(config.SourceURI) {
+		ociDownloader, err := oci.NewDownloader(log, config, upgradeDetails)
+		if err != nil {
+			return nil, err
+		}
+
+		downloaders = append(downloaders, ociDownloader)
+		return composed.NewDownloader(downloaders...), nil
+	}
+
 	// If the current build is a snapshot we use this downloader to update
 	// to the latest snapshot of the same version. Useful for testing with
 	// a snapshot version of fleet, for example.
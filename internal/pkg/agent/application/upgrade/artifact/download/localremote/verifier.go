@@ -26,6 +26,12 @@ func NewVerifier(log *logger.Logger, config *artifact.Config, pgp []byte) (downl
 	}
 	verifiers = append(verifiers, fsVer)
 
+	// the OCI downloader downloads the signatures along with the artifacts, they are
+	// verified locally
+	if artifact.IsOCISourceURI(config.SourceURI) {
+		return composed.NewVerifier(log, verifiers...), nil
+	}
+
 	// if the current build is a snapshot we use this downloader to update to the latest snapshot of the same version
 	// useful for testing with a snapshot version of fleet for example
 	// try snapshot repo before official
@@ -0,0 +1,343 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+	agtversion "github.com/elastic/elastic-agent/pkg/version"
+)
+
+const (
+	packagePermissions = 0o660
+
+	mediaTypeOCIManifest    = "application/vnd.oci.image.manifest.v1+json"
+	mediaTypeOCIIndex       = "application/vnd.oci.image.index.v1+json"
+	mediaTypeDockerManifest = "application/vnd.docker.distribution.manifest.v2+json"
+	mediaTypeDockerList     = "application/vnd.docker.distribution.manifest.list.v2+json"
+
+	// annotationTitle is the annotation of the layers holding the file name of their content.
+	annotationTitle = "org.opencontainers.image.title"
+
+	// maxManifestSize is the maximum size of the manifests, as recommended by the
+	// distribution spec.
+	maxManifestSize = 4 << 20
+)
+
+var manifestMediaTypes = []string{mediaTypeOCIManifest, mediaTypeOCIIndex, mediaTypeDockerManifest, mediaTypeDockerList}
+
+// descriptor describes a blob or manifest of the registry.
+type descriptor struct {
+	MediaType   string            `json:"mediaType"`
+	Digest      string            `json:"digest"`
+	Size        int64             `json:"size"`
+	Annotations map[string]string `json:"annotations,omitempty"`
+}
+
+// manifest is an image manifest or an index of manifests.
+type manifest struct {
+	MediaType string       `json:"mediaType"`
+	Layers    []descriptor `json:"layers"`
+	Manifests []descriptor `json:"manifests"`
+}
+
+// Downloader downloads the artifacts stored as OCI artifacts in a registry. Each artifact
+// is a layer of the manifest, named by its title annotation, along with its .sha512 hash,
+// .asc signature and cosign signature bundle when the manifest has them.
+type Downloader struct {
+	log            *logger.Logger
+	upgradeDetails *details.Details
+
+	mu       sync.Mutex
+	config   *artifact.Config
+	registry *registryClient
+}
+
+// NewDownloader creates a downloader of the artifacts of the OCI registry of the source URI.
+func NewDownloader(log *logger.Logger, config *artifact.Config, upgradeDetails *details.Details) (*Downloader, error) {
+	registry, err := newRegistry(config)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Downloader{
+		log:            log,
+		config:         config,
+		registry:       registry,
+		upgradeDetails: upgradeDetails,
+	}, nil
+}
+
+func newRegistry(config *artifact.Config) (*registryClient, error) {
+	client, err := config.HTTPTransportSettings.Client(
+		httpcommon.WithAPMHTTPInstrumentation(),
+		httpcommon.WithKeepaliveSettings{Disable: false, IdleConnTimeout: 30 * time.Second},
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	client.Transport = download.WithThrottle(download.WithHeaders(client.Transport, download.Headers), config)
+	return newRegistryClient(client, config.OCI), nil
+}
+
+// Reload reloads the client and the credentials of the registry.
+func (e *Downloader) Reload(c *artifact.Config) error {
+	registry, err := newRegistry(c)
+	if err != nil {
+		return errors.New(err, "oci.downloader: failed to generate client out of config")
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.registry = registry
+	e.config = c
+	return nil
+}
+
+// Download fetches the package from the registry of the configured source URI.
+// Returns absolute path to downloaded package and an error.
+func (e *Downloader) Download(ctx context.Context, a artifact.Artifact, version *agtversion.ParsedSemVer) (_ string, err error) {
+	e.mu.Lock()
+	config, registry := e.config, e.registry
+	e.mu.Unlock()
+
+	ref, err := parseReference(config.SourceURI, a, *version)
+	if err != nil {
+		return "", errors.New(err, "invalid OCI source URI", errors.TypeConfig)
+	}
+	filename, err := artifact.GetArtifactName(a, *version, config.OS(), config.Arch())
+	if err != nil {
+		return "", errors.New(err, "generating package name failed")
+	}
+	fullPath, err := artifact.GetArtifactPath(a, *version, config.OS(), config.Arch(), config.TargetDirectory)
+	if err != nil {
+		return "", errors.New(err, "generating package path failed")
+	}
+
+	layers, err := e.layers(ctx, registry, ref, ref.manifestRef(), filename)
+	if err != nil {
+		return "", err
+	}
+
+	downloadedFiles := make([]string, 0, 4)
+	defer func() {
+		if err != nil {
+			for _, path := range downloadedFiles {
+				if err := os.Remove(path); err != nil && !os.IsNotExist(err) {
+					e.log.Warnf("failed to cleanup %s: %v", path, err)
+				}
+			}
+		}
+	}()
+
+	if err := os.MkdirAll(filepath.Dir(fullPath), 0o755); err != nil {
+		return "", err
+	}
+
+	e.log.Infow("Downloading artifact from OCI registry", "reference", ref.String(), "file", filename)
+	downloadedFiles = append(downloadedFiles, fullPath)
+	if err := e.downloadBlob(ctx, registry, ref, layers[filename], fullPath, true); err != nil {
+		return "", err
+	}
+
+	hashPath := fullPath + ".sha512"
+	downloadedFiles = append(downloadedFiles, hashPath)
+	if hashLayer, ok := layers[filename+".sha512"]; ok {
+		if err := e.downloadBlob(ctx, registry, ref, hashLayer, hashPath, false); err != nil {
+			return "", err
+		}
+	} else if err := writeHashFile(fullPath, filename, layers[filename]); err != nil {
+		return "", err
+	}
+
+	// the signatures are verified by the fs verifier when the verification requires them
+	for _, suffix := range []string{".asc", download.CosignBundleSuffix} {
+		signatureLayer, ok := layers[filename+suffix]
+		if !ok {
+			continue
+		}
+		downloadedFiles = append(downloadedFiles, fullPath+suffix)
+		if err := e.downloadBlob(ctx, registry, ref, signatureLayer, fullPath+suffix, false); err != nil {
+			return "", err
+		}
+	}
+
+	return fullPath, nil
+}
+
+// layers returns the layers of the manifest of the artifact filename by title. The
+// manifests of an index are searched for the first one containing the artifact.
+func (e *Downloader) layers(ctx context.Context, registry *registryClient, ref reference, manifestRef, filename string) (map[string]descriptor, error) {
+	resp, err := registry.get(ctx, ref, "manifests/"+manifestRef, manifestMediaTypes...)
+	if err != nil {
+		return nil, errors.New(err, "fetching OCI manifest failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, ref.String()))
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxManifestSize+1))
+	if err != nil {
+		return nil, errors.New(err, "fetching OCI manifest failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, ref.String()))
+	}
+	if len(body) > maxManifestSize {
+		return nil, fmt.Errorf("manifest %s of %s exceeds %d bytes", manifestRef, ref, maxManifestSize)
+	}
+	if strings.HasPrefix(manifestRef, "sha") {
+		// manifests pulled by digest must match it
+		if err := verifyDigest(manifestRef, body); err != nil {
+			return nil, fmt.Errorf("manifest of %s: %w", ref, err)
+		}
+	}
+
+	var m manifest
+	if err := json.Unmarshal(body, &m); err != nil {
+		return nil, fmt.Errorf("invalid manifest %s of %s: %w", manifestRef, ref, err)
+	}
+
+	if len(m.Manifests) > 0 {
+		for _, sub := range m.Manifests {
+			layers, err := e.layers(ctx, registry, ref, sub.Digest, filename)
+			if err == nil {
+				return layers, nil
+			}
+			e.log.Debugf("Manifest %s of %s has no artifact %s: %v", sub.Digest, ref, filename, err)
+		}
+		return nil, fmt.Errorf("artifact %s is in none of the manifests of %s: %w", filename, ref, ErrNotFound)
+	}
+
+	layers := make(map[string]descriptor, len(m.Layers))
+	for _, layer := range m.Layers {
+		if title := layer.Annotations[annotationTitle]; title != "" {
+			layers[title] = layer
+		}
+	}
+	if _, ok := layers[filename]; !ok {
+		return nil, fmt.Errorf("artifact %s is not in %s: %w", filename, ref, ErrNotFound)
+	}
+	return layers, nil
+}
+
+// downloadBlob downloads the blob of layer to fullPath and verifies its digest and size.
+func (e *Downloader) downloadBlob(ctx context.Context, registry *registryClient, ref reference, layer descriptor, fullPath string, reportProgress bool) (err error) {
+	h, err := newDigester(layer.Digest)
+	if err != nil {
+		return fmt.Errorf("layer of %s: %w", filepath.Base(fullPath), err)
+	}
+
+	resp, err := registry.get(ctx, ref, "blobs/"+layer.Digest)
+	if err != nil {
+		return errors.New(err, "fetching package failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, ref.String()))
+	}
+	defer resp.Body.Close()
+
+	destinationFile, err := os.OpenFile(fullPath, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, packagePermissions)
+	if err != nil {
+		return errors.New(err, "creating package file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, fullPath))
+	}
+	defer destinationFile.Close()
+
+	var w io.Writer = io.MultiWriter(destinationFile, h)
+	if reportProgress {
+		timeout := e.config.HTTPTransportSettings.Timeout
+		dp := download.NewDownloadProgressReporter(ref.String(), timeout, int(layer.Size),
+			download.NewLoggingProgressObserver(e.log, timeout), download.NewDetailsProgressObserver(e.upgradeDetails))
+		dp.Report(ctx)
+		defer func() {
+			if err != nil {
+				dp.ReportFailed(err)
+				return
+			}
+			dp.ReportComplete()
+		}()
+		w = io.MultiWriter(w, dp)
+	}
+
+	// read one byte more than the size to detect blobs larger than their descriptor
+	n, err := io.Copy(w, io.LimitReader(resp.Body, layer.Size+1))
+	if err != nil {
+		return errors.New(err, "copying fetched package failed", errors.TypeNetwork, errors.M(errors.MetaKeyURI, ref.String()))
+	}
+	if n != layer.Size {
+		return fmt.Errorf("blob %s of %s has %d bytes instead of %d", layer.Digest, ref, n, layer.Size)
+	}
+	if actual := digestAlgorithm(layer.Digest) + ":" + hex.EncodeToString(h.Sum(nil)); actual != layer.Digest {
+		return fmt.Errorf("blob of %s has digest %s instead of %s", ref, actual, layer.Digest)
+	}
+	if err := destinationFile.Close(); err != nil {
+		return errors.New(err, "writing package file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, fullPath))
+	}
+	return nil
+}
+
+// writeHashFile writes the .sha512 file of the artifact when the manifest has none. The
+// sha512 digest of the layer is trusted as it was verified on download.
+func writeHashFile(fullPath, filename string, layer descriptor) error {
+	var hashHex string
+	if digestAlgorithm(layer.Digest) == "sha512" {
+		hashHex = strings.TrimPrefix(layer.Digest, "sha512:")
+	} else {
+		f, err := os.Open(fullPath)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		h := sha512.New()
+		if _, err := io.Copy(h, f); err != nil {
+			return fmt.Errorf("computing the sha512 hash of %s: %w", fullPath, err)
+		}
+		hashHex = hex.EncodeToString(h.Sum(nil))
+	}
+
+	hashPath := fullPath + ".sha512"
+	if err := os.WriteFile(hashPath, []byte(hashHex+"  "+filename+"\n"), packagePermissions); err != nil {
+		return errors.New(err, "writing hash file failed", errors.TypeFilesystem, errors.M(errors.MetaKeyPath, hashPath))
+	}
+	return nil
+}
+
+func digestAlgorithm(digest string) string {
+	algorithm, _, _ := strings.Cut(digest, ":")
+	return algorithm
+}
+
+func newDigester(digest string) (hash.Hash, error) {
+	if !digestRegexp.MatchString(digest) {
+		return nil, fmt.Errorf("unsupported digest %q", digest)
+	}
+	if digestAlgorithm(digest) == "sha512" {
+		return sha512.New(), nil
+	}
+	return sha256.New(), nil
+}
+
+func verifyDigest(digest string, content []byte) error {
+	h, err := newDigester(digest)
+	if err != nil {
+		return err
+	}
+	_, _ = h.Write(content)
+	if actual := digestAlgorithm(digest) + ":" + hex.EncodeToString(h.Sum(nil)); actual != digest {
+		return fmt.Errorf("digest %s does not match %s", actual, digest)
+	}
+	return nil
+}
@@ -0,0 +1,376 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package oci
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+	agtversion "github.com/elastic/elastic-agent/pkg/version"
+)
+
+const (
+	testUsername = "puller"
+	testPassword = "changeme"
+	testToken    = "registry-token"
+)
+
+// testRegistry is an in-process OCI registry authenticating pulls with the bearer token
+// flow of its token service.
+type testRegistry struct {
+	t         *testing.T
+	server    *httptest.Server
+	manifests map[string][]byte
+	blobs     map[string][]byte
+}
+
+func newTestRegistry(t *testing.T) *testRegistry {
+	r := &testRegistry{
+		t:         t,
+		manifests: make(map[string][]byte),
+		blobs:     make(map[string][]byte),
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/token", r.handleToken)
+	mux.HandleFunc("/v2/", r.handleDistribution)
+	r.server = httptest.NewTLSServer(mux)
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *testRegistry) host() string {
+	return strings.TrimPrefix(r.server.URL, "https://")
+}
+
+func (r *testRegistry) config(t *testing.T, sourceURI string) *artifact.Config {
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: r.server.Certificate().Raw})
+	return &artifact.Config{
+		OperatingSystem: "linux",
+		Architecture:    "64",
+		SourceURI:       sourceURI,
+		TargetDirectory: t.TempDir(),
+		OCI:             artifact.OCIConfig{Username: testUsername, Password: testPassword},
+		HTTPTransportSettings: httpcommon.HTTPTransportSettings{
+			TLS: &tlscommon.Config{CAs: []string{string(certPEM)}},
+		},
+	}
+}
+
+func (r *testRegistry) handleToken(w http.ResponseWriter, req *http.Request) {
+	username, password, ok := req.BasicAuth()
+	if !ok || username != testUsername || password != testPassword {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	assert.Equal(r.t, r.host(), req.URL.Query().Get("service"))
+	_ = json.NewEncoder(w).Encode(map[string]string{"token": testToken})
+}
+
+func (r *testRegistry) handleDistribution(w http.ResponseWriter, req *http.Request) {
+	if req.Header.Get("Authorization") != "Bearer "+testToken {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="%s"`, r.server.URL, r.host()))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	path := strings.TrimPrefix(req.URL.Path, "/v2/")
+	if i := strings.LastIndex(path, "/blobs/"); i >= 0 {
+		blob, ok := r.blobs[path[i+len("/blobs/"):]]
+		if !ok {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_, _ = w.Write(blob)
+		return
+	}
+	m, ok := r.manifests[path]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", mediaTypeOCIManifest)
+	_, _ = w.Write(m)
+}
+
+// push stores the files as the layers of the manifest of repository:tag and returns the
+// digest of the manifest.
+func (r *testRegistry) push(repository, tag string, files map[string][]byte) string {
+	m := manifest{MediaType: mediaTypeOCIManifest}
+	for name, content := range files {
+		digest := sha256Digest(content)
+		r.blobs[digest] = content
+		m.Layers = append(m.Layers, descriptor{
+			MediaType:   "application/octet-stream",
+			Digest:      digest,
+			Size:        int64(len(content)),
+			Annotations: map[string]string{annotationTitle: name},
+		})
+	}
+	body, err := json.Marshal(m)
+	require.NoError(r.t, err)
+
+	digest := sha256Digest(body)
+	r.manifests[repository+"/manifests/"+tag] = body
+	r.manifests[repository+"/manifests/"+digest] = body
+	return digest
+}
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestDownload(t *testing.T) {
+	const filename = "elastic-agent-9.1.0-linux-x86_64.tar.gz"
+	content := []byte("elastic agent package")
+	sum := sha512.Sum512(content)
+	hashFile := []byte(hex.EncodeToString(sum[:]) + "  " + filename + "\n")
+
+	version := agtversion.NewParsedSemVer(9, 1, 0, "", "")
+	registry := newTestRegistry(t)
+	manifestDigest := registry.push("mirror/elastic-agent", "9.1.0", map[string][]byte{
+		filename:                               content,
+		filename + ".sha512":                   hashFile,
+		filename + ".asc":                      []byte("signature"),
+		filename + download.CosignBundleSuffix: []byte("{}"),
+	})
+	registry.push("pinned/agent", "stable", map[string][]byte{filename: content})
+
+	tests := map[string]struct {
+		sourceURI string
+		files     map[string][]byte
+		errMsg    string
+	}{
+		"version tag of the artifact repository": {
+			sourceURI: "oci://" + registry.host() + "/mirror",
+			files: map[string][]byte{
+				filename:                               content,
+				filename + ".sha512":                   hashFile,
+				filename + ".asc":                      []byte("signature"),
+				filename + download.CosignBundleSuffix: []byte("{}"),
+			},
+		},
+		"digest": {
+			sourceURI: "oci://" + registry.host() + "/mirror/elastic-agent@" + manifestDigest,
+			files:     map[string][]byte{filename: content, filename + ".sha512": hashFile},
+		},
+		"tag without hash layer": {
+			sourceURI: "oci://" + registry.host() + "/pinned/agent:stable",
+			files:     map[string][]byte{filename: content, filename + ".sha512": hashFile},
+		},
+		"missing tag": {
+			sourceURI: "oci://" + registry.host() + "/pinned/agent:latest",
+			errMsg:    ErrNotFound.Error(),
+		},
+		"digest of another manifest": {
+			sourceURI: "oci://" + registry.host() + "/mirror/elastic-agent@" + sha256Digest([]byte("other")),
+			errMsg:    ErrNotFound.Error(),
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			config := registry.config(t, tc.sourceURI)
+			log, _ := loggertest.New(name)
+			upgradeDetails := details.NewDetails(version.String(), details.StateRequested, "")
+			downloader, err := NewDownloader(log, config, upgradeDetails)
+			require.NoError(t, err)
+
+			path, err := downloader.Download(context.Background(), artifact.Artifact{Cmd: "elastic-agent", Artifact: "beats/elastic-agent"}, version)
+			if tc.errMsg != "" {
+				assert.ErrorContains(t, err, tc.errMsg)
+				entries, err := os.ReadDir(config.TargetDirectory)
+				require.NoError(t, err)
+				assert.Empty(t, entries, "the files must be cleaned up on error")
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, filepath.Join(config.TargetDirectory, filename), path)
+			for name, expected := range tc.files {
+				actual, err := os.ReadFile(filepath.Join(config.TargetDirectory, name))
+				require.NoError(t, err)
+				assert.Equal(t, expected, actual, name)
+			}
+			assert.NoError(t, download.VerifySHA512Hash(path))
+			assert.Equal(t, 1.0, upgradeDetails.Metadata.DownloadPercent)
+		})
+	}
+}
+
+func TestDownload_Errors(t *testing.T) {
+	const filename = "elastic-agent-9.1.0-linux-x86_64.tar.gz"
+	version := agtversion.NewParsedSemVer(9, 1, 0, "", "")
+	a := artifact.Artifact{Cmd: "elastic-agent", Artifact: "beats/elastic-agent"}
+
+	t.Run("tampered blob", func(t *testing.T) {
+		registry := newTestRegistry(t)
+		registry.push("mirror/elastic-agent", "9.1.0", map[string][]byte{filename: []byte("elastic agent package")})
+		for digest := range registry.blobs {
+			registry.blobs[digest] = []byte("tampered agent package")
+		}
+
+		config := registry.config(t, "oci://"+registry.host()+"/mirror")
+		log, _ := loggertest.New("oci")
+		downloader, err := NewDownloader(log, config, details.NewDetails(version.String(), details.StateRequested, ""))
+		require.NoError(t, err)
+		_, err = downloader.Download(context.Background(), a, version)
+		assert.ErrorContains(t, err, "instead of")
+		assert.NoFileExists(t, filepath.Join(config.TargetDirectory, filename))
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		registry := newTestRegistry(t)
+		registry.push("mirror/elastic-agent", "9.1.0", map[string][]byte{filename: []byte("elastic agent package")})
+
+		config := registry.config(t, "oci://"+registry.host()+"/mirror")
+		config.OCI.Password = "wrong"
+		log, _ := loggertest.New("oci")
+		downloader, err := NewDownloader(log, config, details.NewDetails(version.String(), details.StateRequested, ""))
+		require.NoError(t, err)
+		_, err = downloader.Download(context.Background(), a, version)
+		assert.ErrorContains(t, err, "failed to authenticate")
+	})
+}
+
+func TestParseReference(t *testing.T) {
+	a := artifact.Artifact{Cmd: "elastic-agent"}
+	version := agtversion.NewParsedSemVer(9, 1, 0, "SNAPSHOT", "")
+	digest := "sha256:" + strings.Repeat("a", 64)
+
+	tests := map[string]struct {
+		sourceURI string
+		expected  reference
+		errMsg    string
+	}{
+		"prefix": {
+			sourceURI: "oci://registry.example.com:5000/elastic/",
+			expected:  reference{registry: "registry.example.com:5000", repository: "elastic/elastic-agent", tag: "9.1.0-SNAPSHOT"},
+		},
+		"tag": {
+			sourceURI: "oci://registry.example.com/elastic/agent:9.1.0",
+			expected:  reference{registry: "registry.example.com", repository: "elastic/agent", tag: "9.1.0"},
+		},
+		"digest": {
+			sourceURI: "oci://registry.example.com/elastic/agent@" + digest,
+			expected:  reference{registry: "registry.example.com", repository: "elastic/agent", digest: digest},
+		},
+		"no repository": {
+			sourceURI: "oci://registry.example.com",
+			errMsg:    "no registry or repository",
+		},
+		"invalid digest": {
+			sourceURI: "oci://registry.example.com/elastic/agent@md5:abc",
+			errMsg:    "invalid digest",
+		},
+		"invalid repository": {
+			sourceURI: "oci://registry.example.com/Elastic",
+			errMsg:    "invalid repository",
+		},
+		"not oci": {
+			sourceURI: "https://artifacts.elastic.co/downloads/",
+			errMsg:    "not an OCI source URI",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			ref, err := parseReference(tc.sourceURI, a, *version)
+			if tc.errMsg != "" {
+				assert.ErrorContains(t, err, tc.errMsg)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tc.expected, ref)
+		})
+	}
+}
+
+func TestParseChallenge(t *testing.T) {
+	scheme, params := parseChallenge(`Bearer realm="https://auth.example.com/token",service="registry.example.com",scope="repository:elastic/agent:pull"`)
+	assert.Equal(t, "Bearer", scheme)
+	assert.Equal(t, map[string]string{
+		"realm":   "https://auth.example.com/token",
+		"service": "registry.example.com",
+		"scope":   "repository:elastic/agent:pull",
+	}, params)
+
+	scheme, params = parseChallenge(`Basic realm=registry`)
+	assert.Equal(t, "Basic", scheme)
+	assert.Equal(t, map[string]string{"realm": "registry"}, params)
+}
+
+func TestDownload_VaultCredentials(t *testing.T) {
+	const filename = "elastic-agent-9.1.0-linux-x86_64.tar.gz"
+	ctx := context.Background()
+	registry := newTestRegistry(t)
+	registry.push("mirror/elastic-agent", "9.1.0", map[string][]byte{filename: []byte("elastic agent package")})
+	ref := reference{registry: registry.host(), repository: "mirror/elastic-agent", tag: "9.1.0"}
+
+	vaultOpts := []vault.OptionFunc{vault.WithVaultPath(filepath.Join(t.TempDir(), "vault")), vault.WithUnprivileged(true)}
+	storeCredentials := func(t *testing.T, key string, value []byte) {
+		require.NoError(t, secret.Set(ctx, key, secret.Secret{Value: value}, vaultOpts...))
+	}
+	newClient := func(auth artifact.OCIConfig) *registryClient {
+		c := newRegistryClient(registry.server.Client(), auth)
+		c.vaultOpts = vaultOpts
+		return c
+	}
+
+	t.Run("credentials of the vault", func(t *testing.T) {
+		creds, err := json.Marshal(artifact.OCICredentials{Username: testUsername, Password: testPassword})
+		require.NoError(t, err)
+		storeCredentials(t, "registry", creds)
+
+		// the configured credentials are ignored
+		c := newClient(artifact.OCIConfig{Username: "ignored", Password: "wrong", VaultKey: "registry"})
+		resp, err := c.get(ctx, ref, "manifests/9.1.0", mediaTypeOCIManifest)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+	})
+
+	t.Run("wrong credentials of the vault", func(t *testing.T) {
+		creds, err := json.Marshal(artifact.OCICredentials{Username: testUsername, Password: "wrong"})
+		require.NoError(t, err)
+		storeCredentials(t, "wrong", creds)
+
+		c := newClient(artifact.OCIConfig{Username: testUsername, Password: testPassword, VaultKey: "wrong"})
+		_, err = c.get(ctx, ref, "manifests/9.1.0", mediaTypeOCIManifest)
+		assert.ErrorContains(t, err, "failed to authenticate")
+	})
+
+	t.Run("missing key", func(t *testing.T) {
+		c := newClient(artifact.OCIConfig{VaultKey: "missing"})
+		_, err := c.get(ctx, ref, "manifests/9.1.0", mediaTypeOCIManifest)
+		assert.ErrorContains(t, err, `failed to read the registry credentials "missing" from the vault`)
+	})
+
+	t.Run("invalid credentials", func(t *testing.T) {
+		storeCredentials(t, "invalid", []byte("not json"))
+
+		c := newClient(artifact.OCIConfig{VaultKey: "invalid"})
+		_, err := c.get(ctx, ref, "manifests/9.1.0", mediaTypeOCIManifest)
+		assert.ErrorContains(t, err, `invalid registry credentials "invalid"`)
+	})
+}
@@ -0,0 +1,82 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package oci
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	agtversion "github.com/elastic/elastic-agent/pkg/version"
+)
+
+var (
+	repositoryRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*(?:/[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*)*$`)
+	tagRegexp        = regexp.MustCompile(`^[A-Za-z0-9_][A-Za-z0-9_.-]{0,127}$`)
+	digestRegexp     = regexp.MustCompile(`^(sha256:[a-f0-9]{64}|sha512:[a-f0-9]{128})$`)
+)
+
+// reference is the manifest of an OCI artifact in a registry, by tag or by digest.
+type reference struct {
+	registry   string
+	repository string
+	tag        string
+	digest     string
+}
+
+// parseReference returns the reference of the artifact of version stored under sourceURI.
+// The source URI is one of:
+//   - oci://<registry>/<prefix>, the artifact is the <version> tag of <prefix>/<artifact command>
+//   - oci://<registry>/<repository>:<tag>, the artifact is the tag of the repository
+//   - oci://<registry>/<repository>@<digest>, the artifact is the manifest of the digest
+func parseReference(sourceURI string, a artifact.Artifact, version agtversion.ParsedSemVer) (reference, error) {
+	if !artifact.IsOCISourceURI(sourceURI) {
+		return reference{}, fmt.Errorf("source URI %q is not an OCI source URI", sourceURI)
+	}
+	registry, repository, found := strings.Cut(strings.TrimPrefix(sourceURI, artifact.OCISourceURIScheme), "/")
+	repository = strings.TrimSuffix(repository, "/")
+	if !found || registry == "" || repository == "" {
+		return reference{}, fmt.Errorf("source URI %q has no registry or repository", sourceURI)
+	}
+
+	ref := reference{registry: registry}
+	lastSlash := strings.LastIndex(repository, "/")
+	switch {
+	case strings.Contains(repository, "@"):
+		ref.repository, ref.digest, _ = strings.Cut(repository, "@")
+		if !digestRegexp.MatchString(ref.digest) {
+			return reference{}, fmt.Errorf("source URI %q has an invalid digest %q", sourceURI, ref.digest)
+		}
+	case strings.Contains(repository[lastSlash+1:], ":"):
+		ref.repository, ref.tag, _ = strings.Cut(repository, ":")
+	default:
+		ref.repository = repository + "/" + a.Cmd
+		ref.tag = version.VersionWithPrerelease()
+	}
+
+	if !repositoryRegexp.MatchString(ref.repository) {
+		return reference{}, fmt.Errorf("source URI %q has an invalid repository %q", sourceURI, ref.repository)
+	}
+	if ref.tag != "" && !tagRegexp.MatchString(ref.tag) {
+		return reference{}, fmt.Errorf("source URI %q has an invalid tag %q", sourceURI, ref.tag)
+	}
+	return ref, nil
+}
+
+// manifestRef returns the tag or digest the manifest is pulled by.
+func (r reference) manifestRef() string {
+	if r.digest != "" {
+		return r.digest
+	}
+	return r.tag
+}
+
+func (r reference) String() string {
+	if r.digest != "" {
+		return r.registry + "/" + r.repository + "@" + r.digest
+	}
+	return r.registry + "/" + r.repository + ":" + r.tag
+}
@@ -0,0 +1,255 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package oci
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+// maxTokenSize is the maximum size of the responses of the token services.
+const maxTokenSize = 1 << 20
+
+// ErrNotFound is returned when the manifest or blob is not in the registry.
+var ErrNotFound = errors.New("not found in the OCI registry")
+
+// registryClient requests the distribution API of OCI registries, authenticating with
+// the bearer token flow of the registry token services or with basic authentication.
+type registryClient struct {
+	client *http.Client
+	auth   artifact.OCIConfig
+	// vaultOpts open the vault the credentials of auth.VaultKey are read from
+	vaultOpts []vault.OptionFunc
+
+	mu sync.Mutex
+	// tokens are the bearer tokens obtained from the token services, by scope
+	tokens map[string]string
+	// credentials are the credentials read from the vault
+	credentials *artifact.OCIConfig
+}
+
+func newRegistryClient(client *http.Client, auth artifact.OCIConfig) *registryClient {
+	isRoot, _ := utils.HasRoot()
+	return &registryClient{
+		client:    client,
+		auth:      auth,
+		vaultOpts: []vault.OptionFunc{vault.WithUnprivileged(!isRoot)},
+		tokens:    make(map[string]string),
+	}
+}
+
+// resolveCredentials returns the configured credentials, or the credentials stored in the
+// vault when they are referenced by a vault key. The credentials of the vault are read once,
+// the client is recreated when the configuration is reloaded.
+func (c *registryClient) resolveCredentials(ctx context.Context) (artifact.OCIConfig, error) {
+	if c.auth.VaultKey == "" {
+		return c.auth, nil
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.credentials != nil {
+		return *c.credentials, nil
+	}
+	s, err := secret.Get(ctx, c.auth.VaultKey, c.vaultOpts...)
+	if err != nil {
+		return artifact.OCIConfig{}, fmt.Errorf("failed to read the registry credentials %q from the vault: %w", c.auth.VaultKey, err)
+	}
+	var creds artifact.OCICredentials
+	if err := json.Unmarshal(s.Value, &creds); err != nil {
+		return artifact.OCIConfig{}, fmt.Errorf("invalid registry credentials %q in the vault: %w", c.auth.VaultKey, err)
+	}
+	// unlike the configured ones, the credentials of the vault are not redacted from the
+	// diagnostics by their key
+	diagnostics.AddSecretValue(creds.Password)
+	diagnostics.AddSecretValue(creds.Token)
+	c.credentials = &artifact.OCIConfig{Username: creds.Username, Password: creds.Password, Token: creds.Token}
+	return *c.credentials, nil
+}
+
+// get requests /v2/<repository>/<resource> of the registry of ref. The caller must close
+// the body of the response.
+func (c *registryClient) get(ctx context.Context, ref reference, resource string, accept ...string) (*http.Response, error) {
+	uri := (&url.URL{Scheme: "https", Host: ref.registry, Path: "/v2/" + ref.repository + "/" + resource}).String()
+	scope := "repository:" + ref.repository + ":pull"
+	auth, err := c.resolveCredentials(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.do(ctx, auth, uri, scope, accept)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode == http.StatusUnauthorized {
+		challenge := resp.Header.Get("WWW-Authenticate")
+		_ = resp.Body.Close()
+		if err := c.authenticate(ctx, auth, challenge, scope); err != nil {
+			return nil, fmt.Errorf("failed to authenticate to %s: %w", ref.registry, err)
+		}
+		if resp, err = c.do(ctx, auth, uri, scope, accept); err != nil {
+			return nil, err
+		}
+	}
+
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("%s of %s: %w", resource, ref, ErrNotFound)
+	case resp.StatusCode < 200 || resp.StatusCode > 299:
+		_ = resp.Body.Close()
+		return nil, fmt.Errorf("call to %q returned unsuccessful status code: %d", uri, resp.StatusCode)
+	}
+	return resp, nil
+}
+
+func (c *registryClient) do(ctx context.Context, auth artifact.OCIConfig, uri, scope string, accept []string) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	if len(accept) > 0 {
+		req.Header.Set("Accept", strings.Join(accept, ", "))
+	}
+	c.setAuthorization(req, auth, scope)
+	return c.client.Do(req)
+}
+
+func (c *registryClient) setAuthorization(req *http.Request, auth artifact.OCIConfig, scope string) {
+	if auth.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+auth.Token)
+		return
+	}
+
+	c.mu.Lock()
+	token, ok := c.tokens[scope]
+	c.mu.Unlock()
+	switch {
+	case ok && token != "":
+		req.Header.Set("Authorization", "Bearer "+token)
+	case ok:
+		// the registry requested basic authentication
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+}
+
+// authenticate obtains the credentials requested by the challenge of the registry for
+// scope.
+func (c *registryClient) authenticate(ctx context.Context, auth artifact.OCIConfig, challenge, scope string) error {
+	scheme, params := parseChallenge(challenge)
+	switch {
+	case auth.Token != "":
+		return errors.New("the registry rejected the token")
+	case strings.EqualFold(scheme, "basic"):
+		if auth.Username == "" {
+			return errors.New("the registry requires a username and password")
+		}
+		c.setToken(scope, "")
+		return nil
+	case strings.EqualFold(scheme, "bearer"):
+		token, err := c.fetchToken(ctx, auth, params, scope)
+		if err != nil {
+			return err
+		}
+		c.setToken(scope, token)
+		return nil
+	default:
+		return fmt.Errorf("unsupported authentication challenge %q", challenge)
+	}
+}
+
+func (c *registryClient) setToken(scope, token string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.tokens[scope] = token
+}
+
+// fetchToken requests a bearer token for scope from the token service of the challenge.
+func (c *registryClient) fetchToken(ctx context.Context, auth artifact.OCIConfig, params map[string]string, scope string) (string, error) {
+	realm, err := url.Parse(params["realm"])
+	if err != nil || realm.Host == "" {
+		return "", fmt.Errorf("invalid token service realm %q", params["realm"])
+	}
+	query := realm.Query()
+	if service := params["service"]; service != "" {
+		query.Set("service", service)
+	}
+	if challengeScope := params["scope"]; challengeScope != "" {
+		scope = challengeScope
+	}
+	query.Set("scope", scope)
+	realm.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, realm.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if auth.Username != "" {
+		req.SetBasicAuth(auth.Username, auth.Password)
+	}
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("failed requesting token from %s: %w", realm.Host, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("token service %s returned unsuccessful status code: %d", realm.Host, resp.StatusCode)
+	}
+
+	var tokenResp struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxTokenSize)).Decode(&tokenResp); err != nil {
+		return "", fmt.Errorf("invalid response of token service %s: %w", realm.Host, err)
+	}
+	if tokenResp.Token != "" {
+		return tokenResp.Token, nil
+	}
+	if tokenResp.AccessToken != "" {
+		return tokenResp.AccessToken, nil
+	}
+	return "", fmt.Errorf("token service %s returned no token", realm.Host)
+}
+
+// parseChallenge parses a WWW-Authenticate challenge, e.g.
+// Bearer realm="https://auth.example.com/token",service="registry.example.com"
+func parseChallenge(challenge string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(challenge), " ")
+	params := make(map[string]string)
+	for rest = strings.TrimSpace(rest); rest != ""; rest = strings.TrimSpace(rest) {
+		key, value, found := strings.Cut(rest, "=")
+		if !found {
+			break
+		}
+		key = strings.ToLower(strings.TrimSpace(key))
+		value = strings.TrimSpace(value)
+		if strings.HasPrefix(value, `"`) {
+			end := strings.Index(value[1:], `"`)
+			if end < 0 {
+				break
+			}
+			params[key] = value[1 : end+1]
+			rest = strings.TrimPrefix(strings.TrimSpace(value[end+2:]), ",")
+			continue
+		}
+		value, rest, _ = strings.Cut(value, ",")
+		params[key] = strings.TrimSpace(value)
+	}
+	return scheme, params
+}
@@ -0,0 +1,60 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package peer
+
+import (
+	"context"
+	"net"
+	"strconv"
+	"strings"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// srvResolver resolves DNS SRV records, it is replaced in tests.
+type srvResolver interface {
+	LookupSRV(ctx context.Context, service, proto, name string) (string, []*net.SRV, error)
+}
+
+var resolver srvResolver = net.DefaultResolver
+
+// Peers returns the URLs of the agents to download artifacts from: the configured ones
+// followed by the ones discovered through DNS, in the order of the SRV records. A failed
+// discovery is logged and only the configured peers are returned.
+func Peers(ctx context.Context, log *logger.Logger, config artifact.PeerCacheConfig) []string {
+	peers := make([]string, 0, len(config.Peers))
+	seen := make(map[string]bool)
+	add := func(peer string) {
+		peer = strings.TrimSuffix(strings.TrimSpace(peer), "/")
+		if peer == "" || seen[peer] {
+			return
+		}
+		seen[peer] = true
+		peers = append(peers, peer)
+	}
+
+	for _, peer := range config.Peers {
+		add(peer)
+	}
+
+	if config.Discovery == "" {
+		return peers
+	}
+	_, records, err := resolver.LookupSRV(ctx, "", "", config.Discovery)
+	if err != nil {
+		log.Warnf("Failed to discover the peers artifacts are downloaded from through %s: %v", config.Discovery, err)
+		return peers
+	}
+	scheme := config.DiscoveryScheme
+	if scheme == "" {
+		scheme = "http"
+	}
+	for _, record := range records {
+		host := strings.TrimSuffix(record.Target, ".")
+		add(scheme + "://" + net.JoinHostPort(host, strconv.Itoa(int(record.Port))))
+	}
+	return peers
+}
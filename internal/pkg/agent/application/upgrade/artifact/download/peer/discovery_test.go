@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package peer
+
+import (
+	"context"
+	"errors"
+	"net"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+type fakeResolver struct {
+	records []*net.SRV
+	err     error
+}
+
+func (r fakeResolver) LookupSRV(_ context.Context, _, _, _ string) (string, []*net.SRV, error) {
+	return "", r.records, r.err
+}
+
+func TestPeers(t *testing.T) {
+	defaultResolver := resolver
+	t.Cleanup(func() { resolver = defaultResolver })
+
+	testCases := map[string]struct {
+		config   artifact.PeerCacheConfig
+		resolver srvResolver
+		expected []string
+	}{
+		"no peers": {
+			expected: []string{},
+		},
+		"configured peers": {
+			config:   artifact.PeerCacheConfig{Peers: []string{"http://10.0.0.1:6792/", " http://10.0.0.2:6792", "http://10.0.0.1:6792"}},
+			expected: []string{"http://10.0.0.1:6792", "http://10.0.0.2:6792"},
+		},
+		"discovered peers": {
+			config: artifact.PeerCacheConfig{
+				Peers:           []string{"https://agent-1.example.com:6792"},
+				Discovery:       "_elastic-agent-cache._tcp.example.com",
+				DiscoveryScheme: "https",
+			},
+			resolver: fakeResolver{records: []*net.SRV{
+				{Target: "agent-2.example.com.", Port: 6792},
+				{Target: "agent-1.example.com.", Port: 6792},
+			}},
+			expected: []string{"https://agent-1.example.com:6792", "https://agent-2.example.com:6792"},
+		},
+		"failed discovery": {
+			config: artifact.PeerCacheConfig{
+				Peers:     []string{"http://10.0.0.1:6792"},
+				Discovery: "_elastic-agent-cache._tcp.example.com",
+			},
+			resolver: fakeResolver{err: errors.New("no such host")},
+			expected: []string{"http://10.0.0.1:6792"},
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			resolver = tc.resolver
+			log, _ := loggertest.New(t.Name())
+			assert.Equal(t, tc.expected, Peers(context.Background(), log, tc.config))
+		})
+	}
+}
@@ -0,0 +1,182 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+// Package peer shares the downloaded artifacts between the agents of a network.
+package peer
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const (
+	sha512Suffix = ".sha512"
+	ascSuffix    = ".asc"
+
+	shutdownTimeout = 5 * time.Second
+)
+
+// Server serves the artifacts of the downloads directory to the other agents. Only the
+// artifacts matching their SHA-512 hash file are served, together with their hash and
+// signature files. The agents downloading them verify them locally before use.
+type Server struct {
+	log    *logger.Logger
+	dir    string
+	config artifact.PeerCacheServerConfig
+
+	server   *http.Server
+	listener net.Listener
+
+	mu       sync.Mutex
+	verified map[string]verifiedArtifact
+}
+
+// verifiedArtifact identifies the version of an artifact whose hash was verified.
+type verifiedArtifact struct {
+	size    int64
+	modTime time.Time
+}
+
+// NewServer creates the server of the artifacts downloaded to the target directory of
+// the config.
+func NewServer(log *logger.Logger, config *artifact.Config) *Server {
+	return &Server{
+		log:      log,
+		dir:      config.TargetDirectory,
+		config:   config.PeerCache.Server,
+		verified: make(map[string]verifiedArtifact),
+	}
+}
+
+// Start starts serving the artifacts.
+func (s *Server) Start() error {
+	listener, err := net.Listen("tcp", net.JoinHostPort(s.config.Host, strconv.Itoa(s.config.Port)))
+	if err != nil {
+		return fmt.Errorf("failed to listen on %s:%d: %w", s.config.Host, s.config.Port, err)
+	}
+
+	scheme := "http"
+	if s.config.TLS.IsEnabled() {
+		tlsConfig, err := tlscommon.LoadTLSServerConfig(s.config.TLS)
+		if err != nil {
+			_ = listener.Close()
+			return fmt.Errorf("invalid TLS configuration: %w", err)
+		}
+		listener = tls.NewListener(listener, tlsConfig.BuildServerConfig(s.config.Host))
+		scheme = "https"
+	} else if !isLoopback(listener.Addr()) {
+		s.log.Warnf("Serving the downloaded artifacts to the network over plain HTTP on %s, configure ssl to serve them over HTTPS", listener.Addr())
+	}
+
+	s.listener = listener
+	s.server = &http.Server{
+		Handler:           s,
+		ReadHeaderTimeout: 30 * time.Second,
+	}
+	go func() {
+		if err := s.server.Serve(listener); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			s.log.Errorf("peer cache server failed: %v", err)
+		}
+	}()
+	s.log.Infof("Serving the downloaded artifacts of %s to peers on %s://%s", s.dir, scheme, listener.Addr())
+	return nil
+}
+
+// isLoopback returns true when addr only accepts connections from the local host.
+func isLoopback(addr net.Addr) bool {
+	tcpAddr, ok := addr.(*net.TCPAddr)
+	return ok && tcpAddr.IP.IsLoopback()
+}
+
+// Addr returns the address the server listens on.
+func (s *Server) Addr() net.Addr {
+	return s.listener.Addr()
+}
+
+// Stop stops serving the artifacts.
+func (s *Server) Stop() error {
+	if s.server == nil {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout)
+	defer cancel()
+	return s.server.Shutdown(ctx)
+}
+
+// ServeHTTP serves the artifact named like the last element of the path of the request,
+// whatever its directory, so the URI peers download from is the same as for the source
+// URI, e.g. /beats/elastic-agent/elastic-agent-9.0.0-linux-x86_64.tar.gz.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodGet && r.Method != http.MethodHead {
+		http.Error(w, http.StatusText(http.StatusMethodNotAllowed), http.StatusMethodNotAllowed)
+		return
+	}
+
+	name := path.Base(r.URL.Path)
+	if name == "/" || name == "." || strings.HasPrefix(name, ".") {
+		http.NotFound(w, r)
+		return
+	}
+	filePath := filepath.Join(s.dir, name)
+	artifactPath := strings.TrimSuffix(strings.TrimSuffix(filePath, sha512Suffix), ascSuffix)
+	if !s.isVerified(artifactPath) {
+		http.NotFound(w, r)
+		return
+	}
+
+	f, err := os.Open(filePath)
+	if err != nil {
+		http.NotFound(w, r)
+		return
+	}
+	defer f.Close()
+	fi, err := f.Stat()
+	if err != nil || !fi.Mode().IsRegular() {
+		http.NotFound(w, r)
+		return
+	}
+
+	s.log.Debugf("Serving %s to %s", name, r.RemoteAddr)
+	http.ServeContent(w, r, name, fi.ModTime(), f)
+}
+
+// isVerified returns true when the artifact matches its SHA-512 hash file. The result
+// is cached until the artifact changes.
+func (s *Server) isVerified(artifactPath string) bool {
+	fi, err := os.Stat(artifactPath)
+	if err != nil || !fi.Mode().IsRegular() {
+		return false
+	}
+	current := verifiedArtifact{size: fi.Size(), modTime: fi.ModTime()}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if v, ok := s.verified[artifactPath]; ok && v.size == current.size && v.modTime.Equal(current.modTime) {
+		return true
+	}
+	if err := download.VerifySHA512Hash(artifactPath); err != nil {
+		s.log.Debugf("Not serving %s: %v", artifactPath, err)
+		delete(s.verified, artifactPath)
+		return false
+	}
+	s.verified[artifactPath] = current
+	return true
+}
@@ -0,0 +1,171 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package peer
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+const artifactName = "elastic-agent-9.0.0-linux-x86_64.tar.gz"
+
+// writeArtifact writes the artifact, its hash file, with a wrong hash when corrupted,
+// and its signature file to dir.
+func writeArtifact(t *testing.T, dir string, content []byte, corrupted bool) {
+	t.Helper()
+	hash := sha512.Sum512(content)
+	if corrupted {
+		hash = sha512.Sum512([]byte("something else"))
+	}
+	require.NoError(t, os.WriteFile(filepath.Join(dir, artifactName), content, 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, artifactName+sha512Suffix),
+		[]byte(fmt.Sprintf("%s  %s\n", hex.EncodeToString(hash[:]), artifactName)), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(dir, artifactName+ascSuffix), []byte("signature"), 0o600))
+}
+
+func TestServer(t *testing.T) {
+	content := []byte("artifact content")
+
+	testCases := map[string]struct {
+		corrupted      bool
+		method         string
+		path           string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"artifact": {
+			path:           "/beats/elastic-agent/" + artifactName,
+			expectedStatus: http.StatusOK,
+			expectedBody:   string(content),
+		},
+		"signature": {
+			path:           "/beats/elastic-agent/" + artifactName + ascSuffix,
+			expectedStatus: http.StatusOK,
+			expectedBody:   "signature",
+		},
+		"head": {
+			method:         http.MethodHead,
+			path:           "/" + artifactName,
+			expectedStatus: http.StatusOK,
+		},
+		"corrupted artifact": {
+			corrupted:      true,
+			path:           "/beats/elastic-agent/" + artifactName,
+			expectedStatus: http.StatusNotFound,
+		},
+		"hash of corrupted artifact": {
+			corrupted:      true,
+			path:           "/beats/elastic-agent/" + artifactName + sha512Suffix,
+			expectedStatus: http.StatusNotFound,
+		},
+		"unknown artifact": {
+			path:           "/beats/elastic-agent/elastic-agent-8.0.0-linux-x86_64.tar.gz",
+			expectedStatus: http.StatusNotFound,
+		},
+		"outside of the directory": {
+			path:           "/../" + artifactName,
+			expectedStatus: http.StatusOK,
+			expectedBody:   string(content),
+		},
+		"root": {
+			path:           "/",
+			expectedStatus: http.StatusNotFound,
+		},
+		"post": {
+			method:         http.MethodPost,
+			path:           "/" + artifactName,
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			writeArtifact(t, dir, content, tc.corrupted)
+
+			log, _ := loggertest.New(t.Name())
+			server := NewServer(log, &artifact.Config{TargetDirectory: dir})
+
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			rec := httptest.NewRecorder()
+			server.ServeHTTP(rec, httptest.NewRequest(method, tc.path, nil))
+
+			assert.Equal(t, tc.expectedStatus, rec.Code)
+			if tc.expectedBody != "" {
+				assert.Equal(t, tc.expectedBody, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestServerStartStop(t *testing.T) {
+	dir := t.TempDir()
+	writeArtifact(t, dir, []byte("artifact content"), false)
+
+	log, _ := loggertest.New(t.Name())
+	server := NewServer(log, &artifact.Config{
+		TargetDirectory: dir,
+		PeerCache: artifact.PeerCacheConfig{
+			Server: artifact.PeerCacheServerConfig{Enabled: true, Host: "127.0.0.1", Port: 0},
+		},
+	})
+	require.NoError(t, server.Start())
+
+	resp, err := http.Get(fmt.Sprintf("http://%s/%s", server.Addr(), artifactName)) //nolint:noctx // this is fine in tests
+	require.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "artifact content", string(body))
+	assert.Equal(t, "bytes", resp.Header.Get("Accept-Ranges"))
+
+	require.NoError(t, server.Stop())
+}
+
+func TestServerDefaultHost(t *testing.T) {
+	testcases := []struct {
+		name         string
+		host         string
+		expectedWarn bool
+	}{
+		{name: "default host", host: artifact.DefaultPeerCacheConfig().Server.Host, expectedWarn: false},
+		{name: "all addresses", host: "0.0.0.0", expectedWarn: true},
+	}
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			log, obs := loggertest.New(t.Name())
+			server := NewServer(log, &artifact.Config{
+				TargetDirectory: t.TempDir(),
+				PeerCache: artifact.PeerCacheConfig{
+					Server: artifact.PeerCacheServerConfig{Enabled: true, Host: tc.host, Port: 0},
+				},
+			})
+			require.NoError(t, server.Start())
+			t.Cleanup(func() { require.NoError(t, server.Stop()) })
+
+			if !tc.expectedWarn {
+				assert.True(t, isLoopback(server.Addr()), "the server must only listen on the local host by default, listens on %s", server.Addr())
+			}
+			assert.Equal(t, tc.expectedWarn, obs.FilterMessageSnippet("plain HTTP").Len() > 0)
+		})
+	}
+}
@@ -2,7 +2,7 @@
 // or more contributor license agreements. Licensed under the Elastic License 2.0;
 // you may not use this file except in compliance with the Elastic License 2.0.
 
-package http
+package download
 
 import (
 	"sync"
@@ -14,7 +14,8 @@ import (
 	"github.com/elastic/elastic-agent/pkg/core/logger"
 )
 
-type progressObserver interface {
+// ProgressObserver is notified of the progress of a download.
+type ProgressObserver interface {
 	// Report is called on a periodic basis with information about the download's progress so far.
 	Report(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRate float64)
 
@@ -25,19 +26,22 @@ type progressObserver interface {
 	ReportFailed(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRate float64, err error)
 }
 
-type loggingProgressObserver struct {
+// LoggingProgressObserver logs the progress of a download, as warnings once most of its timeout is past.
+type LoggingProgressObserver struct {
 	log         *logger.Logger
 	warnTimeout time.Duration
 }
 
-func newLoggingProgressObserver(log *logger.Logger, downloadTimeout time.Duration) *loggingProgressObserver {
-	return &loggingProgressObserver{
+// NewLoggingProgressObserver creates the observer logging the progress of a download that must complete
+// within downloadTimeout.
+func NewLoggingProgressObserver(log *logger.Logger, downloadTimeout time.Duration) *LoggingProgressObserver {
+	return &LoggingProgressObserver{
 		log:         log,
 		warnTimeout: time.Duration(float64(downloadTimeout) * warningProgressIntervalPercentage),
 	}
 }
 
-func (lpObs *loggingProgressObserver) Report(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRate float64) {
+func (lpObs *LoggingProgressObserver) Report(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRate float64) {
 	var msg string
 	var args []interface{}
 	if totalBytes > 0 {
@@ -63,7 +67,7 @@ func (lpObs *loggingProgressObserver) Report(sourceURI string, timePast time.Dur
 	}
 }
 
-func (lpObs *loggingProgressObserver) ReportCompleted(sourceURI string, timePast time.Duration, downloadRate float64) {
+func (lpObs *LoggingProgressObserver) ReportCompleted(sourceURI string, timePast time.Duration, downloadRate float64) {
 	msg := "download from %s completed in %s @ %sps"
 	args := []interface{}{
 		sourceURI, units.HumanDuration(timePast), units.HumanSize(downloadRate),
@@ -75,7 +79,7 @@ func (lpObs *loggingProgressObserver) ReportCompleted(sourceURI string, timePast
 	}
 }
 
-func (lpObs *loggingProgressObserver) ReportFailed(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRate float64, err error) {
+func (lpObs *LoggingProgressObserver) ReportFailed(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRate float64, err error) {
 	var msg string
 	var args []interface{}
 	if totalBytes > 0 {
@@ -98,33 +102,36 @@ func (lpObs *loggingProgressObserver) ReportFailed(sourceURI string, timePast ti
 	}
 }
 
-type detailsProgressObserver struct {
+// DetailsProgressObserver records the progress of a download in the upgrade details.
+type DetailsProgressObserver struct {
 	upgradeDetails *details.Details
 	mu             sync.RWMutex
 }
 
-func newDetailsProgressObserver(upgradeDetails *details.Details) *detailsProgressObserver {
+// NewDetailsProgressObserver creates the observer recording the progress of a download in upgradeDetails,
+// and sets their state to downloading.
+func NewDetailsProgressObserver(upgradeDetails *details.Details) *DetailsProgressObserver {
 	upgradeDetails.SetState(details.StateDownloading)
-	return &detailsProgressObserver{
+	return &DetailsProgressObserver{
 		upgradeDetails: upgradeDetails,
 	}
 }
 
-func (dpObs *detailsProgressObserver) Report(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRateBytesPerSecond float64) {
+func (dpObs *DetailsProgressObserver) Report(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRateBytesPerSecond float64) {
 	dpObs.mu.Lock()
 	defer dpObs.mu.Unlock()
 
 	dpObs.upgradeDetails.SetDownloadProgress(percentComplete, downloadRateBytesPerSecond)
 }
 
-func (dpObs *detailsProgressObserver) ReportCompleted(sourceURI string, timePast time.Duration, downloadRateBytesPerSecond float64) {
+func (dpObs *DetailsProgressObserver) ReportCompleted(sourceURI string, timePast time.Duration, downloadRateBytesPerSecond float64) {
 	dpObs.mu.Lock()
 	defer dpObs.mu.Unlock()
 
 	dpObs.upgradeDetails.SetDownloadProgress(1, downloadRateBytesPerSecond)
 }
 
-func (dpObs *detailsProgressObserver) ReportFailed(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRateBytesPerSecond float64, err error) {
+func (dpObs *DetailsProgressObserver) ReportFailed(sourceURI string, timePast time.Duration, downloadedBytes, totalBytes, percentComplete, downloadRateBytesPerSecond float64, err error) {
 	dpObs.mu.Lock()
 	defer dpObs.mu.Unlock()
 
@@ -2,7 +2,7 @@
 // or more contributor license agreements. Licensed under the Elastic License 2.0;
 // you may not use this file except in compliance with the Elastic License 2.0.
 
-package http
+package download
 
 import (
 	"testing"
@@ -18,7 +18,7 @@ import (
 
 func TestDetailsProgressObserver(t *testing.T) {
 	upgradeDetails := details.NewDetails("8.11.0", details.StateRequested, "")
-	detailsObs := newDetailsProgressObserver(upgradeDetails)
+	detailsObs := NewDetailsProgressObserver(upgradeDetails)
 
 	detailsObs.Report("http://some/uri", 20*time.Second, 400*units.MiB, 500*units.MiB, 0.8, 4455)
 	require.Equal(t, details.StateDownloading, upgradeDetails.State)
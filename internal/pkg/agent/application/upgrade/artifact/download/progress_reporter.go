@@ -2,7 +2,7 @@
 // or more contributor license agreements. Licensed under the Elastic License 2.0;
 // you may not use this file except in compliance with the Elastic License 2.0.
 
-package http
+package download
 
 import (
 	"context"
@@ -10,26 +10,46 @@ import (
 	"time"
 )
 
-type downloadProgressReporter struct {
+const (
+	// downloadProgressIntervalPercentage defines how often to report the current download progress when percentage
+	// of time has passed in the overall interval for the complete download to complete. 5% is a good default, as
+	// the default timeout is 10 minutes and this will have it log every 30 seconds.
+	downloadProgressIntervalPercentage = 0.05
+
+	// downloadProgressDefaultInterval defines the default interval at which the current download progress will be reported.
+	// This value is used if the timeout is not specified (and therefore equal to 0).
+	downloadProgressMinInterval = 10 * time.Second
+
+	// warningProgressIntervalPercentage defines how often to log messages as a warning once the amount of time
+	// passed is this percentage or more of the total allotted time to download.
+	warningProgressIntervalPercentage = 0.75
+)
+
+// DownloadProgressReporter reports the progress of a download, written to it, to its observers.
+type DownloadProgressReporter struct {
 	sourceURI   string
 	interval    time.Duration
 	warnTimeout time.Duration
 	length      float64
+	// bytes downloaded by a previous attempt when the download is resumed
+	offset float64
 
 	downloaded atomic.Int64
 	started    time.Time
 
-	progressObservers []progressObserver
+	progressObservers []ProgressObserver
 	done              chan struct{}
 }
 
-func newDownloadProgressReporter(sourceURI string, timeout time.Duration, length int, progressObservers ...progressObserver) *downloadProgressReporter {
+// NewDownloadProgressReporter creates the progress reporter of a download of length bytes, that must
+// complete within timeout, from sourceURI.
+func NewDownloadProgressReporter(sourceURI string, timeout time.Duration, length int, progressObservers ...ProgressObserver) *DownloadProgressReporter {
 	interval := time.Duration(float64(timeout) * downloadProgressIntervalPercentage)
 	if interval == 0 {
 		interval = downloadProgressMinInterval
 	}
 
-	return &downloadProgressReporter{
+	return &DownloadProgressReporter{
 		sourceURI:         sourceURI,
 		interval:          interval,
 		warnTimeout:       time.Duration(float64(timeout) * warningProgressIntervalPercentage),
@@ -39,7 +59,13 @@ func newDownloadProgressReporter(sourceURI string, timeout time.Duration, length
 	}
 }
 
-func (dp *downloadProgressReporter) Write(b []byte) (int, error) {
+// ResumeFrom sets the number of bytes downloaded by a previous attempt, they count in
+// the progress of the download but not in its rate.
+func (dp *DownloadProgressReporter) ResumeFrom(offset int64) {
+	dp.offset = float64(offset)
+}
+
+func (dp *DownloadProgressReporter) Write(b []byte) (int, error) {
 	n := len(b)
 	dp.downloaded.Add(int64(n))
 	return n, nil
@@ -47,12 +73,13 @@ func (dp *downloadProgressReporter) Write(b []byte) (int, error) {
 
 // Report periodically reports download progress to registered observers. Callers MUST either
 // cancel the context provided to this method OR call either ReportComplete or ReportFailed when
-// they no longer need the downloadProgressReporter to avoid resource leaks.
-func (dp *downloadProgressReporter) Report(ctx context.Context) {
+// they no longer need the DownloadProgressReporter to avoid resource leaks.
+func (dp *DownloadProgressReporter) Report(ctx context.Context) {
 	started := time.Now()
 	dp.started = started
 	sourceURI := dp.sourceURI
 	length := dp.length
+	offset := dp.offset
 	interval := dp.interval
 
 	// If there are no observers to report progress to, there is nothing to do!
@@ -72,8 +99,8 @@ func (dp *downloadProgressReporter) Report(ctx context.Context) {
 			case <-t.C:
 				now := time.Now()
 				timePast := now.Sub(started)
-				downloaded := float64(dp.downloaded.Load())
-				bytesPerSecond := downloaded / float64(timePast/time.Second)
+				downloaded := offset + float64(dp.downloaded.Load())
+				bytesPerSecond := (downloaded - offset) / float64(timePast/time.Second)
 				var percentComplete float64
 				if length > 0 {
 					percentComplete = downloaded / length * 100.0
@@ -88,9 +115,9 @@ func (dp *downloadProgressReporter) Report(ctx context.Context) {
 }
 
 // ReportComplete reports the completion of a download to registered observers. Callers MUST call
-// either ReportComplete or ReportFailed when they no longer need the downloadProgressReporter
+// either ReportComplete or ReportFailed when they no longer need the DownloadProgressReporter
 // to avoid resource leaks.
-func (dp *downloadProgressReporter) ReportComplete() {
+func (dp *DownloadProgressReporter) ReportComplete() {
 	defer close(dp.done)
 
 	// If there are no observers to report progress to, there is nothing to do!
@@ -109,9 +136,9 @@ func (dp *downloadProgressReporter) ReportComplete() {
 }
 
 // ReportFailed reports the failure of a download to registered observers. Callers MUST call
-// either ReportFailed or ReportComplete when they no longer need the downloadProgressReporter
+// either ReportFailed or ReportComplete when they no longer need the DownloadProgressReporter
 // to avoid resource leaks.
-func (dp *downloadProgressReporter) ReportFailed(err error) {
+func (dp *DownloadProgressReporter) ReportFailed(err error) {
 	defer close(dp.done)
 
 	// If there are no observers to report progress to, there is nothing to do!
@@ -121,8 +148,8 @@ func (dp *downloadProgressReporter) ReportFailed(err error) {
 
 	now := time.Now()
 	timePast := now.Sub(dp.started)
-	downloaded := float64(dp.downloaded.Load())
-	bytesPerSecond := downloaded / float64(timePast/time.Second)
+	downloaded := dp.offset + float64(dp.downloaded.Load())
+	bytesPerSecond := (downloaded - dp.offset) / float64(timePast/time.Second)
 	var percentComplete float64
 	if dp.length > 0 {
 		percentComplete = downloaded / dp.length * 100.0
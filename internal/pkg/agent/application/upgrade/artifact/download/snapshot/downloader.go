@@ -44,6 +44,7 @@ func NewDownloader(log *logger.Logger, config *artifact.Config, versionOverride
 	if err != nil {
 		return nil, err
 	}
+	client.Transport = download.WithThrottle(client.Transport, config)
 
 	return NewDownloaderWithClient(log, config, versionOverride, client, upgradeDetails)
 }
@@ -0,0 +1,107 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package download
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"time"
+
+	"golang.org/x/time/rate"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+)
+
+// maxThrottleBurst is the maximum number of bytes read at once from a throttled response.
+const maxThrottleBurst = 1 << 20
+
+// ErrOutsideDownloadWindow is returned when an artifact is downloaded outside of the
+// configured download windows, or when the window closes during the download.
+var ErrOutsideDownloadWindow = errors.New("outside of the download windows")
+
+// WithThrottle limits the rate at which the responses are read to the rate limit of the
+// config, shared by all the requests, and fails the requests made outside of its
+// download windows. Responses being read when the window closes fail as well, the
+// download is expected to be resumed once the next window opens.
+func WithThrottle(rtt http.RoundTripper, config *artifact.Config) http.RoundTripper {
+	if rtt == nil {
+		rtt = http.DefaultTransport
+	}
+	if config.RateLimit <= 0 && len(config.Windows) == 0 {
+		return rtt
+	}
+
+	r := &rttWithThrottle{
+		target:  rtt,
+		windows: &artifact.Config{Windows: slices.Clone(config.Windows)},
+		now:     time.Now,
+	}
+	if config.RateLimit > 0 {
+		r.limiter = rate.NewLimiter(rate.Limit(config.RateLimit), int(min(config.RateLimit, maxThrottleBurst)))
+	}
+	return r
+}
+
+type rttWithThrottle struct {
+	target  http.RoundTripper
+	limiter *rate.Limiter
+	windows *artifact.Config
+	now     func() time.Time
+}
+
+func (r *rttWithThrottle) RoundTrip(req *http.Request) (*http.Response, error) {
+	now := r.now()
+	opens, closes := r.windows.DownloadWindowAt(now)
+	if opens.After(now) {
+		return nil, fmt.Errorf("%w: the next one opens at %s", ErrOutsideDownloadWindow, opens.Format(time.RFC3339))
+	}
+
+	resp, err := r.target.RoundTrip(req)
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = &throttledBody{
+		ReadCloser: resp.Body,
+		ctx:        req.Context(),
+		limiter:    r.limiter,
+		closes:     closes,
+		now:        r.now,
+	}
+	return resp, nil
+}
+
+// throttledBody is a response body read at the rate of the limiter until the download
+// window closes.
+type throttledBody struct {
+	io.ReadCloser
+	ctx     context.Context
+	limiter *rate.Limiter
+	closes  time.Time
+	now     func() time.Time
+}
+
+func (b *throttledBody) Read(p []byte) (int, error) {
+	if !b.closes.IsZero() && !b.now().Before(b.closes) {
+		return 0, fmt.Errorf("%w: the download window closed at %s", ErrOutsideDownloadWindow, b.closes.Format(time.RFC3339))
+	}
+	if b.limiter == nil {
+		return b.ReadCloser.Read(p)
+	}
+
+	if len(p) > b.limiter.Burst() {
+		p = p[:b.limiter.Burst()]
+	}
+	n, err := b.ReadCloser.Read(p)
+	if n > 0 {
+		if waitErr := b.limiter.WaitN(b.ctx, n); waitErr != nil {
+			return n, waitErr
+		}
+	}
+	return n, err
+}
@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package download
+
+import (
+	"bytes"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+)
+
+func TestThrottleRateLimit(t *testing.T) {
+	msg := bytes.Repeat([]byte("a"), 3000)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(msg)
+	}))
+	defer server.Close()
+
+	c := server.Client()
+	c.Transport = WithThrottle(c.Transport, &artifact.Config{RateLimit: 1000})
+
+	start := time.Now()
+	resp, err := c.Get(server.URL) //nolint:noctx // this is fine in tests
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	b, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, msg, b)
+	// the first 1000 bytes are read at once, the next 2000 take 2s
+	assert.GreaterOrEqual(t, time.Since(start), 1900*time.Millisecond)
+}
+
+func TestThrottleNoLimit(t *testing.T) {
+	rtt := http.DefaultTransport
+	assert.Same(t, rtt, WithThrottle(rtt, &artifact.Config{}))
+}
+
+func TestThrottleDownloadWindows(t *testing.T) {
+	msg := []byte("OK")
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		_, _ = w.Write(msg)
+	}))
+	defer server.Close()
+
+	now := time.Date(2024, time.May, 1, 12, 0, 0, 0, time.Local)
+	newClient := func(windows ...artifact.DownloadWindow) *http.Client {
+		rtt := WithThrottle(server.Client().Transport, &artifact.Config{Windows: windows})
+		rtt.(*rttWithThrottle).now = func() time.Time { return now }
+		return &http.Client{Transport: rtt}
+	}
+
+	t.Run("inside window", func(t *testing.T) {
+		c := newClient(artifact.DownloadWindow{Start: "11:00", End: "13:00"})
+		resp, err := c.Get(server.URL) //nolint:noctx // this is fine in tests
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		b, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		assert.Equal(t, msg, b)
+	})
+
+	t.Run("outside window", func(t *testing.T) {
+		c := newClient(artifact.DownloadWindow{Start: "22:00", End: "06:00"})
+		_, err := c.Get(server.URL) //nolint:noctx // this is fine in tests
+		assert.ErrorIs(t, err, ErrOutsideDownloadWindow)
+	})
+
+	t.Run("window closes during download", func(t *testing.T) {
+		c := newClient(artifact.DownloadWindow{Start: "11:00", End: "13:00"})
+		resp, err := c.Get(server.URL) //nolint:noctx // this is fine in tests
+		require.NoError(t, err)
+		defer resp.Body.Close()
+		resp.Body.(*throttledBody).now = func() time.Time { return now.Add(2 * time.Hour) }
+		_, err = io.ReadAll(resp.Body)
+		assert.ErrorIs(t, err, ErrOutsideDownloadWindow)
+	})
+}
@@ -0,0 +1,85 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package artifact
+
+import (
+	"fmt"
+	"time"
+)
+
+// timeOfDayLayout is the layout of the start and end of download windows.
+const timeOfDayLayout = "15:04"
+
+// DownloadWindow is a time of day during which artifacts can be downloaded, in the local
+// time of the host. A window ending before it starts spans midnight, e.g. 22:00 to 06:00.
+type DownloadWindow struct {
+	// Start: time the window opens, e.g. 22:00
+	Start string `yaml:"start" config:"start"`
+
+	// End: time the window closes, e.g. 06:00
+	End string `yaml:"end" config:"end"`
+}
+
+// Validate validates the window when the config is unpacked.
+func (w DownloadWindow) Validate() error {
+	start, err := time.Parse(timeOfDayLayout, w.Start)
+	if err != nil {
+		return fmt.Errorf("invalid download window start %q, expected HH:MM: %w", w.Start, err)
+	}
+	end, err := time.Parse(timeOfDayLayout, w.End)
+	if err != nil {
+		return fmt.Errorf("invalid download window end %q, expected HH:MM: %w", w.End, err)
+	}
+	if start.Equal(end) {
+		return fmt.Errorf("download window starts and ends at %s", w.Start)
+	}
+	return nil
+}
+
+// next returns the start and end of the window open at now or, if it is closed, of its
+// next opening.
+func (w DownloadWindow) next(now time.Time) (time.Time, time.Time, error) {
+	if err := w.Validate(); err != nil {
+		return time.Time{}, time.Time{}, err
+	}
+	start, _ := time.Parse(timeOfDayLayout, w.Start)
+	end, _ := time.Parse(timeOfDayLayout, w.End)
+
+	// the window open at now started yesterday at the earliest
+	for day := -1; ; day++ {
+		opens := time.Date(now.Year(), now.Month(), now.Day()+day, start.Hour(), start.Minute(), 0, 0, now.Location())
+		closes := time.Date(now.Year(), now.Month(), now.Day()+day, end.Hour(), end.Minute(), 0, 0, now.Location())
+		if !closes.After(opens) {
+			closes = time.Date(now.Year(), now.Month(), now.Day()+day+1, end.Hour(), end.Minute(), 0, 0, now.Location())
+		}
+		if closes.After(now) {
+			return opens, closes, nil
+		}
+	}
+}
+
+// DownloadWindowAt returns when the download window open at now closes or, if they are
+// all closed, when the next one opens and closes. Both are zero when downloads are not
+// limited to windows.
+func (c *Config) DownloadWindowAt(now time.Time) (opens time.Time, closes time.Time) {
+	for _, w := range c.Windows {
+		wOpens, wCloses, err := w.next(now)
+		if err != nil {
+			// invalid windows are rejected when the config is unpacked
+			continue
+		}
+		if !wOpens.After(now) {
+			// open, keep the one closing last
+			if opens.IsZero() || opens.After(now) || wCloses.After(closes) {
+				opens, closes = wOpens, wCloses
+			}
+			continue
+		}
+		if opens.IsZero() || (opens.After(now) && wOpens.Before(opens)) {
+			opens, closes = wOpens, wCloses
+		}
+	}
+	return opens, closes
+}
@@ -0,0 +1,114 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package artifact
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentlibsconfig "github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestDownloadWindowAt(t *testing.T) {
+	at := func(day, hour, minute int) time.Time {
+		return time.Date(2024, time.May, day, hour, minute, 0, 0, time.UTC)
+	}
+
+	testCases := map[string]struct {
+		windows        []DownloadWindow
+		now            time.Time
+		expectedOpens  time.Time
+		expectedCloses time.Time
+	}{
+		"no windows": {
+			now: at(1, 12, 0),
+		},
+		"inside window": {
+			windows:        []DownloadWindow{{Start: "09:00", End: "17:00"}},
+			now:            at(1, 12, 0),
+			expectedOpens:  at(1, 9, 0),
+			expectedCloses: at(1, 17, 0),
+		},
+		"before window": {
+			windows:        []DownloadWindow{{Start: "09:00", End: "17:00"}},
+			now:            at(1, 8, 0),
+			expectedOpens:  at(1, 9, 0),
+			expectedCloses: at(1, 17, 0),
+		},
+		"after window": {
+			windows:        []DownloadWindow{{Start: "09:00", End: "17:00"}},
+			now:            at(1, 17, 0),
+			expectedOpens:  at(2, 9, 0),
+			expectedCloses: at(2, 17, 0),
+		},
+		"inside window spanning midnight, before midnight": {
+			windows:        []DownloadWindow{{Start: "22:00", End: "06:00"}},
+			now:            at(1, 23, 0),
+			expectedOpens:  at(1, 22, 0),
+			expectedCloses: at(2, 6, 0),
+		},
+		"inside window spanning midnight, after midnight": {
+			windows:        []DownloadWindow{{Start: "22:00", End: "06:00"}},
+			now:            at(2, 1, 0),
+			expectedOpens:  at(1, 22, 0),
+			expectedCloses: at(2, 6, 0),
+		},
+		"outside window spanning midnight": {
+			windows:        []DownloadWindow{{Start: "22:00", End: "06:00"}},
+			now:            at(2, 12, 0),
+			expectedOpens:  at(2, 22, 0),
+			expectedCloses: at(3, 6, 0),
+		},
+		"several windows, next one": {
+			windows:        []DownloadWindow{{Start: "20:00", End: "21:00"}, {Start: "12:00", End: "13:00"}},
+			now:            at(1, 10, 0),
+			expectedOpens:  at(1, 12, 0),
+			expectedCloses: at(1, 13, 0),
+		},
+		"several windows, open one": {
+			windows:        []DownloadWindow{{Start: "11:00", End: "12:00"}, {Start: "10:00", End: "14:00"}},
+			now:            at(1, 11, 30),
+			expectedOpens:  at(1, 10, 0),
+			expectedCloses: at(1, 14, 0),
+		},
+	}
+
+	for name, tc := range testCases {
+		t.Run(name, func(t *testing.T) {
+			cfg := &Config{Windows: tc.windows}
+			opens, closes := cfg.DownloadWindowAt(tc.now)
+			assert.Equal(t, tc.expectedOpens, opens)
+			assert.Equal(t, tc.expectedCloses, closes)
+		})
+	}
+}
+
+func TestConfig_UnpackThrottle(t *testing.T) {
+	rawCfg, err := agentlibsconfig.NewConfigFrom(`
+rate_limit: 1048576
+windows:
+  - start: "22:00"
+    end: "06:00"
+`)
+	require.NoError(t, err)
+
+	cfg := DefaultConfig()
+	require.NoError(t, cfg.Unpack(rawCfg))
+	assert.Equal(t, int64(1048576), cfg.RateLimit)
+	assert.Equal(t, []DownloadWindow{{Start: "22:00", End: "06:00"}}, cfg.Windows)
+
+	for _, invalid := range []string{
+		`windows: [{start: "25:00", end: "06:00"}]`,
+		`windows: [{start: "22:00"}]`,
+		`windows: [{start: "06:00", end: "06:00"}]`,
+	} {
+		rawCfg, err := agentlibsconfig.NewConfigFrom(invalid)
+		require.NoError(t, err)
+		assert.Error(t, DefaultConfig().Unpack(rawCfg), invalid)
+	}
+}
@@ -0,0 +1,42 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package artifact
+
+import "strings"
+
+// OCISourceURIScheme is the scheme of the source URIs of artifacts stored in an OCI
+// registry, e.g. oci://registry.example.com/elastic
+const OCISourceURIScheme = "oci://"
+
+// OCIConfig is the configuration of the authentication to the OCI registry artifacts are
+// downloaded from. The registry is accessed anonymously when unset.
+type OCIConfig struct {
+	// Username: username authenticating to the registry, or to its token service.
+	Username string `yaml:"username" config:"username"`
+
+	// Password: password of the username.
+	Password string `yaml:"password" config:"password"`
+
+	// Token: bearer token authenticating to the registry, used instead of the username
+	// and password.
+	Token string `yaml:"token" config:"token"`
+
+	// VaultKey: key of the credentials stored in the vault of the Elastic Agent with the
+	// vault set-oci-credentials command, used instead of the username, password and token.
+	VaultKey string `yaml:"vault_key" config:"vault_key"`
+}
+
+// OCICredentials are the credentials of an OCI registry stored in the vault of the Elastic
+// Agent, as JSON.
+type OCICredentials struct {
+	Username string `json:"username,omitempty"`
+	Password string `json:"password,omitempty"`
+	Token    string `json:"token,omitempty"`
+}
+
+// IsOCISourceURI returns true when the artifacts of sourceURI are stored in an OCI registry.
+func IsOCISourceURI(sourceURI string) bool {
+	return strings.HasPrefix(sourceURI, OCISourceURIScheme)
+}
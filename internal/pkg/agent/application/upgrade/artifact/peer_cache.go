@@ -0,0 +1,67 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package artifact
+
+import (
+	"github.com/elastic/elastic-agent-libs/transport/tlscommon"
+)
+
+const (
+	// DefaultPeerCacheHost is the default address of the peer cache server, only the local
+	// host can download the artifacts unless another address is configured.
+	DefaultPeerCacheHost = "localhost"
+	// DefaultPeerCachePort is the default port of the peer cache server.
+	DefaultPeerCachePort = 6792
+)
+
+// PeerCacheConfig is the configuration of the sharing of downloaded artifacts between
+// the agents of a network, so each artifact is downloaded from the source URI once.
+type PeerCacheConfig struct {
+	// Server: serving of the downloaded artifacts to the other agents.
+	Server PeerCacheServerConfig `yaml:"server" config:"server"`
+
+	// Peers: URLs of the agents artifacts are downloaded from before the source URI,
+	// e.g. https://10.0.0.5:6792
+	Peers []string `yaml:"peers" config:"peers"`
+
+	// Discovery: name of the DNS SRV record listing the agents artifacts are downloaded
+	// from, in addition to Peers, e.g. _elastic-agent-cache._tcp.example.com
+	Discovery string `yaml:"discovery" config:"discovery"`
+
+	// DiscoveryScheme: scheme of the URLs of the discovered agents, http or https.
+	DiscoveryScheme string `yaml:"discovery_scheme" config:"discovery_scheme"`
+}
+
+// PeerCacheServerConfig is the configuration of the server of the downloaded artifacts.
+type PeerCacheServerConfig struct {
+	// Enabled: serve the downloaded artifacts, disabled by default.
+	Enabled bool `yaml:"enabled" config:"enabled"`
+
+	// Host: address the server listens on, the local host only by default. The artifacts
+	// are only served to the other agents when an address they can reach is configured.
+	Host string `yaml:"host" config:"host"`
+
+	// Port: port the server listens on.
+	Port int `yaml:"port" config:"port"`
+
+	// TLS: TLS configuration of the server, artifacts are served over HTTP when unset.
+	TLS *tlscommon.ServerConfig `yaml:"ssl,omitempty" config:"ssl"`
+}
+
+// DefaultPeerCacheConfig creates a config with pre-set default values.
+func DefaultPeerCacheConfig() PeerCacheConfig {
+	return PeerCacheConfig{
+		DiscoveryScheme: "http",
+		Server: PeerCacheServerConfig{
+			Host: DefaultPeerCacheHost,
+			Port: DefaultPeerCachePort,
+		},
+	}
+}
+
+// HasPeers returns true when artifacts are downloaded from peers.
+func (c PeerCacheConfig) HasPeers() bool {
+	return len(c.Peers) > 0 || c.Discovery != ""
+}
@@ -0,0 +1,93 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package artifact
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+)
+
+const (
+	// VerificationPGP verifies the detached PGP signature (.asc) of the artifacts.
+	VerificationPGP = "pgp"
+	// VerificationCosign verifies the cosign signature bundle of the artifacts.
+	VerificationCosign = "cosign"
+)
+
+// VerificationConfig is the configuration of the verification of the signature of the
+// downloaded artifacts.
+type VerificationConfig struct {
+	// Methods: signature verifications the artifacts must pass, pgp and/or cosign.
+	// Only the PGP signature is verified when empty.
+	Methods []string `yaml:"methods" config:"methods"`
+
+	// Cosign: verification of the cosign signature bundles, required by the cosign method.
+	Cosign CosignConfig `yaml:"cosign" config:"cosign"`
+}
+
+// CosignConfig is the configuration of the offline verification of cosign signature
+// bundles, either with a public key or, for keyless signatures, with a trust root.
+type CosignConfig struct {
+	// PublicKey: path to the PEM public key the artifacts are signed with.
+	PublicKey string `yaml:"public_key" config:"public_key"`
+
+	// CertificateAuthorities: path to the PEM certificates of the Fulcio root and
+	// intermediate CAs issuing the certificates of keyless signatures.
+	CertificateAuthorities string `yaml:"certificate_authorities" config:"certificate_authorities"`
+
+	// RekorPublicKey: path to the PEM public key of the Rekor transparency log the
+	// keyless signatures are recorded in.
+	RekorPublicKey string `yaml:"rekor_public_key" config:"rekor_public_key"`
+
+	// CertificateIdentity: identity, email or URI, the certificate of keyless signatures
+	// must be issued to.
+	CertificateIdentity string `yaml:"certificate_identity" config:"certificate_identity"`
+
+	// CertificateOIDCIssuer: OIDC issuer that must have authenticated the identity.
+	CertificateOIDCIssuer string `yaml:"certificate_oidc_issuer" config:"certificate_oidc_issuer"`
+}
+
+// Validate validates the verification config when it is unpacked.
+func (c VerificationConfig) Validate() error {
+	for _, method := range c.Methods {
+		if method != VerificationPGP && method != VerificationCosign {
+			return fmt.Errorf("unknown verification method %q, expected %s or %s", method, VerificationPGP, VerificationCosign)
+		}
+	}
+	if c.CosignEnabled() {
+		return c.Cosign.validate()
+	}
+	return nil
+}
+
+// PGPEnabled returns true when the PGP signature of the artifacts must be verified.
+func (c VerificationConfig) PGPEnabled() bool {
+	return len(c.Methods) == 0 || slices.Contains(c.Methods, VerificationPGP)
+}
+
+// CosignEnabled returns true when the cosign signature bundle of the artifacts must be verified.
+func (c VerificationConfig) CosignEnabled() bool {
+	return slices.Contains(c.Methods, VerificationCosign)
+}
+
+// Keyless returns true when the signatures are verified with the trust root rather than
+// a public key.
+func (c CosignConfig) Keyless() bool {
+	return c.PublicKey == ""
+}
+
+func (c CosignConfig) validate() error {
+	if !c.Keyless() {
+		return nil
+	}
+	if c.CertificateAuthorities == "" || c.RekorPublicKey == "" {
+		return errors.New("cosign verification requires a public_key, or certificate_authorities and rekor_public_key for keyless signatures")
+	}
+	if c.CertificateIdentity == "" || c.CertificateOIDCIssuer == "" {
+		return errors.New("cosign verification of keyless signatures requires a certificate_identity and a certificate_oidc_issuer")
+	}
+	return nil
+}
@@ -0,0 +1,55 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package artifact
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentlibsconfig "github.com/elastic/elastic-agent-libs/config"
+)
+
+func TestVerificationConfig(t *testing.T) {
+	cfg := DefaultConfig()
+	assert.True(t, cfg.Verification.PGPEnabled(), "PGP is verified by default")
+	assert.False(t, cfg.Verification.CosignEnabled())
+
+	rawCfg, err := agentlibsconfig.NewConfigFrom(`
+verification:
+  methods: [pgp, cosign]
+  cosign:
+    public_key: /etc/elastic-agent/cosign.pub
+`)
+	require.NoError(t, err)
+	require.NoError(t, cfg.Unpack(rawCfg))
+	assert.True(t, cfg.Verification.PGPEnabled())
+	assert.True(t, cfg.Verification.CosignEnabled())
+	assert.False(t, cfg.Verification.Cosign.Keyless())
+
+	rawCfg, err = agentlibsconfig.NewConfigFrom(`verification.methods: [cosign]
+verification.cosign:
+  certificate_authorities: /etc/elastic-agent/fulcio.pem
+  rekor_public_key: /etc/elastic-agent/rekor.pub
+  certificate_identity: release@example.com
+  certificate_oidc_issuer: https://accounts.example.com
+`)
+	require.NoError(t, err)
+	cfg = DefaultConfig()
+	require.NoError(t, cfg.Unpack(rawCfg))
+	assert.False(t, cfg.Verification.PGPEnabled(), "only cosign is verified")
+	assert.True(t, cfg.Verification.Cosign.Keyless())
+
+	for _, invalid := range []string{
+		`verification.methods: [sha1]`,
+		`verification.methods: [cosign]`,
+		`verification: {methods: [cosign], cosign: {certificate_authorities: fulcio.pem, rekor_public_key: rekor.pub}}`,
+	} {
+		rawCfg, err := agentlibsconfig.NewConfigFrom(invalid)
+		require.NoError(t, err)
+		assert.Error(t, DefaultConfig().Unpack(rawCfg), invalid)
+	}
+}
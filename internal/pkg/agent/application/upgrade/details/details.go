@@ -5,6 +5,7 @@
 package details
 
 import (
+	"errors"
 	"math"
 	"sync"
 	"time"
@@ -15,6 +16,12 @@ import (
 // Observer is a function that will be called with upgrade details
 type Observer func(details *Details)
 
+// codedError is an error with a machine-readable code, like fleetapi.AckError.
+type codedError interface {
+	error
+	ErrorCode() string
+}
+
 // Details consists of details regarding an ongoing upgrade.
 type Details struct {
 	TargetVersion string   `json:"target_version" yaml:"target_version"`
@@ -38,6 +45,14 @@ type Metadata struct {
 	// is progressing.
 	DownloadRate details.DownloadRate `json:"download_rate,omitempty" yaml:"download_rate,omitempty"`
 
+	// DownloadRateLimit is the maximum rate, in bytes per second, at which the
+	// artifact is downloaded. It is 0 when the download is not limited.
+	DownloadRateLimit details.DownloadRate `json:"download_rate_limit,omitempty" yaml:"download_rate_limit,omitempty"`
+
+	// DownloadWindowOpensAt is when the next download window opens while the
+	// download is paused outside of the configured download windows.
+	DownloadWindowOpensAt *time.Time `json:"download_window_opens_at,omitempty" yaml:"download_window_opens_at,omitempty"`
+
 	// RetryErrorMsg is any error message that is a result of a retryable upgrade
 	// step, e.g. the download step, being retried.
 	RetryErrorMsg string `json:"retry_error_msg,omitempty" yaml:"retry_error_msg,omitempty"`
@@ -56,9 +71,17 @@ type Metadata struct {
 	// an upgrade fails.
 	ErrorMsg string `json:"error_msg,omitempty" yaml:"error_msg,omitempty"`
 
+	// ErrorCode is the machine-readable code of the error the upgrade failed with, if
+	// the error has one. It is set by the Fail() method together with ErrorMsg.
+	ErrorCode string `json:"error_code,omitempty" yaml:"error_code,omitempty"`
+
 	// Reason is a string that may give out more information about transitioning to the current state. It has been
 	// introduced initially to distinguish between manual and automatic rollbacks
 	Reason string `json:"reason,omitempty" yaml:"reason,omitempty"`
+
+	// RollbackErrorMsg is the error that caused the upgrade watcher to roll back the
+	// upgrade. Use the SetRollback() method of UpgradeDetails to record it.
+	RollbackErrorMsg string `json:"rollback_error_msg,omitempty" yaml:"rollback_error_msg,omitempty"`
 }
 
 func NewDetails(targetVersion string, initialState State, actionID string) *Details {
@@ -85,6 +108,7 @@ func (d *Details) SetState(s State) {
 	// should be set when State is set to StateFailed. See the Fail method.
 	if s != StateFailed {
 		d.Metadata.ErrorMsg = ""
+		d.Metadata.ErrorCode = ""
 		d.Metadata.FailedState = ""
 	}
 
@@ -106,12 +130,33 @@ func (d *Details) SetStateWithReason(s State, reason string) {
 	// should be set when State is set to StateFailed. See the Fail method.
 	if s != StateFailed {
 		d.Metadata.ErrorMsg = ""
+		d.Metadata.ErrorCode = ""
 		d.Metadata.FailedState = ""
 	}
 
 	d.notifyObservers()
 }
 
+// SetRollback is a convenience method to set the state of the upgrade to
+// StateRollback, the metadata.reason and the error that caused the rollback,
+// and notify all observers.
+func (d *Details) SetRollback(reason string, cause error) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.State = StateRollback
+	d.Metadata.Reason = reason
+	d.Metadata.RollbackErrorMsg = ""
+	if cause != nil {
+		d.Metadata.RollbackErrorMsg = cause.Error()
+	}
+	d.Metadata.ErrorMsg = ""
+	d.Metadata.ErrorCode = ""
+	d.Metadata.FailedState = ""
+
+	d.notifyObservers()
+}
+
 // SetDownloadProgress is a convenience method to set the download percent
 // and download rate when the upgrade is in UPG_DOWNLOADING state.
 func (d *Details) SetDownloadProgress(percent, rateBytesPerSecond float64) {
@@ -133,6 +178,24 @@ func (d *Details) SetDownloadProgress(percent, rateBytesPerSecond float64) {
 	d.notifyObservers()
 }
 
+// SetDownloadRateLimit sets the DownloadRateLimit metadata field.
+func (d *Details) SetDownloadRateLimit(rateBytesPerSecond float64) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.Metadata.DownloadRateLimit = details.DownloadRate(rateBytesPerSecond)
+	d.notifyObservers()
+}
+
+// SetDownloadWindowOpensAt sets the DownloadWindowOpensAt metadata field.
+func (d *Details) SetDownloadWindowOpensAt(opensAt *time.Time) {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	d.Metadata.DownloadWindowOpensAt = opensAt
+	d.notifyObservers()
+}
+
 // SetRetryableError sets the RetryErrorMsg metadata field.
 func (d *Details) SetRetryableError(retryableError error) {
 	d.mu.Lock()
@@ -171,6 +234,11 @@ func (d *Details) Fail(err error) {
 	}
 
 	d.Metadata.ErrorMsg = err.Error()
+	d.Metadata.ErrorCode = ""
+	var coded codedError
+	if errors.As(err, &coded) {
+		d.Metadata.ErrorCode = coded.ErrorCode()
+	}
 	d.State = StateFailed
 	d.notifyObservers()
 }
@@ -228,10 +296,14 @@ func (m Metadata) Equals(otherM Metadata) bool {
 	return equalTimePointers(m.ScheduledAt, otherM.ScheduledAt) &&
 		m.FailedState == otherM.FailedState &&
 		m.ErrorMsg == otherM.ErrorMsg &&
+		m.ErrorCode == otherM.ErrorCode &&
 		m.DownloadPercent == otherM.DownloadPercent &&
 		m.DownloadRate == otherM.DownloadRate &&
+		m.DownloadRateLimit == otherM.DownloadRateLimit &&
+		equalTimePointers(m.DownloadWindowOpensAt, otherM.DownloadWindowOpensAt) &&
 		equalTimePointers(m.RetryUntil, otherM.RetryUntil) &&
-		m.RetryErrorMsg == otherM.RetryErrorMsg
+		m.RetryErrorMsg == otherM.RetryErrorMsg &&
+		m.RollbackErrorMsg == otherM.RollbackErrorMsg
 }
 
 func equalTimePointers(t, otherT *time.Time) bool {
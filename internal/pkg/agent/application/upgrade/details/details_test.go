@@ -7,6 +7,7 @@ package details
 import (
 	"encoding/json"
 	"errors"
+	"fmt"
 	"math"
 	"testing"
 	"time"
@@ -40,6 +41,15 @@ func TestDetailsSetStateWithReason(t *testing.T) {
 	assert.Equal(t, ReasonWatchFailed, det.Metadata.Reason)
 }
 
+func TestDetailsSetRollback(t *testing.T) {
+	det := NewDetails("99.999.9999", StateWatching, "test_action_id")
+
+	det.SetRollback(ReasonWatchFailed, errors.New("required component(s) not healthy"))
+	assert.Equal(t, StateRollback, det.State)
+	assert.Equal(t, ReasonWatchFailed, det.Metadata.Reason)
+	assert.Equal(t, "required component(s) not healthy", det.Metadata.RollbackErrorMsg)
+}
+
 func TestDetailsFail(t *testing.T) {
 	det := NewDetails("99.999.9999", StateRequested, "test_action_id")
 	require.Equal(t, StateRequested, det.State)
@@ -57,6 +67,27 @@ func TestDetailsFail(t *testing.T) {
 	require.Equal(t, "", det.Metadata.ErrorMsg)
 }
 
+type testCodedError struct {
+	error
+}
+
+func (e testCodedError) ErrorCode() string {
+	return "TEST_CODE"
+}
+
+func TestDetailsFailWithErrorCode(t *testing.T) {
+	det := NewDetails("99.999.9999", StateDownloading, "test_action_id")
+
+	err := fmt.Errorf("wrapped: %w", testCodedError{errors.New("test error")})
+	det.Fail(err)
+	require.Equal(t, StateFailed, det.State)
+	assert.Equal(t, "wrapped: test error", det.Metadata.ErrorMsg)
+	assert.Equal(t, "TEST_CODE", det.Metadata.ErrorCode)
+
+	det.SetState(StateDownloading)
+	assert.Equal(t, "", det.Metadata.ErrorCode)
+}
+
 func TestDetailsObserver(t *testing.T) {
 	det := NewDetails("99.999.9999", StateRequested, "test_action_id")
 	require.Equal(t, StateRequested, det.State)
@@ -0,0 +1,41 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"errors"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+)
+
+// ErrorWithCode returns the upgrade error with its machine-readable code, or with code
+// when the error has no known cause. Errors that already have a code are returned as is.
+func ErrorWithCode(err error, code fleetapi.ErrorCode) error {
+	if err == nil {
+		return nil
+	}
+
+	var ackErr *fleetapi.AckError
+	if errors.As(err, &ackErr) {
+		return err
+	}
+	var checksumErr *download.ChecksumMismatchError
+	if errors.As(err, &checksumErr) {
+		// the artifact is removed on mismatch, downloading it again may succeed
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeChecksumMismatch, true, err)
+	}
+	var signatureErr *download.InvalidSignatureError
+	if errors.As(err, &signatureErr) {
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeInvalidSignature, false, err)
+	}
+	if errors.Is(err, ErrUpgradeSameVersion) || errors.Is(err, ErrNonFipsToFips) || errors.Is(err, ErrFipsToNonFips) {
+		return fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeNotAllowed, false, err)
+	}
+	if ackErr = fleetapi.AckErrorFrom(err); ackErr.Code != fleetapi.ErrorCodeUnknown {
+		return fleetapi.NewAckError(ackErr.Code, ackErr.Retryable, err)
+	}
+	return fleetapi.NewAckError(code, true, err)
+}
@@ -0,0 +1,220 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"archive/zip"
+	"context"
+	goerrors "errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/docker/go-units"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	agtversion "github.com/elastic/elastic-agent/pkg/version"
+)
+
+// PreflightCheckStatus is the result of a pre-flight check.
+type PreflightCheckStatus string
+
+const (
+	// PreflightCheckPassed is the status of the checks that passed.
+	PreflightCheckPassed PreflightCheckStatus = "passed"
+	// PreflightCheckFailed is the status of the checks that failed, the upgrade would fail.
+	PreflightCheckFailed PreflightCheckStatus = "failed"
+	// PreflightCheckSkipped is the status of the checks that could not or should not run.
+	PreflightCheckSkipped PreflightCheckStatus = "skipped"
+)
+
+// Names of the pre-flight checks.
+const (
+	PreflightCheckUpgradeable  = "upgradeable"
+	PreflightCheckCapabilities = "capabilities"
+	PreflightCheckVersion      = "version"
+	PreflightCheckArtifact     = "artifact_verification"
+	PreflightCheckPermissions  = "path_permissions"
+	PreflightCheckDiskSpace    = "disk_space"
+)
+
+// PreflightCheck is the result of a check run before an upgrade modifies the installation.
+type PreflightCheck struct {
+	Name    string               `json:"name" yaml:"name"`
+	Status  PreflightCheckStatus `json:"status" yaml:"status"`
+	Message string               `json:"message,omitempty" yaml:"message,omitempty"`
+
+	err error
+}
+
+// PreflightReport is the result of the pre-flight checks of an upgrade.
+type PreflightReport struct {
+	Checks []PreflightCheck `json:"checks" yaml:"checks"`
+}
+
+// Add adds the result of a check, failed when err is not nil.
+func (r *PreflightReport) Add(name string, err error, message string) {
+	if err != nil {
+		r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: PreflightCheckFailed, Message: err.Error(), err: err})
+		return
+	}
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: PreflightCheckPassed, Message: message})
+}
+
+// Skip adds a check that was not run.
+func (r *PreflightReport) Skip(name string, reason string) {
+	r.Checks = append(r.Checks, PreflightCheck{Name: name, Status: PreflightCheckSkipped, Message: reason})
+}
+
+// Err returns the errors of the failed checks, nil when none failed.
+func (r *PreflightReport) Err() error {
+	var errs []error
+	for _, c := range r.Checks {
+		if c.Status == PreflightCheckFailed {
+			errs = append(errs, fmt.Errorf("pre-flight check %s failed: %w", c.Name, c.err))
+		}
+	}
+	return goerrors.Join(errs...)
+}
+
+// Preflight runs the pre-flight checks of the upgrade to version without upgrading. The
+// artifact is downloaded and verified, like for an upgrade, and kept in the downloads
+// directory for the upgrade to come. The checks that depend on the artifact are skipped
+// when it is not available.
+func (u *Upgrader) Preflight(ctx context.Context, version string, sourceURI string, det *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) *PreflightReport {
+	report := &PreflightReport{}
+	currentVersion := runningAgentVersion()
+
+	parsedVersion, err := agtversion.ParseVersion(version)
+	if err != nil {
+		report.Add(PreflightCheckVersion, fmt.Errorf("error parsing version %q: %w", version, err), "")
+		return report
+	}
+	if isSameReleaseVersion(u.log, currentVersion, version) {
+		report.Add(PreflightCheckVersion, ErrUpgradeSameVersion, "")
+		return report
+	}
+
+	// the dry run reports the checks right away, it does not wait for the download windows
+	settings := *u.settings
+	settings.Windows = nil
+	archivePath, err := u.downloadArtifactWithSettings(ctx, settings, parsedVersion, u.sourceURI(sourceURI), det, skipVerifyOverride, skipDefaultPgp, pgpBytes...)
+	if err != nil {
+		report.Add(PreflightCheckArtifact, ErrorWithCode(err, fleetapi.ErrorCodeUpgradeDownloadFailed), "")
+		report.Skip(PreflightCheckVersion, "the artifact is not available")
+		u.preflightPermissions(report, paths.Top(), paths.Data())
+		report.Skip(PreflightCheckDiskSpace, "the artifact is not available")
+		return report
+	}
+	if skipVerifyOverride {
+		report.Skip(PreflightCheckArtifact, "verification of the artifact is skipped")
+	} else {
+		report.Add(PreflightCheckArtifact, nil, fmt.Sprintf("%s is verified", filepath.Base(archivePath)))
+	}
+
+	metadata, err := u.getPackageMetadata(archivePath)
+	if err != nil {
+		report.Add(PreflightCheckVersion, fmt.Errorf("reading metadata of package %q: %w", archivePath, err), "")
+	} else {
+		newVersion := extractAgentVersion(metadata, version)
+		report.Add(PreflightCheckVersion, checkUpgrade(u.log, currentVersion, newVersion, metadata),
+			fmt.Sprintf("%s can be upgraded to %s", currentVersion, newVersion))
+	}
+
+	u.preflightInstall(report, archivePath, paths.Top(), paths.Data())
+	return report
+}
+
+// preflightInstall runs the checks that the artifact can be installed: the directories
+// modified by the upgrade are writable and the disk has room for the unpacked artifact.
+func (u *Upgrader) preflightInstall(report *PreflightReport, archivePath, topDir, dataDir string) {
+	u.preflightPermissions(report, topDir, dataDir)
+
+	size, err := unpackedSize(archivePath)
+	if err != nil {
+		report.Add(PreflightCheckDiskSpace, fmt.Errorf("failed to compute the unpacked size of %q: %w", archivePath, err), "")
+		return
+	}
+	free, err := freeDiskSpace(dataDir)
+	if err != nil {
+		report.Skip(PreflightCheckDiskSpace, fmt.Sprintf("failed to get the free disk space of %s: %v", dataDir, err))
+		return
+	}
+	if free < size {
+		err := fmt.Errorf("unpacking the artifact requires %s but %s is available in %s",
+			units.HumanSize(float64(size)), units.HumanSize(float64(free)), dataDir)
+		report.Add(PreflightCheckDiskSpace, fleetapi.NewAckError(fleetapi.ErrorCodeDiskFull, true, err), "")
+		return
+	}
+	report.Add(PreflightCheckDiskSpace, nil, fmt.Sprintf("unpacking the artifact requires %s, %s is available in %s",
+		units.HumanSize(float64(size)), units.HumanSize(float64(free)), dataDir))
+}
+
+// preflightPermissions checks that the directories modified by the upgrade are writable.
+func (u *Upgrader) preflightPermissions(report *PreflightReport, dirs ...string) {
+	var errs []error
+	for _, dir := range dirs {
+		if err := checkWritable(dir); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	if len(errs) > 0 {
+		report.Add(PreflightCheckPermissions, fleetapi.NewAckError(fleetapi.ErrorCodeUpgradeFailed, false, goerrors.Join(errs...)), "")
+		return
+	}
+	report.Add(PreflightCheckPermissions, nil, strings.Join(dirs, ", ")+" are writable")
+}
+
+// checkWritable checks that files can be created in dir.
+func checkWritable(dir string) error {
+	f, err := os.CreateTemp(dir, ".upgrade-preflight-*")
+	if err != nil {
+		return fmt.Errorf("%s is not writable: %w", dir, err)
+	}
+	_ = f.Close()
+	if err := os.Remove(f.Name()); err != nil {
+		return fmt.Errorf("failed to remove %s: %w", f.Name(), err)
+	}
+	return nil
+}
+
+// unpackedSize returns the size of the files of the archive once unpacked.
+func unpackedSize(archivePath string) (uint64, error) {
+	if strings.HasSuffix(archivePath, ".zip") {
+		r, err := zip.OpenReader(archivePath)
+		if err != nil {
+			return 0, err
+		}
+		defer r.Close()
+		var size uint64
+		for _, f := range r.File {
+			size += f.UncompressedSize64
+		}
+		return size, nil
+	}
+
+	tr, tc, err := openTar(archivePath)
+	if err != nil {
+		return 0, err
+	}
+	defer tc.Close()
+	var size uint64
+	for {
+		hdr, err := tr.Next()
+		if goerrors.Is(err, io.EOF) {
+			return size, nil
+		}
+		if err != nil {
+			return 0, err
+		}
+		if hdr.Size > 0 {
+			size += uint64(hdr.Size)
+		}
+	}
+}
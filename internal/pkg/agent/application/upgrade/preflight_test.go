@@ -0,0 +1,143 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func TestPreflightReportErr(t *testing.T) {
+	report := &PreflightReport{}
+	report.Add(PreflightCheckVersion, nil, "1.2.3 can be upgraded to 1.2.4")
+	report.Skip(PreflightCheckArtifact, "verification of the artifact is skipped")
+	assert.NoError(t, report.Err())
+
+	diskErr := errors.New("disk is full")
+	report.Add(PreflightCheckDiskSpace, diskErr, "")
+	err := report.Err()
+	require.Error(t, err)
+	assert.ErrorIs(t, err, diskErr)
+	assert.Contains(t, err.Error(), "pre-flight check disk_space failed")
+
+	require.Len(t, report.Checks, 3)
+	assert.Equal(t, PreflightCheckPassed, report.Checks[0].Status)
+	assert.Equal(t, PreflightCheckSkipped, report.Checks[1].Status)
+	assert.Equal(t, PreflightCheckFailed, report.Checks[2].Status)
+	assert.Equal(t, "disk is full", report.Checks[2].Message)
+}
+
+func TestPreflightInstall(t *testing.T) {
+	archive, err := createTarArchive(t, "elastic-agent-1.2.4-linux-x86_64.tar.gz", []files{
+		{fType: REGULAR, path: "elastic-agent-1.2.4-linux-x86_64/data/elastic-agent-abcdef/elastic-agent", content: agentBinaryPlaceholderContent, mode: fs.ModePerm & 0o750},
+		{fType: REGULAR, path: "elastic-agent-1.2.4-linux-x86_64/data/elastic-agent-abcdef/package.version", content: "1.2.4", mode: fs.ModePerm & 0o640},
+	})
+	require.NoError(t, err)
+
+	log, _ := loggertest.New(t.Name())
+	u := &Upgrader{log: log}
+
+	t.Run("writable directories with free space", func(t *testing.T) {
+		report := &PreflightReport{}
+		u.preflightInstall(report, archive, t.TempDir(), t.TempDir())
+
+		require.NoError(t, report.Err())
+		require.Len(t, report.Checks, 2)
+		assert.Equal(t, PreflightCheckPermissions, report.Checks[0].Name)
+		assert.Equal(t, PreflightCheckDiskSpace, report.Checks[1].Name)
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		report := &PreflightReport{}
+		u.preflightInstall(report, archive, filepath.Join(t.TempDir(), "missing"), t.TempDir())
+
+		err := report.Err()
+		require.Error(t, err)
+		ackErr := fleetapi.AckErrorFrom(err)
+		assert.Equal(t, fleetapi.ErrorCodeUpgradeFailed, ackErr.Code)
+		assert.Equal(t, PreflightCheckFailed, report.Checks[0].Status)
+		assert.Equal(t, PreflightCheckPassed, report.Checks[1].Status)
+	})
+
+	t.Run("invalid archive", func(t *testing.T) {
+		report := &PreflightReport{}
+		u.preflightInstall(report, filepath.Join(t.TempDir(), "missing.tar.gz"), t.TempDir(), t.TempDir())
+
+		require.Error(t, report.Err())
+		assert.Equal(t, PreflightCheckDiskSpace, report.Checks[1].Name)
+		assert.Equal(t, PreflightCheckFailed, report.Checks[1].Status)
+	})
+}
+
+func TestPreflightOutsideDownloadWindow(t *testing.T) {
+	server := httptest.NewServer(http.NotFoundHandler())
+	defer server.Close()
+
+	// the window opens in 2 hours
+	now := time.Now()
+	settings := artifact.Config{
+		SourceURI:              server.URL,
+		TargetDirectory:        t.TempDir(),
+		RetrySleepInitDuration: 20 * time.Millisecond,
+		HTTPTransportSettings: httpcommon.HTTPTransportSettings{
+			Timeout: 200 * time.Millisecond,
+		},
+		Windows: []artifact.DownloadWindow{{
+			Start: now.Add(2 * time.Hour).Format("15:04"),
+			End:   now.Add(3 * time.Hour).Format("15:04"),
+		}},
+	}
+	log, _ := loggertest.New(t.Name())
+	u, err := NewUpgrader(log, &settings, &info.AgentInfo{})
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	det := details.NewDetails("8.9.0", details.StateRequested, "")
+	report := u.Preflight(ctx, "8.9.0", "", det, true, true)
+	require.NoError(t, ctx.Err(), "the pre-flight checks must not wait for the download window")
+
+	require.NotEmpty(t, report.Checks)
+	assert.Equal(t, PreflightCheckArtifact, report.Checks[0].Name)
+	assert.Equal(t, PreflightCheckFailed, report.Checks[0].Status)
+	assert.Nil(t, det.Metadata.DownloadWindowOpensAt)
+}
+
+func TestUnpackedSize(t *testing.T) {
+	archiveFiles := []files{
+		{fType: DIRECTORY, path: "elastic-agent-1.2.4/data", mode: fs.ModeDir | (fs.ModePerm & 0o750)},
+		{fType: REGULAR, path: "elastic-agent-1.2.4/data/a", content: "0123456789", mode: fs.ModePerm & 0o640},
+		{fType: REGULAR, path: "elastic-agent-1.2.4/data/b", content: "01234", mode: fs.ModePerm & 0o640},
+	}
+
+	tarArchive, err := createTarArchive(t, "elastic-agent-1.2.4.tar.gz", archiveFiles)
+	require.NoError(t, err)
+	size, err := unpackedSize(tarArchive)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(15), size)
+
+	zipArchive, err := createZipArchive(t, "elastic-agent-1.2.4.zip", archiveFiles)
+	require.NoError(t, err)
+	size, err = unpackedSize(zipArchive)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(15), size)
+}
@@ -0,0 +1,306 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/internal/pkg/release"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+// retainedInstallsFilename is the file, in the data directory, listing the installs kept
+// after the upgrades.
+const retainedInstallsFilename = ".retained-installs"
+
+var (
+	// ErrNoRetainedInstall is returned when there is no retained install to roll back to.
+	ErrNoRetainedInstall = errors.New("no retained install to roll back to")
+	// ErrRollbackUpgradeWatched is returned when rolling back while an upgrade is watched.
+	ErrRollbackUpgradeWatched = errors.New("an upgrade is being watched, it is rolled back by the upgrade watcher if it fails")
+)
+
+// RetainedInstall is a previous install of the Agent kept after an upgrade, the Agent
+// can be rolled back to it.
+type RetainedInstall struct {
+	// Version is the version of the install.
+	Version string `json:"version" yaml:"version"`
+	// Hash is the commit of the install.
+	Hash string `json:"hash" yaml:"hash"`
+	// VersionedHome is the home of the install, relative to the top path.
+	VersionedHome string `json:"versioned_home" yaml:"versioned_home"`
+	// RetainedOn is when the Agent was upgraded from the install.
+	RetainedOn time.Time `json:"retained_on" yaml:"retained_on"`
+}
+
+// dir returns the directory of the install in the data directory.
+func (r RetainedInstall) dir() string {
+	if r.VersionedHome != "" {
+		return filepath.Base(r.VersionedHome)
+	}
+	// fallback for installs that didn't use the manifest and path remapping
+	return fmt.Sprintf("%s-%s", agentName, r.Hash)
+}
+
+func (r RetainedInstall) versionedHome() string {
+	return filepath.Join("data", r.dir())
+}
+
+// LoadRetainedInstalls returns the retained installs, the most recent first.
+func LoadRetainedInstalls(dataDirPath string) ([]RetainedInstall, error) {
+	data, err := os.ReadFile(filepath.Join(dataDirPath, retainedInstallsFilename))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retained installs: %w", err)
+	}
+	var installs []RetainedInstall
+	if err := yaml.Unmarshal(data, &installs); err != nil {
+		return nil, fmt.Errorf("failed to parse retained installs: %w", err)
+	}
+	return installs, nil
+}
+
+func saveRetainedInstalls(dataDirPath string, installs []RetainedInstall) error {
+	path := filepath.Join(dataDirPath, retainedInstallsFilename)
+	if len(installs) == 0 {
+		if err := os.Remove(path); err != nil && !errors.Is(err, os.ErrNotExist) {
+			return fmt.Errorf("failed to remove retained installs: %w", err)
+		}
+		return nil
+	}
+	data, err := yaml.Marshal(installs)
+	if err != nil {
+		return fmt.Errorf("failed to marshal retained installs: %w", err)
+	}
+	if err := os.WriteFile(path, data, 0o600); err != nil {
+		return fmt.Errorf("failed to write retained installs: %w", err)
+	}
+	return nil
+}
+
+// RetainInstall retains install after a successful upgrade to currentVersionedHome,
+// keeping only the retention most recent installs. The installs that are no longer
+// retained are removed by Cleanup.
+func RetainInstall(log *logger.Logger, dataDirPath string, install RetainedInstall, currentVersionedHome string, retention int) error {
+	installs, err := LoadRetainedInstalls(dataDirPath)
+	if err != nil {
+		return err
+	}
+
+	currentDir := filepath.Base(currentVersionedHome)
+	retained := []RetainedInstall{install}
+	for _, r := range installs {
+		if r.dir() != install.dir() && r.dir() != currentDir {
+			retained = append(retained, r)
+		}
+	}
+	if retention < 0 {
+		retention = 0
+	}
+	if len(retained) > retention {
+		retained = retained[:retention]
+	}
+	log.Infow("Retaining previous installs", "retention", retention, "installs", retained)
+	return saveRetainedInstalls(dataDirPath, retained)
+}
+
+// retainedDirs returns the directories of the retained installs in the data directory.
+func retainedDirs(log *logger.Logger, dataDirPath string) map[string]bool {
+	installs, err := LoadRetainedInstalls(dataDirPath)
+	if err != nil {
+		// do not remove installs that may be retained
+		log.Errorw("Failed to load retained installs, all installs are kept", "error.message", err)
+		return nil
+	}
+	dirs := make(map[string]bool, len(installs))
+	for _, r := range installs {
+		dirs[r.dir()] = true
+	}
+	return dirs
+}
+
+// RollbackToRetained rolls the Agent back to the retained install of version, the most
+// recent retained install when version is empty, and restarts it.
+func RollbackToRetained(ctx context.Context, log *logger.Logger, c client.Client, topDirPath, version string) (*RetainedInstall, error) {
+	target, err := relinkRetained(log, topDirPath, version, utils.GetWatcherPIDs)
+	if err != nil {
+		return nil, err
+	}
+
+	log.Info("Restarting the agent after rollback")
+	if err := restartAgent(ctx, log, c); err != nil {
+		return nil, err
+	}
+	return target, nil
+}
+
+// Rollback relinks the Agent to the retained install of version, the most recent retained
+// install when version is empty, and returns the version rolled back to. The Agent must
+// be re-executed to run it.
+func (u *Upgrader) Rollback(_ context.Context, version string) (string, error) {
+	target, err := relinkRetained(u.log, paths.Top(), version, utils.GetWatcherPIDs)
+	if err != nil {
+		return "", err
+	}
+	return target.Version, nil
+}
+
+// relinkRetained relinks the Agent to the retained install of version, the most recent
+// retained install when version is empty. The running install is retained in its place.
+func relinkRetained(log *logger.Logger, topDirPath, version string, watcherPIDsFetcher func() ([]int, error)) (*RetainedInstall, error) {
+	dataDirPath := paths.DataFrom(topDirPath)
+	marker, err := LoadMarker(dataDirPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to load upgrade marker: %w", err)
+	}
+	if marker != nil {
+		terminal, err := isTerminalMarker(marker, watcherPIDsFetcher)
+		if err != nil {
+			return nil, err
+		}
+		if !terminal {
+			return nil, ErrRollbackUpgradeWatched
+		}
+	}
+
+	installs, err := LoadRetainedInstalls(dataDirPath)
+	if err != nil {
+		return nil, err
+	}
+	idx := findRetainedInstall(installs, version)
+	if idx < 0 {
+		if version == "" {
+			return nil, ErrNoRetainedInstall
+		}
+		var versions []string
+		for _, r := range installs {
+			versions = append(versions, r.Version)
+		}
+		return nil, fmt.Errorf("%w: version %s is not retained, retained versions: [%s]",
+			ErrNoRetainedInstall, version, strings.Join(versions, ", "))
+	}
+	target := installs[idx]
+
+	targetHome := filepath.Join(topDirPath, target.versionedHome())
+	targetPath := paths.BinaryPath(targetHome, agentName)
+	if _, err := os.Stat(targetPath); err != nil {
+		return nil, fmt.Errorf("retained install of version %s is not usable: %w", target.Version, err)
+	}
+
+	log.Infow("Rolling back to retained install", "version", target.Version, "versioned_home", target.versionedHome())
+	if err := copyMissingActionStore(log, paths.Home(), targetHome); err != nil {
+		return nil, fmt.Errorf("failed to copy action store: %w", err)
+	}
+	if err := copyRunDirectory(log, paths.Run(), filepath.Join(targetHome, "run")); err != nil {
+		return nil, fmt.Errorf("failed to copy run directory: %w", err)
+	}
+
+	currentVersionedHome, err := filepath.Rel(topDirPath, paths.Home())
+	if err != nil {
+		return nil, fmt.Errorf("calculating home path relative to top, home: %q top: %q : %w", paths.Home(), topDirPath, err)
+	}
+	if err := changeSymlink(log, topDirPath, filepath.Join(topDirPath, agentName), targetPath); err != nil {
+		return nil, err
+	}
+	if err := UpdateActiveCommit(log, topDirPath, target.Hash); err != nil {
+		return nil, err
+	}
+
+	// the running install takes the place of the install rolled back to
+	current := RetainedInstall{
+		Version:       release.VersionWithSnapshot(),
+		Hash:          release.Commit(),
+		VersionedHome: currentVersionedHome,
+		RetainedOn:    time.Now().UTC(),
+	}
+	retained := append([]RetainedInstall{current}, installs[:idx]...)
+	retained = append(retained, installs[idx+1:]...)
+	if err := saveRetainedInstalls(dataDirPath, retained); err != nil {
+		return nil, err
+	}
+
+	// the details of the last upgrade no longer describe the running Agent
+	if marker != nil {
+		if err := CleanMarker(log, dataDirPath); err != nil {
+			return nil, fmt.Errorf("failed to remove upgrade marker: %w", err)
+		}
+	}
+	return &target, nil
+}
+
+func findRetainedInstall(installs []RetainedInstall, version string) int {
+	for i, r := range installs {
+		if version == "" || r.Version == version {
+			return i
+		}
+	}
+	return -1
+}
+
+// copyMissingActionStore copies the action and state stores of the running install to the
+// retained install only when it has no copy of its own. The copy of the retained install was
+// written by its version, a copy written by a more recent version may not be readable by it.
+func copyMissingActionStore(log *logger.Logger, currentHome, targetHome string) error {
+	for _, name := range []string{
+		filepath.Base(paths.AgentActionStoreFile()),
+		filepath.Base(paths.AgentStateStoreYmlFile()),
+		filepath.Base(paths.AgentStateStoreFile()),
+	} {
+		targetPath := filepath.Join(targetHome, name)
+		if _, err := os.Stat(targetPath); err == nil {
+			log.Infow("Keeping the action store of the retained install", "path", targetPath)
+			continue
+		} else if !errors.Is(err, os.ErrNotExist) {
+			return err
+		}
+
+		content, err := os.ReadFile(filepath.Join(currentHome, name))
+		if errors.Is(err, os.ErrNotExist) {
+			// nothing to copy
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		log.Infow("Copying action store path", "from", filepath.Join(currentHome, name), "to", targetPath)
+		if err := os.WriteFile(targetPath, content, 0o600); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// isTerminalMarker returns true when the upgrade of the marker is no longer watched. Markers
+// without details are written by versions before the upgrade details, their upgrade is
+// watched as long as the upgrade watcher runs.
+func isTerminalMarker(marker *UpdateMarker, watcherPIDsFetcher func() ([]int, error)) (bool, error) {
+	if marker.Details == nil {
+		pids, err := watcherPIDsFetcher()
+		if err != nil {
+			return false, fmt.Errorf("failed to determine if upgrade watcher is running: %w", err)
+		}
+		return len(pids) == 0, nil
+	}
+	switch marker.Details.State {
+	case details.StateCompleted, details.StateRollback, details.StateFailed:
+		return true, nil
+	default:
+		return false, nil
+	}
+}
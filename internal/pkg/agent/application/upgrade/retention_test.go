@@ -0,0 +1,184 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/details"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func TestRetainInstall(t *testing.T) {
+	log, _ := loggertest.New(t.Name())
+	dataDir := t.TempDir()
+	retainedOn := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	install := func(version, hash string) RetainedInstall {
+		return RetainedInstall{
+			Version:       version,
+			Hash:          hash,
+			VersionedHome: filepath.Join("data", "elastic-agent-"+version+"-"+hash),
+			RetainedOn:    retainedOn,
+		}
+	}
+
+	installs, err := LoadRetainedInstalls(dataDir)
+	require.NoError(t, err)
+	assert.Empty(t, installs)
+
+	require.NoError(t, RetainInstall(log, dataDir, install("1.0.0", "aaaaaa"), "data/elastic-agent-1.1.0-bbbbbb", 2))
+	require.NoError(t, RetainInstall(log, dataDir, install("1.1.0", "bbbbbb"), "data/elastic-agent-1.2.0-cccccc", 2))
+	require.NoError(t, RetainInstall(log, dataDir, install("1.2.0", "cccccc"), "data/elastic-agent-1.3.0-dddddd", 2))
+
+	installs, err = LoadRetainedInstalls(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, []RetainedInstall{install("1.2.0", "cccccc"), install("1.1.0", "bbbbbb")}, installs, "only the 2 most recent installs are retained")
+
+	// upgrading again to a retained install removes it from the retained installs
+	require.NoError(t, RetainInstall(log, dataDir, install("1.3.0", "dddddd"), "data/elastic-agent-1.2.0-cccccc", 2))
+	installs, err = LoadRetainedInstalls(dataDir)
+	require.NoError(t, err)
+	assert.Equal(t, []RetainedInstall{install("1.3.0", "dddddd"), install("1.1.0", "bbbbbb")}, installs)
+
+	// no retention removes the retained installs
+	require.NoError(t, RetainInstall(log, dataDir, install("1.2.0", "cccccc"), "data/elastic-agent-1.4.0-eeeeee", 0))
+	assert.NoFileExists(t, filepath.Join(dataDir, retainedInstallsFilename))
+}
+
+func TestRetainedInstallDir(t *testing.T) {
+	assert.Equal(t, "elastic-agent-1.2.3-abcdef", RetainedInstall{Hash: "abcdef", VersionedHome: "data/elastic-agent-1.2.3-abcdef"}.dir())
+	assert.Equal(t, "elastic-agent-abcdef", RetainedInstall{Hash: "abcdef"}.dir(), "legacy installs are named after their hash")
+}
+
+func TestCleanupKeepsRetainedInstalls(t *testing.T) {
+	log, _ := loggertest.New(t.Name())
+	topDir := t.TempDir()
+	setupAgents(t, log, topDir, setupAgentInstallations{
+		installedAgents: []testAgentInstall{
+			{version: version123Snapshot, useVersionInPath: true},
+			{version: version456Snapshot, useVersionInPath: true},
+		},
+		upgradeFrom:  version123Snapshot,
+		upgradeTo:    version456Snapshot,
+		currentAgent: version456Snapshot,
+	})
+
+	dataDir := paths.DataFrom(topDir)
+	retained := RetainedInstall{
+		Version:       version123Snapshot.version,
+		Hash:          version123Snapshot.hash,
+		VersionedHome: filepath.Join("data", "elastic-agent-1.2.3-SNAPSHOT-abcdef"),
+	}
+	require.NoError(t, RetainInstall(log, dataDir, retained, "data/elastic-agent-4.5.6-SNAPSHOT-ghijkl", 1))
+
+	require.NoError(t, cleanup(log, topDir, "data/elastic-agent-4.5.6-SNAPSHOT-ghijkl", "ghijkl", true, false, 0))
+
+	assert.DirExists(t, filepath.Join(topDir, "data", "elastic-agent-4.5.6-SNAPSHOT-ghijkl"))
+	assert.DirExists(t, filepath.Join(topDir, "data", "elastic-agent-1.2.3-SNAPSHOT-abcdef"), "retained install should not be removed")
+	assert.FileExists(t, filepath.Join(dataDir, retainedInstallsFilename))
+}
+
+func TestRelinkRetainedErrors(t *testing.T) {
+	log, _ := loggertest.New(t.Name())
+	noWatcher := func() ([]int, error) { return nil, nil }
+	runningWatcher := func() ([]int, error) { return []int{1234}, nil }
+
+	t.Run("no retained install", func(t *testing.T) {
+		topDir := t.TempDir()
+		require.NoError(t, os.MkdirAll(paths.DataFrom(topDir), 0o750))
+
+		_, err := relinkRetained(log, topDir, "", noWatcher)
+		assert.ErrorIs(t, err, ErrNoRetainedInstall)
+	})
+
+	t.Run("version not retained", func(t *testing.T) {
+		topDir := t.TempDir()
+		dataDir := paths.DataFrom(topDir)
+		require.NoError(t, os.MkdirAll(dataDir, 0o750))
+		require.NoError(t, saveRetainedInstalls(dataDir, []RetainedInstall{{Version: "1.2.3", Hash: "abcdef"}}))
+
+		_, err := relinkRetained(log, topDir, "1.0.0", noWatcher)
+		assert.ErrorIs(t, err, ErrNoRetainedInstall)
+		assert.ErrorContains(t, err, "retained versions: [1.2.3]")
+	})
+
+	t.Run("retained install removed", func(t *testing.T) {
+		topDir := t.TempDir()
+		dataDir := paths.DataFrom(topDir)
+		require.NoError(t, os.MkdirAll(dataDir, 0o750))
+		require.NoError(t, saveRetainedInstalls(dataDir, []RetainedInstall{{Version: "1.2.3", Hash: "abcdef"}}))
+
+		_, err := relinkRetained(log, topDir, "1.2.3", noWatcher)
+		assert.ErrorContains(t, err, "retained install of version 1.2.3 is not usable")
+	})
+
+	t.Run("upgrade watched", func(t *testing.T) {
+		topDir := t.TempDir()
+		dataDir := paths.DataFrom(topDir)
+		require.NoError(t, os.MkdirAll(dataDir, 0o750))
+		marker := &UpdateMarker{
+			Version: "4.5.6",
+			Details: details.NewDetails("4.5.6", details.StateWatching, ""),
+		}
+		require.NoError(t, SaveMarker(dataDir, marker, true))
+
+		_, err := relinkRetained(log, topDir, "", noWatcher)
+		assert.ErrorIs(t, err, ErrRollbackUpgradeWatched)
+	})
+
+	t.Run("marker without details", func(t *testing.T) {
+		topDir := t.TempDir()
+		dataDir := paths.DataFrom(topDir)
+		require.NoError(t, os.MkdirAll(dataDir, 0o750))
+		require.NoError(t, SaveMarker(dataDir, &UpdateMarker{Version: "4.5.6"}, true))
+
+		_, err := relinkRetained(log, topDir, "", runningWatcher)
+		assert.ErrorIs(t, err, ErrRollbackUpgradeWatched, "the upgrade is watched while the watcher runs")
+
+		_, err = relinkRetained(log, topDir, "", noWatcher)
+		assert.ErrorIs(t, err, ErrNoRetainedInstall, "the upgrade is no longer watched once the watcher exited")
+
+		_, err = relinkRetained(log, topDir, "", func() ([]int, error) { return nil, errors.New("no processes") })
+		assert.ErrorContains(t, err, "failed to determine if upgrade watcher is running")
+	})
+}
+
+func TestCopyMissingActionStore(t *testing.T) {
+	log, _ := loggertest.New(t.Name())
+	currentHome := t.TempDir()
+	targetHome := t.TempDir()
+	actionStore := filepath.Base(paths.AgentActionStoreFile())
+	stateStore := filepath.Base(paths.AgentStateStoreFile())
+
+	require.NoError(t, os.WriteFile(filepath.Join(currentHome, actionStore), []byte("current actions"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(currentHome, stateStore), []byte("current state"), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(targetHome, stateStore), []byte("retained state"), 0o600))
+
+	require.NoError(t, copyMissingActionStore(log, currentHome, targetHome))
+
+	content, err := os.ReadFile(filepath.Join(targetHome, stateStore))
+	require.NoError(t, err)
+	assert.Equal(t, "retained state", string(content), "the retained install keeps its own copy")
+	content, err = os.ReadFile(filepath.Join(targetHome, actionStore))
+	require.NoError(t, err)
+	assert.Equal(t, "current actions", string(content), "missing stores are copied from the running install")
+	assert.NoFileExists(t, filepath.Join(targetHome, filepath.Base(paths.AgentStateStoreYmlFile())))
+}
+
+func TestFindRetainedInstall(t *testing.T) {
+	installs := []RetainedInstall{{Version: "1.2.0"}, {Version: "1.1.0"}}
+	assert.Equal(t, 0, findRetainedInstall(installs, ""))
+	assert.Equal(t, 1, findRetainedInstall(installs, "1.1.0"))
+	assert.Equal(t, -1, findRetainedInstall(installs, "1.0.0"))
+	assert.Equal(t, -1, findRetainedInstall(nil, ""))
+}
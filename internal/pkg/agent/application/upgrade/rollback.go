@@ -118,6 +118,8 @@ func cleanup(log *logger.Logger, topDirPath, currentVersionedHome, currentHash s
 		currentDir = fmt.Sprintf("%s-%s", agentName, currentHash)
 	}
 
+	retained := retainedDirs(log, dataDirPath)
+
 	var errs []error
 	for _, dir := range subdirs {
 		if dir == currentDir {
@@ -128,6 +130,11 @@ func cleanup(log *logger.Logger, topDirPath, currentVersionedHome, currentHash s
 			continue
 		}
 
+		if retained == nil || retained[dir] {
+			log.Infow("Keeping retained data directory", "file.path", filepath.Join(dataDirPath, dir))
+			continue
+		}
+
 		hashedDir := filepath.Join(dataDirPath, dir)
 		log.Infow("Removing hashed data directory", "file.path", hashedDir)
 		var ignoredDirs []string
@@ -41,6 +41,12 @@ type downloaderFactory func(*agtversion.ParsedSemVer, *logger.Logger, *artifact.
 type downloader func(context.Context, downloaderFactory, *agtversion.ParsedSemVer, *artifact.Config, *details.Details) (string, error)
 
 func (u *Upgrader) downloadArtifact(ctx context.Context, parsedVersion *agtversion.ParsedSemVer, sourceURI string, upgradeDetails *details.Details, skipVerifyOverride, skipDefaultPgp bool, pgpBytes ...string) (_ string, err error) {
+	// do not update source config
+	return u.downloadArtifactWithSettings(ctx, *u.settings, parsedVersion, sourceURI, upgradeDetails, skipVerifyOverride, skipDefaultPgp, pgpBytes...)
+}
+
+// downloadArtifactWithSettings downloads and verifies the artifact with a copy of the settings of the upgrader.
+func (u *Upgrader) downloadArtifactWithSettings(ctx context.Context, settings artifact.Config, parsedVersion *agtversion.ParsedSemVer, sourceURI string, upgradeDetails *details.Details, skipVerifyOverride, skipDefaultPgp bool, pgpBytes ...string) (_ string, err error) {
 	span, ctx := apm.StartSpan(ctx, "downloadArtifact", "app.internal")
 	defer func() {
 		apm.CaptureError(ctx, err).Send()
@@ -49,8 +55,6 @@ func (u *Upgrader) downloadArtifact(ctx context.Context, parsedVersion *agtversi
 
 	pgpBytes = u.appendFallbackPGP(parsedVersion, pgpBytes)
 
-	// do not update source config
-	settings := *u.settings
 	var downloaderFunc downloader
 	var factory downloaderFactory
 	var verifier download.Verifier
@@ -149,7 +153,7 @@ func (u *Upgrader) appendFallbackPGP(targetVersion *agtversion.ParsedSemVer, pgp
 }
 
 func newDownloader(version *agtversion.ParsedSemVer, log *logger.Logger, settings *artifact.Config, upgradeDetails *details.Details) (download.Downloader, error) {
-	if !version.IsSnapshot() {
+	if !version.IsSnapshot() || artifact.IsOCISourceURI(settings.SourceURI) {
 		return localremote.NewDownloader(log, settings, upgradeDetails)
 	}
 
@@ -166,13 +170,16 @@ func newDownloader(version *agtversion.ParsedSemVer, log *logger.Logger, setting
 		return nil, err
 	}
 
+	if settings.PeerCache.HasPeers() {
+		return composed.NewDownloader(fs.NewDownloader(settings), composed.NewPeerDownloader(log, settings, upgradeDetails), snapDownloader, httpDownloader), nil
+	}
 	return composed.NewDownloader(fs.NewDownloader(settings), snapDownloader, httpDownloader), nil
 }
 
 func newVerifier(version *agtversion.ParsedSemVer, log *logger.Logger, settings *artifact.Config) (download.Verifier, error) {
 	pgp := release.PGP()
 
-	if !version.IsSnapshot() {
+	if !version.IsSnapshot() || artifact.IsOCISourceURI(settings.SourceURI) {
 		return localremote.NewVerifier(log, settings, pgp)
 	}
 
@@ -217,12 +224,60 @@ func (u *Upgrader) downloadOnce(
 	return path, nil
 }
 
+// downloadWithRetries downloads the artifact, retrying until the download timeout.
+// Outside of the configured download windows the download is paused until the next
+// window opens and retried from then on, with a new timeout.
 func (u *Upgrader) downloadWithRetries(
 	ctx context.Context,
 	factory downloaderFactory,
 	version *agtversion.ParsedSemVer,
 	settings *artifact.Config,
 	upgradeDetails *details.Details,
+) (string, error) {
+	upgradeDetails.SetDownloadRateLimit(float64(settings.RateLimit))
+	for {
+		if err := u.waitForDownloadWindow(ctx, settings, upgradeDetails); err != nil {
+			return "", err
+		}
+
+		path, err := u.downloadWithRetriesInWindow(ctx, factory, version, settings, upgradeDetails)
+		if errors.Is(err, download.ErrOutsideDownloadWindow) {
+			u.log.Infof("download paused: %v", err)
+			continue
+		}
+		return path, err
+	}
+}
+
+// waitForDownloadWindow waits until the artifacts can be downloaded.
+func (u *Upgrader) waitForDownloadWindow(ctx context.Context, settings *artifact.Config, upgradeDetails *details.Details) error {
+	now := time.Now()
+	opens, _ := settings.DownloadWindowAt(now)
+	if !opens.After(now) {
+		return nil
+	}
+
+	u.log.Infof("download of the upgrade artifact waits for the download window opening at %s", opens.Format(time.RFC3339))
+	upgradeDetails.SetRetryUntil(nil)
+	upgradeDetails.SetDownloadWindowOpensAt(&opens)
+	defer upgradeDetails.SetDownloadWindowOpensAt(nil)
+
+	t := time.NewTimer(opens.Sub(now))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-t.C:
+		return nil
+	}
+}
+
+func (u *Upgrader) downloadWithRetriesInWindow(
+	ctx context.Context,
+	factory downloaderFactory,
+	version *agtversion.ParsedSemVer,
+	settings *artifact.Config,
+	upgradeDetails *details.Details,
 ) (string, error) {
 	cancelDeadline := time.Now().Add(settings.Timeout)
 	cancelCtx, cancel := context.WithDeadline(ctx, cancelDeadline)
@@ -242,6 +297,10 @@ func (u *Upgrader) downloadWithRetries(
 		u.log.Infof("download attempt %d", attempt)
 		var err error
 		path, err = u.downloadOnce(cancelCtx, factory, version, settings, upgradeDetails)
+		if errors.Is(err, download.ErrOutsideDownloadWindow) {
+			// retried once the next window opens
+			return backoff.Permanent(err)
+		}
 		if err != nil {
 			return err
 		}
@@ -307,3 +307,50 @@ func mockUpgradeDetails(parsedVersion *agtversion.ParsedSemVer) (*details.Detail
 		&upgradeDetailsRetryUntil, &upgradeDetailsRetryUntilWasUnset,
 		&upgradeDetailsRetryErrorMsg
 }
+
+func TestDownloadWithRetriesOutsideDownloadWindow(t *testing.T) {
+	testLogger, _ := loggertest.New("TestDownloadWithRetriesOutsideDownloadWindow")
+
+	// the window opens in 2 hours
+	now := time.Now()
+	settings := artifact.Config{
+		RetrySleepInitDuration: 20 * time.Millisecond,
+		HTTPTransportSettings: httpcommon.HTTPTransportSettings{
+			Timeout: 2 * time.Second,
+		},
+		Windows: []artifact.DownloadWindow{{
+			Start: now.Add(2 * time.Hour).Format("15:04"),
+			End:   now.Add(3 * time.Hour).Format("15:04"),
+		}},
+	}
+
+	downloaderCreated := false
+	mockDownloaderCtor := func(version *agtversion.ParsedSemVer, log *logger.Logger, settings *artifact.Config, upgradeDetails *details.Details) (download.Downloader, error) {
+		downloaderCreated = true
+		return &mockDownloader{"", nil}, nil
+	}
+
+	u, err := NewUpgrader(testLogger, &settings, &info.AgentInfo{})
+	require.NoError(t, err)
+
+	parsedVersion, err := agtversion.ParseVersion("8.9.0")
+	require.NoError(t, err)
+
+	upgradeDetails := details.NewDetails(parsedVersion.String(), details.StateRequested, "")
+	var windowOpensAt *time.Time
+	upgradeDetails.RegisterObserver(func(details *details.Details) {
+		if details != nil && details.Metadata.DownloadWindowOpensAt != nil {
+			windowOpensAt = details.Metadata.DownloadWindowOpensAt
+		}
+	})
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	_, err = u.downloadWithRetries(ctx, mockDownloaderCtor, parsedVersion, &settings, upgradeDetails)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	require.False(t, downloaderCreated, "download must wait for the window")
+	require.NotNil(t, windowOpensAt)
+	require.WithinDuration(t, now.Add(2*time.Hour), *windowOpensAt, time.Minute)
+	require.Nil(t, upgradeDetails.Metadata.DownloadWindowOpensAt)
+}
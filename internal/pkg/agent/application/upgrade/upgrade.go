@@ -171,6 +171,16 @@ func (av agentVersion) String() string {
 	return buf.String()
 }
 
+// runningAgentVersion returns the version of the running agent.
+func runningAgentVersion() agentVersion {
+	return agentVersion{
+		version:  release.Version(),
+		snapshot: release.Snapshot(),
+		hash:     release.Commit(),
+		fips:     release.FIPSDistribution(),
+	}
+}
+
 func checkUpgrade(log *logger.Logger, currentVersion, newVersion agentVersion, metadata packageMetadata) error {
 	// Compare the downloaded version (including git hash) to see if we need to upgrade
 	// versions are the same if the numbers and hash match which may occur in a SNAPSHOT -> SNAPSHOT upgrage
@@ -197,12 +207,7 @@ func checkUpgrade(log *logger.Logger, currentVersion, newVersion agentVersion, m
 func (u *Upgrader) Upgrade(ctx context.Context, version string, sourceURI string, action *fleetapi.ActionUpgrade, det *details.Details, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) (_ reexec.ShutdownCallbackFn, err error) {
 	u.log.Infow("Upgrading agent", "version", version, "source_uri", sourceURI)
 
-	currentVersion := agentVersion{
-		version:  release.Version(),
-		snapshot: release.Snapshot(),
-		hash:     release.Commit(),
-		fips:     release.FIPSDistribution(),
-	}
+	currentVersion := runningAgentVersion()
 
 	// Compare versions and exit before downloading anything if the upgrade
 	// is for the same release version that is currently running
@@ -245,7 +250,7 @@ func (u *Upgrader) Upgrade(ctx context.Context, version string, sourceURI string
 			u.log.Errorw("Unable to remove file after verification failure", "error.message", dErr)
 		}
 
-		return nil, err
+		return nil, ErrorWithCode(err, fleetapi.ErrorCodeUpgradeDownloadFailed)
 	}
 
 	det.SetState(details.StateExtracting)
@@ -260,6 +265,13 @@ func (u *Upgrader) Upgrade(ctx context.Context, version string, sourceURI string
 		return nil, fmt.Errorf("cannot upgrade the agent: %w", err)
 	}
 
+	// last checks before modifying the installation
+	report := &PreflightReport{}
+	u.preflightInstall(report, archivePath, paths.Top(), paths.Data())
+	if err := report.Err(); err != nil {
+		return nil, fmt.Errorf("cannot upgrade the agent: %w", err)
+	}
+
 	u.log.Infow("Unpacking agent package", "version", newVersion)
 
 	// Nice to have: add check that no archive files end up in the current versioned home
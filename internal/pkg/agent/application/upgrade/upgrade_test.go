@@ -432,6 +432,7 @@ agent.download:
 		InstallPath:            "/sonic_screwdriver",
 		DropPath:               "/gallifrey",
 		RetrySleepInitDuration: 10 * time.Second,
+		PeerCache:              artifact.DefaultPeerCacheConfig(),
 
 		HTTPTransportSettings: httpcommon.HTTPTransportSettings{
 			TLS: &tlscommon.Config{
@@ -47,6 +47,9 @@ type AgentWatcher struct {
 	log           *logger.Logger
 	agentClient   client.Client
 	checkInterval time.Duration
+
+	criteria HealthCriteria
+	health   *healthTracker
 }
 
 // NewAgentWatcher creates a new agent watcher.
@@ -62,6 +65,7 @@ func NewAgentWatcher(ch chan error, log *logger.Logger, checkInterval time.Durat
 		agentClient:   c,
 		log:           log,
 		checkInterval: checkInterval,
+		health:        newHealthTracker(HealthCriteria{}),
 	}
 	return ec
 }
@@ -127,6 +131,13 @@ func (ch *AgentWatcher) Run(ctx context.Context) {
 		}
 	}()
 
+	if ch.criteria.hasDeadline() {
+		go ch.checkDeadline(ctx)
+	}
+	if ch.criteria.HTTPProbe != nil {
+		go ch.runHTTPProbe(ctx)
+	}
+
 LOOP:
 	for {
 		ch.lastPid = -1
@@ -145,6 +156,7 @@ LOOP:
 			connectCancel()
 			if err != nil {
 				ch.connectCounter++
+				ch.health.setLost(ErrCannotConnect)
 				ch.log.Errorf("Failed connecting to running daemon: %s", err)
 				if ch.checkFailures() {
 					return
@@ -161,6 +173,7 @@ LOOP:
 				ch.agentClient.Disconnect()
 				ch.log.Errorf("Failed to start state watch: %s", err)
 				ch.connectCounter++
+				ch.health.setLost(ErrCannotConnect)
 				if ch.checkFailures() {
 					return
 				}
@@ -193,6 +206,7 @@ LOOP:
 					ch.agentClient.Disconnect()
 					ch.log.Errorf("Lost connection: failed reading next state: %s", err)
 					ch.lostCounter++
+					ch.health.setLost(ErrLostConnection)
 					if ch.checkFailures() {
 						return
 					}
@@ -220,9 +234,15 @@ LOOP:
 					}
 				}
 
+				// required components that are not healthy are only a failure once the
+				// deadline to become healthy is passed
+				requiredErr := ch.criteria.requiredComponentsErr(state)
+
 				if state.State == client.Failed {
 					// top-level failure (something is really wrong)
-					failedCh <- fmt.Errorf("%w: %s", ErrAgentStatusFailed, state.Message)
+					err := fmt.Errorf("%w: %s", ErrAgentStatusFailed, state.Message)
+					ch.health.setState(err, requiredErr)
+					failedCh <- err
 					continue
 				} else {
 					// agent is healthy; but a component might not be healthy
@@ -234,11 +254,19 @@ LOOP:
 						}
 					}
 					if len(errs) != 0 {
-						failedCh <- fmt.Errorf("%w: %w", ErrAgentComponentFailed, errors.Join(errs...))
+						err := fmt.Errorf("%w: %w", ErrAgentComponentFailed, errors.Join(errs...))
+						ch.health.setState(err, requiredErr)
+						failedCh <- err
 						continue
 					}
 				}
 
+				ch.health.setState(nil, requiredErr)
+				if requiredErr != nil && ch.criteria.pastDeadline(time.Now()) {
+					failedCh <- requiredErr
+					continue
+				}
+
 				// nothing is failed
 				failedCh <- nil
 			}
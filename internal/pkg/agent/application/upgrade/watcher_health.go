@@ -0,0 +1,310 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+var (
+	// ErrAgentNotHealthy is returned when agent does not meet the health criteria by the deadline.
+	ErrAgentNotHealthy = errors.New("agent did not meet the health criteria")
+	// ErrAgentNotStable is returned when agent did not meet the health criteria long enough.
+	ErrAgentNotStable = errors.New("agent was not stable")
+	// ErrRequiredComponentNotHealthy is returned when a required component or unit is not HEALTHY.
+	ErrRequiredComponentNotHealthy = errors.New("required component(s) not healthy")
+	// ErrHTTPProbeFailed is returned when the HTTP probe of the agent fails.
+	ErrHTTPProbeFailed = errors.New("HTTP probe failed")
+
+	errNoAgentState = errors.New("no state received from agent")
+	errNoHTTPProbe  = errors.New("HTTP probe did not run")
+)
+
+// HealthCriteria are the criteria the upgraded Agent must meet, in addition to not
+// failing, for the upgrade to succeed.
+type HealthCriteria struct {
+	// RequiredComponents are the IDs of the components, or of their units as
+	// <component id>/<unit id>, that must be HEALTHY by the deadline.
+	RequiredComponents []string
+	// HTTPProbe is an HTTP endpoint that must respond with a 2xx status by the deadline.
+	HTTPProbe *configuration.UpgradeWatcherHTTPProbeConfig
+	// Deadline is when the required components and the HTTP probe must be healthy.
+	Deadline time.Time
+	// MinStableDuration is the time the Agent must have met the criteria without
+	// interruption at the end of the grace period, as observed by this watcher.
+	MinStableDuration time.Duration
+}
+
+// NewHealthCriteria returns the health criteria of the upgrade performed at upgradedOn.
+// The deadline is capped by the grace period, after which the Agent is no longer watched.
+// The minimum stable duration is capped by the time left in the grace period, a watcher
+// restarted during the grace period cannot observe the Agent for longer than that.
+func NewHealthCriteria(cfg configuration.UpgradeWatcherHealthConfig, upgradedOn time.Time, gracePeriod time.Duration) HealthCriteria {
+	return newHealthCriteria(cfg, upgradedOn, gracePeriod, time.Now())
+}
+
+func newHealthCriteria(cfg configuration.UpgradeWatcherHealthConfig, upgradedOn time.Time, gracePeriod time.Duration, now time.Time) HealthCriteria {
+	deadline := cfg.Deadline
+	if deadline <= 0 || deadline > gracePeriod {
+		deadline = gracePeriod
+	}
+	minStable := cfg.MinStableDuration
+	if left := upgradedOn.Add(gracePeriod).Sub(now); minStable > left {
+		minStable = max(left, 0)
+	}
+	return HealthCriteria{
+		RequiredComponents: cfg.RequiredComponents,
+		HTTPProbe:          cfg.HTTPProbe,
+		Deadline:           upgradedOn.Add(deadline),
+		MinStableDuration:  minStable,
+	}
+}
+
+// hasDeadline returns true when some criteria must be met by the deadline.
+func (c HealthCriteria) hasDeadline() bool {
+	return len(c.RequiredComponents) > 0 || c.HTTPProbe != nil
+}
+
+// pastDeadline returns true when the criteria with a deadline must be met at now.
+func (c HealthCriteria) pastDeadline(now time.Time) bool {
+	return c.hasDeadline() && !now.Before(c.Deadline)
+}
+
+// requiredComponentsErr returns an error listing the required components and units
+// that are not HEALTHY in state, nil when they all are.
+func (c HealthCriteria) requiredComponentsErr(state *client.AgentState) error {
+	var errs []error
+	for _, required := range c.RequiredComponents {
+		compID, unitID, isUnit := strings.Cut(required, "/")
+		comp := findComponent(state, compID)
+		if comp == nil {
+			errs = append(errs, fmt.Errorf("component %s is not running", compID))
+			continue
+		}
+		if !isUnit {
+			if comp.State != client.Healthy {
+				errs = append(errs, fmt.Errorf("component %s is %s: %s", compID, comp.State, comp.Message))
+			}
+			continue
+		}
+		unit := findUnit(comp, unitID)
+		if unit == nil {
+			errs = append(errs, fmt.Errorf("unit %s of component %s is not running", unitID, compID))
+			continue
+		}
+		if unit.State != client.Healthy {
+			errs = append(errs, fmt.Errorf("unit %s of component %s is %s: %s", unitID, compID, unit.State, unit.Message))
+		}
+	}
+	if len(errs) == 0 {
+		return nil
+	}
+	return fmt.Errorf("%w: %w", ErrRequiredComponentNotHealthy, errors.Join(errs...))
+}
+
+func findComponent(state *client.AgentState, id string) *client.ComponentState {
+	for i := range state.Components {
+		if state.Components[i].ID == id {
+			return &state.Components[i]
+		}
+	}
+	return nil
+}
+
+func findUnit(comp *client.ComponentState, id string) *client.ComponentUnitState {
+	for i := range comp.Units {
+		if comp.Units[i].UnitID == id {
+			return &comp.Units[i]
+		}
+	}
+	return nil
+}
+
+// healthTracker tracks whether the Agent meets the health criteria and since when.
+type healthTracker struct {
+	mu           sync.Mutex
+	stateErr     error
+	requiredErr  error
+	probeErr     error
+	healthySince time.Time
+}
+
+func newHealthTracker(criteria HealthCriteria) *healthTracker {
+	h := &healthTracker{stateErr: errNoAgentState}
+	if len(criteria.RequiredComponents) > 0 {
+		h.requiredErr = errNoAgentState
+	}
+	if criteria.HTTPProbe != nil {
+		h.probeErr = errNoHTTPProbe
+	}
+	return h
+}
+
+// setState records the failure of the Agent and the health of its required components.
+func (h *healthTracker) setState(stateErr, requiredErr error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateErr = stateErr
+	h.requiredErr = requiredErr
+	h.update()
+}
+
+// setLost records that the connection to the Agent is lost.
+func (h *healthTracker) setLost(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.stateErr = err
+	h.update()
+}
+
+func (h *healthTracker) setProbeErr(err error) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.probeErr = err
+	h.update()
+}
+
+func (h *healthTracker) update() {
+	if h.stateErr != nil || h.requiredErr != nil || h.probeErr != nil {
+		h.healthySince = time.Time{}
+		return
+	}
+	if h.healthySince.IsZero() {
+		h.healthySince = time.Now()
+	}
+}
+
+// criteriaErr returns why the required components or the HTTP probe are not healthy,
+// nil when they are.
+func (h *healthTracker) criteriaErr() error {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	return errors.Join(h.requiredErr, h.probeErr)
+}
+
+// stableFor returns for how long the Agent has met all the criteria, 0 when it does not.
+func (h *healthTracker) stableFor(now time.Time) time.Duration {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if h.healthySince.IsZero() {
+		return 0
+	}
+	return now.Sub(h.healthySince)
+}
+
+// SetHealthCriteria sets the health criteria the Agent must meet. It must be called
+// before Run.
+func (ch *AgentWatcher) SetHealthCriteria(criteria HealthCriteria) {
+	ch.criteria = criteria
+	ch.health = newHealthTracker(criteria)
+}
+
+// CheckHealth returns an error when the Agent does not meet the health criteria, or has
+// not met them for the minimum stable duration. It is called at the end of the grace
+// period, to decide if the upgrade succeeded.
+func (ch *AgentWatcher) CheckHealth() error {
+	if err := ch.health.criteriaErr(); err != nil {
+		return fmt.Errorf("%w: %w", ErrAgentNotHealthy, err)
+	}
+	if ch.criteria.MinStableDuration > 0 {
+		if stable := ch.health.stableFor(time.Now()); stable < ch.criteria.MinStableDuration {
+			return fmt.Errorf("%w for %s, only for %s", ErrAgentNotStable, ch.criteria.MinStableDuration, stable.Round(time.Second))
+		}
+	}
+	return nil
+}
+
+// checkDeadline reports an error when the required components or the HTTP probe are not
+// healthy at the deadline.
+func (ch *AgentWatcher) checkDeadline(ctx context.Context) {
+	t := time.NewTimer(time.Until(ch.criteria.Deadline))
+	defer t.Stop()
+	select {
+	case <-ctx.Done():
+		return
+	case <-t.C:
+	}
+	if err := ch.health.criteriaErr(); err != nil {
+		err = fmt.Errorf("%w by %s: %w", ErrAgentNotHealthy, ch.criteria.Deadline.Format(time.RFC3339), err)
+		ch.log.Error(err)
+		ch.notify(ctx, err)
+	}
+}
+
+// runHTTPProbe requests the HTTP probe every interval. Failures are allowed before the
+// deadline, while the Agent starts, after it the probe must not fail for longer than
+// the check interval.
+func (ch *AgentWatcher) runHTTPProbe(ctx context.Context) {
+	probe := ch.criteria.HTTPProbe
+	c := &http.Client{Timeout: probe.Timeout}
+	t := time.NewTicker(probe.Interval)
+	defer t.Stop()
+
+	var failingSince time.Time
+	for {
+		err := httpProbe(ctx, c, probe.URL)
+		ch.health.setProbeErr(err)
+		now := time.Now()
+		switch {
+		case err == nil:
+			if !failingSince.IsZero() {
+				ch.log.Info("HTTP probe succeeded")
+			}
+			failingSince = time.Time{}
+		case failingSince.IsZero():
+			ch.log.Errorf("HTTP probe failed: %s", err)
+			failingSince = now
+		}
+		if err != nil && ch.criteria.pastDeadline(now) {
+			since := failingSince
+			if since.Before(ch.criteria.Deadline) {
+				since = ch.criteria.Deadline
+			}
+			if now.Sub(since) >= ch.checkInterval {
+				ch.notify(ctx, fmt.Errorf("%w for more than %s: %w", ErrHTTPProbeFailed, ch.checkInterval, err))
+				return
+			}
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
+
+func httpProbe(ctx context.Context, c *http.Client, url string) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	_ = resp.Body.Close()
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		return fmt.Errorf("GET %s returned %s", url, resp.Status)
+	}
+	return nil
+}
+
+// notify reports err unless the watcher is stopped.
+func (ch *AgentWatcher) notify(ctx context.Context, err error) {
+	select {
+	case ch.notifyChan <- err:
+	case <-ctx.Done():
+	}
+}
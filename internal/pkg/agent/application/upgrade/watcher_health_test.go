@@ -0,0 +1,297 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package upgrade
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/control/v2/cproto"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func TestNewHealthCriteria(t *testing.T) {
+	upgradedOn := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+
+	criteria := NewHealthCriteria(configuration.UpgradeWatcherHealthConfig{}, upgradedOn, 10*time.Minute)
+	assert.Equal(t, upgradedOn.Add(10*time.Minute), criteria.Deadline)
+	assert.False(t, criteria.hasDeadline())
+
+	criteria = NewHealthCriteria(configuration.UpgradeWatcherHealthConfig{
+		RequiredComponents: []string{"endpoint-default"},
+		Deadline:           5 * time.Minute,
+	}, upgradedOn, 10*time.Minute)
+	assert.Equal(t, upgradedOn.Add(5*time.Minute), criteria.Deadline)
+	assert.True(t, criteria.hasDeadline())
+
+	// the deadline cannot exceed the grace period
+	criteria = NewHealthCriteria(configuration.UpgradeWatcherHealthConfig{Deadline: time.Hour}, upgradedOn, 10*time.Minute)
+	assert.Equal(t, upgradedOn.Add(10*time.Minute), criteria.Deadline)
+}
+
+func TestNewHealthCriteria_MinStableDuration(t *testing.T) {
+	upgradedOn := time.Date(2024, 5, 1, 9, 0, 0, 0, time.UTC)
+	cfg := configuration.UpgradeWatcherHealthConfig{MinStableDuration: 5 * time.Minute}
+
+	criteria := newHealthCriteria(cfg, upgradedOn, 10*time.Minute, upgradedOn.Add(time.Second))
+	assert.Equal(t, 5*time.Minute, criteria.MinStableDuration)
+
+	// a watcher restarted during the grace period can only observe the time left in it
+	criteria = newHealthCriteria(cfg, upgradedOn, 10*time.Minute, upgradedOn.Add(8*time.Minute))
+	assert.Equal(t, 2*time.Minute, criteria.MinStableDuration)
+
+	criteria = newHealthCriteria(cfg, upgradedOn, 10*time.Minute, upgradedOn.Add(time.Hour))
+	assert.Zero(t, criteria.MinStableDuration)
+}
+
+func TestHealthCriteria_RequiredComponentsErr(t *testing.T) {
+	state := &client.AgentState{
+		Components: []client.ComponentState{
+			{
+				ID:    "endpoint-default",
+				State: client.Healthy,
+				Units: []client.ComponentUnitState{
+					{UnitID: "endpoint-default", State: client.Healthy},
+					{UnitID: "endpoint-default-unit", State: client.Degraded, Message: "degraded"},
+				},
+			},
+			{ID: "fleet-server-default", State: client.Starting, Message: "starting"},
+		},
+	}
+
+	tests := map[string]struct {
+		required []string
+		errMsg   string
+	}{
+		"none required": {},
+		"healthy component": {
+			required: []string{"endpoint-default"},
+		},
+		"healthy unit": {
+			required: []string{"endpoint-default/endpoint-default"},
+		},
+		"component not healthy": {
+			required: []string{"fleet-server-default"},
+			errMsg:   "component fleet-server-default is STARTING: starting",
+		},
+		"unit not healthy": {
+			required: []string{"endpoint-default/endpoint-default-unit"},
+			errMsg:   "unit endpoint-default-unit of component endpoint-default is DEGRADED: degraded",
+		},
+		"component not running": {
+			required: []string{"apm-default"},
+			errMsg:   "component apm-default is not running",
+		},
+		"unit not running": {
+			required: []string{"endpoint-default/missing"},
+			errMsg:   "unit missing of component endpoint-default is not running",
+		},
+	}
+	for name, tc := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := HealthCriteria{RequiredComponents: tc.required}.requiredComponentsErr(state)
+			if tc.errMsg == "" {
+				assert.NoError(t, err)
+				return
+			}
+			assert.ErrorIs(t, err, ErrRequiredComponentNotHealthy)
+			assert.ErrorContains(t, err, tc.errMsg)
+		})
+	}
+}
+
+func TestAgentWatcher_CheckHealth(t *testing.T) {
+	logger, _ := loggertest.New("watcher")
+
+	w := NewAgentWatcher(make(chan error), logger, time.Second)
+	w.SetHealthCriteria(HealthCriteria{RequiredComponents: []string{"endpoint-default"}})
+	assert.ErrorIs(t, w.CheckHealth(), ErrAgentNotHealthy, "no state was received")
+
+	w.health.setState(nil, nil)
+	assert.NoError(t, w.CheckHealth())
+
+	w.SetHealthCriteria(HealthCriteria{MinStableDuration: time.Minute})
+	w.health.setState(nil, nil)
+	assert.ErrorIs(t, w.CheckHealth(), ErrAgentNotStable)
+
+	w.health.healthySince = time.Now().Add(-2 * time.Minute)
+	assert.NoError(t, w.CheckHealth())
+
+	// any interruption resets the stable duration
+	w.health.setLost(ErrLostConnection)
+	w.health.setState(nil, nil)
+	assert.ErrorIs(t, w.CheckHealth(), ErrAgentNotStable)
+}
+
+func TestWatcher_RequiredComponentNotHealthy(t *testing.T) {
+	// timeout ensures that if it doesn't work; it doesn't block forever
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	errCh := make(chan error)
+	logger, _ := loggertest.New("watcher")
+	w := NewAgentWatcher(errCh, logger, 100*time.Millisecond)
+	w.SetHealthCriteria(HealthCriteria{
+		RequiredComponents: []string{"endpoint-default"},
+		Deadline:           time.Now().Add(500 * time.Millisecond),
+	})
+
+	// the agent is healthy, but the required component never is
+	mockHandler := func(srv cproto.ElasticAgentControl_StateWatchServer) error {
+		err := srv.Send(&cproto.StateResponse{
+			Info:    &cproto.StateAgentInfo{},
+			State:   cproto.State_HEALTHY,
+			Message: "healthy",
+			Components: []*cproto.ComponentState{
+				{
+					Id:      "endpoint-default",
+					Name:    "endpoint",
+					State:   cproto.State_STARTING,
+					Message: "starting",
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		// keep open until end (exiting will count as a lost connection)
+		<-ctx.Done()
+		return nil
+	}
+	mock := &mockDaemon{watch: mockHandler}
+	require.NoError(t, mock.Start())
+	defer mock.Stop()
+
+	// set client to mock; before running
+	w.agentClient = mock.Client()
+	go w.Run(ctx)
+
+	select {
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	case err := <-errCh:
+		assert.ErrorIs(t, err, ErrAgentNotHealthy)
+		assert.ErrorIs(t, err, ErrRequiredComponentNotHealthy)
+	}
+}
+
+func TestWatcher_RequiredComponentHealthy(t *testing.T) {
+	// test tests for success, which only happens when no error comes in
+	// during this time period
+	ctx, cancel := context.WithTimeout(context.Background(), 1*time.Second)
+	defer cancel()
+
+	errCh := make(chan error)
+	logger, _ := loggertest.New("watcher")
+	w := NewAgentWatcher(errCh, logger, 100*time.Millisecond)
+	w.SetHealthCriteria(HealthCriteria{
+		RequiredComponents: []string{"endpoint-default/endpoint-default"},
+		Deadline:           time.Now().Add(500 * time.Millisecond),
+	})
+
+	mockHandler := func(srv cproto.ElasticAgentControl_StateWatchServer) error {
+		err := srv.Send(&cproto.StateResponse{
+			Info:    &cproto.StateAgentInfo{},
+			State:   cproto.State_HEALTHY,
+			Message: "healthy",
+			Components: []*cproto.ComponentState{
+				{
+					Id:      "endpoint-default",
+					Name:    "endpoint",
+					State:   cproto.State_HEALTHY,
+					Message: "healthy",
+					Units: []*cproto.ComponentUnitState{
+						{
+							UnitId:  "endpoint-default",
+							State:   cproto.State_HEALTHY,
+							Message: "healthy",
+						},
+					},
+				},
+			},
+		})
+		if err != nil {
+			return err
+		}
+		// keep open until end (exiting will count as a lost connection)
+		<-ctx.Done()
+		return nil
+	}
+	mock := &mockDaemon{watch: mockHandler}
+	require.NoError(t, mock.Start())
+	defer mock.Stop()
+
+	// set client to mock; before running
+	w.agentClient = mock.Client()
+	go w.Run(ctx)
+
+	select {
+	case <-ctx.Done():
+		require.ErrorIs(t, ctx.Err(), context.DeadlineExceeded)
+	case err := <-errCh:
+		assert.NoError(t, err, "error should not have been reported")
+	}
+	assert.NoError(t, w.CheckHealth())
+}
+
+func TestWatcher_HTTPProbeFailed(t *testing.T) {
+	// timeout ensures that if it doesn't work; it doesn't block forever
+	ctx, cancel := context.WithTimeout(context.Background(), 3*time.Second)
+	defer cancel()
+
+	probe := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer probe.Close()
+
+	errCh := make(chan error)
+	logger, _ := loggertest.New("watcher")
+	w := NewAgentWatcher(errCh, logger, 100*time.Millisecond)
+	w.SetHealthCriteria(HealthCriteria{
+		HTTPProbe: &configuration.UpgradeWatcherHTTPProbeConfig{
+			URL:      probe.URL,
+			Interval: 50 * time.Millisecond,
+			Timeout:  time.Second,
+		},
+		Deadline: time.Now().Add(300 * time.Millisecond),
+	})
+
+	mockHandler := func(srv cproto.ElasticAgentControl_StateWatchServer) error {
+		err := srv.Send(&cproto.StateResponse{
+			Info:    &cproto.StateAgentInfo{},
+			State:   cproto.State_HEALTHY,
+			Message: "healthy",
+		})
+		if err != nil {
+			return err
+		}
+		// keep open until end (exiting will count as a lost connection)
+		<-ctx.Done()
+		return nil
+	}
+	mock := &mockDaemon{watch: mockHandler}
+	require.NoError(t, mock.Start())
+	defer mock.Stop()
+
+	// set client to mock; before running
+	w.agentClient = mock.Client()
+	go w.Run(ctx)
+
+	select {
+	case <-ctx.Done():
+		require.NoError(t, ctx.Err())
+	case err := <-errCh:
+		assert.ErrorIs(t, err, ErrAgentNotHealthy)
+		assert.ErrorContains(t, err, "503 Service Unavailable")
+	}
+}
@@ -0,0 +1,366 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+// Package backup exports and imports the identity and the state of the agent.
+package backup
+
+import (
+	"archive/tar"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"runtime"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/perms"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/crypto"
+	"github.com/elastic/elastic-agent/internal/pkg/release"
+	"github.com/elastic/elastic-agent/pkg/utils"
+	"github.com/elastic/elastic-agent/pkg/version"
+)
+
+const (
+	// formatVersion is the version of the layout of the archives
+	formatVersion = 1
+
+	manifestName       = "manifest.json"
+	agentSecretName    = "vault/secret.json"
+	previousSecretName = "vault/secret.previous.json"
+
+	// maxEntrySize is the maximum size of the entries of the archives
+	maxEntrySize = 256 << 20
+)
+
+// currentVersion returns the version of the running agent, overridden in tests.
+var currentVersion = release.Version
+
+// Manifest describes the content of a backup archive.
+type Manifest struct {
+	FormatVersion int       `json:"format_version"`
+	AgentID       string    `json:"agent_id"`
+	Version       string    `json:"version"`
+	Commit        string    `json:"commit"`
+	OS            string    `json:"os"`
+	CreatedAt     time.Time `json:"created_at"`
+	Files         []string  `json:"files"`
+}
+
+// File is a file of the agent included in the backups.
+type File struct {
+	// Name is the name of the file in the archive
+	Name string
+	// Path is the path of the file on disk
+	Path string
+}
+
+// Files returns the files of the agent included in the backups: the configuration and the stores
+// encrypted with the agent key. The upgrade marker is not included, it refers to the installs of
+// the backed up host.
+func Files() []File {
+	return []File{
+		{Name: "config/elastic-agent.yml", Path: paths.ConfigFile()},
+		{Name: "config/fleet.enc", Path: paths.AgentConfigFile()},
+		{Name: "config/secrets.enc", Path: paths.SecretsStoreFile()},
+		{Name: "data/state.enc", Path: paths.AgentStateStoreFile()},
+		{Name: "data/ack_journal.enc", Path: paths.AckJournalFile()},
+		{Name: "data/local_action_store.enc", Path: paths.LocalActionsStateFile()},
+	}
+}
+
+// Backup writes the archive of the files and of the agent key, encrypted with the passphrase, to w.
+// The files that do not exist are skipped.
+func Backup(ctx context.Context, w io.Writer, passphrase []byte, agentID string, files []File, opts ...vault.OptionFunc) (*Manifest, error) {
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is required")
+	}
+
+	manifest := &Manifest{
+		FormatVersion: formatVersion,
+		AgentID:       agentID,
+		Version:       currentVersion(),
+		Commit:        release.Commit(),
+		OS:            runtime.GOOS,
+		CreatedAt:     time.Now().UTC(),
+	}
+	entries := make(map[string][]byte)
+
+	agentSecret, err := secret.GetAgentSecret(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the agent key: %w", err)
+	}
+	if entries[agentSecretName], err = json.Marshal(agentSecret); err != nil {
+		return nil, err
+	}
+	// a rotation of the agent key in progress is completed by the restored agent
+	previous, rotating, err := secret.GetPreviousAgentSecret(ctx, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get the previous agent key: %w", err)
+	}
+	if rotating {
+		if entries[previousSecretName], err = json.Marshal(previous); err != nil {
+			return nil, err
+		}
+	}
+
+	for _, f := range files {
+		content, err := os.ReadFile(f.Path)
+		if errors.Is(err, fs.ErrNotExist) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("could not read %s: %w", f.Path, err)
+		}
+		entries[f.Name] = content
+		manifest.Files = append(manifest.Files, f.Name)
+	}
+
+	if err := writeArchive(w, passphrase, manifest, entries); err != nil {
+		return nil, fmt.Errorf("could not write the archive: %w", err)
+	}
+	return manifest, nil
+}
+
+// Restore replaces the files and the agent key with the ones of the archive read from r. Nothing is
+// restored when the archive cannot be decrypted or when it is not compatible with the running agent.
+// The files that are not in the archive are removed. The restored files are owned by ownership, the
+// owner of the installation, the vault options must match the installation too.
+func Restore(ctx context.Context, r io.Reader, passphrase []byte, files []File, ownership utils.FileOwner, opts ...vault.OptionFunc) (*Manifest, error) {
+	manifest, entries, err := readArchive(r, passphrase)
+	if err != nil {
+		return nil, err
+	}
+	if err := validate(manifest); err != nil {
+		return nil, err
+	}
+
+	var agentSecret secret.Secret
+	if err := json.Unmarshal(entries[agentSecretName], &agentSecret); err != nil {
+		return nil, fmt.Errorf("invalid agent key in the archive: %w", err)
+	}
+	var previous *secret.Secret
+	if content, ok := entries[previousSecretName]; ok {
+		previous = &secret.Secret{}
+		if err := json.Unmarshal(content, previous); err != nil {
+			return nil, fmt.Errorf("invalid previous agent key in the archive: %w", err)
+		}
+	}
+
+	// the files are staged before the agent key is replaced, so a failure to write them leaves the
+	// agent untouched
+	staged := make(map[string]string, len(files))
+	defer func() {
+		for _, tmp := range staged {
+			_ = os.Remove(tmp)
+		}
+	}()
+	for _, f := range files {
+		content, ok := entries[f.Name]
+		if !ok {
+			continue
+		}
+		tmp, err := stageFile(f.Path, content, ownership)
+		if err != nil {
+			return nil, fmt.Errorf("could not restore %s: %w", f.Path, err)
+		}
+		staged[f.Path] = tmp
+	}
+
+	if err := secret.SetAgentSecret(ctx, agentSecret, opts...); err != nil {
+		return nil, fmt.Errorf("could not restore the agent key: %w", err)
+	}
+	if previous != nil {
+		// the rotation of the agent key is completed when the agent starts
+		if err := secret.Set(ctx, secret.AgentSecretPreviousKey, *previous, opts...); err != nil {
+			return nil, fmt.Errorf("could not restore the previous agent key: %w", err)
+		}
+	} else if err := secret.CompleteAgentSecretRotation(ctx, opts...); err != nil {
+		return nil, fmt.Errorf("could not remove the previous agent key: %w", err)
+	}
+	// the file-based vault only sets its ownership on windows
+	vaultOpts, err := vault.ApplyOptions(opts...)
+	if err != nil {
+		return nil, err
+	}
+	if err := perms.FixPermissions(vaultOpts.VaultPath(), perms.WithOwnership(ownership)); err != nil {
+		return nil, fmt.Errorf("could not set the owner of the vault: %w", err)
+	}
+
+	for _, f := range files {
+		tmp, ok := staged[f.Path]
+		if !ok {
+			if err := os.Remove(f.Path); err != nil && !errors.Is(err, fs.ErrNotExist) {
+				return nil, fmt.Errorf("could not remove %s: %w", f.Path, err)
+			}
+			continue
+		}
+		if err := os.Rename(tmp, f.Path); err != nil {
+			return nil, fmt.Errorf("could not restore %s: %w", f.Path, err)
+		}
+		delete(staged, f.Path)
+	}
+	return manifest, nil
+}
+
+// validate returns an error when the archive was created by an agent the running agent cannot
+// restore the state of: another OS, another major version or a more recent version.
+func validate(manifest *Manifest) error {
+	if manifest.FormatVersion != formatVersion {
+		return fmt.Errorf("unsupported archive format version %d", manifest.FormatVersion)
+	}
+	if manifest.OS != runtime.GOOS {
+		return fmt.Errorf("the archive was created on %s and cannot be restored on %s", manifest.OS, runtime.GOOS)
+	}
+
+	backupVersion, err := version.ParseVersion(manifest.Version)
+	if err != nil {
+		return fmt.Errorf("invalid version %q in the archive: %w", manifest.Version, err)
+	}
+	runningVersion, err := version.ParseVersion(currentVersion())
+	if err != nil {
+		return fmt.Errorf("invalid version of the running agent %q: %w", currentVersion(), err)
+	}
+	if backupVersion.Major() != runningVersion.Major() {
+		return fmt.Errorf("the archive was created by version %s and cannot be restored by version %s of another major version",
+			manifest.Version, runningVersion.Original())
+	}
+	// the prerelease and build metadata are not relevant to the format of the stores
+	backupCore := version.NewParsedSemVer(backupVersion.Major(), backupVersion.Minor(), backupVersion.Patch(), "", "")
+	runningCore := version.NewParsedSemVer(runningVersion.Major(), runningVersion.Minor(), runningVersion.Patch(), "", "")
+	if runningCore.Less(*backupCore) {
+		return fmt.Errorf("the archive was created by version %s and cannot be restored by the older version %s",
+			manifest.Version, runningVersion.Original())
+	}
+	return nil
+}
+
+// writeArchive writes the manifest, first, and the entries to a tar archive compressed with gzip and
+// encrypted with the passphrase.
+func writeArchive(w io.Writer, passphrase []byte, manifest *Manifest, entries map[string][]byte) error {
+	ew, err := crypto.NewWriterWithDefaults(w, passphrase)
+	if err != nil {
+		return err
+	}
+	gw := gzip.NewWriter(ew)
+	tw := tar.NewWriter(gw)
+
+	content, err := json.Marshal(manifest)
+	if err != nil {
+		return err
+	}
+	names := append([]string{manifestName, agentSecretName, previousSecretName}, manifest.Files...)
+	entries[manifestName] = content
+	for _, name := range names {
+		content, ok := entries[name]
+		if !ok {
+			continue
+		}
+		hdr := &tar.Header{
+			Name:    name,
+			Mode:    0600,
+			Size:    int64(len(content)),
+			ModTime: manifest.CreatedAt,
+		}
+		if err := tw.WriteHeader(hdr); err != nil {
+			return err
+		}
+		if _, err := tw.Write(content); err != nil {
+			return err
+		}
+	}
+
+	if err := tw.Close(); err != nil {
+		return err
+	}
+	return gw.Close()
+}
+
+// readArchive reads and decrypts the whole archive, so nothing is restored from a corrupted archive.
+func readArchive(r io.Reader, passphrase []byte) (*Manifest, map[string][]byte, error) {
+	er, err := crypto.NewReaderWithDefaults(r, passphrase)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer er.Close()
+	gr, err := gzip.NewReader(er)
+	if err != nil {
+		return nil, nil, fmt.Errorf("could not decrypt the archive, the passphrase may be wrong: %w", err)
+	}
+	defer gr.Close()
+	tr := tar.NewReader(gr)
+
+	entries := make(map[string][]byte)
+	for {
+		hdr, err := tr.Next()
+		if errors.Is(err, io.EOF) {
+			break
+		}
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read the archive: %w", err)
+		}
+		if hdr.Size > maxEntrySize {
+			return nil, nil, fmt.Errorf("entry %s of the archive is too large", hdr.Name)
+		}
+		content, err := io.ReadAll(tr)
+		if err != nil {
+			return nil, nil, fmt.Errorf("could not read %s from the archive: %w", hdr.Name, err)
+		}
+		entries[hdr.Name] = content
+	}
+
+	var manifest Manifest
+	content, ok := entries[manifestName]
+	if !ok {
+		return nil, nil, errors.New("the archive has no manifest")
+	}
+	if err := json.Unmarshal(content, &manifest); err != nil {
+		return nil, nil, fmt.Errorf("invalid manifest: %w", err)
+	}
+	if _, ok := entries[agentSecretName]; !ok {
+		return nil, nil, errors.New("the archive has no agent key")
+	}
+	for _, name := range manifest.Files {
+		if _, ok := entries[name]; !ok {
+			return nil, nil, fmt.Errorf("the archive has no %s", name)
+		}
+	}
+	return &manifest, entries, nil
+}
+
+// stageFile writes content next to path, keeping the permissions of the file at path, and returns
+// the path of the staged file. The staged file, and the directory of path when it is created, are
+// owned by ownership.
+func stageFile(path string, content []byte, ownership utils.FileOwner) (string, error) {
+	dir := filepath.Dir(path)
+	if _, err := os.Stat(dir); errors.Is(err, fs.ErrNotExist) {
+		if err := os.MkdirAll(dir, 0750); err != nil {
+			return "", err
+		}
+		if err := perms.FixPermissions(dir, perms.WithOwnership(ownership)); err != nil {
+			return "", err
+		}
+	}
+	perm := fs.FileMode(0600)
+	if fi, err := os.Stat(path); err == nil {
+		perm = fi.Mode().Perm()
+	}
+	tmp := path + ".restore"
+	if err := os.WriteFile(tmp, content, perm); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	if err := perms.FixPermissions(tmp, perms.WithOwnership(ownership)); err != nil {
+		_ = os.Remove(tmp)
+		return "", err
+	}
+	return tmp, nil
+}
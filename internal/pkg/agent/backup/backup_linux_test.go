@@ -0,0 +1,69 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build linux
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/testutils/fipsutils"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+func TestRestore_Ownership(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "vault does not use NewGCMWithRandomNonce.")
+	if os.Geteuid() != 0 {
+		t.Skip("changing the owner of the files requires root")
+	}
+	ctx := context.Background()
+	setCurrentVersion(t, "9.1.0")
+
+	src := t.TempDir()
+	srcVault := vault.WithVaultPath(filepath.Join(src, "vault"))
+	require.NoError(t, secret.CreateAgentSecret(ctx, srcVault))
+	srcFiles := newTestFiles(t, src, map[string]string{
+		"config/fleet.enc": "fleet",
+		"data/state.enc":   "state",
+	})
+	var archive bytes.Buffer
+	_, err := Backup(ctx, &archive, testPassphrase, "agent-id", srcFiles, srcVault)
+	require.NoError(t, err)
+
+	// an unprivileged installation, owned by another user
+	ownership := utils.FileOwner{UID: 1234, GID: 5678}
+	dst := t.TempDir()
+	dstVaultPath := filepath.Join(dst, "vault")
+	dstFiles := newTestFiles(t, dst, map[string]string{"config/fleet.enc": "new host fleet"})
+
+	_, err = Restore(ctx, bytes.NewReader(archive.Bytes()), testPassphrase, dstFiles, ownership,
+		vault.WithVaultPath(dstVaultPath), vault.WithVaultOwnership(ownership))
+	require.NoError(t, err)
+
+	owned := []string{dstFiles[1].Path, dstFiles[2].Path, filepath.Dir(dstFiles[2].Path)}
+	require.NoError(t, filepath.WalkDir(dstVaultPath, func(path string, _ fs.DirEntry, err error) error {
+		owned = append(owned, path)
+		return err
+	}))
+	for _, path := range owned {
+		fi, err := os.Stat(path)
+		require.NoError(t, err)
+		stat, ok := fi.Sys().(*syscall.Stat_t)
+		require.True(t, ok)
+		assert.Equal(t, ownership.UID, int(stat.Uid), "uid of %s", path)
+		assert.Equal(t, ownership.GID, int(stat.Gid), "gid of %s", path)
+	}
+}
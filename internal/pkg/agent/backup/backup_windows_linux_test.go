@@ -0,0 +1,168 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build linux || windows
+
+package backup
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/testutils/fipsutils"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+var testPassphrase = []byte("correct horse battery staple")
+
+func newTestFiles(t *testing.T, dir string, contents map[string]string) []File {
+	files := []File{
+		{Name: "config/elastic-agent.yml", Path: filepath.Join(dir, "elastic-agent.yml")},
+		{Name: "config/fleet.enc", Path: filepath.Join(dir, "fleet.enc")},
+		{Name: "data/state.enc", Path: filepath.Join(dir, "data", "state.enc")},
+	}
+	for _, f := range files {
+		if content, ok := contents[f.Name]; ok {
+			require.NoError(t, os.MkdirAll(filepath.Dir(f.Path), 0750))
+			require.NoError(t, os.WriteFile(f.Path, []byte(content), 0600))
+		}
+	}
+	return files
+}
+
+func currentOwner(t *testing.T) utils.FileOwner {
+	ownership, err := utils.CurrentFileOwner()
+	require.NoError(t, err)
+	return ownership
+}
+
+func setCurrentVersion(t *testing.T, v string) {
+	original := currentVersion
+	currentVersion = func() string { return v }
+	t.Cleanup(func() { currentVersion = original })
+}
+
+func TestBackupRestore(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "vault does not use NewGCMWithRandomNonce.")
+	ctx := context.Background()
+	setCurrentVersion(t, "9.1.0")
+
+	src := t.TempDir()
+	srcVault := vault.WithVaultPath(filepath.Join(src, "vault"))
+	require.NoError(t, secret.CreateAgentSecret(ctx, srcVault))
+	srcFiles := newTestFiles(t, src, map[string]string{
+		"config/elastic-agent.yml": "agent.logging.level: info",
+		"config/fleet.enc":         "fleet",
+	})
+
+	var archive bytes.Buffer
+	manifest, err := Backup(ctx, &archive, testPassphrase, "agent-id", srcFiles, srcVault)
+	require.NoError(t, err)
+	assert.Equal(t, "agent-id", manifest.AgentID)
+	assert.Equal(t, []string{"config/elastic-agent.yml", "config/fleet.enc"}, manifest.Files)
+	assert.NotContains(t, archive.String(), "agent.logging.level", "the archive should be encrypted")
+
+	// restore on another host, with its own agent key and state
+	dst := t.TempDir()
+	dstVault := vault.WithVaultPath(filepath.Join(dst, "vault"))
+	require.NoError(t, secret.CreateAgentSecret(ctx, dstVault))
+	dstFiles := newTestFiles(t, dst, map[string]string{
+		"config/elastic-agent.yml": "new host",
+		"config/fleet.enc":         "new host fleet",
+		"data/state.enc":           "new host state",
+	})
+
+	restored, err := Restore(ctx, bytes.NewReader(archive.Bytes()), testPassphrase, dstFiles, currentOwner(t), dstVault)
+	require.NoError(t, err)
+	assert.Equal(t, manifest.AgentID, restored.AgentID)
+
+	for _, f := range []struct{ path, content string }{
+		{dstFiles[0].Path, "agent.logging.level: info"},
+		{dstFiles[1].Path, "fleet"},
+	} {
+		content, err := os.ReadFile(f.path)
+		require.NoError(t, err)
+		assert.Equal(t, f.content, string(content))
+	}
+	assert.NoFileExists(t, dstFiles[2].Path, "the files not in the archive should be removed")
+	assert.NoFileExists(t, dstFiles[1].Path+".restore")
+
+	srcSecret, err := secret.GetAgentSecret(ctx, srcVault)
+	require.NoError(t, err)
+	dstSecret, err := secret.GetAgentSecret(ctx, dstVault)
+	require.NoError(t, err)
+	assert.Equal(t, srcSecret.Value, dstSecret.Value, "the agent key should be restored")
+}
+
+func TestRestore_Errors(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "vault does not use NewGCMWithRandomNonce.")
+	ctx := context.Background()
+	setCurrentVersion(t, "9.1.0")
+
+	src := t.TempDir()
+	srcVault := vault.WithVaultPath(filepath.Join(src, "vault"))
+	require.NoError(t, secret.CreateAgentSecret(ctx, srcVault))
+	var archive bytes.Buffer
+	_, err := Backup(ctx, &archive, testPassphrase, "agent-id", newTestFiles(t, src, map[string]string{"config/fleet.enc": "fleet"}), srcVault)
+	require.NoError(t, err)
+
+	dst := t.TempDir()
+	dstVault := vault.WithVaultPath(filepath.Join(dst, "vault"))
+	require.NoError(t, secret.CreateAgentSecret(ctx, dstVault))
+	dstFiles := newTestFiles(t, dst, map[string]string{"config/fleet.enc": "new host fleet"})
+
+	_, err = Restore(ctx, bytes.NewReader(archive.Bytes()), []byte("wrong passphrase"), dstFiles, currentOwner(t), dstVault)
+	assert.Error(t, err, "the archive should not be decrypted with a wrong passphrase")
+
+	for name, tc := range map[string]struct {
+		version string
+		err     string
+	}{
+		"older version": {version: "9.0.3", err: "cannot be restored by the older version 9.0.3"},
+		"other major":   {version: "10.0.0", err: "another major version"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			setCurrentVersion(t, tc.version)
+			_, err := Restore(ctx, bytes.NewReader(archive.Bytes()), testPassphrase, dstFiles, currentOwner(t), dstVault)
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	// nothing is restored from an archive that cannot be restored
+	content, err := os.ReadFile(dstFiles[1].Path)
+	require.NoError(t, err)
+	assert.Equal(t, "new host fleet", string(content))
+
+	// more recent patch and snapshot versions restore the archive
+	setCurrentVersion(t, "9.1.1-SNAPSHOT")
+	_, err = Restore(ctx, bytes.NewReader(archive.Bytes()), testPassphrase, dstFiles, currentOwner(t), dstVault)
+	assert.NoError(t, err)
+}
+
+func TestValidate(t *testing.T) {
+	setCurrentVersion(t, "9.1.0")
+	m := &Manifest{FormatVersion: formatVersion, OS: runtime.GOOS, Version: "9.1.0"}
+	assert.NoError(t, validate(m))
+
+	other := *m
+	other.OS = "plan9"
+	assert.ErrorContains(t, validate(&other), "cannot be restored on "+runtime.GOOS)
+
+	other = *m
+	other.FormatVersion = formatVersion + 1
+	assert.ErrorContains(t, validate(&other), "unsupported archive format version")
+
+	other = *m
+	other.Version = "not a version"
+	assert.ErrorContains(t, validate(&other), "invalid version")
+}
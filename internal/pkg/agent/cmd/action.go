@@ -0,0 +1,103 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func newActionCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "action <subcommand>",
+		Short: "Perform actions on running components",
+		Long:  "Perform actions on the units of the components run by the Elastic Agent.",
+	}
+
+	cmd.AddCommand(newActionRunCommandWithArgs(args, streams))
+
+	return cmd
+}
+
+func newActionRunCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "run <component-id>/<unit-id> <action-name>",
+		Short: "Run an action on a unit of a running component",
+		Long: `Runs an action on a unit of a running component and prints the action result as JSON.
+
+The parameters of the action are provided as a JSON object with --params, for example:
+
+  elastic-agent action run osquery-default/osquery-default-osquery osquery --params '{"query": "select * from uptime"}'`,
+		Args: cobra.ExactArgs(2),
+		Run: func(c *cobra.Command, args []string) {
+			if err := actionRunCmd(streams, c, args); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String("params", "", "action parameters as a JSON object")
+
+	return cmd
+}
+
+func actionRunCmd(streams *cli.IOStreams, cmd *cobra.Command, args []string) error {
+	componentID, unitID, err := parseActionTarget(args[0])
+	if err != nil {
+		return err
+	}
+	name := args[1]
+
+	var params map[string]interface{}
+	rawParams, _ := cmd.Flags().GetString("params")
+	if rawParams != "" {
+		if err := json.Unmarshal([]byte(rawParams), &params); err != nil {
+			return fmt.Errorf("failed to parse --params as a JSON object: %w", err)
+		}
+	}
+
+	ctx := handleSignal(context.Background())
+
+	c := client.New()
+	err = c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	res, err := c.ComponentAction(ctx, componentID, unitID, name, params)
+	if err != nil {
+		return fmt.Errorf("action %s on unit %s of component %s failed: %w", name, unitID, componentID, err)
+	}
+	if res == nil {
+		res = map[string]interface{}{}
+	}
+	out, err := json.MarshalIndent(res, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal action result: %w", err)
+	}
+	fmt.Fprintln(streams.Out, string(out))
+	return nil
+}
+
+// parseActionTarget splits a <component-id>/<unit-id> target.
+func parseActionTarget(target string) (string, string, error) {
+	componentID, unitID, ok := strings.Cut(target, "/")
+	if !ok || componentID == "" || unitID == "" {
+		return "", "", fmt.Errorf("invalid target %q, expected <component-id>/<unit-id>", target)
+	}
+	return componentID, unitID, nil
+}
@@ -0,0 +1,24 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseActionTarget(t *testing.T) {
+	componentID, unitID, err := parseActionTarget("osquery-default/osquery-default-osquery")
+	require.NoError(t, err)
+	assert.Equal(t, "osquery-default", componentID)
+	assert.Equal(t, "osquery-default-osquery", unitID)
+
+	for _, target := range []string{"osquery-default", "/unit", "component/", ""} {
+		_, _, err := parseActionTarget(target)
+		assert.Errorf(t, err, "target %q", target)
+	}
+}
@@ -0,0 +1,172 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func newActionsCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "actions <subcommand>",
+		Short: "Inspect the Fleet actions of the running Elastic Agent",
+		Long:  "Lists, cancels and shows the history of the Fleet actions handled by the running Elastic Agent.",
+	}
+
+	cmd.AddCommand(newActionsListCommandWithArgs(args, streams))
+	cmd.AddCommand(newActionsCancelCommandWithArgs(args, streams))
+	cmd.AddCommand(newActionsHistoryCommandWithArgs(args, streams))
+
+	return cmd
+}
+
+func newActionsListCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the scheduled actions waiting in the queue",
+		Long:  "Lists the scheduled Fleet actions waiting in the queue, ordered by start time.",
+		Args:  cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := actionsListCmd(streams); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func newActionsCancelCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "cancel <action-id>",
+		Short: "Cancel a scheduled action waiting in the queue",
+		Long: `Removes a scheduled Fleet action from the queue so it is never run.
+
+The cancellation is local to the Elastic Agent, Fleet is not notified.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := actionsCancelCmd(streams, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func newActionsHistoryCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "history",
+		Short: "Show the recently handled actions",
+		Long:  "Shows the last Fleet actions handled by the Elastic Agent and their results, oldest first.",
+		Args:  cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := actionsHistoryCmd(streams); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func actionsListCmd(streams *cli.IOStreams) error {
+	ctx := handleSignal(context.Background())
+
+	c := client.New()
+	err := c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	actions, err := c.ListActions(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list actions: %w", err)
+	}
+	if len(actions) == 0 {
+		fmt.Fprintln(streams.Out, "No queued actions.")
+		return nil
+	}
+	for _, a := range actions {
+		fmt.Fprintln(streams.Out, formatQueuedAction(a))
+	}
+	return nil
+}
+
+func actionsCancelCmd(streams *cli.IOStreams, actionID string) error {
+	ctx := handleSignal(context.Background())
+
+	c := client.New()
+	err := c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	n, err := c.CancelAction(ctx, actionID)
+	if err != nil {
+		return fmt.Errorf("failed to cancel action %s: %w", actionID, err)
+	}
+	if n == 0 {
+		return fmt.Errorf("action %s is not in the queue", actionID)
+	}
+	fmt.Fprintf(streams.Out, "Action %s cancelled.\n", actionID)
+	return nil
+}
+
+func actionsHistoryCmd(streams *cli.IOStreams) error {
+	ctx := handleSignal(context.Background())
+
+	c := client.New()
+	err := c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	actions, err := c.ActionHistory(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get action history: %w", err)
+	}
+	if len(actions) == 0 {
+		fmt.Fprintln(streams.Out, "No handled actions.")
+		return nil
+	}
+	for _, a := range actions {
+		fmt.Fprintln(streams.Out, formatHandledAction(a))
+	}
+	return nil
+}
+
+func formatQueuedAction(a client.QueuedAction) string {
+	expiration := "never"
+	if !a.Expiration.IsZero() {
+		expiration = a.Expiration.Local().Format(time.RFC3339)
+	}
+	line := fmt.Sprintf("%s %s start=%s expiration=%s", a.ID, a.Type, a.StartTime.Local().Format(time.RFC3339), expiration)
+	if a.RetryAttempt > 0 {
+		line += fmt.Sprintf(" retry_attempt=%d", a.RetryAttempt)
+	}
+	return line
+}
+
+func formatHandledAction(a client.HandledAction) string {
+	line := fmt.Sprintf("%s %-9s %s %s", a.Time.Local().Format(time.RFC3339), a.Result, a.ID, a.Type)
+	if a.ErrorCode != "" {
+		line += " [" + a.ErrorCode + "]"
+	}
+	if a.Error != "" {
+		line += ": " + a.Error
+	}
+	return line
+}
@@ -0,0 +1,56 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestFormatQueuedAction(t *testing.T) {
+	start := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	line := formatQueuedAction(client.QueuedAction{
+		ID:        "action-1",
+		Type:      "UPGRADE",
+		StartTime: start,
+	})
+	assert.Equal(t, "action-1 UPGRADE start="+start.Local().Format(time.RFC3339)+" expiration=never", line)
+
+	line = formatQueuedAction(client.QueuedAction{
+		ID:           "action-2",
+		Type:         "UPGRADE",
+		StartTime:    start,
+		Expiration:   start.Add(time.Hour),
+		RetryAttempt: 2,
+	})
+	assert.Equal(t, "action-2 UPGRADE start="+start.Local().Format(time.RFC3339)+
+		" expiration="+start.Add(time.Hour).Local().Format(time.RFC3339)+" retry_attempt=2", line)
+}
+
+func TestFormatHandledAction(t *testing.T) {
+	at := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	line := formatHandledAction(client.HandledAction{
+		ID:     "action-1",
+		Type:   "POLICY_CHANGE",
+		Time:   at,
+		Result: "success",
+	})
+	assert.Equal(t, at.Local().Format(time.RFC3339)+" success   action-1 POLICY_CHANGE", line)
+
+	line = formatHandledAction(client.HandledAction{
+		ID:     "action-2",
+		Type:   "UPGRADE",
+		Time:   at,
+		Result: "failed",
+		Error:  "download failed",
+	})
+	assert.Equal(t, at.Local().Format(time.RFC3339)+" failed    action-2 UPGRADE: download failed", line)
+}
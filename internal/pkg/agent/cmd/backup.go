@@ -0,0 +1,225 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/backup"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+const (
+	flagBackupOut            = "out"
+	flagBackupPassphraseFile = "passphrase-file"
+	flagRestoreForce         = "force"
+)
+
+var backupNotRootError = errors.New("backup and restore commands need to be executed as root")
+
+func newBackupCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "backup",
+		Short: "Back up the identity and the state of the Elastic Agent",
+		Long: `This command writes the agent ID, the agent key, the encrypted stores (fleet.enc, state.enc...) and the configuration
+of the Elastic Agent to an archive encrypted with a passphrase, to restore them with the restore command after re-imaging the host.
+The passphrase is read from --passphrase-file, or prompted for when the command runs in a terminal.`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := backupCmd(streams, c); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().StringP(flagBackupOut, "o", "", "File the archive is written to")
+	_ = cmd.MarkFlagRequired(flagBackupOut)
+	cmd.Flags().String(flagBackupPassphraseFile, "", "File the passphrase of the archive is read from")
+
+	return cmd
+}
+
+func newRestoreCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "restore <file>",
+		Short: "Restore the identity and the state of the Elastic Agent",
+		Long: `This command replaces the agent ID, the agent key, the encrypted stores and the configuration of the Elastic Agent
+with the ones of an archive written by the backup command. The Elastic Agent must be stopped.
+The archive must have been written on the same OS by a version of the same major version, not more recent than the running one.
+The passphrase is read from --passphrase-file, or prompted for when the command runs in a terminal.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := restoreCmd(streams, c, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagBackupPassphraseFile, "", "File the passphrase of the archive is read from")
+	cmd.Flags().BoolP(flagRestoreForce, "f", false, "Force overwrite the current identity and state without prompting for confirmation")
+
+	return cmd
+}
+
+func backupCmd(streams *cli.IOStreams, cmd *cobra.Command) (err error) {
+	out, _ := cmd.Flags().GetString(flagBackupOut)
+	passphraseFile, _ := cmd.Flags().GetString(flagBackupPassphraseFile)
+
+	if err := checkBackupRoot(); err != nil {
+		return err
+	}
+	ctx := handleSignal(context.Background())
+
+	_, unprivileged, err := installOwnership(paths.Top())
+	if err != nil {
+		return fmt.Errorf("could not get the owner of the installation: %w", err)
+	}
+	agentInfo, err := info.NewAgentInfoWithLog(ctx, "error", false)
+	if err != nil {
+		return fmt.Errorf("could not load agent info: %w", err)
+	}
+	passphrase, err := readBackupPassphrase(streams, passphraseFile, true)
+	if err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(out, os.O_CREATE|os.O_WRONLY|os.O_TRUNC, 0600)
+	if err != nil {
+		return fmt.Errorf("could not create %s: %w", out, err)
+	}
+	defer func() {
+		if closeErr := f.Close(); closeErr != nil && err == nil {
+			err = fmt.Errorf("could not write %s: %w", out, closeErr)
+		}
+		if err != nil {
+			_ = os.Remove(out)
+		}
+	}()
+
+	manifest, err := backup.Backup(ctx, f, passphrase, agentInfo.AgentID(), backup.Files(), vault.WithUnprivileged(unprivileged))
+	if err != nil {
+		return fmt.Errorf("failed to back up the Elastic Agent: %w", err)
+	}
+	fmt.Fprintf(streams.Out, "Elastic Agent %s backed up to %s\n", manifest.AgentID, out)
+	return nil
+}
+
+func restoreCmd(streams *cli.IOStreams, cmd *cobra.Command, archive string) error {
+	passphraseFile, _ := cmd.Flags().GetString(flagBackupPassphraseFile)
+	force, _ := cmd.Flags().GetBool(flagRestoreForce)
+
+	if err := checkBackupRoot(); err != nil {
+		return err
+	}
+	ctx := handleSignal(context.Background())
+
+	if _, err := getDaemonState(ctx); err == nil {
+		// the running agent would overwrite the restored state
+		return errors.New("the Elastic Agent is running, stop it before restoring")
+	}
+
+	if !force {
+		confirm, err := cli.Confirm("This will replace the agent ID, the state and the configuration of this Elastic Agent. Do you want to continue?", false)
+		if err != nil {
+			return fmt.Errorf("problem reading prompt response: %w", err)
+		}
+		if !confirm {
+			fmt.Fprintln(streams.Out, "Restore was cancelled by the user")
+			return nil
+		}
+	}
+
+	passphrase, err := readBackupPassphrase(streams, passphraseFile, false)
+	if err != nil {
+		return err
+	}
+	f, err := os.Open(archive)
+	if err != nil {
+		return fmt.Errorf("could not open %s: %w", archive, err)
+	}
+	defer f.Close()
+
+	// the restored files and keys are owned by the user the agent runs as, like when enrolling
+	ownership, unprivileged, err := installOwnership(paths.Top())
+	if err != nil {
+		return fmt.Errorf("could not get the owner of the installation: %w", err)
+	}
+	manifest, err := backup.Restore(ctx, f, passphrase, backup.Files(), ownership,
+		vault.WithUnprivileged(unprivileged), vault.WithVaultOwnership(ownership))
+	if err != nil {
+		return fmt.Errorf("failed to restore the Elastic Agent: %w", err)
+	}
+	fmt.Fprintf(streams.Out, "Elastic Agent %s restored from the backup of version %s created on %s\n",
+		manifest.AgentID, manifest.Version, manifest.CreatedAt.Format(time.RFC3339))
+	return nil
+}
+
+func checkBackupRoot() error {
+	isRoot, err := utils.HasRoot()
+	if err != nil {
+		return fmt.Errorf("error while retrieving user permission: %w", err)
+	}
+	if !isRoot {
+		return backupNotRootError
+	}
+	return nil
+}
+
+// readBackupPassphrase reads the passphrase from passphraseFile, or prompts for it when the standard
+// input is a terminal, twice when confirm is true.
+func readBackupPassphrase(streams *cli.IOStreams, passphraseFile string, confirm bool) ([]byte, error) {
+	if passphraseFile != "" {
+		content, err := os.ReadFile(passphraseFile)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the passphrase: %w", err)
+		}
+		passphrase := []byte(strings.TrimRight(string(content), "\r\n"))
+		if len(passphrase) == 0 {
+			return nil, fmt.Errorf("passphrase file %s is empty", passphraseFile)
+		}
+		return passphrase, nil
+	}
+
+	fd := int(os.Stdin.Fd()) //nolint:gosec // file descriptors fit in an int
+	if !term.IsTerminal(fd) {
+		return nil, fmt.Errorf("--%s is required when not running in a terminal", flagBackupPassphraseFile)
+	}
+	fmt.Fprint(streams.Out, "Passphrase: ")
+	passphrase, err := term.ReadPassword(fd)
+	fmt.Fprintln(streams.Out)
+	if err != nil {
+		return nil, fmt.Errorf("could not read the passphrase: %w", err)
+	}
+	if len(passphrase) == 0 {
+		return nil, errors.New("passphrase is required")
+	}
+	if confirm {
+		fmt.Fprint(streams.Out, "Confirm passphrase: ")
+		confirmation, err := term.ReadPassword(fd)
+		fmt.Fprintln(streams.Out)
+		if err != nil {
+			return nil, fmt.Errorf("could not read the passphrase: %w", err)
+		}
+		if !bytes.Equal(passphrase, confirmation) {
+			return nil, errors.New("passphrases do not match")
+		}
+	}
+	return passphrase, nil
+}
@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build !windows
+
+package cmd
+
+import (
+	"fmt"
+	"os"
+	"syscall"
+
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+// installOwnership returns the owner of the installation at topPath, set by install, and whether
+// the installation runs unprivileged, not as root.
+func installOwnership(topPath string) (utils.FileOwner, bool, error) {
+	fileInfo, err := os.Stat(topPath)
+	if err != nil {
+		return utils.FileOwner{}, false, fmt.Errorf("failed to get file info: %w", err)
+	}
+	stat, ok := fileInfo.Sys().(*syscall.Stat_t)
+	if !ok {
+		return utils.FileOwner{}, false, fmt.Errorf("failed to get system specific file info of %s", topPath)
+	}
+	ownership := utils.FileOwner{UID: int(stat.Uid), GID: int(stat.Gid)}
+	return ownership, ownership.UID != 0, nil
+}
@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build windows
+
+package cmd
+
+import (
+	"fmt"
+
+	"golang.org/x/sys/windows"
+
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+// installOwnership returns the owner of the installation at topPath, set by install, and whether
+// the installation runs unprivileged, not as SYSTEM or Administrators.
+func installOwnership(topPath string) (utils.FileOwner, bool, error) {
+	sd, err := windows.GetNamedSecurityInfo(topPath, windows.SE_FILE_OBJECT,
+		windows.OWNER_SECURITY_INFORMATION|windows.GROUP_SECURITY_INFORMATION)
+	if err != nil {
+		return utils.FileOwner{}, false, fmt.Errorf("failed to get the security info of %s: %w", topPath, err)
+	}
+	owner, _, err := sd.Owner()
+	if err != nil {
+		return utils.FileOwner{}, false, fmt.Errorf("failed to get the owner of %s: %w", topPath, err)
+	}
+	group, _, err := sd.Group()
+	if err != nil {
+		return utils.FileOwner{}, false, fmt.Errorf("failed to get the group of %s: %w", topPath, err)
+	}
+	ownership := utils.FileOwner{UID: owner.String(), GID: group.String()}
+	if ownership.UID == utils.SystemSID || ownership.UID == utils.AdministratorSID {
+		// privileged installations have no owner, like for install
+		return utils.FileOwner{}, false, nil
+	}
+	return ownership, true, nil
+}
@@ -83,6 +83,7 @@ func NewCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
 	cmd.AddCommand(newInstallCommandWithArgs(args, streams))
 	cmd.AddCommand(newUninstallCommandWithArgs(args, streams))
 	cmd.AddCommand(newUpgradeCommandWithArgs(args, streams))
+	cmd.AddCommand(newRollbackCommandWithArgs(args, streams))
 	cmd.AddCommand(newEnrollCommandWithArgs(args, streams))
 	cmd.AddCommand(newInspectCommandWithArgs(args, streams))
 	cmd.AddCommand(newPrivilegedCommandWithArgs(args, streams))
@@ -92,9 +93,17 @@ func NewCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
 	cmd.AddCommand(newStatusCommand(args, streams))
 	cmd.AddCommand(newDiagnosticsCommand(args, streams))
 	cmd.AddCommand(newComponentCommandWithArgs(args, streams))
+	cmd.AddCommand(newActionCommandWithArgs(args, streams))
+	cmd.AddCommand(newActionsCommandWithArgs(args, streams))
+	cmd.AddCommand(newLogLevelCommandWithArgs(args, streams))
+	cmd.AddCommand(newEventsCommandWithArgs(args, streams))
 	cmd.AddCommand(newLogsCommandWithArgs(args, streams))
 	cmd.AddCommand(newOtelCommandWithArgs(args, streams))
 	cmd.AddCommand(newApplyFlavorCommandWithArgs(args, streams))
+	cmd.AddCommand(newVaultCommandWithArgs(args, streams))
+	cmd.AddCommand(newSecretsCommandWithArgs(args, streams))
+	cmd.AddCommand(newBackupCommandWithArgs(args, streams))
+	cmd.AddCommand(newRestoreCommandWithArgs(args, streams))
 
 	// windows special hidden sub-command (only added on Windows)
 	reexec := newReExecWindowsCommand(args, streams)
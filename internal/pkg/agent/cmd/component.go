@@ -18,6 +18,9 @@ func newComponentCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.C
 	}
 
 	cmd.AddCommand(newComponentSpecCommandWithArgs(args, streams))
+	cmd.AddCommand(newComponentPauseCommandWithArgs(args, streams))
+	cmd.AddCommand(newComponentResumeCommandWithArgs(args, streams))
+	cmd.AddCommand(newComponentRestartCommandWithArgs(args, streams))
 
 	return cmd
 }
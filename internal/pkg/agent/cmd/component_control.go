@@ -0,0 +1,77 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func newComponentPauseCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "pause <component-id>",
+		Short: "Pause a running component",
+		Long:  "Stops a running component and keeps it stopped, across restarts of the Elastic Agent, until it is resumed.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return componentControlCmd(c.Context(), streams, args[0], "paused", func(ctx context.Context, cl client.Client, id string) error {
+				return cl.PauseComponent(ctx, id)
+			})
+		},
+	}
+}
+
+func newComponentResumeCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "resume <component-id>",
+		Short: "Resume a paused component",
+		Long:  "Resumes a component that was previously paused, starting it again with its current configuration.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return componentControlCmd(c.Context(), streams, args[0], "resumed", func(ctx context.Context, cl client.Client, id string) error {
+				return cl.ResumeComponent(ctx, id)
+			})
+		},
+	}
+}
+
+func newComponentRestartCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "restart <component-id>",
+		Short: "Restart a running component",
+		Long:  "Stops and starts a single running component without restarting the Elastic Agent.",
+		Args:  cobra.ExactArgs(1),
+		RunE: func(c *cobra.Command, args []string) error {
+			return componentControlCmd(c.Context(), streams, args[0], "restarted", func(ctx context.Context, cl client.Client, id string) error {
+				return cl.RestartComponent(ctx, id)
+			})
+		},
+	}
+}
+
+func componentControlCmd(ctx context.Context, streams *cli.IOStreams, id string, verb string, fn func(context.Context, client.Client, string) error) error {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	c := client.New()
+	err := c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	if err := fn(ctx, c, id); err != nil {
+		return fmt.Errorf("failed to control component %q: %w", id, err)
+	}
+	fmt.Fprintf(streams.Out, "Component %s %s\n", id, verb)
+	return nil
+}
@@ -0,0 +1,125 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func newEventsCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "events",
+		Short: "Show the state transitions of the running components",
+		Long: `Shows the recorded state transitions of the components and units run by the Elastic Agent, oldest first.
+
+--since accepts either a duration, like 15m, or an RFC3339 timestamp.`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := eventsCmd(streams, c); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().BoolP("follow", "f", false, "keep streaming new state transitions")
+	cmd.Flags().String("since", "", "only show the state transitions after this duration ago or timestamp")
+	cmd.Flags().String("component", "", "only show the state transitions of this component")
+	cmd.Flags().String("severity", "info", "only show the state transitions with at least this severity (info, warning, error)")
+
+	return cmd
+}
+
+func eventsCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
+	follow, _ := cmd.Flags().GetBool("follow")
+	componentID, _ := cmd.Flags().GetString("component")
+	rawSince, _ := cmd.Flags().GetString("since")
+	since, err := parseEventsSince(rawSince, time.Now())
+	if err != nil {
+		return err
+	}
+	rawSeverity, _ := cmd.Flags().GetString("severity")
+	severity, err := parseEventSeverity(rawSeverity)
+	if err != nil {
+		return err
+	}
+
+	ctx := handleSignal(context.Background())
+
+	c := client.New()
+	err = c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	events, err := c.Events(ctx, client.EventsRequest{
+		ComponentID: componentID,
+		MinSeverity: severity,
+		Since:       since,
+		Follow:      follow,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to get events: %w", err)
+	}
+	for {
+		e, err := events.Recv()
+		if errors.Is(err, io.EOF) || ctx.Err() != nil {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("failed to receive events: %w", err)
+		}
+		fmt.Fprintln(streams.Out, formatEvent(e))
+	}
+}
+
+// parseEventsSince parses --since as a duration before now or as an RFC3339 timestamp.
+func parseEventsSince(val string, now time.Time) (time.Time, error) {
+	if val == "" {
+		return time.Time{}, nil
+	}
+	if d, err := time.ParseDuration(val); err == nil {
+		return now.Add(-d), nil
+	}
+	t, err := time.Parse(time.RFC3339, val)
+	if err != nil {
+		return time.Time{}, fmt.Errorf("invalid --since %q, expected a duration or an RFC3339 timestamp", val)
+	}
+	return t, nil
+}
+
+func parseEventSeverity(val string) (client.EventSeverity, error) {
+	switch strings.ToLower(val) {
+	case "", "info":
+		return client.SeverityInfo, nil
+	case "warn", "warning":
+		return client.SeverityWarning, nil
+	case "error":
+		return client.SeverityError, nil
+	}
+	return client.SeverityInfo, fmt.Errorf("invalid --severity %q, expected info, warning or error", val)
+}
+
+func formatEvent(e *client.Event) string {
+	target := e.ComponentID
+	if e.UnitID != "" {
+		target = fmt.Sprintf("%s/%s (%s)", e.ComponentID, e.UnitID, strings.ToLower(e.UnitType.String()))
+	}
+	return fmt.Sprintf("%s %-7s %s %s->%s: %s",
+		e.Time.Local().Format(time.RFC3339), e.Severity.String(), target, e.OldState.String(), e.State.String(), e.Message)
+}
@@ -0,0 +1,51 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+func TestParseEventsSince(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	since, err := parseEventsSince("", now)
+	require.NoError(t, err)
+	assert.True(t, since.IsZero())
+
+	since, err = parseEventsSince("15m", now)
+	require.NoError(t, err)
+	assert.Equal(t, now.Add(-15*time.Minute), since)
+
+	since, err = parseEventsSince("2024-05-01T10:00:00Z", now)
+	require.NoError(t, err)
+	assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), since)
+
+	_, err = parseEventsSince("yesterday", now)
+	assert.Error(t, err)
+}
+
+func TestParseEventSeverity(t *testing.T) {
+	for val, expected := range map[string]client.EventSeverity{
+		"":        client.SeverityInfo,
+		"info":    client.SeverityInfo,
+		"warning": client.SeverityWarning,
+		"WARN":    client.SeverityWarning,
+		"error":   client.SeverityError,
+	} {
+		severity, err := parseEventSeverity(val)
+		require.NoError(t, err)
+		assert.Equal(t, expected, severity, val)
+	}
+
+	_, err := parseEventSeverity("critical")
+	assert.Error(t, err)
+}
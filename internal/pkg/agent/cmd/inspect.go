@@ -472,7 +472,8 @@ func printComponents(
 	if err != nil {
 		return errors.New(err, "could not marshal to YAML")
 	}
-	_, err = streams.Out.Write(data)
+	// the units hold the configuration with the secrets resolved
+	_, err = streams.Out.Write(diagnostics.RedactSecretValues(data))
 	return err
 }
 
@@ -481,7 +482,7 @@ func printComponent(comp component.Component, streams *cli.IOStreams) error {
 	if err != nil {
 		return errors.New(err, "could not marshal to YAML")
 	}
-	_, err = streams.Out.Write(data)
+	_, err = streams.Out.Write(diagnostics.RedactSecretValues(data))
 	return err
 }
 
@@ -490,7 +491,7 @@ func printUnit(unit component.Unit, streams *cli.IOStreams) error {
 	if err != nil {
 		return errors.New(err, "could not marshal to YAML")
 	}
-	_, err = streams.Out.Write(data)
+	_, err = streams.Out.Write(diagnostics.RedactSecretValues(data))
 	return err
 }
 
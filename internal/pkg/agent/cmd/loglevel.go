@@ -0,0 +1,98 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/component"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+)
+
+const defaultLogLevelTTL = 30 * time.Minute
+
+func newLogLevelCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "loglevel <subcommand>",
+		Short: "Manage the log level of running components",
+		Long:  "Manage the log level of the components and units run by the Elastic Agent.",
+	}
+
+	cmd.AddCommand(newLogLevelSetCommandWithArgs(args, streams))
+
+	return cmd
+}
+
+func newLogLevelSetCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "set <level>",
+		Short: "Temporarily set the log level of running components",
+		Long: `Temporarily overrides the log level of running components and units.
+
+Without --component and --unit the log level of all components is overridden. The override
+is reverted once the --ttl has elapsed. Valid levels are error, warning, info, debug and trace.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := logLevelSetCmd(streams, c, args); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String("component", "", "ID of the component to set the log level for")
+	cmd.Flags().String("unit", "", "ID of the unit to set the log level for")
+	cmd.Flags().Duration("ttl", defaultLogLevelTTL, "duration after which the log level is reverted")
+
+	return cmd
+}
+
+func logLevelSetCmd(streams *cli.IOStreams, cmd *cobra.Command, args []string) error {
+	level := args[0]
+	if _, err := component.StringToLogLevel(level); err != nil {
+		return err
+	}
+	componentID, _ := cmd.Flags().GetString("component")
+	unitID, _ := cmd.Flags().GetString("unit")
+	ttl, _ := cmd.Flags().GetDuration("ttl")
+	if ttl < time.Second {
+		return fmt.Errorf("--ttl must be at least 1s, got %s", ttl)
+	}
+
+	ctx := handleSignal(context.Background())
+
+	c := client.New()
+	err := c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "Failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	if err := c.SetComponentLogLevel(ctx, componentID, unitID, level, ttl); err != nil {
+		return fmt.Errorf("failed to set log level: %w", err)
+	}
+	fmt.Fprintf(streams.Out, "Log level set to %s for %s, reverting in %s\n", level, logLevelTarget(componentID, unitID), ttl)
+	return nil
+}
+
+func logLevelTarget(componentID string, unitID string) string {
+	switch {
+	case componentID == "" && unitID == "":
+		return "all components"
+	case unitID == "":
+		return fmt.Sprintf("component %s", componentID)
+	case componentID == "":
+		return fmt.Sprintf("unit %s", unitID)
+	}
+	return fmt.Sprintf("unit %s of component %s", unitID, componentID)
+}
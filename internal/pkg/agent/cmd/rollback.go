@@ -0,0 +1,90 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+const flagRollbackTo = "to"
+
+var rollbackNotRootError = errors.New("rollback command needs to be executed as root")
+
+func newRollbackCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "rollback",
+		Short: "Roll back the Elastic Agent to a previous version",
+		Long: `This command rolls the Elastic Agent back to a previous install retained after an upgrade, the most recent one unless --to is specified.
+The number of installs retained is set by agent.upgrade.rollback.retention.`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := rollbackCmd(streams, c); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+
+	cmd.Flags().String(flagRollbackTo, "", "Version of the retained install to roll back to")
+
+	return cmd
+}
+
+func rollbackCmd(streams *cli.IOStreams, cmd *cobra.Command) error {
+	version, _ := cmd.Flags().GetString(flagRollbackTo)
+
+	isRoot, err := utils.HasRoot()
+	if err != nil {
+		return fmt.Errorf("error while retrieving user permission: %w", err)
+	}
+	if !isRoot {
+		return rollbackNotRootError
+	}
+
+	c := client.New()
+	ctx, cancel := context.WithTimeout(context.Background(), time.Minute)
+	defer cancel()
+
+	err = c.Connect(ctx)
+	if err != nil {
+		return errors.New(err, "failed communicating to running daemon", errors.TypeNetwork, errors.M("socket", control.Address()))
+	}
+	defer c.Disconnect()
+
+	inProgress, err := upgrade.IsInProgress(c, utils.GetWatcherPIDs)
+	if err != nil {
+		return fmt.Errorf("failed to check if upgrade is already in progress: %w", err)
+	}
+	if inProgress {
+		return errors.New("an upgrade is already in progress; please try again later")
+	}
+
+	log, err := logger.New("rollback", false)
+	if err != nil {
+		return err
+	}
+
+	target, err := upgrade.RollbackToRetained(ctx, log, c, paths.Top(), version)
+	if err != nil {
+		return fmt.Errorf("failed to roll back: %w", err)
+	}
+
+	fmt.Fprintf(streams.Out, "Elastic Agent rolled back to version %s\n", target.Version)
+	return nil
+}
@@ -42,6 +42,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/reexec"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact/download/peer"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/install"
@@ -218,6 +219,14 @@ func runElasticAgent(ctx context.Context, cancel context.CancelFunc, override ap
 		return logReturn(l, fmt.Errorf("failed to read/write secrets: %w", err))
 	}
 
+	// the stores remain readable when a rotation of the agent key was interrupted, it is
+	// completed on start
+	if resumed, err := storage.ResumeKeyRotation(ctx, paths.EncryptedStoreFiles()); err != nil {
+		l.Errorf("Failed to complete the interrupted rotation of the agent key: %v", err)
+	} else if resumed {
+		l.Info("Completed the interrupted rotation of the agent key")
+	}
+
 	// Migrate .yml files if the corresponding .enc does not exist
 
 	// the encrypted config does not exist but the unencrypted file does
@@ -301,6 +310,23 @@ func runElasticAgent(ctx context.Context, cancel context.CancelFunc, override ap
 		}
 	}()
 
+	if cfg.Settings.DownloadConfig.PeerCache.Server.Enabled {
+		// a failure to share the downloaded artifacts does not prevent the agent from running
+		peerCache := peer.NewServer(l.Named("peer-cache"), cfg.Settings.DownloadConfig)
+		if err := peerCache.Start(); err != nil {
+			l.Errorf("Failed to start the peer cache server: %v", err)
+		} else {
+			defer func() {
+				_ = peerCache.Stop()
+			}()
+		}
+	}
+
+	if cfg.Settings.Vault != nil && cfg.Settings.Vault.RotationInterval > 0 {
+		keyRotator := storage.NewKeyRotator(l.Named("key-rotator"), cfg.Settings.Vault.RotationInterval, paths.EncryptedStoreFiles())
+		go keyRotator.Run(ctx)
+	}
+
 	diagHooks := diagnostics.GlobalHooks()
 	diagHooks = append(diagHooks, coord.DiagnosticHooks()...)
 	controlLog := l.Named("control")
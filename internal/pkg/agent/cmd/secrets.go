@@ -0,0 +1,152 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/internal/pkg/composable/providers/secret"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+func newSecretsCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "secrets <subcommand>",
+		Short: "Manage the local secrets of the Elastic Agent",
+		Long: `Manage the secrets of the local secret store, encrypted with the agent key.
+
+The secrets are referenced in the policy as ${secret.local.<name>}, the running Elastic Agent
+picks up a changed secret within the cache_ttl of the secret provider.`,
+	}
+
+	cmd.AddCommand(
+		newSecretsSetCommandWithArgs(args, streams),
+		newSecretsGetCommandWithArgs(args, streams),
+		newSecretsListCommandWithArgs(args, streams),
+	)
+
+	return cmd
+}
+
+func newSecretsSetCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set <name> [<value>]",
+		Short: "Set a secret",
+		Long:  "Sets the value of a secret. The value is read from the standard input when it is not provided, to keep it out of the shell history.",
+		Args:  cobra.RangeArgs(1, 2),
+		Run: func(c *cobra.Command, args []string) {
+			if err := secretsSetCmd(streams, args); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func newSecretsGetCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "get <name>",
+		Short: "Print the value of a secret",
+		Args:  cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := secretsGetCmd(streams, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func newSecretsListCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "list",
+		Short: "List the names of the secrets",
+		Args:  cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := secretsListCmd(streams); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func secretsSetCmd(streams *cli.IOStreams, args []string) error {
+	ctx := handleSignal(context.Background())
+	store, err := newLocalSecretStore()
+	if err != nil {
+		return err
+	}
+
+	name := args[0]
+	var value string
+	if len(args) > 1 {
+		value = args[1]
+	} else {
+		data, err := io.ReadAll(streams.In)
+		if err != nil {
+			return fmt.Errorf("failed to read the secret value: %w", err)
+		}
+		value = strings.TrimSuffix(strings.TrimSuffix(string(data), "\n"), "\r")
+	}
+
+	if err := store.Set(ctx, name, value); err != nil {
+		return fmt.Errorf("failed to set secret %q: %w", name, err)
+	}
+	fmt.Fprintf(streams.Out, "Secret %q set\n", name)
+	return nil
+}
+
+func secretsGetCmd(streams *cli.IOStreams, name string) error {
+	ctx := handleSignal(context.Background())
+	store, err := newLocalSecretStore()
+	if err != nil {
+		return err
+	}
+
+	value, ok, err := store.Get(ctx, name)
+	if err != nil {
+		return fmt.Errorf("failed to get secret %q: %w", name, err)
+	}
+	if !ok {
+		return fmt.Errorf("secret %q not found", name)
+	}
+	fmt.Fprintln(streams.Out, value)
+	return nil
+}
+
+func secretsListCmd(streams *cli.IOStreams) error {
+	ctx := handleSignal(context.Background())
+	store, err := newLocalSecretStore()
+	if err != nil {
+		return err
+	}
+
+	names, err := store.List(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to list the secrets: %w", err)
+	}
+	for _, name := range names {
+		fmt.Fprintln(streams.Out, name)
+	}
+	return nil
+}
+
+func newLocalSecretStore() (*secret.LocalStore, error) {
+	isAdmin, err := utils.HasRoot()
+	if err != nil {
+		return nil, fmt.Errorf("error checking for root/Administrator privileges: %w", err)
+	}
+	return secret.NewLocalStore(paths.SecretsStoreFile(), storage.WithUnprivileged(!isAdmin)), nil
+}
@@ -22,6 +22,7 @@ import (
 
 	"github.com/spf13/cobra"
 
+	"github.com/docker/go-units"
 	"github.com/jedib0t/go-pretty/v6/list"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
@@ -221,9 +222,18 @@ func listUpgradeDetails(l list.Writer, upgradeDetails *cproto.UpgradeDetails) {
 		if upgradeDetails.Metadata.ErrorMsg != "" {
 			l.AppendItem("error_msg: " + upgradeDetails.Metadata.ErrorMsg)
 		}
+		if upgradeDetails.Metadata.ErrorCode != "" {
+			l.AppendItem("error_code: " + upgradeDetails.Metadata.ErrorCode)
+		}
 		if upgradeDetails.State == string(details.StateDownloading) {
 			l.AppendItem(fmt.Sprintf("download_percent: %.2f%%", upgradeDetails.Metadata.DownloadPercent*100))
 		}
+		if upgradeDetails.Metadata.DownloadRateLimit > 0 {
+			l.AppendItem("download_rate_limit: " + units.HumanSize(upgradeDetails.Metadata.DownloadRateLimit) + "ps")
+		}
+		if upgradeDetails.Metadata.DownloadWindowOpensAt != "" {
+			l.AppendItem("download_window_opens_at: " + upgradeDetails.Metadata.DownloadWindowOpensAt)
+		}
 		if upgradeDetails.Metadata.RetryUntil != "" {
 			l.AppendItem("retry_until: " + humanDurationUntil(upgradeDetails.Metadata.RetryUntil, time.Now()))
 		}
@@ -233,6 +243,9 @@ func listUpgradeDetails(l list.Writer, upgradeDetails *cproto.UpgradeDetails) {
 		if upgradeDetails.Metadata.Reason != "" {
 			l.AppendItem("reason: " + upgradeDetails.Metadata.Reason)
 		}
+		if upgradeDetails.Metadata.RollbackErrorMsg != "" {
+			l.AppendItem("rollback_error_msg: " + upgradeDetails.Metadata.RollbackErrorMsg)
+		}
 		l.UnIndent()
 	}
 
@@ -7,6 +7,7 @@ package cmd
 import (
 	"context"
 	"fmt"
+	"io"
 	"os"
 	"strings"
 	"time"
@@ -33,6 +34,7 @@ const (
 	flagPGPBytesPath   = "pgp-path"
 	flagPGPBytesURI    = "pgp-uri"
 	flagForce          = "force"
+	flagDryRun         = "dry-run"
 )
 
 var (
@@ -63,6 +65,7 @@ func newUpgradeCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Comman
 	cmd.Flags().String(flagPGPBytes, "", "PGP to use for package verification")
 	cmd.Flags().String(flagPGPBytesURI, "", "Path to a web location containing PGP to use for package verification")
 	cmd.Flags().String(flagPGPBytesPath, "", "Path to a file containing PGP to use for package verification")
+	cmd.Flags().Bool(flagDryRun, false, "Only download and verify the package and run the pre-flight checks of the upgrade, without upgrading")
 	cmd.Flags().BoolP(flagForce, "", false, "Advanced option to force an upgrade on a fleet managed agent")
 	err := cmd.Flags().MarkHidden(flagForce)
 	if err != nil {
@@ -167,8 +170,14 @@ func upgradeCmdWithClient(input *upgradeInput) error {
 		return fmt.Errorf("failed to retrieve %s flag information while upgrading the agent: %w", flagSkipVerify, err)
 	}
 
+	dryRun, err := cmd.Flags().GetBool(flagDryRun)
+	if err != nil {
+		return fmt.Errorf("failed to retrieve %s flag information while upgrading the agent: %w", flagDryRun, err)
+	}
+
 	err = checkUpgradable(upgradeCond{
-		isManaged:  input.agentInfo.IsManaged,
+		// a dry run does not upgrade, it is allowed on fleet managed agents
+		isManaged:  input.agentInfo.IsManaged && !dryRun,
 		force:      force,
 		isRoot:     input.isRoot,
 		skipVerify: skipVerification,
@@ -215,6 +224,15 @@ func upgradeCmdWithClient(input *upgradeInput) error {
 		}
 	}
 	skipDefaultPgp, _ := cmd.Flags().GetBool(flagSkipDefaultPgp)
+	if dryRun {
+		checks, err := c.UpgradeDryRun(context.Background(), version, sourceURI, skipVerification, skipDefaultPgp, pgpChecks)
+		printPreflightChecks(input.streams.Out, version, checks)
+		if err != nil {
+			return fmt.Errorf("upgrade to version %s would fail: %w", version, err)
+		}
+		fmt.Fprintf(input.streams.Out, "Elastic Agent can be upgraded to version %s\n", version)
+		return nil
+	}
 	version, err = c.Upgrade(context.Background(), version, sourceURI, skipVerification, skipDefaultPgp, pgpChecks...)
 	if err != nil {
 		s, ok := status.FromError(err)
@@ -229,3 +247,17 @@ func upgradeCmdWithClient(input *upgradeInput) error {
 	fmt.Fprintf(input.streams.Out, "Upgrade triggered to version %s, Elastic Agent is currently restarting\n", version)
 	return nil
 }
+
+func printPreflightChecks(w io.Writer, version string, checks []client.UpgradePreflightCheck) {
+	if len(checks) == 0 {
+		return
+	}
+	fmt.Fprintf(w, "Pre-flight checks of the upgrade to version %s:\n", version)
+	for _, check := range checks {
+		line := fmt.Sprintf("  [%s] %s", check.Status, check.Name)
+		if check.Message != "" {
+			line += ": " + check.Message
+		}
+		fmt.Fprintln(w, line)
+	}
+}
@@ -5,7 +5,9 @@
 package cmd
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"log"
 	"net"
 	"sync/atomic"
@@ -260,6 +262,40 @@ func TestUpgradeCmd(t *testing.T) {
 		err = upgradeCmdWithClient(commandInput)
 		assert.NoError(t, err)
 	})
+	t.Run("dry run on a fleet managed agent without --force", func(t *testing.T) {
+		mockClient := clientmocks.NewClient(t)
+		mockClient.EXPECT().State(mock.Anything).Return(&client.AgentState{State: cproto.State_HEALTHY}, nil)
+		mockClient.EXPECT().UpgradeDryRun(mock.Anything, "8.13.0", "", false, false, []string(nil)).Return([]client.UpgradePreflightCheck{
+			{Name: "version", Status: "passed", Message: "8.12.0 can be upgraded to 8.13.0"},
+			{Name: "disk_space", Status: "failed", Message: "not enough space"},
+		}, errors.New("pre-flight check disk_space failed"))
+
+		args := []string{"8.13.0"} // Version argument
+		var out bytes.Buffer
+		streams := cli.NewIOStreams()
+		streams.Out = &out
+		cmd := newUpgradeCommandWithArgs(args, streams)
+		cmd.SetContext(context.Background())
+		err := cmd.Flags().Set(flagDryRun, "true")
+		if err != nil {
+			log.Fatal(err)
+		}
+
+		commandInput := &upgradeInput{
+			streams,
+			cmd,
+			args,
+			mockClient,
+			client.AgentStateInfo{IsManaged: true},
+			true,
+		}
+
+		err = upgradeCmdWithClient(commandInput)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "upgrade to version 8.13.0 would fail")
+		assert.Contains(t, out.String(), "[passed] version: 8.12.0 can be upgraded to 8.13.0")
+		assert.Contains(t, out.String(), "[failed] disk_space: not enough space")
+	})
 }
 
 type mockServer struct {
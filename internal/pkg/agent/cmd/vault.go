@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package cmd
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"github.com/spf13/cobra"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade/artifact"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/cli"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/utils"
+)
+
+// maxOCICredentialsSize is the maximum size of the registry credentials read by set-oci-credentials.
+const maxOCICredentialsSize = 64 * 1024
+
+func newVaultCommandWithArgs(args []string, streams *cli.IOStreams) *cobra.Command {
+	cmd := &cobra.Command{
+		Use:   "vault <subcommand>",
+		Short: "Manage the vault of the Elastic Agent",
+		Long:  "Manage the vault holding the agent key the Elastic Agent encrypts its stores with, and the credentials of the OCI registries.",
+	}
+
+	cmd.AddCommand(newVaultRotateCommandWithArgs(args, streams))
+	cmd.AddCommand(newVaultSetOCICredentialsCommandWithArgs(args, streams))
+
+	return cmd
+}
+
+func newVaultRotateCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "rotate",
+		Short: "Rotate the agent key of the vault",
+		Long: `Replaces the agent key of the vault with a new key and re-encrypts the encrypted stores (fleet.enc, state.enc...) with it.
+
+The rotation is performed by the running Elastic Agent, or by this command when the Elastic Agent is not running.
+A rotation interrupted before it completes is completed by the next rotation or when the Elastic Agent starts.
+The agent key can be rotated automatically with agent.vault.rotation_interval.`,
+		Args: cobra.NoArgs,
+		Run: func(c *cobra.Command, _ []string) {
+			if err := vaultRotateCmd(streams); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func vaultRotateCmd(streams *cli.IOStreams) error {
+	ctx := handleSignal(context.Background())
+
+	if _, err := getDaemonState(ctx); err != nil {
+		// the stores are only re-encrypted by this process when the Elastic Agent is not
+		// running, otherwise they could be saved concurrently with the previous key
+		if err := storage.RotateKey(ctx, paths.EncryptedStoreFiles()); err != nil {
+			return fmt.Errorf("failed to rotate the agent key: %w", err)
+		}
+		fmt.Fprintln(streams.Out, "Agent key rotated")
+		return nil
+	}
+
+	c := client.New()
+	if err := c.Connect(ctx); err != nil {
+		return fmt.Errorf("failed communicating to running daemon: %w", err)
+	}
+	defer c.Disconnect()
+
+	if err := c.RotateVaultKey(ctx); err != nil {
+		return fmt.Errorf("failed to rotate the agent key: %w", err)
+	}
+	fmt.Fprintln(streams.Out, "Agent key rotated by the running Elastic Agent")
+	return nil
+}
+
+func newVaultSetOCICredentialsCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command {
+	return &cobra.Command{
+		Use:   "set-oci-credentials <key>",
+		Short: "Store the credentials of an OCI registry in the vault",
+		Long: `Stores the credentials of the OCI registry of the oci:// source URIs in the vault under <key>, to be referenced by
+agent.download.oci.vault_key instead of writing them in the configuration.
+
+The credentials are read from the standard input as a JSON object, e.g. {"username": "puller", "password": "changeme"}
+or {"token": "..."}. The Elastic Agent reads them again when its configuration is reloaded.`,
+		Args: cobra.ExactArgs(1),
+		Run: func(c *cobra.Command, args []string) {
+			if err := vaultSetOCICredentialsCmd(streams, args[0]); err != nil {
+				fmt.Fprintf(streams.Err, "Error: %v\n%s\n", err, troubleshootMessage())
+				os.Exit(1)
+			}
+		},
+	}
+}
+
+func vaultSetOCICredentialsCmd(streams *cli.IOStreams, key string) error {
+	if key == secret.AgentSecretKey || key == secret.AgentSecretPreviousKey {
+		return fmt.Errorf("%q is reserved for the agent key", key)
+	}
+	isRoot, err := utils.HasRoot()
+	if err != nil {
+		return fmt.Errorf("error while retrieving user permission: %w", err)
+	}
+	if !isRoot {
+		return errors.New("set-oci-credentials command needs to be executed as root")
+	}
+	ctx := handleSignal(context.Background())
+
+	data, err := io.ReadAll(io.LimitReader(streams.In, maxOCICredentialsSize))
+	if err != nil {
+		return fmt.Errorf("could not read the credentials: %w", err)
+	}
+	var creds artifact.OCICredentials
+	if err := json.Unmarshal(data, &creds); err != nil {
+		return fmt.Errorf("invalid credentials: %w", err)
+	}
+	if creds.Token == "" && creds.Username == "" {
+		return errors.New("the credentials need a username or a token")
+	}
+	value, err := json.Marshal(creds)
+	if err != nil {
+		return fmt.Errorf("could not marshal the credentials: %w", err)
+	}
+
+	// the vault is read by the Elastic Agent, it is owned by the user it runs as
+	ownership, unprivileged, err := installOwnership(paths.Top())
+	if err != nil {
+		return fmt.Errorf("could not get the owner of the installation: %w", err)
+	}
+	err = secret.Set(ctx, key, secret.Secret{Value: value, CreatedOn: time.Now().UTC()},
+		vault.WithUnprivileged(unprivileged), vault.WithVaultOwnership(ownership))
+	if err != nil {
+		return fmt.Errorf("failed to store the credentials: %w", err)
+	}
+	fmt.Fprintf(streams.Out, "Credentials stored in the vault as %q\n", key)
+	return nil
+}
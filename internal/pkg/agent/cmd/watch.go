@@ -53,7 +53,7 @@ func newWatchCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command
 			// Make sure to flush any buffered logs before we're done.
 			defer log.Sync() //nolint:errcheck // flushing buffered logs is best effort.
 
-			if err := watchCmd(log, paths.Top(), cfg.Settings.Upgrade.Watcher, new(upgradeAgentWatcher), new(upgradeInstallationModifier)); err != nil {
+			if err := watchCmd(log, paths.Top(), cfg.Settings.Upgrade, new(upgradeAgentWatcher), new(upgradeInstallationModifier)); err != nil {
 				log.Errorw("Watch command failed", "error.message", err)
 				fmt.Fprintf(streams.Err, "Watch command failed: %v\n%s\n", err, troubleshootMessage())
 				os.Exit(4)
@@ -65,7 +65,7 @@ func newWatchCommandWithArgs(_ []string, streams *cli.IOStreams) *cobra.Command
 }
 
 type agentWatcher interface {
-	Watch(ctx context.Context, tilGrace, errorCheckInterval time.Duration, log *logp.Logger) error
+	Watch(ctx context.Context, tilGrace, errorCheckInterval time.Duration, criteria upgrade.HealthCriteria, log *logp.Logger) error
 }
 
 type installationModifier interface {
@@ -73,7 +73,8 @@ type installationModifier interface {
 	Rollback(ctx context.Context, log *logger.Logger, c client.Client, topDirPath, prevVersionedHome, prevHash string) error
 }
 
-func watchCmd(log *logp.Logger, topDir string, cfg *configuration.UpgradeWatcherConfig, watcher agentWatcher, installModifier installationModifier) error {
+func watchCmd(log *logp.Logger, topDir string, upgradeCfg *configuration.UpgradeConfig, watcher agentWatcher, installModifier installationModifier) error {
+	cfg := upgradeCfg.Watcher
 	log.Infow("Upgrade Watcher started", "process.pid", os.Getpid(), "agent.version", version.GetAgentPackageVersion(), "config", cfg)
 	dataDir := paths.DataFrom(topDir)
 	marker, err := upgrade.LoadMarker(dataDir)
@@ -128,11 +129,12 @@ func watchCmd(log *logp.Logger, topDir string, cfg *configuration.UpgradeWatcher
 	upgradeDetails := initUpgradeDetails(marker, saveMarkerFunc, log)
 
 	errorCheckInterval := cfg.ErrorCheck.Interval
+	criteria := upgrade.NewHealthCriteria(cfg.Health, marker.UpdatedOn, cfg.GracePeriod)
 	ctx := context.Background()
-	if err := watcher.Watch(ctx, tilGrace, errorCheckInterval, log); err != nil {
+	if err := watcher.Watch(ctx, tilGrace, errorCheckInterval, criteria, log); err != nil {
 		log.Error("Error detected, proceeding to rollback: %v", err)
 
-		upgradeDetails.SetStateWithReason(details.StateRollback, details.ReasonWatchFailed)
+		upgradeDetails.SetRollback(details.ReasonWatchFailed, err)
 		err = installModifier.Rollback(ctx, log, client.New(), paths.Top(), marker.PrevVersionedHome, marker.PrevHash)
 		if err != nil {
 			log.Error("rollback failed", err)
@@ -144,6 +146,18 @@ func watchCmd(log *logp.Logger, topDir string, cfg *configuration.UpgradeWatcher
 	// watch succeeded - upgrade was successful!
 	upgradeDetails.SetState(details.StateCompleted)
 
+	// retain the previous install, so it can be rolled back to manually, before the
+	// cleanup removes the installs that are not retained
+	retained := upgrade.RetainedInstall{
+		Version:       marker.PrevVersion,
+		Hash:          marker.PrevHash,
+		VersionedHome: marker.PrevVersionedHome,
+		RetainedOn:    time.Now().UTC(),
+	}
+	if err := upgrade.RetainInstall(log, dataDir, retained, marker.VersionedHome, upgradeCfg.Rollback.Retention); err != nil {
+		log.Error("retaining previous install failed", err)
+	}
+
 	// cleanup older versions,
 	// in windows it might leave self untouched, this will get cleaned up
 	// later at the start, because for windows we leave marker untouched.
@@ -180,7 +194,7 @@ func isWindows() bool {
 	return runtime.GOOS == "windows"
 }
 
-func watch(ctx context.Context, tilGrace time.Duration, errorCheckInterval time.Duration, log *logger.Logger) error {
+func watch(ctx context.Context, tilGrace time.Duration, errorCheckInterval time.Duration, criteria upgrade.HealthCriteria, log *logger.Logger) error {
 	errChan := make(chan error)
 
 	ctx, cancel := context.WithCancel(ctx)
@@ -192,6 +206,7 @@ func watch(ctx context.Context, tilGrace time.Duration, errorCheckInterval time.
 	}()
 
 	agentWatcher := upgrade.NewAgentWatcher(errChan, log, errorCheckInterval)
+	agentWatcher.SetHealthCriteria(criteria)
 	go agentWatcher.Run(ctx)
 
 	signals := make(chan os.Signal, 1)
@@ -211,6 +226,10 @@ WATCHLOOP:
 		// grace period passed, agent is considered stable
 		case <-t.C:
 			log.Info("Grace period passed, not watching")
+			if err := agentWatcher.CheckHealth(); err != nil {
+				log.Errorf("Agent Error detected: %s", err.Error())
+				return err
+			}
 			break WATCHLOOP
 		// Agent in degraded state.
 		case err := <-errChan:
@@ -16,8 +16,8 @@ import (
 
 type upgradeAgentWatcher struct{}
 
-func (a upgradeAgentWatcher) Watch(ctx context.Context, tilGrace, errorCheckInterval time.Duration, log *logp.Logger) error {
-	return watch(ctx, tilGrace, errorCheckInterval, log)
+func (a upgradeAgentWatcher) Watch(ctx context.Context, tilGrace, errorCheckInterval time.Duration, criteria upgrade.HealthCriteria, log *logp.Logger) error {
+	return watch(ctx, tilGrace, errorCheckInterval, criteria, log)
 }
 
 type upgradeInstallationModifier struct{}
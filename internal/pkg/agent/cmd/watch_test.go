@@ -5,6 +5,7 @@
 package cmd
 
 import (
+	"context"
 	"fmt"
 	"os"
 	"runtime"
@@ -23,6 +24,8 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
 	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
+	"github.com/elastic/elastic-agent/pkg/control/v2/client"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
 	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
@@ -87,7 +90,7 @@ func TestInitUpgradeDetails(t *testing.T) {
 
 func Test_watchCmd(t *testing.T) {
 	type args struct {
-		cfg *configuration.UpgradeWatcherConfig
+		cfg *configuration.UpgradeConfig
 	}
 	tests := []struct {
 		name               string
@@ -103,7 +106,7 @@ func Test_watchCmd(t *testing.T) {
 				require.NoError(t, err)
 			},
 			args: args{
-				cfg: configuration.DefaultUpgradeConfig().Watcher,
+				cfg: configuration.DefaultUpgradeConfig(),
 			},
 			wantErr: assert.NoError,
 		},
@@ -133,7 +136,7 @@ func Test_watchCmd(t *testing.T) {
 				require.NoError(t, err)
 
 				watcher.EXPECT().
-					Watch(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Watch(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(nil)
 
 				// on windows the marker is not removed immediately to allow for cleanup on restart
@@ -144,7 +147,7 @@ func Test_watchCmd(t *testing.T) {
 					Return(nil)
 			},
 			args: args{
-				cfg: configuration.DefaultUpgradeConfig().Watcher,
+				cfg: configuration.DefaultUpgradeConfig(),
 			},
 			wantErr: assert.NoError,
 		},
@@ -174,14 +177,23 @@ func Test_watchCmd(t *testing.T) {
 				require.NoError(t, err)
 
 				watcher.EXPECT().
-					Watch(mock.Anything, mock.Anything, mock.Anything, mock.Anything).
+					Watch(mock.Anything, mock.Anything, mock.Anything, mock.Anything, mock.Anything).
 					Return(errors.New("some watch error due to agent misbehaving"))
 				installModifier.EXPECT().
 					Rollback(mock.Anything, mock.Anything, mock.Anything, paths.Top(), "elastic-agent-prvver", "prvver").
-					Return(nil)
+					RunAndReturn(func(context.Context, *logger.Logger, client.Client, string, string, string) error {
+						// the reason of the rollback is recorded in the marker before rolling back
+						marker, err := upgrade.LoadMarker(dataDirPath)
+						require.NoError(t, err)
+						require.NotNil(t, marker.Details)
+						assert.Equal(t, details.StateRollback, marker.Details.State)
+						assert.Equal(t, details.ReasonWatchFailed, marker.Details.Metadata.Reason)
+						assert.Equal(t, "some watch error due to agent misbehaving", marker.Details.Metadata.RollbackErrorMsg)
+						return nil
+					})
 			},
 			args: args{
-				cfg: configuration.DefaultUpgradeConfig().Watcher,
+				cfg: configuration.DefaultUpgradeConfig(),
 			},
 			wantErr: assert.NoError,
 		},
@@ -222,7 +234,7 @@ func Test_watchCmd(t *testing.T) {
 					Return(nil)
 			},
 			args: args{
-				cfg: configuration.DefaultUpgradeConfig().Watcher,
+				cfg: configuration.DefaultUpgradeConfig(),
 			},
 			wantErr: assert.NoError,
 		},
@@ -259,11 +271,14 @@ func Test_watchCmd(t *testing.T) {
 					Return(nil)
 			},
 			args: args{
-				cfg: &configuration.UpgradeWatcherConfig{
-					GracePeriod: 2 * time.Minute,
-					ErrorCheck: configuration.UpgradeWatcherCheckConfig{
-						Interval: time.Second,
+				cfg: &configuration.UpgradeConfig{
+					Watcher: &configuration.UpgradeWatcherConfig{
+						GracePeriod: 2 * time.Minute,
+						ErrorCheck: configuration.UpgradeWatcherCheckConfig{
+							Interval: time.Second,
+						},
 					},
+					Rollback: configuration.DefaultUpgradeConfig().Rollback,
 				},
 			},
 			wantErr: assert.NoError,
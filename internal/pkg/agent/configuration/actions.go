@@ -0,0 +1,37 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package configuration
+
+import "time"
+
+// default number of actions handled at the same time, so a slow action like diagnostics or
+// an upgrade does not block the actions of the other types.
+const defaultActionsMaxConcurrency = 4
+
+// ActionsConfig is the configuration of the dispatch of the actions.
+type ActionsConfig struct {
+	// MaxConcurrency is the maximum number of actions handled at the same time. Actions
+	// that must be ordered, like two policy changes, are always handled one after the other.
+	MaxConcurrency int `yaml:"max_concurrency" config:"max_concurrency" json:"max_concurrency"`
+	// Timeout is the time an action handler has to complete, no timeout when 0.
+	Timeout time.Duration `yaml:"timeout" config:"timeout" json:"timeout"`
+	// Timeouts overrides Timeout for the action types, e.g. UPGRADE: 1h.
+	Timeouts map[string]time.Duration `yaml:"timeouts,omitempty" config:"timeouts" json:"timeouts,omitempty"`
+}
+
+// DefaultActionsConfig creates a config with pre-set default values.
+func DefaultActionsConfig() *ActionsConfig {
+	return &ActionsConfig{
+		MaxConcurrency: defaultActionsMaxConcurrency,
+	}
+}
+
+// TimeoutFor returns the timeout of the actions of the type, 0 when they have none.
+func (c *ActionsConfig) TimeoutFor(actionType string) time.Duration {
+	if t, ok := c.Timeouts[actionType]; ok {
+		return t
+	}
+	return c.Timeout
+}
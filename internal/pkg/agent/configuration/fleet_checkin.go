@@ -0,0 +1,24 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package configuration
+
+import "time"
+
+// default time between full check-ins when Fleet Server supports delta check-ins.
+const defaultFullCheckinInterval = 10 * time.Minute
+
+// FleetCheckinConfig is the configuration of the check-ins with Fleet Server.
+type FleetCheckinConfig struct {
+	// FullCheckinInterval is the time between check-ins sending the state of all the components
+	// when Fleet Server supports delta check-ins. Every check-in sends all the components when 0.
+	FullCheckinInterval time.Duration `yaml:"full_checkin_interval" config:"full_checkin_interval" json:"full_checkin_interval"`
+}
+
+// DefaultFleetCheckinConfig creates a config with pre-set default values.
+func DefaultFleetCheckinConfig() *FleetCheckinConfig {
+	return &FleetCheckinConfig{
+		FullCheckinInterval: defaultFullCheckinInterval,
+	}
+}
@@ -0,0 +1,46 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package configuration
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/config"
+)
+
+func TestParseFleetCheckinConfig(t *testing.T) {
+	tests := map[string]struct {
+		cfg      map[string]any
+		expected time.Duration
+	}{
+		"default": {
+			cfg:      map[string]any{},
+			expected: defaultFullCheckinInterval,
+		},
+		"full_checkin_interval": {
+			cfg: map[string]any{
+				"agent.fleet_checkin.full_checkin_interval": "1h",
+			},
+			expected: time.Hour,
+		},
+		"full check-ins only": {
+			cfg: map[string]any{
+				"agent.fleet_checkin.full_checkin_interval": "0s",
+			},
+			expected: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			c, err := NewFromConfig(config.MustNewConfigFrom(test.cfg))
+			require.NoError(t, err)
+			require.Equal(t, test.expected, c.Settings.FleetCheckin.FullCheckinInterval)
+		})
+	}
+}
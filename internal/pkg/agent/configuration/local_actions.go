@@ -0,0 +1,30 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package configuration
+
+import "time"
+
+// default interval between two scans of the local actions directory.
+const defaultLocalActionsPeriod = 5 * time.Second
+
+// LocalActionsConfig is the configuration of the actions accepted from the host by standalone agents.
+type LocalActionsConfig struct {
+	// Enabled makes a standalone agent dispatch the actions written to the actions directory.
+	Enabled bool `yaml:"enabled" config:"enabled" json:"enabled"`
+	// Path is the directory watched for actions, defaults to the actions directory under the data path.
+	Path string `yaml:"path" config:"path" json:"path"`
+	// Period is the interval between two scans of the actions directory.
+	Period time.Duration `yaml:"period" config:"period" json:"period"`
+	// ResultsFile is the file the results of the actions are written to, defaults to a file under the data path.
+	ResultsFile string `yaml:"results_file" config:"results_file" json:"results_file"`
+}
+
+// DefaultLocalActionsConfig creates a config with pre-set default values.
+func DefaultLocalActionsConfig() *LocalActionsConfig {
+	return &LocalActionsConfig{
+		Enabled: false,
+		Period:  defaultLocalActionsPeriod,
+	}
+}
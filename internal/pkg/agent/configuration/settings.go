@@ -22,11 +22,16 @@ type SettingsConfig struct {
 	LoggingConfig      *logger.Config                  `yaml:"logging,omitempty" config:"logging,omitempty" json:"logging,omitempty"`
 	EventLoggingConfig *logger.Config                  `yaml:"logging.event_data,omitempty" config:"logging.event_data,omitempty" json:"logging.event_data,omitempty"`
 	Upgrade            *UpgradeConfig                  `yaml:"upgrade" config:"upgrade" json:"upgrade"`
+	StateJournal       *StateJournalConfig             `yaml:"state_journal" config:"state_journal" json:"state_journal"`
+	Actions            *ActionsConfig                  `yaml:"actions" config:"actions" json:"actions"`
+	Vault              *VaultConfig                    `yaml:"vault" config:"vault" json:"vault"`
+	FleetCheckin       *FleetCheckinConfig             `yaml:"fleet_checkin" config:"fleet_checkin" json:"fleet_checkin"`
 
 	// standalone config
-	Reload              *ReloadConfig `config:"reload" yaml:"reload" json:"reload"`
-	Path                string        `config:"path" yaml:"path" json:"path"`
-	V1MonitoringEnabled bool          `config:"v1_monitoring_enabled" yaml:"v1_monitoring_enabled" json:"v1_monitoring_enabled"`
+	Reload              *ReloadConfig       `config:"reload" yaml:"reload" json:"reload"`
+	Path                string              `config:"path" yaml:"path" json:"path"`
+	V1MonitoringEnabled bool                `config:"v1_monitoring_enabled" yaml:"v1_monitoring_enabled" json:"v1_monitoring_enabled"`
+	LocalActions        *LocalActionsConfig `config:"local_actions" yaml:"local_actions" json:"local_actions"`
 }
 
 // DefaultSettingsConfig creates a config with pre-set default values.
@@ -39,7 +44,12 @@ func DefaultSettingsConfig() *SettingsConfig {
 		MonitoringConfig:    monitoringCfg.DefaultConfig(),
 		GRPC:                DefaultGRPCConfig(),
 		Upgrade:             DefaultUpgradeConfig(),
+		StateJournal:        DefaultStateJournalConfig(),
+		Actions:             DefaultActionsConfig(),
+		Vault:               DefaultVaultConfig(),
+		FleetCheckin:        DefaultFleetCheckinConfig(),
 		Reload:              DefaultReloadConfig(),
 		V1MonitoringEnabled: true,
+		LocalActions:        DefaultLocalActionsConfig(),
 	}
 }
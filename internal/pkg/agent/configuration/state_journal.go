@@ -0,0 +1,24 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package configuration
+
+// default number of state transitions kept in the state journal.
+const defaultStateJournalSize = 1000
+
+// StateJournalConfig is the configuration of the journal of component and unit state transitions.
+type StateJournalConfig struct {
+	// Size is the maximum number of state transitions kept in the journal.
+	Size int `yaml:"size" config:"size" json:"size"`
+	// Persist writes the journal to disk, so it is kept across restarts of the Elastic Agent.
+	Persist bool `yaml:"persist" config:"persist" json:"persist"`
+}
+
+// DefaultStateJournalConfig creates a config with pre-set default values.
+func DefaultStateJournalConfig() *StateJournalConfig {
+	return &StateJournalConfig{
+		Size:    defaultStateJournalSize,
+		Persist: false,
+	}
+}
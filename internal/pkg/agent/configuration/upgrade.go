@@ -4,7 +4,12 @@
 
 package configuration
 
-import "time"
+import (
+	"errors"
+	"fmt"
+	"net/url"
+	"time"
+)
 
 const (
 	// period during which we monitor for failures resulting in a rollback.
@@ -16,6 +21,12 @@ const (
 	// period during which an upgraded Agent can be asked to rollback to the previous
 	// Agent version on disk.
 	defaultRollbackWindowDuration = 7 * 24 * time.Hour // 7 days
+
+	// interval between two requests of the HTTP probe of the upgraded Agent.
+	defaultHTTPProbeInterval = 10 * time.Second
+
+	// time an HTTP probe request has to complete.
+	defaultHTTPProbeTimeout = 5 * time.Second
 )
 
 // UpgradeConfig is the configuration related to Agent upgrades.
@@ -25,15 +36,74 @@ type UpgradeConfig struct {
 }
 
 type UpgradeWatcherConfig struct {
-	GracePeriod time.Duration             `yaml:"grace_period" config:"grace_period" json:"grace_period"`
-	ErrorCheck  UpgradeWatcherCheckConfig `yaml:"error_check" config:"error_check" json:"error_check"`
+	GracePeriod time.Duration              `yaml:"grace_period" config:"grace_period" json:"grace_period"`
+	ErrorCheck  UpgradeWatcherCheckConfig  `yaml:"error_check" config:"error_check" json:"error_check"`
+	Health      UpgradeWatcherHealthConfig `yaml:"health" config:"health" json:"health"`
+}
+
+// Validate validates the watcher configuration.
+func (c *UpgradeWatcherConfig) Validate() error {
+	// the upgrade could never succeed, the Agent could not be stable for the minimum
+	// duration by the end of the grace period
+	if c.Health.MinStableDuration > 0 && c.Health.MinStableDuration >= c.GracePeriod {
+		return fmt.Errorf("health.min_stable_duration (%s) must be less than grace_period (%s)", c.Health.MinStableDuration, c.GracePeriod)
+	}
+	return nil
 }
+
 type UpgradeWatcherCheckConfig struct {
 	Interval time.Duration `yaml:"interval" config:"interval" json:"interval"`
 }
 
+// UpgradeWatcherHealthConfig is the configuration of the criteria the upgraded Agent must
+// meet, in addition to not failing, for the upgrade to succeed. The upgrade is rolled
+// back when they are not met.
+type UpgradeWatcherHealthConfig struct {
+	// RequiredComponents are the IDs of the components, or of their units as
+	// <component id>/<unit id>, that must be HEALTHY.
+	RequiredComponents []string `yaml:"required_components,omitempty" config:"required_components" json:"required_components,omitempty"`
+	// Deadline is the time after the upgrade by which the required components and the
+	// HTTP probe must be healthy. It defaults to, and cannot exceed, the grace period.
+	Deadline time.Duration `yaml:"deadline" config:"deadline" json:"deadline"`
+	// MinStableDuration is the time the upgraded Agent must have met all the criteria
+	// without interruption at the end of the grace period.
+	MinStableDuration time.Duration `yaml:"min_stable_duration" config:"min_stable_duration" json:"min_stable_duration"`
+	// HTTPProbe is an HTTP endpoint that must respond with a 2xx status, none when nil.
+	HTTPProbe *UpgradeWatcherHTTPProbeConfig `yaml:"http_probe,omitempty" config:"http_probe" json:"http_probe,omitempty"`
+}
+
+// UpgradeWatcherHTTPProbeConfig is the configuration of the HTTP probe of the upgraded Agent.
+type UpgradeWatcherHTTPProbeConfig struct {
+	URL      string        `yaml:"url" config:"url" json:"url"`
+	Interval time.Duration `yaml:"interval" config:"interval" json:"interval"`
+	Timeout  time.Duration `yaml:"timeout" config:"timeout" json:"timeout"`
+}
+
+// InitDefaults initializes the defaults of the HTTP probe configuration.
+func (c *UpgradeWatcherHTTPProbeConfig) InitDefaults() {
+	c.Interval = defaultHTTPProbeInterval
+	c.Timeout = defaultHTTPProbeTimeout
+}
+
+// Validate validates the HTTP probe configuration.
+func (c *UpgradeWatcherHTTPProbeConfig) Validate() error {
+	if c.URL == "" {
+		return errors.New("http_probe.url is required")
+	}
+	if _, err := url.ParseRequestURI(c.URL); err != nil {
+		return fmt.Errorf("invalid http_probe.url: %w", err)
+	}
+	if c.Interval <= 0 || c.Timeout <= 0 {
+		return errors.New("http_probe.interval and http_probe.timeout must be positive")
+	}
+	return nil
+}
+
 type UpgradeRollbackConfig struct {
 	Window time.Duration `yaml:"window" config:"window" json:"window"`
+	// Retention is the number of previous installs kept after successful upgrades, the
+	// Agent can be rolled back to them with the rollback command or action.
+	Retention int `yaml:"retention" config:"retention" json:"retention"`
 }
 
 func DefaultUpgradeConfig() *UpgradeConfig {
@@ -97,3 +97,23 @@ func TestParseUpgradeConfig(t *testing.T) {
 		})
 	}
 }
+
+func TestParseUpgradeConfig_MinStableDuration(t *testing.T) {
+	c := DefaultUpgradeConfig()
+	cfg := config.MustNewConfigFrom(map[string]any{
+		"watcher.health.min_stable_duration": "5m",
+	})
+	require.NoError(t, cfg.UnpackTo(c))
+	require.Equal(t, 5*time.Minute, c.Watcher.Health.MinStableDuration)
+
+	for name, watcher := range map[string]map[string]any{
+		"equal to the grace period":    {"grace_period": "5m", "health.min_stable_duration": "5m"},
+		"longer than the grace period": {"health.min_stable_duration": "1h"},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c := DefaultUpgradeConfig()
+			cfg := config.MustNewConfigFrom(map[string]any{"watcher": watcher})
+			require.ErrorContains(t, cfg.UnpackTo(c), "must be less than grace_period")
+		})
+	}
+}
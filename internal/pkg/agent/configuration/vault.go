@@ -0,0 +1,21 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package configuration
+
+import "time"
+
+// VaultConfig is the configuration of the vault holding the agent key.
+type VaultConfig struct {
+	// RotationInterval is the age at which the agent key is rotated and the encrypted
+	// stores re-encrypted with a new key. The key is not rotated automatically when 0.
+	RotationInterval time.Duration `yaml:"rotation_interval" config:"rotation_interval" json:"rotation_interval"`
+}
+
+// DefaultVaultConfig creates a config with pre-set default values.
+func DefaultVaultConfig() *VaultConfig {
+	return &VaultConfig{
+		RotationInterval: 0,
+	}
+}
@@ -12,6 +12,7 @@ import (
 	"io/fs"
 	"os"
 	"runtime"
+	"sync"
 
 	"github.com/elastic/elastic-agent-libs/file"
 
@@ -28,6 +29,27 @@ const darwin = "darwin"
 
 var encryptionDisabled bool
 
+var (
+	// agentKeysMx protects access to agentKeys and agentKeysGeneration
+	agentKeysMx sync.Mutex
+	// agentKeys caches the agent keys read from the vaults, so the vault is not read
+	// on every Load and Save.
+	agentKeys = make(map[agentKeyCacheKey]cachedAgentKey)
+	// agentKeysGeneration is incremented every time an agent key is replaced, invalidating
+	// the keys cached before.
+	agentKeysGeneration uint64
+)
+
+type agentKeyCacheKey struct {
+	vaultPath    string
+	unprivileged bool
+}
+
+type cachedAgentKey struct {
+	value      []byte
+	generation uint64
+}
+
 // DisableEncryptionDarwin disables storage encryption.
 // Is needed for existing unit tests on Mac OS, because the system keychain requires sudo
 func DisableEncryptionDarwin() {
@@ -42,6 +64,21 @@ type EncryptedOptionFunc func(s *EncryptedDiskStore)
 // NewEncryptedDiskStore creates an encrypted disk store.
 // Drop-in replacement for NewDiskStorage
 func NewEncryptedDiskStore(ctx context.Context, target string, opts ...EncryptedOptionFunc) (Storage, error) {
+	s, err := newEncryptedDiskStore(ctx, target, opts...)
+	if err != nil {
+		return nil, err
+	}
+	if encryptionDisabled {
+		var opts []DiskStoreOptionFunc
+		if s.ownership != nil {
+			opts = append(opts, DiskStoreWithOwnership(*s.ownership))
+		}
+		return NewDiskStore(target, opts...)
+	}
+	return s, nil
+}
+
+func newEncryptedDiskStore(ctx context.Context, target string, opts ...EncryptedOptionFunc) (*EncryptedDiskStore, error) {
 	unprivileged := false
 	hasRoot, err := utils.HasRoot()
 	if err != nil {
@@ -60,13 +97,6 @@ func NewEncryptedDiskStore(ctx context.Context, target string, opts ...Encrypted
 	for _, opt := range opts {
 		opt(s)
 	}
-	if encryptionDisabled {
-		var opts []DiskStoreOptionFunc
-		if s.ownership != nil {
-			opts = append(opts, DiskStoreWithOwnership(*s.ownership))
-		}
-		return NewDiskStore(target, opts...)
-	}
 	return s, nil
 }
 
@@ -103,15 +133,39 @@ func (d *EncryptedDiskStore) Exists() (bool, error) {
 	return true, nil
 }
 
-func (d *EncryptedDiskStore) ensureKey(ctx context.Context) error {
-	if d.key == nil {
-		key, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(d.vaultPath), vault.WithUnprivileged(d.unprivileged))
-		if err != nil {
-			return fmt.Errorf("could not get agent key: %w", err)
-		}
-		d.key = key.Value
+func (d *EncryptedDiskStore) vaultOpts() []vault.OptionFunc {
+	return []vault.OptionFunc{vault.WithVaultPath(d.vaultPath), vault.WithUnprivileged(d.unprivileged)}
+}
+
+// agentKey returns the agent key of the vault. The key is cached until it is rotated,
+// rotations are performed by the running Elastic Agent so they invalidate its cache.
+func (d *EncryptedDiskStore) agentKey(ctx context.Context) ([]byte, error) {
+	cacheKey := agentKeyCacheKey{vaultPath: d.vaultPath, unprivileged: d.unprivileged}
+	agentKeysMx.Lock()
+	cached, ok := agentKeys[cacheKey]
+	// the generation is read before the vault, a key read while being rotated is not kept
+	generation := agentKeysGeneration
+	agentKeysMx.Unlock()
+	if ok && cached.generation == generation {
+		return cached.value, nil
 	}
-	return nil
+
+	key, err := secret.GetAgentSecret(ctx, d.vaultOpts()...)
+	if err != nil {
+		return nil, fmt.Errorf("could not get agent key: %w", err)
+	}
+
+	agentKeysMx.Lock()
+	agentKeys[cacheKey] = cachedAgentKey{value: key.Value, generation: generation}
+	agentKeysMx.Unlock()
+	return key.Value, nil
+}
+
+// invalidateAgentKeys drops the cached agent keys, they are read from the vaults again.
+func invalidateAgentKeys() {
+	agentKeysMx.Lock()
+	agentKeysGeneration++
+	agentKeysMx.Unlock()
 }
 
 // Save will read 'in' and write its contents encrypted to disk.
@@ -122,12 +176,19 @@ func (d *EncryptedDiskStore) ensureKey(ctx context.Context) error {
 // Specially on windows systems, if the original files is still open because of
 // Load(), Save() would fail.
 func (d *EncryptedDiskStore) Save(in io.Reader) error {
-	// Ensure has agent key
-	err := d.ensureKey(d.ctx)
+	// the store must not be saved with the agent key being replaced
+	rotationMx.RLock()
+	defer rotationMx.RUnlock()
+
+	key, err := d.agentKey(d.ctx)
 	if err != nil {
 		return errors.New(err, "failed to ensure key")
 	}
 
+	return d.save(in, key)
+}
+
+func (d *EncryptedDiskStore) save(in io.Reader, key []byte) error {
 	tmpFile := d.target + ".tmp"
 
 	fd, err := os.OpenFile(tmpFile, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, permMask)
@@ -142,7 +203,7 @@ func (d *EncryptedDiskStore) Save(in io.Reader) error {
 	defer os.Remove(tmpFile)
 
 	// Wrap into crypto writer, reusing already existing crypto writer, open to other suggestions
-	w, err := crypto.NewWriterWithDefaults(fd, d.key)
+	w, err := crypto.NewWriterWithDefaults(fd, key)
 	if err != nil {
 		fd.Close()
 		return errors.New(err, "failed to open crypto writers")
@@ -198,8 +259,10 @@ func (d *EncryptedDiskStore) Save(in io.Reader) error {
 }
 
 // Load returns an io.ReadCloser for the target.
+// While the agent key is rotated, the target is decrypted with the previous agent key
+// when it is not re-encrypted with the new one yet.
 func (d *EncryptedDiskStore) Load() (rc io.ReadCloser, err error) {
-	fd, err := os.OpenFile(d.target, os.O_RDONLY, permMask)
+	ciphertext, err := os.ReadFile(d.target)
 	if err != nil {
 		if errors.Is(err, os.ErrNotExist) {
 			// If file doesn't exist, return empty reader closer
@@ -211,18 +274,39 @@ func (d *EncryptedDiskStore) Load() (rc io.ReadCloser, err error) {
 			errors.M(errors.MetaKeyPath, d.target))
 	}
 
-	// Close fd if there is an error upon return
-	defer func() {
-		if err != nil && fd != nil {
-			_ = fd.Close()
+	key, err := d.agentKey(d.ctx)
+	if err != nil {
+		return nil, errors.New(err, "failed to ensure key during encrypted disk store Load")
+	}
+
+	content, err := decrypt(ciphertext, key)
+	if err != nil {
+		// the agent key may have been replaced by another process, e.g. restored from a backup
+		invalidateAgentKeys()
+		if key, err = d.agentKey(d.ctx); err != nil {
+			return nil, errors.New(err, "failed to ensure key during encrypted disk store Load")
+		}
+		content, err = decrypt(ciphertext, key)
+	}
+	if err != nil {
+		previous, rotating, prevErr := secret.GetPreviousAgentSecret(d.ctx, d.vaultOpts()...)
+		if prevErr != nil || !rotating {
+			return nil, errors.New(err, fmt.Sprintf("could not decrypt %s", d.target), errors.M(errors.MetaKeyPath, d.target))
+		}
+		if content, err = decrypt(ciphertext, previous.Value); err != nil {
+			return nil, errors.New(err, fmt.Sprintf("could not decrypt %s", d.target), errors.M(errors.MetaKeyPath, d.target))
 		}
-	}()
+	}
 
-	// Ensure has agent key
-	err = d.ensureKey(d.ctx)
+	return io.NopCloser(bytes.NewReader(content)), nil
+}
+
+func decrypt(ciphertext []byte, key []byte) ([]byte, error) {
+	r, err := crypto.NewReaderWithDefaults(bytes.NewReader(ciphertext), key)
 	if err != nil {
-		return nil, errors.New(err, "failed to ensure key during encrypted disk store Load")
+		return nil, err
 	}
+	defer r.Close()
 
-	return crypto.NewReaderWithDefaults(fd, d.key)
+	return io.ReadAll(r)
 }
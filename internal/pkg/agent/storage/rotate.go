@@ -0,0 +1,197 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+// keyRotationCheckInterval is the maximum interval at which the age of the agent key is checked.
+const keyRotationCheckInterval = time.Hour
+
+var (
+	// rotationMx prevents the encrypted disk stores from being saved while they are
+	// re-encrypted, so their content is not overwritten with the content loaded before.
+	rotationMx sync.RWMutex
+
+	// rotateMx serializes the rotations of the agent key.
+	rotateMx sync.Mutex
+)
+
+// RotateKey replaces the agent key with a new one and re-encrypts the encrypted disk
+// stores of targets with it.
+//
+// The previous agent key is kept in the vault, to decrypt the stores not re-encrypted
+// yet, until all the stores are re-encrypted. Each store is replaced atomically, so a
+// rotation interrupted at any point leaves every store readable and is completed by
+// ResumeKeyRotation or the next RotateKey.
+func RotateKey(ctx context.Context, targets []string, opts ...EncryptedOptionFunc) error {
+	if encryptionDisabled {
+		return nil
+	}
+
+	rotateMx.Lock()
+	defer rotateMx.Unlock()
+
+	s, err := newEncryptedDiskStore(ctx, "", opts...)
+	if err != nil {
+		return err
+	}
+	_, err = secret.StartAgentSecretRotation(ctx, s.vaultOpts()...)
+	// invalidated even on failure, the key may have been replaced before the error
+	invalidateAgentKeys()
+	if err != nil {
+		return fmt.Errorf("failed to replace the agent key: %w", err)
+	}
+	return completeKeyRotation(ctx, s, targets, opts...)
+}
+
+// ResumeKeyRotation completes the rotation of the agent key when one was interrupted,
+// returns true when a rotation was completed.
+func ResumeKeyRotation(ctx context.Context, targets []string, opts ...EncryptedOptionFunc) (bool, error) {
+	if encryptionDisabled {
+		return false, nil
+	}
+
+	rotateMx.Lock()
+	defer rotateMx.Unlock()
+
+	s, err := newEncryptedDiskStore(ctx, "", opts...)
+	if err != nil {
+		return false, err
+	}
+	_, rotating, err := secret.GetPreviousAgentSecret(ctx, s.vaultOpts()...)
+	if err != nil || !rotating {
+		return false, err
+	}
+	// the rotation may have been interrupted before the agent key was replaced
+	_, err = secret.StartAgentSecretRotation(ctx, s.vaultOpts()...)
+	invalidateAgentKeys()
+	if err != nil {
+		return false, fmt.Errorf("failed to replace the agent key: %w", err)
+	}
+	return true, completeKeyRotation(ctx, s, targets, opts...)
+}
+
+// completeKeyRotation re-encrypts the targets with the agent key then removes the
+// previous agent key from the vault of s.
+func completeKeyRotation(ctx context.Context, s *EncryptedDiskStore, targets []string, opts ...EncryptedOptionFunc) error {
+	for _, target := range targets {
+		store, err := newEncryptedDiskStore(ctx, target, opts...)
+		if err != nil {
+			return err
+		}
+		if err := store.reencrypt(); err != nil {
+			return fmt.Errorf("failed to re-encrypt %s: %w", target, err)
+		}
+	}
+
+	if err := secret.CompleteAgentSecretRotation(ctx, s.vaultOpts()...); err != nil {
+		return fmt.Errorf("failed to remove the previous agent key: %w", err)
+	}
+	return nil
+}
+
+// reencrypt encrypts the store with the agent key when it is encrypted with the previous one.
+func (d *EncryptedDiskStore) reencrypt() error {
+	rotationMx.Lock()
+	defer rotationMx.Unlock()
+
+	ciphertext, err := os.ReadFile(d.target)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+
+	key, err := d.agentKey(d.ctx)
+	if err != nil {
+		return err
+	}
+	if _, err := decrypt(ciphertext, key); err == nil {
+		// already re-encrypted
+		return nil
+	}
+
+	previous, rotating, err := secret.GetPreviousAgentSecret(d.ctx, d.vaultOpts()...)
+	if err != nil {
+		return fmt.Errorf("could not get the previous agent key: %w", err)
+	}
+	if !rotating {
+		return errors.New("the store is encrypted with neither the agent key nor the previous agent key")
+	}
+	content, err := decrypt(ciphertext, previous.Value)
+	if err != nil {
+		return fmt.Errorf("the store is encrypted with neither the agent key nor the previous agent key: %w", err)
+	}
+
+	return d.save(bytes.NewReader(content), key)
+}
+
+// KeyRotator rotates the agent key once it is older than the rotation interval.
+type KeyRotator struct {
+	log      *logger.Logger
+	interval time.Duration
+	targets  []string
+	opts     []EncryptedOptionFunc
+}
+
+// NewKeyRotator creates a rotator of the agent key re-encrypting the encrypted disk stores of targets.
+func NewKeyRotator(log *logger.Logger, interval time.Duration, targets []string, opts ...EncryptedOptionFunc) *KeyRotator {
+	return &KeyRotator{
+		log:      log,
+		interval: interval,
+		targets:  targets,
+		opts:     opts,
+	}
+}
+
+// Run rotates the agent key every time it is older than the rotation interval, until
+// the context is cancelled.
+func (r *KeyRotator) Run(ctx context.Context) {
+	checkInterval := min(r.interval, keyRotationCheckInterval)
+	for {
+		r.rotateIfDue(ctx)
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(checkInterval):
+		}
+	}
+}
+
+func (r *KeyRotator) rotateIfDue(ctx context.Context) {
+	s, err := newEncryptedDiskStore(ctx, "", r.opts...)
+	if err != nil {
+		r.log.Errorf("Failed to check the age of the agent key: %v", err)
+		return
+	}
+	key, err := secret.GetAgentSecret(ctx, s.vaultOpts()...)
+	if err != nil {
+		r.log.Errorf("Failed to check the age of the agent key: %v", err)
+		return
+	}
+	if time.Since(key.CreatedOn) < r.interval {
+		return
+	}
+
+	r.log.Infof("Rotating the agent key created on %s", key.CreatedOn.Format(time.RFC3339))
+	if err := RotateKey(ctx, r.targets, r.opts...); err != nil {
+		r.log.Errorf("Failed to rotate the agent key: %v", err)
+		return
+	}
+	r.log.Info("Rotated the agent key")
+}
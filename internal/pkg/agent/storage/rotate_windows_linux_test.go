@@ -0,0 +1,204 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build linux || windows
+
+package storage
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/testutils/fipsutils"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+func newRotationTestStores(t *testing.T, ctx context.Context, dir string, contents map[string]string) []string {
+	require.NoError(t, secret.CreateAgentSecret(ctx, vault.WithVaultPath(dir)))
+
+	targets := make([]string, 0, len(contents)+1)
+	for name, content := range contents {
+		target := filepath.Join(dir, name)
+		s, err := NewEncryptedDiskStore(ctx, target, WithVaultPath(dir))
+		require.NoError(t, err)
+		require.NoError(t, s.Save(bytes.NewBufferString(content)))
+		targets = append(targets, target)
+	}
+	// stores that do not exist are skipped
+	return append(targets, filepath.Join(dir, "missing.enc"))
+}
+
+func requireStoreContent(t *testing.T, ctx context.Context, dir string, target string, expected string) {
+	s, err := NewEncryptedDiskStore(ctx, target, WithVaultPath(dir))
+	require.NoError(t, err)
+	r, err := s.Load()
+	require.NoError(t, err)
+	defer r.Close()
+	content, err := io.ReadAll(r)
+	require.NoError(t, err)
+	require.Equal(t, expected, string(content))
+}
+
+func TestRotateKey(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "encrypted disk storage does not use NewGCMWithRandomNonce.")
+	dir := t.TempDir()
+	ctx := context.Background()
+	contents := map[string]string{"fleet.enc": "fleet config", "state.enc": "state"}
+	targets := newRotationTestStores(t, ctx, dir, contents)
+
+	original, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	ciphertexts := make(map[string][]byte)
+	for _, target := range targets[:len(contents)] {
+		ciphertexts[target], err = os.ReadFile(target)
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, RotateKey(ctx, targets, WithVaultPath(dir)))
+
+	rotated, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.NotEqual(t, original.Value, rotated.Value)
+	_, rotating, err := secret.GetPreviousAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.False(t, rotating, "the previous key must be removed once the rotation completes")
+
+	for name, content := range contents {
+		target := filepath.Join(dir, name)
+		requireStoreContent(t, ctx, dir, target, content)
+		ciphertext, err := os.ReadFile(target)
+		require.NoError(t, err)
+		_, err = decrypt(ciphertext, rotated.Value)
+		assert.NoError(t, err, "%s must be re-encrypted with the new key", name)
+		assert.NotEqual(t, ciphertexts[target], ciphertext)
+	}
+	assert.NoFileExists(t, filepath.Join(dir, "missing.enc"))
+}
+
+func TestRotateKey_Interrupted(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "encrypted disk storage does not use NewGCMWithRandomNonce.")
+	dir := t.TempDir()
+	ctx := context.Background()
+	contents := map[string]string{"fleet.enc": "fleet config", "state.enc": "state"}
+	targets := newRotationTestStores(t, ctx, dir, contents)
+
+	// the rotation stopped once the first store was re-encrypted
+	_, err := secret.StartAgentSecretRotation(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	first, err := newEncryptedDiskStore(ctx, filepath.Join(dir, "fleet.enc"), WithVaultPath(dir))
+	require.NoError(t, err)
+	require.NoError(t, first.reencrypt())
+
+	// the stores encrypted with either key are readable and saved with the new key
+	for name, content := range contents {
+		requireStoreContent(t, ctx, dir, filepath.Join(dir, name), content)
+	}
+
+	resumed, err := ResumeKeyRotation(ctx, targets, WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.True(t, resumed)
+	_, rotating, err := secret.GetPreviousAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.False(t, rotating)
+	for name, content := range contents {
+		requireStoreContent(t, ctx, dir, filepath.Join(dir, name), content)
+	}
+
+	resumed, err = ResumeKeyRotation(ctx, targets, WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.False(t, resumed, "no rotation is in progress")
+}
+
+func TestRotateKey_UnknownKey(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "encrypted disk storage does not use NewGCMWithRandomNonce.")
+	dir := t.TempDir()
+	ctx := context.Background()
+	targets := newRotationTestStores(t, ctx, dir, map[string]string{"fleet.enc": "fleet config"})
+
+	// a store encrypted with another key is not lost, the previous key is kept
+	other := filepath.Join(dir, "other")
+	require.NoError(t, os.Mkdir(other, 0o750))
+	otherTargets := newRotationTestStores(t, ctx, other, map[string]string{"state.enc": "state"})
+	targets = append(targets, otherTargets[0])
+
+	err := RotateKey(ctx, targets, WithVaultPath(dir))
+	assert.ErrorContains(t, err, "neither the agent key nor the previous agent key")
+	_, rotating, err := secret.GetPreviousAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.True(t, rotating)
+	requireStoreContent(t, ctx, dir, targets[0], "fleet config")
+}
+
+func TestKeyRotator(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "encrypted disk storage does not use NewGCMWithRandomNonce.")
+	dir := t.TempDir()
+	ctx := context.Background()
+	targets := newRotationTestStores(t, ctx, dir, map[string]string{"fleet.enc": "fleet config"})
+	original, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	log, _ := loggertest.New("key-rotator")
+
+	// the key is not rotated before the interval
+	NewKeyRotator(log, time.Hour, targets, WithVaultPath(dir)).rotateIfDue(ctx)
+	current, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.Equal(t, original.Value, current.Value)
+
+	original.CreatedOn = time.Now().Add(-2 * time.Hour)
+	require.NoError(t, secret.SetAgentSecret(ctx, original, vault.WithVaultPath(dir)))
+	NewKeyRotator(log, time.Hour, targets, WithVaultPath(dir)).rotateIfDue(ctx)
+	current, err = secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	assert.NotEqual(t, original.Value, current.Value)
+	requireStoreContent(t, ctx, dir, targets[0], "fleet config")
+}
+
+func TestAgentKeyCache(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "encrypted disk storage does not use NewGCMWithRandomNonce.")
+	dir := t.TempDir()
+	ctx := context.Background()
+	targets := newRotationTestStores(t, ctx, dir, map[string]string{"fleet.enc": "fleet config"})
+	original, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+
+	otherDir := t.TempDir()
+	require.NoError(t, secret.CreateAgentSecret(ctx, vault.WithVaultPath(otherDir)))
+	other, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(otherDir))
+	require.NoError(t, err)
+	require.NoError(t, secret.SetAgentSecret(ctx, other, vault.WithVaultPath(dir)))
+
+	// the key cached by the previous Save is used, the vault is not read again
+	s, err := newEncryptedDiskStore(ctx, targets[0], WithVaultPath(dir))
+	require.NoError(t, err)
+	require.NoError(t, s.Save(bytes.NewBufferString("cached key")))
+	ciphertext, err := os.ReadFile(targets[0])
+	require.NoError(t, err)
+	_, err = decrypt(ciphertext, original.Value)
+	assert.NoError(t, err, "the store must be encrypted with the cached key")
+	requireStoreContent(t, ctx, dir, targets[0], "cached key")
+
+	// a store that cannot be decrypted with the cached key is decrypted with the key of the vault
+	require.NoError(t, s.save(bytes.NewBufferString("vault key"), other.Value))
+	requireStoreContent(t, ctx, dir, targets[0], "vault key")
+
+	// the rotation invalidates the cached key
+	require.NoError(t, RotateKey(ctx, targets, WithVaultPath(dir)))
+	rotated, err := secret.GetAgentSecret(ctx, vault.WithVaultPath(dir))
+	require.NoError(t, err)
+	require.NoError(t, s.Save(bytes.NewBufferString("rotated key")))
+	ciphertext, err = os.ReadFile(targets[0])
+	require.NoError(t, err)
+	_, err = decrypt(ciphertext, rotated.Value)
+	assert.NoError(t, err, "the store must be encrypted with the rotated key")
+}
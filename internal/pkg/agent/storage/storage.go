@@ -45,7 +45,6 @@ type EncryptedDiskStore struct {
 	ctx          context.Context
 	target       string
 	vaultPath    string
-	key          []byte
 	unprivileged bool
 	ownership    *utils.FileOwner
 }
@@ -35,6 +35,7 @@ type saveLoader interface {
 // StateStore stores the agent state:
 //   - the last fleet action (not all actions are stored, refer to Save for details)
 //   - a queue of scheduled actions
+//   - the history of the handled actions
 //   - the ack token
 //
 // See each method documentation for details.
@@ -52,6 +53,7 @@ type state struct {
 	ActionSerializer actionSerializer `json:"action,omitempty"`
 	AckToken         string           `json:"ack_token,omitempty"`
 	Queue            actionQueue      `json:"action_queue,omitempty"`
+	ActionHistory    json.RawMessage  `json:"action_history,omitempty"`
 }
 
 // actionSerializer is JSON Marshaler/Unmarshaler for fleetapi.Action.
@@ -220,6 +222,15 @@ func (s *StateStore) SetQueue(q []fleetapi.ScheduledAction) {
 	s.dirty = true
 }
 
+// SetActionHistory sets the history of the handled actions to agent state. The history is
+// stored as is, its format is owned by the action dispatcher.
+func (s *StateStore) SetActionHistory(history json.RawMessage) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.state.ActionHistory = history
+	s.dirty = true
+}
+
 // Save saves the actions into the state store. If the action type is not
 // supported or if any error happens, it returns a non-nil error.
 func (s *StateStore) Save() (err error) {
@@ -268,6 +279,13 @@ func (s *StateStore) Queue() []fleetapi.ScheduledAction {
 	return q
 }
 
+// ActionHistory returns the persisted history of the handled actions.
+func (s *StateStore) ActionHistory() json.RawMessage {
+	s.mx.RLock()
+	defer s.mx.RUnlock()
+	return s.state.ActionHistory
+}
+
 // Action the action to execute. See SetAction for the possible action types.
 func (s *StateStore) Action() fleetapi.Action {
 	s.mx.RLock()
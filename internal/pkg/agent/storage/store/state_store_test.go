@@ -6,6 +6,7 @@ package store
 
 import (
 	"context"
+	"encoding/json"
 	"os"
 	"path/filepath"
 	"reflect"
@@ -426,6 +427,25 @@ func runTestStateStore(t *testing.T, ackToken string) {
 		}
 	})
 
+	t.Run("can save the action history", func(t *testing.T) {
+		history := json.RawMessage(`[{"id":"abc123","type":"UPGRADE","result":"cancelled"}]`)
+
+		storePath := filepath.Join(t.TempDir(), "state.json")
+		s, err := storage.NewDiskStore(storePath)
+		require.NoError(t, err, "failed creating DiskStore")
+
+		store, err := NewStateStore(log, s)
+		require.NoError(t, err)
+		require.Empty(t, store.ActionHistory())
+
+		store.SetActionHistory(history)
+		require.NoError(t, store.Save())
+
+		store, err = NewStateStore(log, s)
+		require.NoError(t, err)
+		assert.JSONEq(t, string(history), string(store.ActionHistory()))
+	})
+
 	t.Run("when we ACK we save to disk", func(t *testing.T) {
 		ActionPolicyChange := &fleetapi.ActionPolicyChange{
 			ActionID: "abc123",
@@ -6,6 +6,7 @@ package vault
 
 import (
 	"context"
+	"errors"
 	"runtime"
 	"time"
 )
@@ -18,6 +19,9 @@ const (
 	lockFile = `.lock`
 )
 
+// ErrKeyringNotAvailable is returned when the kernel keyrings cannot be used
+var ErrKeyringNotAvailable = errors.New("vault keyring implementation is not available")
+
 type Vault interface {
 	Exists(ctx context.Context, key string) (bool, error)
 	Get(ctx context.Context, key string) (dec []byte, err error)
@@ -36,5 +40,16 @@ func New(ctx context.Context, opts ...OptionFunc) (Vault, error) {
 		return NewDarwinKeyChainVault(ctx, options)
 	}
 
+	if runtime.GOOS == "linux" && options.keyring != "" {
+		v, err := NewKeyringVault(ctx, options)
+		if err == nil {
+			return v, nil
+		}
+		if !errors.Is(err, ErrKeyringNotAvailable) {
+			return nil, err
+		}
+		// fall back to the file-based vault, the keyrings are not available
+	}
+
 	return NewFileVault(ctx, options)
 }
@@ -0,0 +1,206 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build linux
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path/filepath"
+
+	"golang.org/x/sys/unix"
+)
+
+const (
+	// keyType is the type of the keys holding the vault data
+	keyType = "user"
+	// keyPerm grants all the permissions to the possessor and to the owner of the keys
+	keyPerm = 0x3f3f0000
+)
+
+// KeyringVault is the vault backed by the Linux kernel keyring. The kernel keyrings are not persisted, so the
+// keys are also written through to the file-based vault, the durable copy the keyring is repopulated from.
+type KeyringVault struct {
+	ringID   int
+	prefix   string
+	readonly bool
+
+	// file is the file-based vault holding the durable copy of the keys, nil when a readonly vault has none
+	file *FileVault
+}
+
+// NewKeyringVault creates the vault backed by the keyring of the options, returns ErrKeyringNotAvailable when
+// the kernel keyrings cannot be used.
+func NewKeyringVault(ctx context.Context, options Options) (*KeyringVault, error) {
+	ringID, err := keyringID(options.keyring)
+	if err != nil {
+		if isKeyringNotAvailable(err) {
+			return nil, fmt.Errorf("%w: could not get the %s keyring: %w", ErrKeyringNotAvailable, options.keyring, err)
+		}
+		return nil, fmt.Errorf("could not get the %s keyring: %w", options.keyring, err)
+	}
+
+	v := &KeyringVault{
+		ringID:   ringID,
+		prefix:   options.entryName + ":" + filepath.Clean(options.vaultPath) + ":",
+		readonly: options.readonly,
+	}
+
+	v.file, err = NewFileVault(ctx, options)
+	if err != nil {
+		if !options.readonly || !errors.Is(err, fs.ErrNotExist) {
+			return nil, fmt.Errorf("could not open the file vault: %w", err)
+		}
+		v.file = nil
+	}
+	return v, nil
+}
+
+// Set stores the key in the file-based vault and in the keyring
+func (v *KeyringVault) Set(ctx context.Context, key string, data []byte) error {
+	if v.file != nil {
+		if err := v.file.Set(ctx, key, data); err != nil {
+			return fmt.Errorf("vault Set: could not store key in the file vault: %w", err)
+		}
+	}
+	return v.setKey(key, data)
+}
+
+// Get retrieves the key from the keyring, or from the file-based vault when it is not in the keyring, e.g. after
+// a reboot, the keyring is then repopulated unless the vault is readonly
+func (v *KeyringVault) Get(ctx context.Context, key string) ([]byte, error) {
+	id, err := v.search(key)
+	if errors.Is(err, fs.ErrNotExist) && v.file != nil {
+		return v.restore(ctx, key)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	size, err := unix.KeyctlBuffer(unix.KEYCTL_READ, id, nil, 0)
+	for err == nil {
+		buf := make([]byte, size)
+		var n int
+		n, err = unix.KeyctlBuffer(unix.KEYCTL_READ, id, buf, 0)
+		if err == nil && n <= len(buf) {
+			return buf[:n], nil
+		}
+		// the key was updated in between
+		size = n
+	}
+	return nil, fmt.Errorf("could not read key from the keyring: %w", err)
+}
+
+// Exists checks if the key exists in the keyring or in the file-based vault
+func (v *KeyringVault) Exists(ctx context.Context, key string) (bool, error) {
+	_, err := v.search(key)
+	switch {
+	case err == nil:
+		return true, nil
+	case !errors.Is(err, fs.ErrNotExist):
+		return false, err
+	case v.file != nil:
+		return v.file.Exists(ctx, key)
+	default:
+		return false, nil
+	}
+}
+
+// Remove removes the key from the keyring and from the file-based vault
+func (v *KeyringVault) Remove(ctx context.Context, key string) error {
+	id, err := v.search(key)
+	switch {
+	case err == nil:
+		if _, err := unix.KeyctlInt(unix.KEYCTL_UNLINK, id, v.ringID, 0, 0); err != nil && !errors.Is(err, unix.ENOENT) {
+			return fmt.Errorf("could not unlink key from the keyring: %w", err)
+		}
+	case !errors.Is(err, fs.ErrNotExist):
+		return err
+	}
+
+	if v.file != nil {
+		return v.file.Remove(ctx, key)
+	}
+	return nil
+}
+
+// Close closes the vault store
+// Noop for the keyring implementation
+func (v *KeyringVault) Close() error {
+	return nil
+}
+
+// restore reads the key from the file-based vault and adds it back to the keyring, the key is only read from the
+// file-based vault when the vault is readonly
+func (v *KeyringVault) restore(ctx context.Context, key string) ([]byte, error) {
+	data, err := v.file.Get(ctx, key)
+	if err != nil {
+		return nil, err
+	}
+	if v.readonly {
+		return data, nil
+	}
+	if err := v.setKey(key, data); err != nil {
+		return nil, fmt.Errorf("could not restore key to the keyring: %w", err)
+	}
+	return data, nil
+}
+
+// setKey adds or updates the key in the keyring
+func (v *KeyringVault) setKey(key string, data []byte) error {
+	id, err := unix.AddKey(keyType, v.description(key), data, v.ringID)
+	if err != nil {
+		return fmt.Errorf("vault Set: could not add key to the keyring: %w", err)
+	}
+	if err := unix.KeyctlSetperm(id, keyPerm); err != nil {
+		return fmt.Errorf("vault Set: could not set the permissions of the key: %w", err)
+	}
+	return nil
+}
+
+// search returns the id of the key, fs.ErrNotExist when the keyring has no valid key
+func (v *KeyringVault) search(key string) (int, error) {
+	id, err := unix.KeyctlSearch(v.ringID, keyType, v.description(key), 0)
+	if err != nil {
+		if errors.Is(err, unix.ENOKEY) || errors.Is(err, unix.EKEYEXPIRED) || errors.Is(err, unix.EKEYREVOKED) {
+			return 0, fmt.Errorf("key %s not in the keyring: %w", key, fs.ErrNotExist)
+		}
+		return 0, fmt.Errorf("could not search key in the keyring: %w", err)
+	}
+	return id, nil
+}
+
+// description ties the key with the vault entry name and the path of the file-based vault, so the keys of
+// multiple vaults, e.g. the privileged and unprivileged vaults or the vaults of multiple agents, do not collide
+// in the keyrings of the same user
+func (v *KeyringVault) description(key string) string {
+	return v.prefix + key
+}
+
+func keyringID(keyring string) (int, error) {
+	switch keyring {
+	case KeyringUser:
+		return unix.KeyctlGetKeyringID(unix.KEY_SPEC_USER_KEYRING, true)
+	case KeyringSession:
+		return unix.KeyctlGetKeyringID(unix.KEY_SPEC_SESSION_KEYRING, true)
+	case KeyringPersistent:
+		// links the persistent keyring of the user into the session keyring, to possess it
+		return unix.KeyctlInt(unix.KEYCTL_GET_PERSISTENT, -1, unix.KEY_SPEC_SESSION_KEYRING, 0, 0)
+	default:
+		return 0, fmt.Errorf("unknown keyring %q", keyring)
+	}
+}
+
+// isKeyringNotAvailable returns true when the keyctl syscalls are not supported by the kernel or blocked,
+// e.g. by the seccomp profile of a container.
+func isKeyringNotAvailable(err error) bool {
+	return errors.Is(err, unix.ENOSYS) ||
+		errors.Is(err, unix.EPERM) ||
+		errors.Is(err, unix.EACCES) ||
+		errors.Is(err, unix.EOPNOTSUPP)
+}
@@ -0,0 +1,185 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build linux
+
+package vault
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"golang.org/x/sys/unix"
+
+	"github.com/elastic/elastic-agent/internal/pkg/testutils/fipsutils"
+)
+
+func newTestKeyringVault(t *testing.T, keyring string, opts ...OptionFunc) *KeyringVault {
+	t.Helper()
+	opts = append([]OptionFunc{
+		WithKeyring(keyring),
+		WithVaultPath(getTestFileVaultPath(t)),
+		WithVaultEntryName(fmt.Sprintf("co.elastic.elastic-agent.test.%d", time.Now().UnixNano())),
+	}, opts...)
+	options, err := ApplyOptions(opts...)
+	require.NoError(t, err)
+
+	v, err := NewKeyringVault(context.Background(), options)
+	if errors.Is(err, ErrKeyringNotAvailable) {
+		t.Skipf("the %s keyring is not available: %v", keyring, err)
+	}
+	require.NoError(t, err)
+	return v
+}
+
+func TestKeyringVault(t *testing.T) {
+	for _, keyring := range []string{KeyringUser, KeyringSession, KeyringPersistent} {
+		t.Run(keyring, func(t *testing.T) {
+			fipsutils.SkipIfFIPSOnly(t, "vault does not use NewGCMWithRandomNonce.")
+			ctx := context.Background()
+			v := newTestKeyringVault(t, keyring)
+			defer v.Close()
+
+			exists, err := v.Exists(ctx, "key")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			_, err = v.Get(ctx, "key")
+			assert.ErrorIs(t, err, fs.ErrNotExist)
+
+			require.NoError(t, v.Set(ctx, "key", []byte("value")))
+			t.Cleanup(func() { _ = v.Remove(ctx, "key") })
+			exists, err = v.Exists(ctx, "key")
+			require.NoError(t, err)
+			assert.True(t, exists)
+			data, err := v.Get(ctx, "key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("value"), data)
+
+			require.NoError(t, v.Set(ctx, "key", []byte("updated value")))
+			data, err = v.Get(ctx, "key")
+			require.NoError(t, err)
+			assert.Equal(t, []byte("updated value"), data)
+
+			require.NoError(t, v.Remove(ctx, "key"))
+			exists, err = v.Exists(ctx, "key")
+			require.NoError(t, err)
+			assert.False(t, exists)
+			require.NoError(t, v.Remove(ctx, "key"), "removing a missing key should succeed")
+		})
+	}
+}
+
+func TestKeyringVault_FileVaultCopy(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "vault does not use NewGCMWithRandomNonce.")
+	ctx := context.Background()
+	vaultPath := getTestFileVaultPath(t)
+
+	options, err := ApplyOptions(WithVaultPath(vaultPath))
+	require.NoError(t, err)
+	fv, err := NewFileVault(ctx, options)
+	require.NoError(t, err)
+	require.NoError(t, fv.Set(ctx, "key", []byte("value")))
+	require.NoError(t, fv.Set(ctx, "readonly key", []byte("readonly value")))
+
+	v := newTestKeyringVault(t, KeyringSession, WithVaultPath(vaultPath))
+	t.Cleanup(func() {
+		_ = v.Remove(ctx, "key")
+		_ = v.Remove(ctx, "readonly key")
+		_ = v.Remove(ctx, "new key")
+	})
+
+	exists, err := v.Exists(ctx, "key")
+	require.NoError(t, err)
+	assert.True(t, exists, "the keys of the file vault should exist")
+
+	data, err := v.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), data)
+	_, err = v.search("key")
+	require.NoError(t, err, "the key should be added to the keyring")
+	data, err = fv.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("value"), data, "the key should be kept in the file vault")
+
+	require.NoError(t, v.Set(ctx, "new key", []byte("new value")))
+	data, err = fv.Get(ctx, "new key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new value"), data, "the key should be written through to the file vault")
+
+	// the keyring is lost, e.g. on reboot
+	id, err := v.search("new key")
+	require.NoError(t, err)
+	_, err = unix.KeyctlInt(unix.KEYCTL_UNLINK, id, v.ringID, 0, 0)
+	require.NoError(t, err)
+	_, err = v.search("new key")
+	require.ErrorIs(t, err, fs.ErrNotExist)
+
+	data, err = v.Get(ctx, "new key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("new value"), data, "the key should be read from the file vault")
+	_, err = v.search("new key")
+	require.NoError(t, err, "the key should be added back to the keyring")
+
+	readonly := *v
+	readonly.readonly = true
+	data, err = readonly.Get(ctx, "readonly key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("readonly value"), data)
+	_, err = v.search("readonly key")
+	assert.ErrorIs(t, err, fs.ErrNotExist, "a readonly vault should not add the key to the keyring")
+}
+
+func TestNew_Keyring(t *testing.T) {
+	_, err := New(context.Background(), WithKeyring("unknown"))
+	assert.ErrorContains(t, err, `unknown keyring "unknown"`)
+
+	v, err := New(context.Background(), WithKeyring(KeyringSession), WithVaultPath(getTestFileVaultPath(t)))
+	require.NoError(t, err)
+	switch v.(type) {
+	case *KeyringVault, *FileVault:
+	default:
+		t.Fatalf("expected the keyring vault or the file vault as fallback, got %T", v)
+	}
+}
+
+func TestKeyringVault_VaultPathPrefix(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "vault does not use NewGCMWithRandomNonce.")
+	ctx := context.Background()
+	entryName := WithVaultEntryName(fmt.Sprintf("co.elastic.elastic-agent.test.%d", time.Now().UnixNano()))
+	privileged := newTestKeyringVault(t, KeyringSession, entryName)
+	unprivileged := newTestKeyringVault(t, KeyringSession, entryName)
+
+	require.NoError(t, privileged.Set(ctx, "key", []byte("privileged value")))
+	t.Cleanup(func() { _ = privileged.Remove(ctx, "key") })
+	require.NoError(t, unprivileged.Set(ctx, "key", []byte("unprivileged value")))
+	t.Cleanup(func() { _ = unprivileged.Remove(ctx, "key") })
+
+	data, err := privileged.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("privileged value"), data, "the keys of vaults with different paths should not collide")
+	data, err = unprivileged.Get(ctx, "key")
+	require.NoError(t, err)
+	assert.Equal(t, []byte("unprivileged value"), data)
+}
+
+func TestApplyOptions_KeyringEnvVar(t *testing.T) {
+	t.Setenv(KeyringEnvVar, KeyringPersistent)
+	options, err := ApplyOptions()
+	require.NoError(t, err)
+	assert.Equal(t, KeyringPersistent, options.keyring)
+
+	options, err = ApplyOptions(WithKeyring(""))
+	require.NoError(t, err)
+	assert.Empty(t, options.keyring, "the option should override the environment variable")
+
+	t.Setenv(KeyringEnvVar, "unknown")
+	_, err = ApplyOptions()
+	assert.ErrorContains(t, err, `unknown keyring "unknown"`)
+}
@@ -0,0 +1,39 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build !linux
+
+package vault
+
+import (
+	"context"
+)
+
+// Empty KeyringVault implementation for non-linux OSes
+type KeyringVault struct {
+}
+
+func (v KeyringVault) Exists(ctx context.Context, key string) (bool, error) {
+	return false, ErrKeyringNotAvailable
+}
+
+func (v KeyringVault) Get(ctx context.Context, key string) (dec []byte, err error) {
+	return nil, ErrKeyringNotAvailable
+}
+
+func (v KeyringVault) Set(ctx context.Context, key string, data []byte) (err error) {
+	return ErrKeyringNotAvailable
+}
+
+func (v KeyringVault) Remove(ctx context.Context, key string) (err error) {
+	return ErrKeyringNotAvailable
+}
+
+func (v KeyringVault) Close() error {
+	return ErrKeyringNotAvailable
+}
+
+func NewKeyringVault(ctx context.Context, opts Options) (v *KeyringVault, err error) {
+	return nil, ErrKeyringNotAvailable
+}
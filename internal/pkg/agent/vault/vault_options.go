@@ -6,6 +6,7 @@ package vault
 
 import (
 	"fmt"
+	"os"
 	"time"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
@@ -14,6 +15,21 @@ import (
 
 type OptionFunc func(o *Options)
 
+const (
+	// KeyringUser is the keyring of the user, shared by all the processes of the user
+	KeyringUser = "user"
+	// KeyringSession is the session keyring of the process
+	KeyringSession = "session"
+	// KeyringPersistent is the persistent keyring of the user, kept after the user logs out
+	KeyringPersistent = "persistent"
+
+	// KeyringEnvVar is the environment variable selecting the keyring of the Linux kernel keyring vault, one of
+	// KeyringUser, KeyringSession or KeyringPersistent. The vault is opened before the agent configuration is
+	// loaded, as the configuration itself can be stored encrypted in it, so it is selected by the environment of
+	// the agent process rather than by the configuration.
+	KeyringEnvVar = "ELASTIC_AGENT_VAULT_KEYRING"
+)
+
 type CommonVaultOptions struct {
 	readonly     bool
 	unprivileged bool
@@ -25,14 +41,24 @@ type FileVaultOptions struct {
 	ownership      utils.FileOwner
 }
 
+// VaultPath returns the path of the file-based vault
+func (o FileVaultOptions) VaultPath() string {
+	return o.vaultPath
+}
+
 type KeychainVaultOptions struct {
 	entryName string
 }
 
+type KeyringVaultOptions struct {
+	keyring string
+}
+
 type Options struct {
 	CommonVaultOptions
 	FileVaultOptions
 	KeychainVaultOptions
+	KeyringVaultOptions
 }
 
 // WithReadonly opens storage for read-only access only, noop for Darwin
@@ -56,7 +82,7 @@ func WithVaultOwnership(ownership utils.FileOwner) OptionFunc {
 	}
 }
 
-// WithVaultEntryName allows to specify the vault key entry name in the keychain (it applies only for keychain vault on darwin)
+// WithVaultEntryName allows to specify the vault key entry name in the keychain (it applies only for keychain vault on darwin and keyring vault on linux)
 func WithVaultEntryName(entryName string) OptionFunc {
 	return func(o *Options) {
 		o.entryName = entryName
@@ -70,6 +96,20 @@ func WithUnprivileged(unprivileged bool) OptionFunc {
 	}
 }
 
+// WithKeyring selects the Linux kernel keyring vault backed by the keyring, one of KeyringUser, KeyringSession or
+// KeyringPersistent (it applies only on linux), overriding the keyring of the KeyringEnvVar environment variable.
+// An empty keyring selects the file-based vault.
+// The kernel keyrings are not persisted, their keys are lost on reboot, so the keys are still written encrypted to
+// the file-based vault, next to its seed, and added back to the keyring from it when read. The keyring serves the
+// reads, it does not remove the on-disk copy.
+// Existing file-based vaults are migrated as their keys are read.
+// The file-based vault is used when the keyrings are not available, e.g. in containers blocking the keyctl syscalls.
+func WithKeyring(keyring string) OptionFunc {
+	return func(o *Options) {
+		o.keyring = keyring
+	}
+}
+
 // ApplyOptions applies options for Windows, Linux and Mac, not all the options may be used
 func ApplyOptions(opts ...OptionFunc) (Options, error) {
 	ownership, err := utils.CurrentFileOwner()
@@ -89,10 +129,19 @@ func ApplyOptions(opts ...OptionFunc) (Options, error) {
 		KeychainVaultOptions: KeychainVaultOptions{
 			entryName: paths.AgentKeychainName(),
 		},
+		KeyringVaultOptions: KeyringVaultOptions{
+			keyring: os.Getenv(KeyringEnvVar),
+		},
 	}
 
 	for _, opt := range opts {
 		opt(&o)
 	}
+
+	switch o.keyring {
+	case "", KeyringUser, KeyringSession, KeyringPersistent:
+	default:
+		return Options{}, fmt.Errorf("unknown keyring %q", o.keyring)
+	}
 	return o, nil
 }
@@ -0,0 +1,86 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package secret
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+const (
+	// StoreTypeLocal is the encrypted local secrets file managed by the secrets command
+	StoreTypeLocal = "local"
+	// StoreTypeVault is a secrets engine of a HashiCorp Vault compatible HTTP API
+	StoreTypeVault = "vault"
+
+	// defaultStoreName is the name of the local store available without configuration
+	defaultStoreName = "local"
+)
+
+// Config for secret provider
+type Config struct {
+	Stores map[string]*StoreConfig `config:"stores"`
+
+	CacheTTL       time.Duration `config:"cache_ttl" validate:"positive,nonzero"`
+	CacheDisable   bool          `config:"cache_disable"`
+	RequestTimeout time.Duration `config:"request_timeout" validate:"positive,nonzero"`
+}
+
+// StoreConfig is the configuration of a secret store, referenced as ${secret.<store>.<path>}
+type StoreConfig struct {
+	Type string `config:"type"`
+
+	// Path is the secrets file of the local store
+	Path string `config:"path"`
+
+	// Address, Token, TokenFile, Namespace, Mount and KVVersion configure the vault store
+	Address   string `config:"address"`
+	Token     string `config:"token"`
+	TokenFile string `config:"token_file"`
+	Namespace string `config:"namespace"`
+	Mount     string `config:"mount"`
+	KVVersion int    `config:"kv_version"`
+
+	Transport httpcommon.HTTPTransportSettings `config:",inline" yaml:",inline"`
+}
+
+// defaultConfig returns default configuration for secret provider
+func defaultConfig() *Config {
+	return &Config{
+		CacheTTL:       5 * time.Minute,
+		CacheDisable:   false,
+		RequestTimeout: 5 * time.Second,
+	}
+}
+
+// InitDefaults initializes the defaults for the store config.
+func (c *StoreConfig) InitDefaults() {
+	c.Type = StoreTypeLocal
+	c.Mount = "secret"
+	c.KVVersion = 2
+	c.Transport = httpcommon.DefaultHTTPTransportSettings()
+}
+
+// Validate implements ucfg.Validator.
+func (c *StoreConfig) Validate() error {
+	switch c.Type {
+	case StoreTypeLocal:
+	case StoreTypeVault:
+		if c.Address == "" {
+			return fmt.Errorf("address is required for %s stores", StoreTypeVault)
+		}
+		if c.Token == "" && c.TokenFile == "" {
+			return fmt.Errorf("token or token_file is required for %s stores", StoreTypeVault)
+		}
+		if c.KVVersion != 1 && c.KVVersion != 2 {
+			return fmt.Errorf("unsupported kv_version %d, must be 1 or 2", c.KVVersion)
+		}
+	default:
+		return fmt.Errorf("unknown store type %q, must be %s or %s", c.Type, StoreTypeLocal, StoreTypeVault)
+	}
+	return nil
+}
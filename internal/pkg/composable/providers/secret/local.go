@@ -0,0 +1,123 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package secret
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"slices"
+	"time"
+
+	"github.com/gofrs/flock"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+)
+
+// localLockRetryDelay is the delay between the attempts to lock the secrets file
+const localLockRetryDelay = 10 * time.Millisecond
+
+// LocalStore is the local secrets file, encrypted with the agent key, managed by the secrets command.
+type LocalStore struct {
+	path string
+	opts []storage.EncryptedOptionFunc
+}
+
+// NewLocalStore creates the local store of the secrets file at path.
+func NewLocalStore(path string, opts ...storage.EncryptedOptionFunc) *LocalStore {
+	return &LocalStore{
+		path: path,
+		opts: opts,
+	}
+}
+
+// Get returns the value of the secret name, false when the store has no such secret.
+func (s *LocalStore) Get(ctx context.Context, name string) (string, bool, error) {
+	secrets, err := s.load(ctx)
+	if err != nil {
+		return "", false, err
+	}
+	value, ok := secrets[name]
+	return value, ok, nil
+}
+
+// Set sets the value of the secret name.
+func (s *LocalStore) Set(ctx context.Context, name string, value string) (err error) {
+	if name == "" {
+		return fmt.Errorf("secret name is required")
+	}
+
+	// serializes the updates of the secrets file, so concurrent updates are not lost
+	lock := flock.New(s.path + ".lock")
+	if _, err := lock.TryLockContext(ctx, localLockRetryDelay); err != nil {
+		return fmt.Errorf("failed to lock the secrets file: %w", err)
+	}
+	defer func() {
+		if unlockErr := lock.Unlock(); unlockErr != nil && err == nil {
+			err = fmt.Errorf("failed to unlock the secrets file: %w", unlockErr)
+		}
+	}()
+
+	secrets, err := s.load(ctx)
+	if err != nil {
+		return err
+	}
+	secrets[name] = value
+
+	data, err := json.Marshal(secrets)
+	if err != nil {
+		return fmt.Errorf("failed to marshal the secrets: %w", err)
+	}
+	store, err := storage.NewEncryptedDiskStore(ctx, s.path, s.opts...)
+	if err != nil {
+		return fmt.Errorf("failed to create the secrets store: %w", err)
+	}
+	if err := store.Save(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to save the secrets: %w", err)
+	}
+	return nil
+}
+
+// List returns the sorted names of the secrets.
+func (s *LocalStore) List(ctx context.Context) ([]string, error) {
+	secrets, err := s.load(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := make([]string, 0, len(secrets))
+	for name := range secrets {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	return names, nil
+}
+
+func (s *LocalStore) load(ctx context.Context) (map[string]string, error) {
+	store, err := storage.NewEncryptedDiskStore(ctx, s.path, s.opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the secrets store: %w", err)
+	}
+	rc, err := store.Load()
+	if err != nil {
+		return nil, fmt.Errorf("failed to load the secrets: %w", err)
+	}
+	defer rc.Close()
+
+	data, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read the secrets: %w", err)
+	}
+	secrets := make(map[string]string)
+	if len(data) == 0 {
+		// no secret was set yet
+		return secrets, nil
+	}
+	if err := json.Unmarshal(data, &secrets); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal the secrets: %w", err)
+	}
+	return secrets, nil
+}
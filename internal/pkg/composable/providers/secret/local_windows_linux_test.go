@@ -0,0 +1,54 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+//go:build linux || windows
+
+package secret
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	agentsecret "github.com/elastic/elastic-agent/internal/pkg/agent/application/secret"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/vault"
+	"github.com/elastic/elastic-agent/internal/pkg/testutils/fipsutils"
+)
+
+func TestLocalStore(t *testing.T) {
+	fipsutils.SkipIfFIPSOnly(t, "encrypted disk storage does not use NewGCMWithRandomNonce.")
+	ctx := context.Background()
+	dir := t.TempDir()
+	require.NoError(t, agentsecret.CreateAgentSecret(ctx, vault.WithVaultPath(dir)))
+	s := NewLocalStore(filepath.Join(dir, "secrets.enc"), storage.WithVaultPath(dir))
+
+	names, err := s.List(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, names)
+	_, found, err := s.Get(ctx, "api_key")
+	require.NoError(t, err)
+	assert.False(t, found)
+
+	require.NoError(t, s.Set(ctx, "api_key", "value"))
+	require.NoError(t, s.Set(ctx, "es.password", "password"))
+	require.NoError(t, s.Set(ctx, "api_key", "updated"))
+	assert.Error(t, s.Set(ctx, "", "value"), "secrets without name should be rejected")
+
+	names, err = s.List(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"api_key", "es.password"}, names)
+	value, found, err := s.Get(ctx, "api_key")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "updated", value)
+
+	content, err := os.ReadFile(filepath.Join(dir, "secrets.enc"))
+	require.NoError(t, err)
+	assert.NotContains(t, string(content), "password", "the secrets file should be encrypted")
+}
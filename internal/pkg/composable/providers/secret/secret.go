@@ -0,0 +1,234 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package secret
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/errors"
+	"github.com/elastic/elastic-agent/internal/pkg/composable"
+	"github.com/elastic/elastic-agent/internal/pkg/config"
+	corecomp "github.com/elastic/elastic-agent/internal/pkg/core/composable"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+var _ corecomp.FetchContextProvider = (*contextProviderSecret)(nil)
+
+const secretProviderName = "secret"
+
+func init() {
+	composable.Providers.MustAddContextProvider(secretProviderName, ContextProviderBuilder)
+}
+
+// store is a backend the secrets are resolved from
+type store interface {
+	// Get returns the value of the secret at path, false when the store has no such secret.
+	Get(ctx context.Context, path string) (string, bool, error)
+}
+
+// cacheEntry is a secret resolved from a store
+type cacheEntry struct {
+	store string
+	path  string
+	value string
+	// found is true when the store has the secret
+	found bool
+	// fetchTime is the time the secret was resolved from the store
+	fetchTime time.Time
+}
+
+type contextProviderSecret struct {
+	logger *logger.Logger
+	config *Config
+	stores map[string]store
+
+	cacheMx sync.Mutex
+	cache   map[string]*cacheEntry
+}
+
+// ContextProviderBuilder builds the secret context provider, resolving ${secret.<store>.<path>} from the
+// configured stores. The local store, the secrets file managed by the secrets command, is always available.
+// The resolved secrets are cached for Config.CacheTTL and refreshed in the background every Config.CacheTTL,
+// the provider calls ContextProviderComm.Signal() when a secret value changes. The resolved secrets are
+// redacted from the diagnostics and from the output of inspect.
+func ContextProviderBuilder(logger *logger.Logger, c *config.Config, _ bool) (corecomp.ContextProvider, error) {
+	cfg := defaultConfig()
+
+	if c == nil {
+		c = config.New()
+	}
+
+	err := c.UnpackTo(cfg)
+	if err != nil {
+		return nil, errors.New(err, "failed to unpack configuration")
+	}
+
+	if cfg.Stores == nil {
+		cfg.Stores = make(map[string]*StoreConfig)
+	}
+	if _, ok := cfg.Stores[defaultStoreName]; !ok {
+		local := &StoreConfig{}
+		local.InitDefaults()
+		cfg.Stores[defaultStoreName] = local
+	}
+
+	stores := make(map[string]store, len(cfg.Stores))
+	for name, storeCfg := range cfg.Stores {
+		switch storeCfg.Type {
+		case StoreTypeLocal:
+			path := storeCfg.Path
+			if path == "" {
+				path = paths.SecretsStoreFile()
+			}
+			stores[name] = NewLocalStore(path)
+		case StoreTypeVault:
+			vs, err := newVaultStore(storeCfg)
+			if err != nil {
+				return nil, fmt.Errorf("failed to create store %q: %w", name, err)
+			}
+			stores[name] = vs
+		}
+	}
+
+	return &contextProviderSecret{
+		logger: logger,
+		config: cfg,
+		stores: stores,
+		cache:  make(map[string]*cacheEntry),
+	}, nil
+}
+
+// Run refreshes the cached secrets until the provider is stopped.
+func (p *contextProviderSecret) Run(ctx context.Context, comm corecomp.ContextProviderComm) error {
+	if !p.config.CacheDisable {
+		go p.refreshCache(ctx, comm)
+	}
+
+	<-comm.Done()
+	return comm.Err()
+}
+
+// Fetch returns the secret value for the given key
+func (p *contextProviderSecret) Fetch(key string) (string, bool) {
+	// Make sure the key has the expected format "secret.store.path"
+	tokens := strings.SplitN(key, ".", 3)
+	if len(tokens) > 0 && tokens[0] != secretProviderName {
+		return "", false
+	}
+	if len(tokens) != 3 || tokens[1] == "" || tokens[2] == "" {
+		p.logger.Warnf(`Invalid secret key format: %q. Secrets should be of the format secret.store.path`, key)
+		return "", false
+	}
+	storeName, path := tokens[1], tokens[2]
+
+	if !p.config.CacheDisable {
+		p.cacheMx.Lock()
+		entry, ok := p.cache[key]
+		p.cacheMx.Unlock()
+		if ok && time.Since(entry.fetchTime) < p.config.CacheTTL {
+			// cache hit
+			return entry.value, entry.found
+		}
+	}
+
+	entry, err := p.fetchFromStore(context.Background(), storeName, path)
+	if err != nil {
+		p.logger.Warnf(`Could not retrieve secret %q: %s`, key, err.Error())
+		// serve the last known value while the store is unavailable
+		p.cacheMx.Lock()
+		defer p.cacheMx.Unlock()
+		if cached, ok := p.cache[key]; ok {
+			return cached.value, cached.found
+		}
+		return "", false
+	}
+
+	if !p.config.CacheDisable {
+		p.cacheMx.Lock()
+		p.cache[key] = entry
+		p.cacheMx.Unlock()
+	}
+	return entry.value, entry.found
+}
+
+// refreshCache refreshes the secrets in the cache every p.config.CacheTTL
+func (p *contextProviderSecret) refreshCache(ctx context.Context, comm corecomp.ContextProviderComm) {
+	timer := time.NewTimer(p.config.CacheTTL)
+	defer timer.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-timer.C:
+			if p.updateSecrets(ctx) {
+				p.logger.Info("Cache: refresh ended with updates, agent will be notified")
+				comm.Signal()
+			}
+			timer.Reset(p.config.CacheTTL)
+		}
+	}
+}
+
+// updateSecrets resolves the cached secrets again, returns true when any secret changed
+func (p *contextProviderSecret) updateSecrets(ctx context.Context) bool {
+	p.cacheMx.Lock()
+	entries := make(map[string]cacheEntry, len(p.cache))
+	for key, entry := range p.cache {
+		entries[key] = *entry
+	}
+	p.cacheMx.Unlock()
+
+	hasUpdates := false
+	for key, entry := range entries {
+		updated, err := p.fetchFromStore(ctx, entry.store, entry.path)
+		if err != nil {
+			p.logger.Warnf(`Cache: could not refresh secret %q: %s`, key, err.Error())
+			continue
+		}
+
+		p.cacheMx.Lock()
+		if existing, ok := p.cache[key]; ok && !existing.fetchTime.After(updated.fetchTime) {
+			if existing.value != updated.value || existing.found != updated.found {
+				p.logger.Infof(`Cache: %q updated`, key)
+				hasUpdates = true
+			}
+			p.cache[key] = updated
+		}
+		p.cacheMx.Unlock()
+	}
+	return hasUpdates
+}
+
+// fetchFromStore resolves the secret at path from the store
+func (p *contextProviderSecret) fetchFromStore(ctx context.Context, storeName string, path string) (*cacheEntry, error) {
+	s, ok := p.stores[storeName]
+	if !ok {
+		return nil, fmt.Errorf("unknown secret store %q", storeName)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, p.config.RequestTimeout)
+	defer cancel()
+	now := time.Now()
+	value, found, err := s.Get(ctx, path)
+	if err != nil {
+		return nil, err
+	}
+	if found {
+		diagnostics.AddSecretValue(value)
+	}
+	return &cacheEntry{
+		store:     storeName,
+		path:      path,
+		value:     value,
+		found:     found,
+		fetchTime: now,
+	}, nil
+}
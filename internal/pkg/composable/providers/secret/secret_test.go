@@ -0,0 +1,202 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package secret
+
+import (
+	"context"
+	"errors"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/transpiler"
+	ctesting "github.com/elastic/elastic-agent/internal/pkg/composable/testing"
+	"github.com/elastic/elastic-agent/internal/pkg/config"
+	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+type fakeStore struct {
+	mx      sync.Mutex
+	secrets map[string]string
+	err     error
+	calls   int
+}
+
+func (s *fakeStore) Get(_ context.Context, path string) (string, bool, error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.calls++
+	if s.err != nil {
+		return "", false, s.err
+	}
+	value, ok := s.secrets[path]
+	return value, ok, nil
+}
+
+func (s *fakeStore) set(path string, value string, err error) {
+	s.mx.Lock()
+	defer s.mx.Unlock()
+	s.secrets[path] = value
+	s.err = err
+}
+
+func newTestProvider(t *testing.T, cfg *Config, s store) *contextProviderSecret {
+	log, _ := loggertest.New("secret")
+	return &contextProviderSecret{
+		logger: log,
+		config: cfg,
+		stores: map[string]store{"test": s},
+		cache:  make(map[string]*cacheEntry),
+	}
+}
+
+func Test_Fetch(t *testing.T) {
+	s := &fakeStore{secrets: map[string]string{"db.password": "fetched-secret-value"}}
+	p := newTestProvider(t, defaultConfig(), s)
+
+	value, found := p.Fetch("secret.test.db.password")
+	assert.True(t, found)
+	assert.Equal(t, "fetched-secret-value", value)
+
+	_, found = p.Fetch("secret.test.missing")
+	assert.False(t, found)
+	_, found = p.Fetch("secret.unknown.db.password")
+	assert.False(t, found, "unknown stores should not resolve")
+	_, found = p.Fetch("secret.test")
+	assert.False(t, found, "keys without a path should not resolve")
+	_, found = p.Fetch("other.test.db.password")
+	assert.False(t, found, "keys of other providers should not resolve")
+
+	// the resolved values are redacted
+	assert.Equal(t, "password: "+diagnostics.REDACTED, string(diagnostics.RedactSecretValues([]byte("password: fetched-secret-value"))))
+}
+
+func Test_Fetch_Cache(t *testing.T) {
+	s := &fakeStore{secrets: map[string]string{"key": "value"}}
+	p := newTestProvider(t, defaultConfig(), s)
+
+	for range 3 {
+		value, found := p.Fetch("secret.test.key")
+		require.True(t, found)
+		require.Equal(t, "value", value)
+	}
+	assert.Equal(t, 1, s.calls, "the secret should be served from the cache")
+
+	// the last known value is served while the store is unavailable
+	p.cache["secret.test.key"].fetchTime = time.Now().Add(-time.Hour)
+	s.set("key", "", errors.New("unavailable"))
+	value, found := p.Fetch("secret.test.key")
+	assert.True(t, found)
+	assert.Equal(t, "value", value)
+
+	// an expired secret is fetched again
+	s.set("key", "updated", nil)
+	value, found = p.Fetch("secret.test.key")
+	assert.True(t, found)
+	assert.Equal(t, "updated", value)
+
+	cfg := defaultConfig()
+	cfg.CacheDisable = true
+	s = &fakeStore{secrets: map[string]string{"key": "value"}}
+	p = newTestProvider(t, cfg, s)
+	for range 3 {
+		_, found := p.Fetch("secret.test.key")
+		require.True(t, found)
+	}
+	assert.Equal(t, 3, s.calls, "the secret should be fetched every time when the cache is disabled")
+}
+
+func Test_RefreshCache(t *testing.T) {
+	cfg := defaultConfig()
+	cfg.CacheTTL = 10 * time.Millisecond
+	s := &fakeStore{secrets: map[string]string{"key": "value"}}
+	p := newTestProvider(t, cfg, s)
+
+	_, found := p.Fetch("secret.test.key")
+	require.True(t, found)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	comm := ctesting.NewContextComm(ctx)
+	signaled := make(chan struct{}, 1)
+	comm.CallOnSignal(func() {
+		select {
+		case signaled <- struct{}{}:
+		default:
+		}
+	})
+	go func() {
+		_ = p.Run(ctx, comm)
+	}()
+
+	s.set("key", "updated", nil)
+	select {
+	case <-signaled:
+	case <-time.After(5 * time.Second):
+		t.Fatal("the provider should signal the updated secret")
+	}
+	p.cacheMx.Lock()
+	defer p.cacheMx.Unlock()
+	assert.Equal(t, "updated", p.cache["secret.test.key"].value)
+}
+
+func Test_ContextProviderBuilder(t *testing.T) {
+	log, _ := loggertest.New("secret")
+
+	provider, err := ContextProviderBuilder(log, nil, false)
+	require.NoError(t, err)
+	p := provider.(*contextProviderSecret)
+	assert.IsType(t, &LocalStore{}, p.stores[defaultStoreName], "the local store should be available by default")
+
+	c, err := config.NewConfigFrom(map[string]interface{}{
+		"cache_ttl": "1m",
+		"stores": map[string]interface{}{
+			"prod": map[string]interface{}{
+				"type":    "vault",
+				"address": "https://vault.example.com:8200",
+				"token":   "token",
+			},
+		},
+	})
+	require.NoError(t, err)
+	provider, err = ContextProviderBuilder(log, c, false)
+	require.NoError(t, err)
+	p = provider.(*contextProviderSecret)
+	assert.Equal(t, time.Minute, p.config.CacheTTL)
+	require.IsType(t, &vaultStore{}, p.stores["prod"])
+	assert.Equal(t, "secret", p.stores["prod"].(*vaultStore).config.Mount)
+	assert.Equal(t, 2, p.stores["prod"].(*vaultStore).config.KVVersion)
+
+	for name, storeCfg := range map[string]map[string]interface{}{
+		"unknown type":    {"type": "unknown"},
+		"missing address": {"type": "vault", "token": "token"},
+		"missing token":   {"type": "vault", "address": "https://vault.example.com:8200"},
+		"bad kv version":  {"type": "vault", "address": "https://vault.example.com:8200", "token": "token", "kv_version": 3},
+	} {
+		t.Run(name, func(t *testing.T) {
+			c, err := config.NewConfigFrom(map[string]interface{}{
+				"stores": map[string]interface{}{"prod": storeCfg},
+			})
+			require.NoError(t, err)
+			_, err = ContextProviderBuilder(log, c, false)
+			assert.Error(t, err)
+		})
+	}
+}
+
+func Test_Vars(t *testing.T) {
+	s := &fakeStore{secrets: map[string]string{"api_key": "resolved-api-key"}}
+	p := newTestProvider(t, defaultConfig(), s)
+
+	vars, err := transpiler.NewVars("", map[string]interface{}{}, map[string]interface{}{secretProviderName: p}, "")
+	require.NoError(t, err)
+	node, err := vars.Replace("${secret.test.api_key}")
+	require.NoError(t, err)
+	assert.Equal(t, "resolved-api-key", node.Value())
+}
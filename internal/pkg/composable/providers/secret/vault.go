@@ -0,0 +1,119 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package secret
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/elastic/elastic-agent-libs/transport/httpcommon"
+)
+
+// maxVaultResponseSize is the maximum size of the responses of the vault API.
+const maxVaultResponseSize = 1 << 20
+
+// vaultStore reads the secrets of a KV secrets engine of a HashiCorp Vault compatible HTTP API.
+type vaultStore struct {
+	client *http.Client
+	config *StoreConfig
+}
+
+func newVaultStore(config *StoreConfig) (*vaultStore, error) {
+	client, err := config.Transport.Client(httpcommon.WithAPMHTTPInstrumentation())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create the HTTP client: %w", err)
+	}
+	return &vaultStore{
+		client: client,
+		config: config,
+	}, nil
+}
+
+// Get returns the value of the field of the secret of path <secret path>.<field>, false when the
+// engine has no such secret or field.
+func (s *vaultStore) Get(ctx context.Context, path string) (string, bool, error) {
+	idx := strings.LastIndex(path, ".")
+	if idx <= 0 || idx == len(path)-1 {
+		return "", false, fmt.Errorf("invalid vault secret reference %q, must be <secret path>.<field>", path)
+	}
+	secretPath, field := strings.Trim(path[:idx], "/"), path[idx+1:]
+
+	token, err := s.token()
+	if err != nil {
+		return "", false, err
+	}
+
+	uri := strings.TrimSuffix(s.config.Address, "/") + "/v1/" + strings.Trim(s.config.Mount, "/") + "/"
+	if s.config.KVVersion == 2 {
+		uri += "data/"
+	}
+	uri += secretPath
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return "", false, err
+	}
+	req.Header.Set("X-Vault-Token", token)
+	if s.config.Namespace != "" {
+		req.Header.Set("X-Vault-Namespace", s.config.Namespace)
+	}
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return "", false, fmt.Errorf("failed requesting secret %q: %w", secretPath, err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode == http.StatusNotFound {
+		return "", false, nil
+	}
+	if resp.StatusCode != http.StatusOK {
+		return "", false, fmt.Errorf("request of secret %q returned unsuccessful status code: %d", secretPath, resp.StatusCode)
+	}
+
+	var body struct {
+		Data map[string]json.RawMessage `json:"data"`
+	}
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxVaultResponseSize)).Decode(&body); err != nil {
+		return "", false, fmt.Errorf("invalid response for secret %q: %w", secretPath, err)
+	}
+	data := body.Data
+	if s.config.KVVersion == 2 {
+		// the version 2 of the KV engine nests the secret with its metadata
+		data = nil
+		if nested, ok := body.Data["data"]; ok {
+			if err := json.Unmarshal(nested, &data); err != nil {
+				return "", false, fmt.Errorf("invalid response for secret %q: %w", secretPath, err)
+			}
+		}
+	}
+
+	raw, ok := data[field]
+	if !ok {
+		return "", false, nil
+	}
+	var value string
+	if err := json.Unmarshal(raw, &value); err != nil {
+		// not a string, e.g. a number or a boolean
+		return string(raw), true, nil
+	}
+	return value, true, nil
+}
+
+// token returns the vault token, the token file is read on every request so a token renewed by
+// an external process is used.
+func (s *vaultStore) token() (string, error) {
+	if s.config.Token != "" {
+		return s.config.Token, nil
+	}
+	token, err := os.ReadFile(s.config.TokenFile)
+	if err != nil {
+		return "", fmt.Errorf("failed to read the vault token: %w", err)
+	}
+	return strings.TrimSpace(string(token)), nil
+}
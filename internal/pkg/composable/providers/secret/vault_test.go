@@ -0,0 +1,110 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package secret
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newTestVaultServer(t *testing.T) *httptest.Server {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("X-Vault-Token") != "test-token" {
+			w.WriteHeader(http.StatusForbidden)
+			return
+		}
+		switch r.URL.Path {
+		case "/v1/secret/data/myapp/db":
+			assert.Equal(t, "team", r.Header.Get("X-Vault-Namespace"))
+			_, _ = w.Write([]byte(`{"data":{"data":{"password":"kv2-password","port":5432},"metadata":{"version":3}}}`))
+		case "/v1/kv/myapp/db":
+			_, _ = w.Write([]byte(`{"data":{"password":"kv1-password"}}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(srv.Close)
+	return srv
+}
+
+func newTestVaultStore(t *testing.T, mutate func(c *StoreConfig)) *vaultStore {
+	c := &StoreConfig{}
+	c.InitDefaults()
+	c.Type = StoreTypeVault
+	c.Address = newTestVaultServer(t).URL
+	c.Token = "test-token"
+	c.Namespace = "team"
+	if mutate != nil {
+		mutate(c)
+	}
+	s, err := newVaultStore(c)
+	require.NoError(t, err)
+	return s
+}
+
+func TestVaultStore(t *testing.T) {
+	ctx := context.Background()
+	s := newTestVaultStore(t, nil)
+
+	value, found, err := s.Get(ctx, "myapp/db.password")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "kv2-password", value)
+
+	value, found, err = s.Get(ctx, "myapp/db.port")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "5432", value)
+
+	_, found, err = s.Get(ctx, "myapp/db.missing")
+	require.NoError(t, err)
+	assert.False(t, found, "missing fields should not be found")
+
+	_, found, err = s.Get(ctx, "myapp/missing.password")
+	require.NoError(t, err)
+	assert.False(t, found, "missing secrets should not be found")
+
+	_, _, err = s.Get(ctx, "myapp/db")
+	assert.Error(t, err, "references without field should fail")
+}
+
+func TestVaultStore_KVVersion1(t *testing.T) {
+	s := newTestVaultStore(t, func(c *StoreConfig) {
+		c.Mount = "kv"
+		c.KVVersion = 1
+	})
+
+	value, found, err := s.Get(context.Background(), "myapp/db.password")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "kv1-password", value)
+}
+
+func TestVaultStore_Token(t *testing.T) {
+	tokenFile := filepath.Join(t.TempDir(), "token")
+	require.NoError(t, os.WriteFile(tokenFile, []byte("test-token\n"), 0o600))
+	s := newTestVaultStore(t, func(c *StoreConfig) {
+		c.Token = ""
+		c.TokenFile = tokenFile
+	})
+
+	value, found, err := s.Get(context.Background(), "myapp/db.password")
+	require.NoError(t, err)
+	assert.True(t, found)
+	assert.Equal(t, "kv2-password", value)
+
+	s = newTestVaultStore(t, func(c *StoreConfig) {
+		c.Token = "wrong-token"
+	})
+	_, _, err = s.Get(context.Background(), "myapp/db.password")
+	assert.ErrorContains(t, err, "unsuccessful status code: 403")
+}
@@ -19,6 +19,7 @@ import (
 	"strings"
 	"time"
 
+	"github.com/elastic/elastic-agent-libs/monitoring"
 	"github.com/elastic/elastic-agent/pkg/control/v2/client"
 	"github.com/elastic/go-ucfg"
 
@@ -27,6 +28,7 @@ import (
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/config"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
+	"github.com/elastic/elastic-agent/internal/pkg/remote"
 	"github.com/elastic/elastic-agent/pkg/component"
 	"github.com/elastic/elastic-agent/version"
 )
@@ -94,6 +96,23 @@ func GlobalHooks() Hooks {
 				return fileBytes
 			},
 		},
+		{
+			Name:        "remote hosts",
+			Filename:    "remote_hosts.yaml",
+			Description: "health statistics and circuit breaker state of the remote hosts, such as Fleet Server",
+			ContentType: "application/yaml",
+			Hook: func(_ context.Context) []byte {
+				hosts := map[string]any{}
+				if reg := monitoring.Default.GetRegistry(remote.MetricsNamespace); reg != nil {
+					hosts = monitoring.CollectStructSnapshot(reg, monitoring.Full, false)
+				}
+				o, err := yaml.Marshal(hosts["hosts"])
+				if err != nil {
+					return []byte(fmt.Sprintf("error: %q", err))
+				}
+				return o
+			},
+		},
 		{
 			Name:        "goroutine",
 			Filename:    "goroutine.pprof.gz",
@@ -369,12 +388,16 @@ func redactMap[K comparable](errOut io.Writer, inputMap map[K]interface{}) map[K
 				rootValue = redactMap(errOut, cast)
 			case map[int]interface{}:
 				rootValue = redactMap(errOut, cast)
+			case []interface{}:
+				rootValue = redactSlice(errOut, cast)
 			case string:
 				if keyString, ok := any(rootKey).(string); ok {
 					if redactKey(keyString) {
 						rootValue = REDACTED
+						break
 					}
 				}
+				rootValue = redactSecretValues(cast)
 			default:
 				// in cases where we got some weird kind of map we couldn't parse, print a warning
 				if reflect.TypeOf(rootValue).Kind() == reflect.Map {
@@ -390,6 +413,26 @@ func redactMap[K comparable](errOut io.Writer, inputMap map[K]interface{}) map[K
 	return inputMap
 }
 
+// redactSlice redacts the maps and the secret values of the elements of a list, like the
+// streams of an input.
+func redactSlice(errOut io.Writer, inputSlice []interface{}) []interface{} {
+	for i, value := range inputSlice {
+		switch cast := value.(type) {
+		case map[string]interface{}:
+			inputSlice[i] = redactMap(errOut, cast)
+		case map[interface{}]interface{}:
+			inputSlice[i] = redactMap(errOut, cast)
+		case map[int]interface{}:
+			inputSlice[i] = redactMap(errOut, cast)
+		case []interface{}:
+			inputSlice[i] = redactSlice(errOut, cast)
+		case string:
+			inputSlice[i] = redactSecretValues(cast)
+		}
+	}
+	return inputSlice
+}
+
 func redactKey(k string) bool {
 	// "routekey" shouldn't be redacted.
 	// Add any other exceptions here.
@@ -518,14 +561,30 @@ func zipLogsWithPath(pathsHome, commitName string, collectServices, excludeEvent
 }
 
 func collectServiceComponentsLogs(zw *zip.Writer) error {
+	files, err := serviceComponentsLogFiles()
+	if err != nil {
+		return err
+	}
+	for _, f := range files {
+		if err := saveLogs(f.name, f.path, zw); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// serviceComponentsLogFiles lists the log files of the service components, named
+// services/<file> relative to the logs/ directory of the archive.
+func serviceComponentsLogFiles() ([]logFile, error) {
 	platform, err := component.LoadPlatformDetail()
 	if err != nil {
-		return fmt.Errorf("failed to gather system information: %w", err)
+		return nil, fmt.Errorf("failed to gather system information: %w", err)
 	}
 	specs, err := component.LoadRuntimeSpecs(paths.Components(), platform)
 	if err != nil {
-		return fmt.Errorf("failed to detect inputs and outputs: %w", err)
+		return nil, fmt.Errorf("failed to detect inputs and outputs: %w", err)
 	}
+	var files []logFile
 	for _, spec := range specs.ServiceSpecs() {
 		if spec.Spec.Service.Log == nil || spec.Spec.Service.Log.Path == "" {
 			// no log path set in specification
@@ -551,13 +610,14 @@ func collectServiceComponentsLogs(zw *zip.Writer) error {
 				return nil
 			}
 
-			return saveLogs("services/"+name, path, zw)
+			files = append(files, logFile{name: "services/" + name, path: path, service: spec.InputType})
+			return nil
 		})
 		if err != nil {
-			return err
+			return nil, err
 		}
 	}
-	return nil
+	return files, nil
 }
 
 func saveLogs(name string, logPath string, zw *zip.Writer) error {
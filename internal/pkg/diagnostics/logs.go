@@ -0,0 +1,342 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package diagnostics
+
+import (
+	"archive/zip"
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+)
+
+// LogsManifestFilename is the file describing the content of a logs bundle.
+const LogsManifestFilename = "manifest.yaml"
+
+// logFile is a log file collected under the logs/ directory of an archive.
+type logFile struct {
+	// name relative to the logs/ directory of the archive
+	name string
+	path string
+	// input type of the service component, empty for the logs of the Elastic Agent
+	service string
+}
+
+// LogsFilter selects the log lines included by ZipLogs.
+type LogsFilter struct {
+	// Since only includes the lines written after this time, all lines when zero.
+	Since time.Time `yaml:"since,omitempty"`
+	// Until only includes the lines written before this time, all lines when zero.
+	Until time.Time `yaml:"until,omitempty"`
+	// Components only includes the lines of these components, all lines when empty. Like
+	// with the logs command, the lines of the Elastic Agent itself are not included.
+	Components []string `yaml:"components,omitempty"`
+	// ExcludeEvents excludes the events logs.
+	ExcludeEvents bool `yaml:"exclude_events"`
+	// MaxSize caps the uncompressed size of the included lines, no cap when 0.
+	MaxSize int64 `yaml:"max_size,omitempty"`
+}
+
+// logsManifest describes the content of a logs bundle.
+type logsManifest struct {
+	Generated time.Time  `yaml:"generated"`
+	Filter    LogsFilter `yaml:"filter"`
+	Size      int64      `yaml:"size"`
+	Truncated bool       `yaml:"truncated"`
+	Files     []string   `yaml:"files"`
+	Errors    []string   `yaml:"errors,omitempty"`
+}
+
+// ZipLogs creates a zipped bundle, using the passed writer, of the Elastic Agent and
+// component log lines selected by filter.
+//
+// Files are read newest first, so when the size of the lines reaches filter.MaxSize it
+// is the lines of the oldest files that are dropped and the bundle is flagged as truncated
+// in its manifest. Sensitive values of the JSON log lines are redacted.
+func ZipLogs(errOut, w io.Writer, topPath string, filter LogsFilter) error {
+	ts := time.Now().UTC()
+	zw := zip.NewWriter(w)
+	defer zw.Close()
+
+	files, err := agentLogFiles(topPath, filter.ExcludeEvents)
+	if err != nil {
+		return fmt.Errorf("failed to list log files: %w", err)
+	}
+	services, err := serviceComponentsLogFiles()
+	if err != nil {
+		// service components logs are best effort, the agent logs are still collected
+		fmt.Fprintf(errOut, "[WARNING] Could not list the logs of the service components: %v\n", err)
+	}
+	for _, f := range services {
+		if filter.matchesService(f.service) {
+			files = append(files, f)
+		}
+	}
+
+	manifest := logsManifest{Generated: ts, Filter: filter}
+	sortNewestFirst(files)
+	for _, f := range files {
+		if filter.MaxSize > 0 && manifest.Size >= filter.MaxSize {
+			manifest.Truncated = true
+			break
+		}
+		written, truncated, err := zipLogFile(errOut, zw, f, filter, filter.MaxSize-manifest.Size)
+		if errors.Is(err, fs.ErrNotExist) {
+			// rotated away while collecting
+			continue
+		}
+		if err != nil {
+			manifest.Errors = append(manifest.Errors, fmt.Sprintf("%s: %v", f.name, err))
+			continue
+		}
+		if written > 0 {
+			manifest.Files = append(manifest.Files, f.name)
+		}
+		manifest.Size += written
+		if truncated {
+			manifest.Truncated = true
+			break
+		}
+	}
+
+	mw, err := zw.CreateHeader(&zip.FileHeader{
+		Name:     LogsManifestFilename,
+		Method:   zip.Deflate,
+		Modified: ts,
+	})
+	if err != nil {
+		return fmt.Errorf("error creating header for logs manifest: %w", err)
+	}
+	return yaml.NewEncoder(mw).Encode(manifest)
+}
+
+// agentLogFiles lists the log files of the Elastic Agent, including the ones of the
+// previous versions kept in the data directory, named <version-dir>/<file> relative to
+// the logs/ directory of the archive.
+func agentLogFiles(topPath string, excludeEvents bool) ([]logFile, error) {
+	homePath := paths.HomeFrom(topPath)
+	if !paths.IsVersionHome() {
+		// running in a container with custom top path set
+		// logs are directly under top path
+		return agentLogFilesWithPath(homePath, filepath.Base(homePath), excludeEvents)
+	}
+
+	dataPath := paths.DataFrom(topPath)
+	subdirs, err := os.ReadDir(dataPath)
+	if err != nil {
+		return nil, err
+	}
+	var files []logFile
+	dirPrefix := fmt.Sprintf("%s-", agentName)
+	for _, dir := range subdirs {
+		if !dir.IsDir() || !strings.HasPrefix(dir.Name(), dirPrefix) {
+			continue
+		}
+		dirFiles, err := agentLogFilesWithPath(filepath.Join(dataPath, dir.Name()), dir.Name(), excludeEvents)
+		if err != nil {
+			return nil, err
+		}
+		files = append(files, dirFiles...)
+	}
+	return files, nil
+}
+
+func agentLogFilesWithPath(pathsHome, commitName string, excludeEvents bool) ([]logFile, error) {
+	var files []logFile
+	logPath := filepath.Join(pathsHome, "logs") + string(filepath.Separator)
+	err := filepath.WalkDir(logPath, func(path string, d fs.DirEntry, fErr error) error {
+		if errors.Is(fErr, fs.ErrNotExist) {
+			return nil
+		}
+		if fErr != nil {
+			return fmt.Errorf("unable to walk log dir: %w", fErr)
+		}
+		name := filepath.ToSlash(strings.TrimPrefix(path, logPath))
+		if name == "" || d.IsDir() {
+			return nil
+		}
+		if excludeEvents && strings.HasPrefix(name, "events") {
+			return nil
+		}
+		files = append(files, logFile{name: commitName + "/" + name, path: path})
+		return nil
+	})
+	return files, err
+}
+
+// sortNewestFirst sorts the files by modification time, newest first. Files that cannot
+// be stat'ed are sorted last.
+func sortNewestFirst(files []logFile) {
+	modTimes := make(map[string]time.Time, len(files))
+	for _, f := range files {
+		if fi, err := os.Stat(f.path); err == nil {
+			modTimes[f.path] = fi.ModTime()
+		}
+	}
+	sort.SliceStable(files, func(i, j int) bool {
+		return modTimes[files[i].path].After(modTimes[files[j].path])
+	})
+}
+
+// zipLogFile writes the lines of the file selected by filter to zw, stopping once
+// maxSize bytes have been written when filter.MaxSize is set. It returns the number of
+// bytes written and whether lines were dropped because of the size cap.
+func zipLogFile(errOut io.Writer, zw *zip.Writer, f logFile, filter LogsFilter, maxSize int64) (int64, bool, error) {
+	lf, err := os.Open(f.path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer lf.Close()
+
+	modTime := time.Now().UTC()
+	if fi, err := lf.Stat(); err == nil {
+		modTime = fi.ModTime()
+		if !filter.Since.IsZero() && modTime.Before(filter.Since) {
+			// the file was last written before the requested range
+			return 0, false, nil
+		}
+	}
+
+	var zf io.Writer
+	var written int64
+	r := bufio.NewReader(lf)
+	for {
+		line, readErr := r.ReadBytes('\n')
+		if len(line) > 0 {
+			out, ok := filter.selectLine(errOut, line, f.service != "")
+			if ok {
+				if filter.MaxSize > 0 && written+int64(len(out)) > maxSize {
+					return written, true, nil
+				}
+				if zf == nil {
+					zf, err = zw.CreateHeader(&zip.FileHeader{
+						Name:     "logs/" + f.name,
+						Method:   zip.Deflate,
+						Modified: modTime,
+					})
+					if err != nil {
+						return written, false, err
+					}
+				}
+				n, err := zf.Write(out)
+				written += int64(n)
+				if err != nil {
+					return written, false, err
+				}
+			}
+		}
+		if errors.Is(readErr, io.EOF) {
+			return written, false, nil
+		}
+		if readErr != nil {
+			return written, false, readErr
+		}
+	}
+}
+
+// matchesService returns true when the logs of the service component with the input
+// type are selected. Service component logs are not tagged with a component ID, so a
+// component filter selects them when one of its components is of their input type.
+func (f LogsFilter) matchesService(inputType string) bool {
+	if len(f.Components) == 0 {
+		return true
+	}
+	for _, id := range f.Components {
+		if id == inputType || strings.HasPrefix(id, inputType+"-") {
+			return true
+		}
+	}
+	return false
+}
+
+// selectLine returns the line, redacted, and whether it is selected by the filter. Lines
+// that are not JSON cannot be filtered on time or component, they are written by the
+// Elastic Agent so they are only selected when no component filter is set or when they
+// come from a service component. Their keys cannot be told apart, only the secret values
+// are redacted from them.
+func (f LogsFilter) selectLine(errOut io.Writer, line []byte, service bool) ([]byte, bool) {
+	dec := json.NewDecoder(bytes.NewReader(line))
+	dec.UseNumber()
+	var entry map[string]interface{}
+	if err := dec.Decode(&entry); err != nil {
+		if len(f.Components) > 0 && !service {
+			return nil, false
+		}
+		return RedactSecretValues(line), true
+	}
+
+	if ts, ok := logEntryTimestamp(entry); ok {
+		if !f.Since.IsZero() && ts.Before(f.Since) {
+			return nil, false
+		}
+		if !f.Until.IsZero() && ts.After(f.Until) {
+			return nil, false
+		}
+	}
+	// the lines of the Elastic Agent have no component ID, they never match a component filter
+	if len(f.Components) > 0 && !service && !f.matchesComponent(logEntryComponentID(entry)) {
+		return nil, false
+	}
+
+	// quick check on the whole line, if it contains no sensitive key then none of its keys do
+	if !redactKey(string(line)) {
+		return RedactSecretValues(line), true
+	}
+	var redacted bytes.Buffer
+	enc := json.NewEncoder(&redacted)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(redactMap(errOut, entry)); err != nil {
+		fmt.Fprintf(errOut, "[WARNING] Dropping log line that could not be redacted: %v\n", err)
+		return nil, false
+	}
+	return redacted.Bytes(), true
+}
+
+func (f LogsFilter) matchesComponent(id string) bool {
+	for _, c := range f.Components {
+		if c == id {
+			return true
+		}
+	}
+	return false
+}
+
+func logEntryTimestamp(entry map[string]interface{}) (time.Time, bool) {
+	raw, ok := entry["@timestamp"].(string)
+	if !ok {
+		return time.Time{}, false
+	}
+	ts, err := time.Parse(time.RFC3339Nano, raw)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// logEntryComponentID returns the ID of the component that wrote the log line, empty
+// for the lines of the Elastic Agent itself.
+func logEntryComponentID(entry map[string]interface{}) string {
+	if id, ok := entry["component.id"].(string); ok {
+		return id
+	}
+	if comp, ok := entry["component"].(map[string]interface{}); ok {
+		if id, ok := comp["id"].(string); ok {
+			return id
+		}
+	}
+	return ""
+}
@@ -0,0 +1,136 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package diagnostics
+
+import (
+	"archive/zip"
+	"bytes"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"gopkg.in/yaml.v3"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+)
+
+const testLogLines = `{"@timestamp":"2024-05-01T09:00:00.000Z","log.level":"info","message":"agent starting"}
+{"@timestamp":"2024-05-01T10:30:00.000Z","log.level":"info","message":"filestream running","component":{"id":"filestream-default"}}
+{"@timestamp":"2024-05-01T10:45:00.000Z","log.level":"info","message":"metrics running","component":{"id":"system/metrics-default"},"api_key":"secret-value"}
+not a json line with resolved-log-secret
+{"@timestamp":"2024-05-01T12:00:00.000Z","log.level":"info","message":"agent stopping resolved-log-secret"}
+`
+
+func readZippedLogs(t *testing.T, buf *bytes.Buffer) (map[string]string, logsManifest) {
+	t.Helper()
+	r, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	require.NoError(t, err)
+
+	files := make(map[string]string)
+	var manifest logsManifest
+	for _, f := range r.File {
+		rc, err := f.Open()
+		require.NoError(t, err)
+		content, err := io.ReadAll(rc)
+		require.NoError(t, err)
+		rc.Close()
+		if f.Name == LogsManifestFilename {
+			require.NoError(t, yaml.Unmarshal(content, &manifest))
+			continue
+		}
+		files[f.Name] = string(content)
+	}
+	return files, manifest
+}
+
+func TestZipLogsFilter(t *testing.T) {
+	AddSecretValue("resolved-log-secret")
+	topPath := t.TempDir()
+	logsDir := filepath.Join(paths.HomeFrom(topPath), "logs")
+	require.NoError(t, os.MkdirAll(filepath.Join(logsDir, "events"), 0o700))
+	require.NoError(t, os.WriteFile(filepath.Join(logsDir, "elastic-agent.ndjson"), []byte(testLogLines), 0o600))
+	require.NoError(t, os.WriteFile(filepath.Join(logsDir, "events", "elastic-agent-events.ndjson"), []byte(testLogLines), 0o600))
+	logName := "logs/" + filepath.Base(paths.HomeFrom(topPath)) + "/elastic-agent.ndjson"
+
+	t.Run("all lines are redacted", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ZipLogs(io.Discard, &buf, topPath, LogsFilter{ExcludeEvents: true}))
+		files, manifest := readZippedLogs(t, &buf)
+
+		require.Len(t, files, 1)
+		content := files[logName]
+		assert.Equal(t, 5, strings.Count(content, "\n"))
+		assert.NotContains(t, content, "secret-value")
+		assert.NotContains(t, content, "resolved-log-secret", "secret values must be redacted from all lines")
+		assert.Contains(t, content, "not a json line with "+REDACTED)
+		assert.Contains(t, content, REDACTED)
+		// lines without sensitive keys are kept as they are
+		assert.Contains(t, content, `{"@timestamp":"2024-05-01T09:00:00.000Z","log.level":"info","message":"agent starting"}`)
+		assert.False(t, manifest.Truncated)
+		assert.Len(t, manifest.Files, 1)
+	})
+
+	t.Run("time range", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ZipLogs(io.Discard, &buf, topPath, LogsFilter{
+			Since:         time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC),
+			Until:         time.Date(2024, 5, 1, 11, 0, 0, 0, time.UTC),
+			ExcludeEvents: true,
+		}))
+		files, _ := readZippedLogs(t, &buf)
+
+		content := files[logName]
+		assert.Contains(t, content, "filestream running")
+		assert.Contains(t, content, "metrics running")
+		assert.Contains(t, content, "not a json line")
+		assert.NotContains(t, content, "agent starting")
+		assert.NotContains(t, content, "agent stopping")
+	})
+
+	t.Run("component filter", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ZipLogs(io.Discard, &buf, topPath, LogsFilter{
+			Components:    []string{"filestream-default"},
+			ExcludeEvents: true,
+		}))
+		files, _ := readZippedLogs(t, &buf)
+
+		content := files[logName]
+		assert.Equal(t, 1, strings.Count(content, "\n"))
+		assert.Contains(t, content, "filestream running")
+		// the lines of the Elastic Agent, JSON or not, are not included
+		assert.NotContains(t, content, "agent starting")
+		assert.NotContains(t, content, "not a json line")
+	})
+
+	t.Run("events logs", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ZipLogs(io.Discard, &buf, topPath, LogsFilter{}))
+		files, manifest := readZippedLogs(t, &buf)
+
+		assert.Len(t, files, 2)
+		assert.Len(t, manifest.Files, 2)
+	})
+
+	t.Run("size cap", func(t *testing.T) {
+		var buf bytes.Buffer
+		require.NoError(t, ZipLogs(io.Discard, &buf, topPath, LogsFilter{
+			ExcludeEvents: true,
+			MaxSize:       200,
+		}))
+		files, manifest := readZippedLogs(t, &buf)
+
+		content := files[logName]
+		assert.LessOrEqual(t, len(content), 200)
+		assert.Contains(t, content, "agent starting")
+		assert.True(t, manifest.Truncated)
+		assert.Equal(t, int64(len(content)), manifest.Size)
+	})
+}
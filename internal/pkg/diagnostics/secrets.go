@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package diagnostics
+
+import (
+	"bytes"
+	"cmp"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// secretValues are the values resolved from the secret stores by this process, they are
+// redacted wherever they appear, independently of the key holding them.
+var secretValues = struct {
+	sync.RWMutex
+	values map[string]struct{}
+}{values: make(map[string]struct{})}
+
+// AddSecretValue registers a value resolved from a secret store so it is redacted from the
+// diagnostics and from the configurations printed by inspect.
+func AddSecretValue(value string) {
+	if value == "" {
+		return
+	}
+	secretValues.Lock()
+	defer secretValues.Unlock()
+	secretValues.values[value] = struct{}{}
+}
+
+// RedactSecretValues replaces the registered secret values in content with REDACTED.
+func RedactSecretValues(content []byte) []byte {
+	for _, value := range sortedSecretValues() {
+		content = bytes.ReplaceAll(content, []byte(value), []byte(REDACTED))
+	}
+	return content
+}
+
+func redactSecretValues(s string) string {
+	for _, value := range sortedSecretValues() {
+		s = strings.ReplaceAll(s, value, REDACTED)
+	}
+	return s
+}
+
+// sortedSecretValues returns the secret values from the longest to the shortest, so a value
+// containing another one is redacted entirely.
+func sortedSecretValues() []string {
+	secretValues.RLock()
+	values := make([]string, 0, len(secretValues.values))
+	for value := range secretValues.values {
+		values = append(values, value)
+	}
+	secretValues.RUnlock()
+
+	slices.SortFunc(values, func(a, b string) int {
+		return cmp.Compare(len(b), len(a))
+	})
+	return values
+}
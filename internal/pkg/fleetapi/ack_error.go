@@ -0,0 +1,149 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package fleetapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"syscall"
+)
+
+// ErrorCode is a machine-readable code of the error an action failed with, sent in the
+// payload of the ack so Fleet can tell the failures apart.
+type ErrorCode string
+
+const (
+	// ErrorCodeUnknown is the code of the errors that were not classified.
+	ErrorCodeUnknown ErrorCode = "UNKNOWN"
+	// ErrorCodeUnknownAction is the code of the actions unknown to the agent.
+	ErrorCodeUnknownAction ErrorCode = "UNKNOWN_ACTION"
+	// ErrorCodeTimeout is the code of the actions that did not complete in time.
+	ErrorCodeTimeout ErrorCode = "TIMEOUT"
+	// ErrorCodeRateLimited is the code of the actions rejected because they are sent too often.
+	ErrorCodeRateLimited ErrorCode = "RATE_LIMITED"
+	// ErrorCodeDiskFull is the code of the actions that failed because the disk is full.
+	ErrorCodeDiskFull ErrorCode = "DISK_FULL"
+	// ErrorCodeNetwork is the code of the actions that failed because of a network error.
+	ErrorCodeNetwork ErrorCode = "NETWORK"
+	// ErrorCodeCancelled is the code of the queued actions cancelled on the agent.
+	ErrorCodeCancelled ErrorCode = "CANCELLED"
+
+	// ErrorCodeUpgradeDownloadFailed is the code of the upgrades whose artifact could not be downloaded.
+	ErrorCodeUpgradeDownloadFailed ErrorCode = "UPGRADE_DOWNLOAD_FAILED"
+	// ErrorCodeUpgradeChecksumMismatch is the code of the upgrades whose artifact has an unexpected checksum.
+	ErrorCodeUpgradeChecksumMismatch ErrorCode = "UPGRADE_CHECKSUM_MISMATCH"
+	// ErrorCodeUpgradeInvalidSignature is the code of the upgrades whose artifact has an invalid signature.
+	ErrorCodeUpgradeInvalidSignature ErrorCode = "UPGRADE_INVALID_SIGNATURE"
+	// ErrorCodeUpgradeInProgress is the code of the upgrades received while another upgrade is running.
+	ErrorCodeUpgradeInProgress ErrorCode = "UPGRADE_IN_PROGRESS"
+	// ErrorCodeUpgradeNotAllowed is the code of the upgrades the agent refuses to perform.
+	ErrorCodeUpgradeNotAllowed ErrorCode = "UPGRADE_NOT_ALLOWED"
+	// ErrorCodeUpgradeFailed is the code of the upgrades that failed for another reason.
+	ErrorCodeUpgradeFailed ErrorCode = "UPGRADE_FAILED"
+	// ErrorCodeRollbackNotAvailable is the code of the rollbacks to a version that is not retained.
+	ErrorCodeRollbackNotAvailable ErrorCode = "ROLLBACK_NOT_AVAILABLE"
+	// ErrorCodeRollbackFailed is the code of the rollbacks that failed for another reason.
+	ErrorCodeRollbackFailed ErrorCode = "ROLLBACK_FAILED"
+
+	// ErrorCodePolicyInvalid is the code of the policy changes with an invalid policy.
+	ErrorCodePolicyInvalid ErrorCode = "POLICY_INVALID"
+	// ErrorCodePolicyApplyFailed is the code of the policy changes that could not be applied.
+	ErrorCodePolicyApplyFailed ErrorCode = "POLICY_APPLY_FAILED"
+
+	// ErrorCodeDiagnosticsFailed is the code of the diagnostics and logs that could not be collected.
+	ErrorCodeDiagnosticsFailed ErrorCode = "DIAGNOSTICS_FAILED"
+	// ErrorCodeUploadFailed is the code of the diagnostics and logs that could not be uploaded.
+	ErrorCodeUploadFailed ErrorCode = "UPLOAD_FAILED"
+
+	// ErrorCodeMigrateNotSupported is the code of the migrations the agent cannot perform.
+	ErrorCodeMigrateNotSupported ErrorCode = "MIGRATE_NOT_SUPPORTED"
+	// ErrorCodeMigrateFailed is the code of the migrations that failed.
+	ErrorCodeMigrateFailed ErrorCode = "MIGRATE_FAILED"
+
+	// ErrorCodeUnenrollFailed is the code of the unenrolls that failed.
+	ErrorCodeUnenrollFailed ErrorCode = "UNENROLL_FAILED"
+)
+
+// AckError is an action error with a machine-readable code and whether sending the
+// action again may succeed.
+type AckError struct {
+	Code      ErrorCode
+	Retryable bool
+	Err       error
+}
+
+// NewAckError returns an AckError wrapping err.
+func NewAckError(code ErrorCode, retryable bool, err error) *AckError {
+	return &AckError{Code: code, Retryable: retryable, Err: err}
+}
+
+func (e *AckError) Error() string {
+	if e.Err == nil {
+		return string(e.Code)
+	}
+	return e.Err.Error()
+}
+
+// Unwrap returns the wrapped error.
+func (e *AckError) Unwrap() error {
+	return e.Err
+}
+
+// ErrorCode returns the code of the error as a string.
+func (e *AckError) ErrorCode() string {
+	return string(e.Code)
+}
+
+// AckErrorFrom returns the AckError err wraps. Errors without one are classified from
+// their cause: timeouts, full disks and network errors are retryable, other errors are
+// unknown and not retryable. It returns nil when err is nil.
+func AckErrorFrom(err error) *AckError {
+	if err == nil {
+		return nil
+	}
+	var ackErr *AckError
+	if errors.As(err, &ackErr) {
+		return ackErr
+	}
+	if errors.Is(err, context.DeadlineExceeded) {
+		return NewAckError(ErrorCodeTimeout, true, err)
+	}
+	if errors.Is(err, syscall.ENOSPC) {
+		return NewAckError(ErrorCodeDiskFull, true, err)
+	}
+	var netErr net.Error
+	if errors.As(err, &netErr) {
+		return NewAckError(ErrorCodeNetwork, true, err)
+	}
+	return NewAckError(ErrorCodeUnknown, false, err)
+}
+
+// ackErrorPayload is the error in the payload of an ack event.
+type ackErrorPayload struct {
+	Code      ErrorCode `json:"code"`
+	Retryable bool      `json:"retryable"`
+}
+
+func newAckErrorPayload(err error) *ackErrorPayload {
+	ackErr := AckErrorFrom(err)
+	return &ackErrorPayload{Code: ackErr.Code, Retryable: ackErr.Retryable}
+}
+
+// SetAckEventError sets the error of the event and its code in the event payload.
+func SetAckEventError(event *AckEvent, err error) {
+	if err == nil {
+		return
+	}
+	event.Error = err.Error()
+	payload := struct {
+		Error *ackErrorPayload `json:"error"`
+	}{
+		Error: newAckErrorPayload(err),
+	}
+	p, _ := json.Marshal(payload)
+	event.Payload = p
+}
@@ -0,0 +1,143 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package fleetapi
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"syscall"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestAckErrorFrom(t *testing.T) {
+	tests := []struct {
+		name      string
+		err       error
+		code      ErrorCode
+		retryable bool
+	}{
+		{
+			name:      "ack error",
+			err:       fmt.Errorf("wrapped: %w", NewAckError(ErrorCodeUpgradeChecksumMismatch, true, errors.New("mismatch"))),
+			code:      ErrorCodeUpgradeChecksumMismatch,
+			retryable: true,
+		},
+		{
+			name:      "timeout",
+			err:       fmt.Errorf("waiting: %w", context.DeadlineExceeded),
+			code:      ErrorCodeTimeout,
+			retryable: true,
+		},
+		{
+			name:      "disk full",
+			err:       &os.PathError{Op: "write", Path: "/tmp/file", Err: syscall.ENOSPC},
+			code:      ErrorCodeDiskFull,
+			retryable: true,
+		},
+		{
+			name: "unknown",
+			err:  errors.New("something failed"),
+			code: ErrorCodeUnknown,
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			ackErr := AckErrorFrom(tc.err)
+			require.NotNil(t, ackErr)
+			assert.Equal(t, tc.code, ackErr.Code)
+			assert.Equal(t, tc.retryable, ackErr.Retryable)
+		})
+	}
+
+	assert.Nil(t, AckErrorFrom(nil))
+}
+
+func TestAckEventErrorPayload(t *testing.T) {
+	t.Run("diagnostics", func(t *testing.T) {
+		action := &ActionDiagnostics{
+			ActionID:   "action-1",
+			ActionType: ActionTypeDiagnostics,
+			Err:        NewAckError(ErrorCodeUploadFailed, true, errors.New("upload failed")),
+		}
+		event := action.AckEvent()
+		assert.Equal(t, "upload failed", event.Error)
+		assert.JSONEq(t, `{"error": {"code": "UPLOAD_FAILED", "retryable": true}}`, string(event.Payload))
+	})
+
+	t.Run("upgrade", func(t *testing.T) {
+		action := &ActionUpgrade{
+			ActionID:   "action-1",
+			ActionType: ActionTypeUpgrade,
+			Data:       ActionUpgradeData{Retry: 2},
+			Err:        NewAckError(ErrorCodeUpgradeDownloadFailed, true, errors.New("download failed")),
+		}
+		event := action.AckEvent()
+		assert.Equal(t, "download failed", event.Error)
+		assert.JSONEq(t, `{"retry": true, "retry_attempt": 2, "error": {"code": "UPGRADE_DOWNLOAD_FAILED", "retryable": true}}`, string(event.Payload))
+	})
+
+	t.Run("policy change", func(t *testing.T) {
+		action := &ActionPolicyChange{
+			ActionID:   "action-1",
+			ActionType: ActionTypePolicyChange,
+			Err:        NewAckError(ErrorCodePolicyInvalid, false, errors.New("invalid policy")),
+		}
+		assertSerializedAckError(t, action, "invalid policy", ErrorCodePolicyInvalid, false)
+	})
+
+	t.Run("unenroll", func(t *testing.T) {
+		action := &ActionUnenroll{
+			ActionID:   "action-1",
+			ActionType: ActionTypeUnenroll,
+			Err:        NewAckError(ErrorCodeUnenrollFailed, true, errors.New("failed to notify fleet")),
+		}
+		assertSerializedAckError(t, action, "failed to notify fleet", ErrorCodeUnenrollFailed, true)
+	})
+
+	t.Run("unknown action", func(t *testing.T) {
+		action := &ActionUnknown{ActionID: "action-1", ActionType: ActionTypeUnknown, OriginalType: "NEW_TYPE"}
+		event := action.AckEvent()
+		assert.Equal(t, `Action "action-1" of type "NEW_TYPE" is unknown to the elastic-agent`, event.Error)
+		var payload struct {
+			Error ackErrorPayload `json:"error"`
+		}
+		require.NoError(t, json.Unmarshal(event.Payload, &payload))
+		assert.Equal(t, ErrorCodeUnknownAction, payload.Error.Code)
+		assert.False(t, payload.Error.Retryable)
+	})
+
+	t.Run("no error", func(t *testing.T) {
+		event := (&ActionMigrate{ActionID: "action-1", ActionType: ActionTypeMigrate}).AckEvent()
+		assert.Empty(t, event.Error)
+		assert.Empty(t, event.Payload)
+	})
+}
+
+// assertSerializedAckError checks the error of the ack event of action as it is sent to fleet-server.
+func assertSerializedAckError(t *testing.T, action Action, message string, code ErrorCode, retryable bool) {
+	t.Helper()
+	raw, err := json.Marshal(action.AckEvent())
+	require.NoError(t, err)
+
+	var event struct {
+		Error   string          `json:"error"`
+		Payload json.RawMessage `json:"payload"`
+	}
+	require.NoError(t, json.Unmarshal(raw, &event))
+	assert.Equal(t, message, event.Error)
+
+	var payload struct {
+		Error ackErrorPayload `json:"error"`
+	}
+	require.NoError(t, json.Unmarshal(event.Payload, &payload))
+	assert.Equal(t, code, payload.Error.Code)
+	assert.Equal(t, retryable, payload.Error.Retryable)
+}
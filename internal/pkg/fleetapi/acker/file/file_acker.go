@@ -0,0 +1,90 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package file
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const fileTimeFormat = "2006-01-02T15:04:05.99999-07:00"
+
+type agentInfo interface {
+	AgentID() string
+}
+
+// Acker writes the acks of the actions to a local results file, one JSON encoded
+// ack event per line, instead of sending them to Fleet.
+type Acker struct {
+	log       *logger.Logger
+	agentInfo agentInfo
+	path      string
+
+	mx      sync.Mutex
+	pending []fleetapi.AckEvent
+}
+
+// NewAcker creates a new file acker writing to path.
+func NewAcker(log *logger.Logger, agentInfo agentInfo, path string) *Acker {
+	return &Acker{
+		log:       log,
+		agentInfo: agentInfo,
+		path:      path,
+	}
+}
+
+// Ack adds the action to the acks written on the next Commit.
+func (f *Acker) Ack(_ context.Context, action fleetapi.Action) error {
+	event := action.AckEvent()
+	event.AgentID = f.agentInfo.AgentID()
+	event.Timestamp = time.Now().Format(fileTimeFormat)
+
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	f.pending = append(f.pending, event)
+	return nil
+}
+
+// Commit appends the pending acks to the results file.
+func (f *Acker) Commit(_ context.Context) error {
+	f.mx.Lock()
+	defer f.mx.Unlock()
+	if len(f.pending) == 0 {
+		return nil
+	}
+
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	for _, event := range f.pending {
+		if err := enc.Encode(event); err != nil {
+			return fmt.Errorf("failed to encode ack for action %s: %w", event.ActionID, err)
+		}
+	}
+
+	if err := os.MkdirAll(filepath.Dir(f.path), 0o750); err != nil {
+		return fmt.Errorf("failed to create directory of results file %s: %w", f.path, err)
+	}
+	fd, err := os.OpenFile(f.path, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0o600)
+	if err != nil {
+		return fmt.Errorf("failed to open results file %s: %w", f.path, err)
+	}
+	defer fd.Close()
+	if _, err := fd.Write(buf.Bytes()); err != nil {
+		return fmt.Errorf("failed to write results file %s: %w", f.path, err)
+	}
+
+	f.log.Debugf("%d action results written to %s", len(f.pending), f.path)
+	f.pending = nil
+	return nil
+}
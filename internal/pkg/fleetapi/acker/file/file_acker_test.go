@@ -0,0 +1,77 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package file
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger/loggertest"
+)
+
+type testAgentInfo struct{}
+
+func (testAgentInfo) AgentID() string { return "agent-1" }
+
+func readResults(t *testing.T, path string) []fleetapi.AckEvent {
+	t.Helper()
+	f, err := os.Open(path)
+	require.NoError(t, err)
+	defer f.Close()
+
+	var events []fleetapi.AckEvent
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		var event fleetapi.AckEvent
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &event))
+		events = append(events, event)
+	}
+	require.NoError(t, scanner.Err())
+	return events
+}
+
+func TestAcker(t *testing.T) {
+	log, _ := loggertest.New("file_acker")
+	path := filepath.Join(t.TempDir(), "results", "local_action_results.ndjson")
+	acker := NewAcker(log, testAgentInfo{}, path)
+	ctx := context.Background()
+
+	// nothing is written until commit
+	require.NoError(t, acker.Ack(ctx, &fleetapi.ActionSettings{ActionID: "action-1", ActionType: fleetapi.ActionTypeSettings}))
+	_, err := os.Stat(path)
+	require.ErrorIs(t, err, os.ErrNotExist)
+
+	require.NoError(t, acker.Commit(ctx))
+	require.NoError(t, acker.Ack(ctx, &fleetapi.ActionUpgrade{
+		ActionID:   "action-2",
+		ActionType: fleetapi.ActionTypeUpgrade,
+		Err:        errors.New("download failed"),
+	}))
+	require.NoError(t, acker.Commit(ctx))
+
+	events := readResults(t, path)
+	require.Len(t, events, 2)
+	assert.Equal(t, "action-1", events[0].ActionID)
+	assert.Equal(t, "agent-1", events[0].AgentID)
+	assert.NotEmpty(t, events[0].Timestamp)
+	assert.Empty(t, events[0].Error)
+	assert.Equal(t, "action-2", events[1].ActionID)
+	assert.Equal(t, "download failed", events[1].Error)
+
+	info, err := os.Stat(path)
+	require.NoError(t, err)
+	if filepath.Separator == '/' {
+		assert.Equal(t, os.FileMode(0o600), info.Mode().Perm())
+	}
+}
@@ -7,6 +7,7 @@ package fleet
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"strings"
 	"time"
 
@@ -24,24 +25,46 @@ type agentInfo interface {
 	AgentID() string
 }
 
+type ackJournal interface {
+	Add(actions ...fleetapi.Action) error
+	Remove(actionIDs ...string) error
+}
+
 // Acker is acker capable of acking action in fleet.
 type Acker struct {
 	log       *logger.Logger
 	client    client.Sender
 	agentInfo agentInfo
+	journal   ackJournal
 }
 
+// Option Acker option function
+type Option func(f *Acker)
+
 // NewAcker creates a new fleet acker.
 func NewAcker(
 	log *logger.Logger,
 	agentInfo agentInfo,
 	client client.Sender,
+	opts ...Option,
 ) (*Acker, error) {
-	return &Acker{
+	f := &Acker{
 		log:       log,
 		client:    client,
 		agentInfo: agentInfo,
-	}, nil
+	}
+	for _, opt := range opts {
+		opt(f)
+	}
+	return f, nil
+}
+
+// WithJournal option allows to specify the journal the acks are written through,
+// acks are removed from the journal once accepted by fleet.
+func WithJournal(j ackJournal) Option {
+	return func(f *Acker) {
+		f.journal = j
+	}
 }
 
 // SetClient sets client to be used for http communication.
@@ -56,6 +79,7 @@ func (f *Acker) Ack(ctx context.Context, action fleetapi.Action) (err error) {
 		apm.CaptureError(ctx, err).Send()
 		span.End()
 	}()
+	f.journalAdd(action)
 	// checkin
 	agentID := f.agentInfo.AgentID()
 	cmd := fleetapi.NewAckCmd(f.agentInfo, f.client)
@@ -71,6 +95,7 @@ func (f *Acker) Ack(ctx context.Context, action fleetapi.Action) (err error) {
 		return errors.New(err, fmt.Sprintf("acknowledge action '%s' for elastic-agent '%s' failed", action.ID(), agentID), errors.TypeNetwork)
 	}
 
+	f.journalRemove(action.ID())
 	f.log.Debugf("action with id '%s' was just acknowledged", action.ID())
 
 	return nil
@@ -102,6 +127,7 @@ func (f *Acker) AckBatch(ctx context.Context, actions []fleetapi.Action) (res *f
 		return &fleetapi.AckResponse{}, nil
 	}
 
+	f.journalAdd(actions...)
 	cmd := fleetapi.NewAckCmd(f.agentInfo, f.client)
 	req := &fleetapi.AckRequest{
 		Events: events,
@@ -113,6 +139,7 @@ func (f *Acker) AckBatch(ctx context.Context, actions []fleetapi.Action) (res *f
 	if err != nil {
 		return nil, errors.New(err, fmt.Sprintf("acknowledge %d actions '%v' for elastic-agent '%s' failed", len(actions), actions, agentID), errors.TypeNetwork)
 	}
+	f.journalRemove(ackedIDs(ids, res)...)
 	return res, nil
 }
 
@@ -120,3 +147,35 @@ func (f *Acker) AckBatch(ctx context.Context, actions []fleetapi.Action) (res *f
 func (f *Acker) Commit(ctx context.Context) error {
 	return nil
 }
+
+func (f *Acker) journalAdd(actions ...fleetapi.Action) {
+	if f.journal == nil {
+		return
+	}
+	if err := f.journal.Add(actions...); err != nil {
+		f.log.Warnf("fleet acker: failed to journal acks: %v", err)
+	}
+}
+
+func (f *Acker) journalRemove(ids ...string) {
+	if f.journal == nil || len(ids) == 0 {
+		return
+	}
+	if err := f.journal.Remove(ids...); err != nil {
+		f.log.Warnf("fleet acker: failed to remove acks from journal: %v", err)
+	}
+}
+
+// ackedIDs returns the IDs of the actions that fleet accepted in the response.
+func ackedIDs(ids []string, res *fleetapi.AckResponse) []string {
+	if res == nil || !res.Errors {
+		return ids
+	}
+	acked := make([]string, 0, len(ids))
+	for i, item := range res.Items {
+		if i < len(ids) && item.Status < http.StatusBadRequest {
+			acked = append(acked, ids[i])
+		}
+	}
+	return acked
+}
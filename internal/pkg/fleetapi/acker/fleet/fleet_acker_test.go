@@ -214,3 +214,48 @@ func TestAcker_Ack(t *testing.T) {
 		})
 	}
 }
+
+type testJournal struct {
+	added   []string
+	removed []string
+}
+
+func (j *testJournal) Add(actions ...fleetapi.Action) error {
+	for _, a := range actions {
+		j.added = append(j.added, a.ID())
+	}
+	return nil
+}
+
+func (j *testJournal) Remove(actionIDs ...string) error {
+	j.removed = append(j.removed, actionIDs...)
+	return nil
+}
+
+func TestAcker_Journal(t *testing.T) {
+	log, _ := logger.New("fleet_acker", false)
+	journal := &testJournal{}
+	acker, err := NewAcker(log, testAgentInfo{}, &testSender{}, WithJournal(journal))
+	require.NoError(t, err)
+
+	_, err = acker.AckBatch(context.Background(), []fleetapi.Action{
+		&fleetapi.ActionUnknown{ActionID: "action-1", ActionType: fleetapi.ActionTypeUnknown},
+		&fleetapi.ActionUnknown{ActionID: "action-2", ActionType: fleetapi.ActionTypeUnknown},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"action-1", "action-2"}, journal.added)
+	assert.Equal(t, []string{"action-1", "action-2"}, journal.removed)
+}
+
+func TestAckedIDs(t *testing.T) {
+	ids := []string{"action-1", "action-2", "action-3"}
+	assert.Equal(t, ids, ackedIDs(ids, &fleetapi.AckResponse{}))
+	assert.Equal(t, []string{"action-1", "action-3"}, ackedIDs(ids, &fleetapi.AckResponse{
+		Errors: true,
+		Items: []fleetapi.AckResponseItem{
+			{Status: http.StatusOK},
+			{Status: http.StatusInternalServerError},
+			{Status: http.StatusOK},
+		},
+	}))
+}
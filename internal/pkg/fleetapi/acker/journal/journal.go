@@ -0,0 +1,232 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package journal
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+const (
+	defaultMaxEntries = 1000
+	defaultMaxAge     = 7 * 24 * time.Hour
+)
+
+// Option Journal option function
+type Option func(*Journal)
+
+// Journal is a durable journal of the acks that have not been delivered to Fleet yet.
+//
+// Acks are written to the journal before being sent, removed once Fleet accepted them
+// and replayed on startup, so they are not lost when the Elastic Agent restarts or is
+// upgraded while Fleet is unreachable. The journal keeps at most one ack per action ID,
+// at most maxEntries acks and drops acks older than maxAge.
+type Journal struct {
+	log   *logger.Logger
+	store storage.Storage
+
+	maxEntries int
+	maxAge     time.Duration
+	now        func() time.Time
+
+	mx      sync.Mutex
+	entries []entry // oldest first
+}
+
+type entry struct {
+	ActionType string            `json:"action_type"`
+	Event      fleetapi.AckEvent `json:"event"`
+	Added      time.Time         `json:"added"`
+}
+
+// WithMaxEntries configures the maximum number of acks kept in the journal
+func WithMaxEntries(n int) Option {
+	return func(j *Journal) {
+		j.maxEntries = n
+	}
+}
+
+// WithMaxAge configures the age after which acks are dropped from the journal
+func WithMaxAge(d time.Duration) Option {
+	return func(j *Journal) {
+		j.maxAge = d
+	}
+}
+
+// New creates a journal persisted in store, loading the acks previously written to it.
+func New(store storage.Storage, log *logger.Logger, opts ...Option) (*Journal, error) {
+	j := &Journal{
+		log:        log,
+		store:      store,
+		maxEntries: defaultMaxEntries,
+		maxAge:     defaultMaxAge,
+		now:        time.Now,
+	}
+	for _, opt := range opts {
+		opt(j)
+	}
+	if j.maxEntries <= 0 {
+		return nil, fmt.Errorf("ack journal max entries must be positive, got %d", j.maxEntries)
+	}
+
+	if err := j.load(); err != nil {
+		return nil, err
+	}
+	return j, nil
+}
+
+// Add writes the acks of the actions to the journal, replacing the acks already
+// journaled for the same action IDs.
+func (j *Journal) Add(actions ...fleetapi.Action) error {
+	if len(actions) == 0 {
+		return nil
+	}
+
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	now := j.now()
+	for _, action := range actions {
+		e := entry{
+			ActionType: action.Type(),
+			Event:      action.AckEvent(),
+			Added:      now,
+		}
+		if i := j.index(action.ID()); i >= 0 {
+			// keep the original time so retried acks still expire
+			e.Added = j.entries[i].Added
+			j.entries[i] = e
+			continue
+		}
+		j.entries = append(j.entries, e)
+	}
+	return j.save()
+}
+
+// Remove removes the acks of the actions from the journal.
+func (j *Journal) Remove(actionIDs ...string) error {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	removed := false
+	for _, id := range actionIDs {
+		if i := j.index(id); i >= 0 {
+			j.entries = append(j.entries[:i], j.entries[i+1:]...)
+			removed = true
+		}
+	}
+	if !removed {
+		return nil
+	}
+	return j.save()
+}
+
+// Pending returns the journaled acks as actions to be acked again, oldest first.
+func (j *Journal) Pending() []fleetapi.Action {
+	j.mx.Lock()
+	defer j.mx.Unlock()
+
+	j.prune()
+	actions := make([]fleetapi.Action, 0, len(j.entries))
+	for _, e := range j.entries {
+		actions = append(actions, &journaledAction{actionType: e.ActionType, event: e.Event})
+	}
+	return actions
+}
+
+func (j *Journal) index(actionID string) int {
+	for i, e := range j.entries {
+		if e.Event.ActionID == actionID {
+			return i
+		}
+	}
+	return -1
+}
+
+// prune drops the expired acks and the oldest acks above maxEntries.
+func (j *Journal) prune() {
+	if j.maxAge > 0 {
+		deadline := j.now().Add(-j.maxAge)
+		kept := j.entries[:0]
+		for _, e := range j.entries {
+			if e.Added.After(deadline) {
+				kept = append(kept, e)
+			} else {
+				j.log.Warnf("ack journal: dropping ack for action '%s' journaled at %s", e.Event.ActionID, e.Added)
+			}
+		}
+		j.entries = kept
+	}
+	if len(j.entries) > j.maxEntries {
+		dropped := len(j.entries) - j.maxEntries
+		j.log.Warnf("ack journal: full, dropping the %d oldest acks", dropped)
+		j.entries = j.entries[dropped:]
+	}
+}
+
+func (j *Journal) load() error {
+	reader, err := j.store.Load()
+	if err != nil {
+		return fmt.Errorf("failed to load ack journal: %w", err)
+	}
+	defer reader.Close()
+
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return fmt.Errorf("failed to read ack journal: %w", err)
+	}
+	if len(data) == 0 {
+		return nil
+	}
+	if err := json.Unmarshal(data, &j.entries); err != nil {
+		// a corrupted journal must not prevent the agent from starting
+		j.log.Errorf("ack journal: discarding unreadable journal: %v", err)
+		j.entries = nil
+	}
+	j.prune()
+	return nil
+}
+
+func (j *Journal) save() error {
+	j.prune()
+	data, err := json.Marshal(j.entries)
+	if err != nil {
+		return fmt.Errorf("failed to encode ack journal: %w", err)
+	}
+	if err := j.store.Save(bytes.NewReader(data)); err != nil {
+		return fmt.Errorf("failed to save ack journal: %w", err)
+	}
+	return nil
+}
+
+// journaledAction is an action replayed from the journal, it only carries the ack event.
+type journaledAction struct {
+	actionType string
+	event      fleetapi.AckEvent
+}
+
+func (a *journaledAction) String() string {
+	return fmt.Sprintf("action_id: %s, type: %s (journaled)", a.event.ActionID, a.actionType)
+}
+
+func (a *journaledAction) Type() string {
+	return a.actionType
+}
+
+func (a *journaledAction) ID() string {
+	return a.event.ActionID
+}
+
+func (a *journaledAction) AckEvent() fleetapi.AckEvent {
+	return a.event
+}
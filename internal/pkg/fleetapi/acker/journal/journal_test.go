@@ -0,0 +1,84 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package journal
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func newTestJournal(t *testing.T, path string, opts ...Option) *Journal {
+	t.Helper()
+	log, _ := logger.New("ack_journal", false)
+	store, err := storage.NewDiskStore(path)
+	require.NoError(t, err)
+	j, err := New(store, log, opts...)
+	require.NoError(t, err)
+	return j
+}
+
+func pendingIDs(j *Journal) []string {
+	var ids []string
+	for _, a := range j.Pending() {
+		ids = append(ids, a.ID())
+	}
+	return ids
+}
+
+func TestJournalReplay(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "ack_journal.enc")
+	j := newTestJournal(t, path)
+
+	require.NoError(t, j.Add(
+		&fleetapi.ActionUnknown{ActionID: "action-1", ActionType: fleetapi.ActionTypeUnknown},
+		&fleetapi.ActionApp{ActionID: "action-2", ActionType: fleetapi.ActionTypeInputAction, InputType: "osquery", Error: "failed"},
+	))
+	// de-duplicated by action ID
+	require.NoError(t, j.Add(&fleetapi.ActionUnknown{ActionID: "action-1", ActionType: fleetapi.ActionTypeUnknown}))
+	require.NoError(t, j.Remove("action-3"))
+
+	// replayed after a restart
+	reloaded := newTestJournal(t, path)
+	pending := reloaded.Pending()
+	require.Len(t, pending, 2)
+	assert.Equal(t, "action-1", pending[0].ID())
+	assert.Equal(t, fleetapi.ActionTypeInputAction, pending[1].Type())
+	assert.Equal(t, "osquery", pending[1].AckEvent().ActionInputType)
+	assert.Equal(t, "failed", pending[1].AckEvent().Error)
+
+	require.NoError(t, reloaded.Remove("action-1"))
+	assert.Equal(t, []string{"action-2"}, pendingIDs(newTestJournal(t, path)))
+}
+
+func TestJournalBounded(t *testing.T) {
+	j := newTestJournal(t, filepath.Join(t.TempDir(), "ack_journal.enc"), WithMaxEntries(2))
+	for _, id := range []string{"action-1", "action-2", "action-3"} {
+		require.NoError(t, j.Add(&fleetapi.ActionUnknown{ActionID: id, ActionType: fleetapi.ActionTypeUnknown}))
+	}
+	assert.Equal(t, []string{"action-2", "action-3"}, pendingIDs(j))
+}
+
+func TestJournalExpiry(t *testing.T) {
+	j := newTestJournal(t, filepath.Join(t.TempDir(), "ack_journal.enc"), WithMaxAge(time.Hour))
+	now := time.Now()
+	j.now = func() time.Time { return now }
+
+	require.NoError(t, j.Add(&fleetapi.ActionUnknown{ActionID: "action-1", ActionType: fleetapi.ActionTypeUnknown}))
+	now = now.Add(30 * time.Minute)
+	require.NoError(t, j.Add(&fleetapi.ActionUnknown{ActionID: "action-2", ActionType: fleetapi.ActionTypeUnknown}))
+	// re-adding does not extend the expiry
+	require.NoError(t, j.Add(&fleetapi.ActionUnknown{ActionID: "action-1", ActionType: fleetapi.ActionTypeUnknown}))
+
+	now = now.Add(45 * time.Minute)
+	assert.Equal(t, []string{"action-2"}, pendingIDs(j))
+}
@@ -7,6 +7,7 @@ package lazy
 import (
 	"context"
 	"net/http"
+	"sync"
 
 	"go.elastic.co/apm/v2"
 
@@ -22,12 +23,19 @@ type retrier interface {
 	Enqueue([]fleetapi.Action)
 }
 
+type ackJournal interface {
+	Add(actions ...fleetapi.Action) error
+}
+
 // Acker is a lazy acker which performs HTTP communication on commit.
+// It is safe for concurrent use, as actions can be handled concurrently.
 type Acker struct {
 	log     *logger.Logger
 	acker   batchAcker
+	mx      sync.Mutex
 	queue   []fleetapi.Action
 	retrier retrier
+	journal ackJournal
 }
 
 // Option Acker option function
@@ -55,6 +63,14 @@ func WithRetrier(r retrier) Option {
 	}
 }
 
+// WithJournal option allows to specify the journal acks are written to as soon as
+// they are queued, so they survive a restart before being committed.
+func WithJournal(j ackJournal) Option {
+	return func(f *Acker) {
+		f.journal = j
+	}
+}
+
 // Ack acknowledges action.
 func (f *Acker) Ack(ctx context.Context, action fleetapi.Action) (err error) {
 	span, ctx := apm.StartSpan(ctx, "ack", "app.internal")
@@ -63,6 +79,11 @@ func (f *Acker) Ack(ctx context.Context, action fleetapi.Action) (err error) {
 		span.End()
 	}()
 	f.enqueue(action)
+	if f.journal != nil {
+		if err := f.journal.Add(action); err != nil {
+			f.log.Warnf("lazy acker: failed to journal ack for action with id '%s': %v", action.ID(), err)
+		}
+	}
 	return nil
 }
 
@@ -73,12 +94,13 @@ func (f *Acker) Commit(ctx context.Context) (err error) {
 		apm.CaptureError(ctx, err).Send()
 		span.End()
 	}()
-	if len(f.queue) == 0 {
-		return nil
-	}
-
+	f.mx.Lock()
 	actions := f.queue
 	f.queue = make([]fleetapi.Action, 0)
+	f.mx.Unlock()
+	if len(actions) == 0 {
+		return nil
+	}
 
 	f.log.Debugf("lazy acker: ack batch: %s", actions)
 	var resp *fleetapi.AckResponse
@@ -116,6 +138,8 @@ func (f *Acker) Commit(ctx context.Context) (err error) {
 }
 
 func (f *Acker) enqueue(action fleetapi.Action) {
+	f.mx.Lock()
+	defer f.mx.Unlock()
 	for _, a := range f.queue {
 		if a.ID() == action.ID() {
 			f.log.Debugf("action with id '%s' has already been queued", action.ID())
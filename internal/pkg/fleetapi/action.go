@@ -36,6 +36,8 @@ const (
 	ActionTypeDiagnostics = "REQUEST_DIAGNOSTICS"
 	// ActionTypeDiagnostics specifies a diagnostics action.
 	ActionTypeMigrate = "MIGRATE"
+	// ActionTypeRequestLogs specifies a logs upload action.
+	ActionTypeRequestLogs = "REQUEST_LOGS"
 )
 
 // Error values that the Action interface can return
@@ -111,6 +113,8 @@ func NewAction(actionType string) Action {
 		action = &ActionPolicyChange{}
 	case ActionTypePolicyReassign:
 		action = &ActionPolicyReassign{}
+	case ActionTypeRequestLogs:
+		action = &ActionRequestLogs{}
 	case ActionTypeSettings:
 		action = &ActionSettings{}
 	case ActionTypeUnenroll:
@@ -170,13 +174,15 @@ func (a *ActionUnknown) String() string {
 }
 
 func (a *ActionUnknown) AckEvent() AckEvent {
-	return AckEvent{
+	event := AckEvent{
 		EventType: "ACTION_RESULT", // TODO Discuss EventType/SubType needed - by default only ACTION_RESULT was used - what is (or was) the intended purpose of these attributes? Are they documented? Can we change them to better support acking an error or a retry?
 		SubType:   "ACKNOWLEDGED",
 		ActionID:  a.ActionID,
 		Message:   fmt.Sprintf("Action %q of type %q acknowledged.", a.ActionID, a.ActionType),
-		Error:     fmt.Sprintf("Action %q of type %q is unknown to the elastic-agent", a.ActionID, a.OriginalType),
 	}
+	msg := fmt.Sprintf("Action %q of type %q is unknown to the elastic-agent", a.ActionID, a.OriginalType)
+	SetAckEventError(&event, NewAckError(ErrorCodeUnknownAction, false, errors.New(msg)))
+	return event
 }
 
 // ActionPolicyReassign is a request to apply a new policy
@@ -218,6 +224,8 @@ type ActionPolicyChange struct {
 	ActionID   string                 `json:"id" yaml:"id"`
 	ActionType string                 `json:"type" yaml:"type"`
 	Data       ActionPolicyChangeData `json:"data,omitempty" yaml:"data,omitempty"`
+
+	Err error `json:"-" yaml:"-"`
 }
 
 type ActionPolicyChangeData struct {
@@ -244,7 +252,9 @@ func (a *ActionPolicyChange) ID() string {
 }
 
 func (a *ActionPolicyChange) AckEvent() AckEvent {
-	return newAckEvent(a.ActionID, a.ActionType)
+	event := newAckEvent(a.ActionID, a.ActionType)
+	SetAckEventError(&event, a.Err)
+	return event
 }
 
 // ActionUpgrade is a request for agent to upgrade.
@@ -264,6 +274,9 @@ type ActionUpgradeData struct {
 	SourceURI string `json:"source_uri,omitempty" yaml:"source_uri,omitempty" mapstructure:"-"`
 	// TODO: update fleet open api schema
 	Retry int `json:"retry_attempt,omitempty" yaml:"retry_attempt,omitempty" mapstructure:"-"`
+	// Rollback rolls the agent back to the retained install of Version, the most recent
+	// retained install when Version is empty, instead of upgrading it.
+	Rollback bool `json:"rollback,omitempty" yaml:"rollback,omitempty" mapstructure:"-"`
 }
 
 func (a *ActionUpgrade) String() string {
@@ -281,11 +294,13 @@ func (a *ActionUpgrade) AckEvent() AckEvent {
 		// FIXME Do we want to change EventType/SubType here?
 		event.Error = a.Err.Error()
 		var payload struct {
-			Retry   bool `json:"retry"`
-			Attempt int  `json:"retry_attempt,omitempty"`
+			Retry   bool             `json:"retry"`
+			Attempt int              `json:"retry_attempt,omitempty"`
+			Error   *ackErrorPayload `json:"error"`
 		}
 		payload.Retry = true
 		payload.Attempt = a.Data.Retry
+		payload.Error = newAckErrorPayload(a.Err)
 		if a.Data.Retry < 1 { // retry is set to -1 if it will not re attempt
 			payload.Retry = false
 		}
@@ -367,6 +382,8 @@ type ActionUnenroll struct {
 	ActionType string  `json:"type" yaml:"type" mapstructure:"type"`
 	IsDetected bool    `json:"is_detected,omitempty" yaml:"is_detected,omitempty" mapstructure:"-"`
 	Signed     *Signed `json:"signed,omitempty" mapstructure:"signed,omitempty"`
+
+	Err error `json:"-" yaml:"-" mapstructure:"-"`
 }
 
 func (a *ActionUnenroll) String() string {
@@ -389,7 +406,9 @@ func (a *ActionUnenroll) ID() string {
 }
 
 func (a *ActionUnenroll) AckEvent() AckEvent {
-	return newAckEvent(a.ActionID, a.ActionType)
+	event := newAckEvent(a.ActionID, a.ActionType)
+	SetAckEventError(&event, a.Err)
+	return event
 }
 
 // MarshalMap marshals ActionUnenroll into a corresponding map
@@ -462,9 +481,7 @@ func (a *ActionMigrate) String() string {
 
 func (a *ActionMigrate) AckEvent() AckEvent {
 	event := newAckEvent(a.ActionID, a.ActionType)
-	if a.Err != nil {
-		event.Error = a.Err.Error()
-	}
+	SetAckEventError(&event, a.Err)
 	return event
 }
 
@@ -554,9 +571,64 @@ func (a *ActionDiagnostics) String() string {
 
 func (a *ActionDiagnostics) AckEvent() AckEvent {
 	event := newAckEvent(a.ActionID, a.ActionType)
-	if a.Err != nil {
-		event.Error = a.Err.Error()
+	SetAckEventError(&event, a.Err)
+	if a.UploadID != "" {
+		var data struct {
+			UploadID string `json:"upload_id"`
+		}
+		data.UploadID = a.UploadID
+		p, _ := json.Marshal(data)
+		event.Data = p
 	}
+
+	return event
+}
+
+// ActionRequestLogs is a request to upload the agent and component logs.
+type ActionRequestLogs struct {
+	ActionID   string                `json:"id"`
+	ActionType string                `json:"type"`
+	Data       ActionRequestLogsData `json:"data"`
+	UploadID   string                `json:"-"`
+	Err        error                 `json:"-"`
+}
+
+type ActionRequestLogsData struct {
+	// Since only includes the log lines written after this time, all lines when unset.
+	Since time.Time `json:"since,omitempty"`
+	// Until only includes the log lines written before this time, all lines when unset.
+	Until time.Time `json:"until,omitempty"`
+	// Components only includes the log lines of these components, all logs when empty. The
+	// log lines of the Elastic Agent itself are not included when set.
+	Components []string `json:"components,omitempty"`
+	// MaxSize caps the size in bytes of the uncompressed logs, the handler limit applies when unset.
+	MaxSize int64 `json:"max_size,omitempty"`
+	// ExcludeEventsLog excludes the events logs.
+	ExcludeEventsLog bool `json:"exclude_events_log"`
+}
+
+// ID returns the ID of the action.
+func (a *ActionRequestLogs) ID() string {
+	return a.ActionID
+}
+
+// Type returns the type of the action.
+func (a *ActionRequestLogs) Type() string {
+	return a.ActionType
+}
+
+func (a *ActionRequestLogs) String() string {
+	var s strings.Builder
+	s.WriteString("id: ")
+	s.WriteString(a.ActionID)
+	s.WriteString(", type: ")
+	s.WriteString(a.ActionType)
+	return s.String()
+}
+
+func (a *ActionRequestLogs) AckEvent() AckEvent {
+	event := newAckEvent(a.ActionID, a.ActionType)
+	SetAckEventError(&event, a.Err)
 	if a.UploadID != "" {
 		var data struct {
 			UploadID string `json:"upload_id"`
@@ -7,6 +7,7 @@ package fleetapi
 import (
 	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/google/go-cmp/cmp"
 	"github.com/stretchr/testify/assert"
@@ -174,6 +175,20 @@ func TestActionsUnmarshalJSON(t *testing.T) {
 		require.Len(t, action.Data.AdditionalMetrics, 1)
 		assert.Equal(t, "CPU", action.Data.AdditionalMetrics[0])
 	})
+	t.Run("ActionRequestLogs", func(t *testing.T) {
+		p := []byte(`[{"id":"testid","type":"REQUEST_LOGS","data":{"since":"2024-05-01T10:00:00Z","components":["filestream-default"],"max_size":1024}}]`)
+		a := &Actions{}
+		err := a.UnmarshalJSON(p)
+		require.Nil(t, err)
+		action, ok := (*a)[0].(*ActionRequestLogs)
+		require.True(t, ok, "unable to cast action to specific type")
+		assert.Equal(t, "testid", action.ActionID)
+		assert.Equal(t, ActionTypeRequestLogs, action.ActionType)
+		assert.Equal(t, time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC), action.Data.Since)
+		assert.True(t, action.Data.Until.IsZero())
+		assert.Equal(t, []string{"filestream-default"}, action.Data.Components)
+		assert.Equal(t, int64(1024), action.Data.MaxSize)
+	})
 }
 
 func TestActionUnenrollMarshalMap(t *testing.T) {
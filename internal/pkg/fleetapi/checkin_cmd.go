@@ -6,11 +6,14 @@ package fleetapi
 
 import (
 	"bytes"
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
 	"io"
 	"net/http"
+	"slices"
+	"strings"
 	"time"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
@@ -21,6 +24,18 @@ import (
 
 const checkingPath = "/api/fleet/agents/%s/checkin"
 
+// checkinCapabilitiesHeader is the response header Fleet Server uses to advertise the
+// optional check-in features it supports, as a comma separated list.
+const checkinCapabilitiesHeader = "Elastic-Checkin-Capabilities"
+
+const (
+	// CheckinCapabilityDelta means Fleet Server accepts check-ins that only contain the
+	// components and units that changed since the previous check-in.
+	CheckinCapabilityDelta = "delta_components"
+	// CheckinCapabilityGzip means Fleet Server accepts gzip compressed check-in bodies.
+	CheckinCapabilityGzip = "gzip"
+)
+
 // CheckinUnit provides information about a unit during checkin.
 type CheckinUnit struct {
 	ID      string                 `json:"id"`
@@ -37,6 +52,16 @@ type CheckinComponent struct {
 	Status  string        `json:"status"`
 	Message string        `json:"message"`
 	Units   []CheckinUnit `json:"units,omitempty"`
+	// RemovedUnits are the units removed since the previous check-in, only set in delta
+	// check-ins.
+	RemovedUnits []CheckinRemovedUnit `json:"removed_units,omitempty"`
+}
+
+// CheckinRemovedUnit identifies a unit removed since the previous check-in. Units are only
+// unique by type and ID, the input and output units of a component can share the same ID.
+type CheckinRemovedUnit struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
 }
 
 // CheckinRequest consists of multiple events reported to fleet ui.
@@ -47,6 +72,12 @@ type CheckinRequest struct {
 	Message        string             `json:"message"`    // V2 Agent message
 	Components     []CheckinComponent `json:"components"` // V2 Agent components
 	UpgradeDetails *details.Details   `json:"upgrade_details,omitempty"`
+
+	// ComponentsDelta is true when Components only contains the components and units that
+	// changed since the previous check-in, RemovedComponents then lists the IDs of the
+	// components that were removed.
+	ComponentsDelta   bool     `json:"components_delta,omitempty"`
+	RemovedComponents []string `json:"removed_components,omitempty"`
 }
 
 // SerializableEvent is a representation of the event to be send to the Fleet Server API via the checkin
@@ -75,6 +106,13 @@ type CheckinResponse struct {
 	AckToken     string  `json:"ack_token"`
 	Actions      Actions `json:"actions"`
 	FleetWarning string  `json:"-"`
+	// Capabilities are the optional check-in features advertised by Fleet Server.
+	Capabilities []string `json:"-"`
+}
+
+// Supports returns true when Fleet Server advertised the check-in capability.
+func (e *CheckinResponse) Supports(capability string) bool {
+	return slices.Contains(e.Capabilities, capability)
 }
 
 // Validate validates the response send from the server.
@@ -86,6 +124,18 @@ func (e *CheckinResponse) Validate() error {
 type CheckinCmd struct {
 	client client.Sender
 	info   AgentInfo
+	gzip   bool
+}
+
+// CheckinCmdOption is a CheckinCmd option function.
+type CheckinCmdOption func(*CheckinCmd)
+
+// WithCheckinGzip compresses the check-in body with gzip, it must only be used when
+// Fleet Server advertised CheckinCapabilityGzip.
+func WithCheckinGzip() CheckinCmdOption {
+	return func(e *CheckinCmd) {
+		e.gzip = true
+	}
 }
 
 type AgentInfo interface {
@@ -93,11 +143,15 @@ type AgentInfo interface {
 }
 
 // NewCheckinCmd creates a new api command.
-func NewCheckinCmd(info AgentInfo, client client.Sender) *CheckinCmd {
-	return &CheckinCmd{
+func NewCheckinCmd(info AgentInfo, client client.Sender, opts ...CheckinCmdOption) *CheckinCmd {
+	e := &CheckinCmd{
 		client: client,
 		info:   info,
 	}
+	for _, opt := range opts {
+		opt(e)
+	}
+	return e
 }
 
 // Execute enroll the Agent in the Fleet Server. Returns the decoded check in response, a duration indicating
@@ -114,9 +168,20 @@ func (e *CheckinCmd) Execute(ctx context.Context, r *CheckinRequest) (*CheckinRe
 			errors.TypeUnexpected)
 	}
 
+	var headers http.Header
+	if e.gzip {
+		b, err = gzipBody(b)
+		if err != nil {
+			return nil, 0, errors.New(err,
+				"fail to compress the checkin request",
+				errors.TypeUnexpected)
+		}
+		headers = http.Header{"Content-Encoding": []string{"gzip"}}
+	}
+
 	cp := fmt.Sprintf(checkingPath, e.info.AgentID())
 	sendStart := time.Now()
-	resp, err := e.client.Send(ctx, "POST", cp, nil, nil, bytes.NewBuffer(b))
+	resp, err := e.client.Send(ctx, "POST", cp, nil, headers, bytes.NewBuffer(b))
 	sendDuration := time.Since(sendStart)
 	if err != nil {
 		return nil, sendDuration, errors.New(err,
@@ -137,6 +202,7 @@ func (e *CheckinCmd) Execute(ctx context.Context, r *CheckinRequest) (*CheckinRe
 
 	checkinResponse := &CheckinResponse{}
 	checkinResponse.FleetWarning = resp.Header.Get("Warning")
+	checkinResponse.Capabilities = parseCheckinCapabilities(resp.Header.Get(checkinCapabilitiesHeader))
 	decoder := json.NewDecoder(bytes.NewReader(rs))
 	if err := decoder.Decode(checkinResponse); err != nil {
 		return nil, sendDuration, errors.New(err,
@@ -151,3 +217,28 @@ func (e *CheckinCmd) Execute(ctx context.Context, r *CheckinRequest) (*CheckinRe
 
 	return checkinResponse, sendDuration, nil
 }
+
+func gzipBody(b []byte) ([]byte, error) {
+	var buf bytes.Buffer
+	w := gzip.NewWriter(&buf)
+	if _, err := w.Write(b); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func parseCheckinCapabilities(header string) []string {
+	if header == "" {
+		return nil
+	}
+	var capabilities []string
+	for _, c := range strings.Split(header, ",") {
+		if c = strings.TrimSpace(c); c != "" {
+			capabilities = append(capabilities, strings.ToLower(c))
+		}
+	}
+	return capabilities
+}
@@ -5,6 +5,7 @@
 package fleetapi
 
 import (
+	"compress/gzip"
 	"context"
 	"encoding/json"
 	"fmt"
@@ -59,6 +60,48 @@ func TestCheckin(t *testing.T) {
 		},
 	))
 
+	t.Run("Checkin sends a gzip body and reads the advertised capabilities", withServerWithAuthClient(
+		func(t *testing.T) *http.ServeMux {
+			mux := http.NewServeMux()
+			path := fmt.Sprintf("/api/fleet/agents/%s/checkin", agentInfo.AgentID())
+			mux.HandleFunc(path, authHandler(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+				gz, err := gzip.NewReader(r.Body)
+				require.NoError(t, err)
+				body, err := io.ReadAll(gz)
+				require.NoError(t, err)
+				// units are only unique by type and ID
+				assert.Contains(t, string(body), `"removed_units":[{"type":"output","id":"comp"}]`)
+				var req CheckinRequest
+				require.NoError(t, json.Unmarshal(body, &req))
+				assert.True(t, req.ComponentsDelta)
+				assert.Equal(t, []string{"removed"}, req.RemovedComponents)
+
+				w.Header().Set(checkinCapabilitiesHeader, "delta_components, GZIP")
+				w.WriteHeader(http.StatusOK)
+				fmt.Fprint(w, `{"actions": []}`)
+			}, withAPIKey))
+			return mux
+		}, withAPIKey,
+		func(t *testing.T, client client.Sender) {
+			cmd := NewCheckinCmd(agentInfo, client, WithCheckinGzip())
+
+			request := CheckinRequest{
+				Components: []CheckinComponent{{
+					ID:           "comp",
+					RemovedUnits: []CheckinRemovedUnit{{Type: "output", ID: "comp"}},
+				}},
+				ComponentsDelta:   true,
+				RemovedComponents: []string{"removed"},
+			}
+
+			r, _, err := cmd.Execute(ctx, &request)
+			require.NoError(t, err)
+			assert.True(t, r.Supports(CheckinCapabilityDelta))
+			assert.True(t, r.Supports(CheckinCapabilityGzip))
+		},
+	))
+
 	t.Run("Checkin receives a PolicyChange", withServerWithAuthClient(
 		func(t *testing.T) *http.ServeMux {
 			raw := `
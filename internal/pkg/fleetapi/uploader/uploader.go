@@ -185,13 +185,23 @@ func (c *Client) Finish(ctx context.Context, id string, r *FinishRequest) error
 
 // UploadDiagnostics is a wrapper to upload a diagnostics request identified by the passed action id contained in the buffer to fleet-server.
 func (c *Client) UploadDiagnostics(ctx context.Context, actionId string, timestamp string, size int64, r io.Reader) (string, error) {
+	return c.uploadZip(ctx, actionId, fmt.Sprintf("elastic-agent-diagnostics-%s.zip", timestamp), size, r)
+}
+
+// UploadLogs is a wrapper to upload a logs request identified by the passed action id contained in the buffer to fleet-server.
+func (c *Client) UploadLogs(ctx context.Context, actionId string, timestamp string, size int64, r io.Reader) (string, error) {
+	return c.uploadZip(ctx, actionId, fmt.Sprintf("elastic-agent-logs-%s.zip", timestamp), size, r)
+}
+
+// uploadZip uploads the zip archive contained in the buffer in chunks to fleet-server.
+func (c *Client) uploadZip(ctx context.Context, actionId string, name string, size int64, r io.Reader) (string, error) {
 	upReq := NewUploadRequest{
 		ActionID: actionId,
 		AgentID:  c.agentID,
 		Source:   "agent",
 		File: FileData{
 			Size:      size,
-			Name:      fmt.Sprintf("elastic-agent-diagnostics-%s.zip", timestamp),
+			Name:      name,
 			Extension: "zip",
 			Mime:      "application/zip",
 		},
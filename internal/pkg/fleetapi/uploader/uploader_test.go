@@ -7,6 +7,7 @@ package uploader
 import (
 	"bytes"
 	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
@@ -237,3 +238,35 @@ func Test_Client_UploadDiagnostics(t *testing.T) {
 	assert.Equal(t, "e", string(chunk2))
 	sender.AssertExpectations(t)
 }
+
+func Test_Client_UploadLogs(t *testing.T) {
+	var upReq NewUploadRequest
+	sender := &mockSender{}
+	sender.On("Send", mock.Anything, "POST", PathNewUpload, mock.Anything, mock.Anything, mock.Anything).Run(func(args mock.Arguments) {
+		r := args.Get(5).(io.Reader)
+		require.NoError(t, json.NewDecoder(r).Decode(&upReq))
+	}).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader([]byte(`{"upload_id":"test-upload","chunk_size":10}`))),
+	}, nil).Once()
+	sender.On("Send", mock.Anything, "PUT", fmt.Sprintf(PathChunk, "test-upload", 0), mock.Anything, mock.Anything, mock.Anything).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}, nil).Once()
+	sender.On("Send", mock.Anything, "POST", fmt.Sprintf(PathFinishUpload, "test-upload"), mock.Anything, mock.Anything, mock.Anything).Return(&http.Response{
+		StatusCode: 200,
+		Body:       io.NopCloser(bytes.NewReader(nil)),
+	}, nil).Once()
+
+	c := &Client{
+		c:       sender,
+		agentID: "test-agent",
+	}
+	id, err := c.UploadLogs(context.Background(), "test-id", "2023-01-30T09-40-02Z-00", 5, bytes.NewBufferString("abcde"))
+	require.NoError(t, err)
+	assert.Equal(t, "test-upload", id)
+	assert.Equal(t, "test-id", upReq.ActionID)
+	assert.Equal(t, "elastic-agent-logs-2023-01-30T09-40-02Z-00.zip", upReq.File.Name)
+	assert.Equal(t, int64(5), upReq.File.Size)
+	sender.AssertExpectations(t)
+}
@@ -37,6 +37,7 @@ type requestClient struct {
 	lastUsed   time.Time
 	lastErr    error
 	lastErrOcc time.Time
+	health     *hostHealth
 }
 
 func (r *requestClient) SetLastError(err error) {
@@ -58,6 +59,7 @@ type Client struct {
 	clientLock sync.Mutex
 	clients    []*requestClient
 	config     Config
+	health     *hostsHealth
 }
 
 // NewConfigFromURL returns a Config based on a received host.
@@ -108,6 +110,7 @@ func NewWithConfig(log *logger.Logger, cfg Config, wrapper wrapperFunc) (*Client
 		p = p + "/"
 	}
 
+	health := newHostsHealth()
 	hosts := cfg.GetHosts()
 	hostCount := len(hosts)
 	log.With("hosts", hosts).Debugf(
@@ -146,10 +149,25 @@ func NewWithConfig(log *logger.Logger, cfg Config, wrapper wrapperFunc) (*Client
 		clients[i] = &requestClient{
 			host:   baseURL,
 			client: httpClient,
+			health: health.get(baseURL),
 		}
 	}
 
-	return newClient(log, cfg, clients...)
+	c, err := newClient(log, cfg, clients...)
+	if err != nil {
+		return nil, err
+	}
+	c.health = health
+	health.registerMetrics()
+	return c, nil
+}
+
+// HostsStats returns the health statistics of the hosts of the client, sorted by host.
+func (c *Client) HostsStats() []HostStats {
+	if c.health == nil {
+		return nil
+	}
+	return c.health.stats()
 }
 
 // Send executes a direct calls against the API, the method will take care of cloning and
@@ -179,8 +197,20 @@ func (c *Client) Send(
 	var errs []error
 
 	clients := c.sortClients()
+	// the hosts with an open circuit are skipped, unless all of them have one
+	available := 0
+	now := time.Now()
+	for _, requester := range clients {
+		if !requester.health.isOpen(now) {
+			available++
+		}
+	}
 
 	for i, requester := range clients {
+		if !requester.health.allow(time.Now()) && available > 0 {
+			c.log.Debugf("skipping requester %d/%d to host %s, circuit is open", i, len(clients), requester.host)
+			continue
+		}
 		req, err := requester.newRequest(method, path, params, body)
 		if err != nil {
 			return nil, fmt.Errorf(
@@ -208,7 +238,14 @@ func (c *Client) Send(
 			}
 		}
 
+		start := time.Now()
 		resp, err = requester.client.Do(req.WithContext(ctx))
+		healthErr := err
+		if err == nil && resp.StatusCode >= http.StatusInternalServerError {
+			// the host answered but is not healthy
+			healthErr = fmt.Errorf("host answered with status %d", resp.StatusCode)
+		}
+		requester.health.record(time.Now(), time.Since(start), healthErr)
 
 		// Using the same lock that was used for sorting above
 		c.clientLock.Lock()
@@ -285,7 +322,10 @@ func newClient(
 }
 
 // sortClients sort the clients according to the following priority:
+//   - circuit not open
 //   - never used
+//   - lowest error rate
+//   - lowest latency
 //   - without errors, last used first when more than one does not have errors
 //   - last errored.
 //
@@ -309,6 +349,12 @@ func (c *Client) sortClients() []*requestClient {
 			c.clients[j].lastErrOcc = time.Time{}
 		}
 
+		// Hosts with an open circuit are only tried when no other host is available
+		iOpen, jOpen := c.clients[i].health.isOpen(now), c.clients[j].health.isOpen(now)
+		if iOpen != jOpen {
+			return jOpen
+		}
+
 		// Pick not yet used first, but if both haven't been used yet,
 		// we return false to comply with the sort.Interface definition.
 		if c.clients[i].lastUsed.IsZero() &&
@@ -321,6 +367,20 @@ func (c *Client) sortClients() []*requestClient {
 			return true
 		}
 
+		if c.clients[j].lastUsed.IsZero() {
+			return false
+		}
+
+		// Then, the one with the lowest error rate
+		if iTier, jTier := c.clients[i].health.tier(), c.clients[j].health.tier(); iTier != jTier {
+			return iTier < jTier
+		}
+
+		// Then, the fastest one
+		if iTier, jTier := c.clients[i].health.latencyTier(), c.clients[j].health.latencyTier(); iTier != jTier {
+			return iTier < jTier
+		}
+
 		// If none has errors, pick the last used
 		// Then, the one without errors
 		if c.clients[i].lastErr == nil &&
@@ -0,0 +1,254 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package remote
+
+import (
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+)
+
+// MetricsNamespace is the registry of the default monitoring registry the health of the hosts
+// is reported in, under hosts.
+const MetricsNamespace = "remote"
+
+const (
+	// number of consecutive failures after which the circuit of a host is opened
+	circuitFailureThreshold = 3
+	// time a circuit stays open before a request is let through to probe the host
+	circuitOpenDuration = time.Minute
+	// weight of the last request in the error rate and latency moving averages
+	healthEWMAAlpha = 0.2
+)
+
+// CircuitState is the state of the circuit breaker of a host.
+type CircuitState string
+
+const (
+	// CircuitClosed lets requests through to the host.
+	CircuitClosed CircuitState = "closed"
+	// CircuitOpen skips the host while other hosts are available.
+	CircuitOpen CircuitState = "open"
+	// CircuitHalfOpen lets a request through to probe whether the host recovered.
+	CircuitHalfOpen CircuitState = "half-open"
+)
+
+// HostStats are the health statistics of a host.
+type HostStats struct {
+	Host                string        `json:"host" yaml:"host"`
+	Requests            uint64        `json:"requests" yaml:"requests"`
+	Failures            uint64        `json:"failures" yaml:"failures"`
+	ConsecutiveFailures int           `json:"consecutive_failures" yaml:"consecutive_failures"`
+	ErrorRate           float64       `json:"error_rate" yaml:"error_rate"`
+	Latency             time.Duration `json:"latency_ns" yaml:"latency"`
+	Circuit             CircuitState  `json:"circuit" yaml:"circuit"`
+	LastError           string        `json:"last_error,omitempty" yaml:"last_error,omitempty"`
+	LastErrorTime       time.Time     `json:"last_error_time,omitempty" yaml:"last_error_time,omitempty"`
+}
+
+// hostHealth tracks the health of a host and implements its circuit breaker.
+type hostHealth struct {
+	mx    sync.Mutex
+	stats HostStats
+	// openedAt is when the circuit was last opened
+	openedAt time.Time
+	// probing is set while the single request probing a half-open circuit is in flight
+	probing bool
+}
+
+// hostsHealth tracks the health of the hosts of a client.
+type hostsHealth struct {
+	mx    sync.Mutex
+	hosts map[string]*hostHealth
+}
+
+// metricsMx serializes the registration of the metrics of the clients
+var metricsMx sync.Mutex
+
+func newHostsHealth() *hostsHealth {
+	return &hostsHealth{hosts: map[string]*hostHealth{}}
+}
+
+// get returns the health of the host.
+func (hs *hostsHealth) get(host string) *hostHealth {
+	hs.mx.Lock()
+	defer hs.mx.Unlock()
+	h, ok := hs.hosts[host]
+	if !ok {
+		h = &hostHealth{stats: HostStats{Host: host, Circuit: CircuitClosed}}
+		hs.hosts[host] = h
+	}
+	return h
+}
+
+// stats returns the health statistics of the hosts, sorted by host.
+func (hs *hostsHealth) stats() []HostStats {
+	hs.mx.Lock()
+	list := make([]*hostHealth, 0, len(hs.hosts))
+	for _, h := range hs.hosts {
+		list = append(list, h)
+	}
+	hs.mx.Unlock()
+
+	now := time.Now()
+	stats := make([]HostStats, 0, len(list))
+	for _, h := range list {
+		stats = append(stats, h.snapshot(now))
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Host < stats[j].Host })
+	return stats
+}
+
+// registerMetrics reports the health of the hosts in the MetricsNamespace registry of the default
+// monitoring registry. They replace the hosts of the client created before, the client
+// created last is the one in use once the configuration changed.
+func (hs *hostsHealth) registerMetrics() {
+	metricsMx.Lock()
+	defer metricsMx.Unlock()
+
+	monitoring.Default.Remove(MetricsNamespace)
+	reg := monitoring.Default.NewRegistry(MetricsNamespace)
+	monitoring.NewFunc(reg, "hosts", func(_ monitoring.Mode, v monitoring.Visitor) {
+		v.OnRegistryStart()
+		defer v.OnRegistryFinished()
+
+		for _, s := range hs.stats() {
+			v.OnKey(s.Host)
+			v.OnRegistryStart()
+			monitoring.ReportInt(v, "requests", int64(s.Requests))
+			monitoring.ReportInt(v, "failures", int64(s.Failures))
+			monitoring.ReportInt(v, "consecutive_failures", int64(s.ConsecutiveFailures))
+			monitoring.ReportFloat(v, "error_rate", s.ErrorRate)
+			monitoring.ReportInt(v, "latency_ns", int64(s.Latency))
+			monitoring.ReportString(v, "circuit", string(s.Circuit))
+			if s.LastError != "" {
+				monitoring.ReportString(v, "last_error", s.LastError)
+				monitoring.ReportString(v, "last_error_time", s.LastErrorTime.Format(time.RFC3339))
+			}
+			v.OnRegistryFinished()
+		}
+	}, monitoring.Report)
+}
+
+// record updates the health of the host with the result of a request.
+func (h *hostHealth) record(now time.Time, took time.Duration, err error) {
+	if h == nil {
+		return
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+
+	h.stats.Requests++
+	// the result of any request tells whether the host recovered, another probe can be sent
+	h.probing = false
+	failed := 0.0
+	if err != nil {
+		failed = 1
+		h.stats.Failures++
+		h.stats.ConsecutiveFailures++
+		h.stats.LastError = err.Error()
+		h.stats.LastErrorTime = now
+	} else {
+		h.stats.ConsecutiveFailures = 0
+		h.stats.Latency = ewmaDuration(h.stats.Latency, took, h.stats.Requests == 1)
+	}
+	h.stats.ErrorRate = healthEWMAAlpha*failed + (1-healthEWMAAlpha)*h.stats.ErrorRate
+
+	switch {
+	case err == nil:
+		h.stats.Circuit = CircuitClosed
+	case h.circuit(now) == CircuitHalfOpen || h.stats.ConsecutiveFailures >= circuitFailureThreshold:
+		// a failed probe opens the circuit again
+		h.stats.Circuit = CircuitOpen
+		h.openedAt = now
+	}
+}
+
+// circuit returns the state of the circuit, moving it to half-open once it was open long enough.
+func (h *hostHealth) circuit(now time.Time) CircuitState {
+	if h == nil {
+		return CircuitClosed
+	}
+	if h.stats.Circuit == CircuitOpen && now.Sub(h.openedAt) >= circuitOpenDuration {
+		h.stats.Circuit = CircuitHalfOpen
+	}
+	return h.stats.Circuit
+}
+
+// isOpen returns true when requests must not be sent to the host, because its circuit is
+// open or the request probing its half-open circuit is still in flight.
+func (h *hostHealth) isOpen(now time.Time) bool {
+	if h == nil {
+		return false
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	switch h.circuit(now) {
+	case CircuitOpen:
+		return true
+	case CircuitHalfOpen:
+		return h.probing
+	default:
+		return false
+	}
+}
+
+// allow returns true when a request can be sent to the host. When the circuit is half-open
+// only a single request is let through to probe the host until its result is recorded.
+func (h *hostHealth) allow(now time.Time) bool {
+	if h == nil {
+		return true
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	switch h.circuit(now) {
+	case CircuitOpen:
+		return false
+	case CircuitHalfOpen:
+		if h.probing {
+			return false
+		}
+		h.probing = true
+	}
+	return true
+}
+
+// tier groups hosts with a similar error rate, lower is healthier.
+func (h *hostHealth) tier() int {
+	if h == nil {
+		return 0
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return int(math.Ceil(h.stats.ErrorRate * 10))
+}
+
+// latencyTier groups hosts with a similar latency, lower is faster. Each tier is twice as
+// slow as the previous one, so small variations do not reorder the hosts.
+func (h *hostHealth) latencyTier() int {
+	if h == nil {
+		return 0
+	}
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	return int(math.Log2(float64(h.stats.Latency/time.Millisecond) + 1))
+}
+
+func (h *hostHealth) snapshot(now time.Time) HostStats {
+	h.mx.Lock()
+	defer h.mx.Unlock()
+	h.circuit(now)
+	return h.stats
+}
+
+func ewmaDuration(avg time.Duration, d time.Duration, first bool) time.Duration {
+	if first || avg == 0 {
+		return d
+	}
+	return time.Duration(healthEWMAAlpha*float64(d) + (1-healthEWMAAlpha)*float64(avg))
+}
@@ -0,0 +1,204 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package remote
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-libs/monitoring"
+	"github.com/elastic/elastic-agent/pkg/core/logger"
+)
+
+func TestHostHealthCircuit(t *testing.T) {
+	errFake := errors.New("fake error")
+	h := &hostHealth{stats: HostStats{Host: "fleet-1", Circuit: CircuitClosed}}
+	now := time.Now()
+
+	for i := 0; i < circuitFailureThreshold-1; i++ {
+		h.record(now, 0, errFake)
+		assert.False(t, h.isOpen(now))
+	}
+	h.record(now, 0, errFake)
+	assert.True(t, h.isOpen(now), "circuit opens after consecutive failures")
+
+	// half-open once the circuit was open long enough, a failed probe opens it again
+	now = now.Add(circuitOpenDuration)
+	assert.False(t, h.isOpen(now))
+	assert.Equal(t, CircuitHalfOpen, h.snapshot(now).Circuit)
+	h.record(now, 0, errFake)
+	assert.True(t, h.isOpen(now))
+
+	// a half-open circuit lets a single probe through until its result is recorded
+	now = now.Add(circuitOpenDuration)
+	assert.True(t, h.allow(now), "first request probes the host")
+	assert.False(t, h.allow(now), "second request waits for the probe")
+	assert.True(t, h.isOpen(now))
+	h.record(now, 0, errFake)
+	assert.True(t, h.isOpen(now))
+
+	// a successful probe closes it
+	now = now.Add(circuitOpenDuration)
+	h.record(now, 100*time.Millisecond, nil)
+	stats := h.snapshot(now)
+	assert.Equal(t, CircuitClosed, stats.Circuit)
+	assert.Equal(t, 0, stats.ConsecutiveFailures)
+	assert.Equal(t, uint64(6), stats.Requests)
+	assert.Equal(t, uint64(5), stats.Failures)
+	assert.Equal(t, 100*time.Millisecond, stats.Latency)
+	assert.Equal(t, errFake.Error(), stats.LastError)
+}
+
+func TestSortClientsHealth(t *testing.T) {
+	errFake := errors.New("fake error")
+	now := time.Now()
+
+	t.Run("Picks requester with lower error rate", func(t *testing.T) {
+		one := &requestClient{lastUsed: now.Add(-3 * time.Minute), health: &hostHealth{}}
+		one.health.record(now, 0, errFake)
+		one.health.record(now, 0, nil)
+		two := &requestClient{lastUsed: now.Add(-time.Minute), health: &hostHealth{}}
+		two.health.record(now, 0, nil)
+		client, err := newClient(nil, Config{}, one, two)
+		require.NoError(t, err)
+
+		clients := client.sortClients()
+
+		assert.Equal(t, two, clients[0])
+	})
+
+	t.Run("Picks requester with lower latency", func(t *testing.T) {
+		one := &requestClient{lastUsed: now.Add(-3 * time.Minute), health: &hostHealth{}}
+		one.health.record(now, 2*time.Second, nil)
+		two := &requestClient{lastUsed: now.Add(-time.Minute), health: &hostHealth{}}
+		two.health.record(now, 50*time.Millisecond, nil)
+		client, err := newClient(nil, Config{}, one, two)
+		require.NoError(t, err)
+
+		clients := client.sortClients()
+
+		assert.Equal(t, two, clients[0])
+	})
+
+	t.Run("Picks open circuit requester last", func(t *testing.T) {
+		one := &requestClient{health: &hostHealth{}}
+		for i := 0; i < circuitFailureThreshold; i++ {
+			one.health.record(now, 0, errFake)
+		}
+		two := &requestClient{
+			lastUsed:   now,
+			lastErr:    errFake,
+			lastErrOcc: now,
+		}
+		client, err := newClient(nil, Config{}, one, two)
+		require.NoError(t, err)
+
+		clients := client.sortClients()
+
+		assert.Equal(t, two, clients[0])
+	})
+}
+
+func TestSendRecordsServerErrors(t *testing.T) {
+	s := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer s.Close()
+
+	l, err := logger.New("", false)
+	require.NoError(t, err)
+	cfg, err := NewConfigFromURL(s.URL)
+	require.NoError(t, err)
+	client, err := NewWithConfig(l, cfg, noopWrapper)
+	require.NoError(t, err)
+
+	for i := 0; i < circuitFailureThreshold; i++ {
+		resp, err := client.Send(context.Background(), http.MethodGet, "/", nil, nil, nil)
+		require.NoError(t, err, "the response is returned to the caller")
+		resp.Body.Close()
+	}
+
+	stats := client.clients[0].health.snapshot(time.Now())
+	assert.Equal(t, uint64(circuitFailureThreshold), stats.Failures)
+	assert.Greater(t, stats.ErrorRate, 0.0)
+	assert.Equal(t, CircuitOpen, stats.Circuit)
+	assert.Contains(t, stats.LastError, "503")
+}
+
+func TestSendSkipsOpenCircuits(t *testing.T) {
+	errFake := errors.New("fake error")
+	var healthyHits, openHits atomic.Int32
+	healthy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		healthyHits.Add(1)
+	}))
+	defer healthy.Close()
+	open := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		openHits.Add(1)
+	}))
+	defer open.Close()
+
+	l, err := logger.New("", false)
+	require.NoError(t, err)
+	cfg, err := NewConfigFromURL(healthy.URL)
+	require.NoError(t, err)
+	cfg.Hosts = []string{healthy.URL, open.URL}
+	client, err := NewWithConfig(l, cfg, noopWrapper)
+	require.NoError(t, err)
+
+	openHealth := client.health.get(open.URL + "/")
+	for i := 0; i < circuitFailureThreshold; i++ {
+		openHealth.record(time.Now(), 0, errFake)
+	}
+
+	resp, err := client.Send(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), healthyHits.Load())
+	assert.Zero(t, openHits.Load(), "the host with an open circuit should be skipped")
+
+	// the healthy host is down, its circuit opens too, the hosts are then all tried
+	healthy.Close()
+	for i := 0; i < circuitFailureThreshold; i++ {
+		client.health.get(healthy.URL+"/").record(time.Now(), 0, errFake)
+	}
+	resp, err = client.Send(context.Background(), http.MethodGet, "/", nil, nil, nil)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, int32(1), openHits.Load(), "the hosts should all be tried when their circuits are all open")
+}
+
+func TestClientHostsHealth(t *testing.T) {
+	l, err := logger.New("", false)
+	require.NoError(t, err)
+
+	first, err := NewWithConfig(l, Config{Protocol: ProtocolHTTP, Hosts: []string{"fleet-1:8220"}}, noopWrapper)
+	require.NoError(t, err)
+	second, err := NewWithConfig(l, Config{Protocol: ProtocolHTTP, Hosts: []string{"fleet-2:8220"}}, noopWrapper)
+	require.NoError(t, err)
+
+	second.clients[0].health.record(time.Now(), 0, errors.New("fake error"))
+	require.Len(t, first.HostsStats(), 1)
+	assert.Zero(t, first.HostsStats()[0].Requests, "the health of the hosts is not shared by the clients")
+	require.Len(t, second.HostsStats(), 1)
+	assert.Equal(t, uint64(1), second.HostsStats()[0].Failures)
+
+	// the metrics report the hosts of the client created last
+	snapshot := monitoring.CollectStructSnapshot(monitoring.Default.GetRegistry(MetricsNamespace), monitoring.Full, false)
+	hosts, ok := snapshot["hosts"].(map[string]any)
+	require.True(t, ok, "expected the hosts in the metrics, got %v", snapshot)
+	require.Len(t, hosts, 1)
+	for host, stats := range hosts {
+		assert.Contains(t, host, "fleet-2")
+		assert.Equal(t, int64(1), stats.(map[string]any)["failures"])
+	}
+}
@@ -814,7 +814,7 @@ func hasDuplicate(outputsMap map[string]outputI, id string) bool {
 func getLogLevel(val map[string]interface{}, ll logp.Level) (client.UnitLogLevel, error) {
 	const logLevelKey = "log_level"
 
-	logLevel, err := stringToLogLevel(ll.String())
+	logLevel, err := StringToLogLevel(ll.String())
 	if err != nil {
 		return defaultUnitLogLevel, err
 	}
@@ -824,7 +824,7 @@ func getLogLevel(val map[string]interface{}, ll logp.Level) (client.UnitLogLevel
 			return defaultUnitLogLevel, fmt.Errorf("expected a string not a %T", logLevelRaw)
 		}
 		var err error
-		logLevel, err = stringToLogLevel(logLevelStr)
+		logLevel, err = StringToLogLevel(logLevelStr)
 		if err != nil {
 			return defaultUnitLogLevel, err
 		}
@@ -833,7 +833,8 @@ func getLogLevel(val map[string]interface{}, ll logp.Level) (client.UnitLogLevel
 	return logLevel, nil
 }
 
-func stringToLogLevel(val string) (client.UnitLogLevel, error) {
+// StringToLogLevel parses a log level name into a unit log level.
+func StringToLogLevel(val string) (client.UnitLogLevel, error) {
 	val = strings.ToLower(strings.TrimSpace(val))
 	switch val {
 	case "error":
@@ -462,6 +462,8 @@ func (c *commandRuntime) startWatcher(info *process.Info, comm Communicator) {
 func (c *commandRuntime) handleProc(state *os.ProcessState) bool {
 	switch c.actionState {
 	case actionStart:
+		// process exited while it should be running, it is restarted after the restart period
+		c.state.Restarts++
 		if c.restartBucket != nil && c.restartBucket.Allow() {
 			stopMsg := fmt.Sprintf("Suppressing FAILED state due to restart for '%d' exited with code '%d'", state.Pid(), state.ExitCode())
 			c.forceCompState(client.UnitStateStopped, stopMsg)
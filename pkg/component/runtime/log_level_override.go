@@ -0,0 +1,73 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package runtime
+
+import (
+	"time"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/pkg/component"
+)
+
+// logLevelOverride temporarily overrides the log level of the matching units.
+type logLevelOverride struct {
+	// componentID matches all components when empty.
+	componentID string
+	// unitID matches all units of the matching components when empty.
+	unitID  string
+	level   client.UnitLogLevel
+	expires time.Time
+}
+
+func (o *logLevelOverride) key() string {
+	return o.componentID + "/" + o.unitID
+}
+
+func (o *logLevelOverride) matches(compID string, unitID string) bool {
+	return (o.componentID == "" || o.componentID == compID) && (o.unitID == "" || o.unitID == unitID)
+}
+
+func (o *logLevelOverride) specificity() int {
+	s := 0
+	if o.unitID != "" {
+		s += 2
+	}
+	if o.componentID != "" {
+		s++
+	}
+	return s
+}
+
+// applyLogLevelOverrides returns the component with the log level overrides applied to its units.
+//
+// Overrides that target a specific unit take precedence over the ones that target a
+// component, which take precedence over the ones that target all components.
+func applyLogLevelOverrides(comp component.Component, overrides []*logLevelOverride) component.Component {
+	if len(overrides) == 0 {
+		return comp
+	}
+	var units []component.Unit
+	for i, unit := range comp.Units {
+		var match *logLevelOverride
+		for _, o := range overrides {
+			if o.matches(comp.ID, unit.ID) && (match == nil || o.specificity() > match.specificity()) {
+				match = o
+			}
+		}
+		if match == nil || match.level == unit.LogLevel {
+			continue
+		}
+		if units == nil {
+			// copy so the units of the component model are not modified
+			units = make([]component.Unit, len(comp.Units))
+			copy(units, comp.Units)
+		}
+		units[i].LogLevel = match.level
+	}
+	if units != nil {
+		comp.Units = units
+	}
+	return comp
+}
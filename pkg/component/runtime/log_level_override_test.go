@@ -0,0 +1,124 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package runtime
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/pkg/component"
+)
+
+func TestApplyLogLevelOverrides(t *testing.T) {
+	comp := component.Component{
+		ID: "filestream-default",
+		Units: []component.Unit{
+			{ID: "filestream-default", Type: client.UnitTypeOutput, LogLevel: client.UnitLogLevelInfo},
+			{ID: "filestream-default-input", Type: client.UnitTypeInput, LogLevel: client.UnitLogLevelInfo},
+		},
+	}
+
+	testcases := []struct {
+		name      string
+		overrides []*logLevelOverride
+		expected  []client.UnitLogLevel
+	}{
+		{
+			name:     "no overrides",
+			expected: []client.UnitLogLevel{client.UnitLogLevelInfo, client.UnitLogLevelInfo},
+		},
+		{
+			name: "all components",
+			overrides: []*logLevelOverride{
+				{level: client.UnitLogLevelDebug},
+			},
+			expected: []client.UnitLogLevel{client.UnitLogLevelDebug, client.UnitLogLevelDebug},
+		},
+		{
+			name: "other component",
+			overrides: []*logLevelOverride{
+				{componentID: "metricbeat-default", level: client.UnitLogLevelDebug},
+			},
+			expected: []client.UnitLogLevel{client.UnitLogLevelInfo, client.UnitLogLevelInfo},
+		},
+		{
+			name: "unit takes precedence over component",
+			overrides: []*logLevelOverride{
+				{componentID: "filestream-default", level: client.UnitLogLevelDebug},
+				{componentID: "filestream-default", unitID: "filestream-default-input", level: client.UnitLogLevelTrace},
+			},
+			expected: []client.UnitLogLevel{client.UnitLogLevelDebug, client.UnitLogLevelTrace},
+		},
+		{
+			name: "unit of any component",
+			overrides: []*logLevelOverride{
+				{unitID: "filestream-default", level: client.UnitLogLevelError},
+			},
+			expected: []client.UnitLogLevel{client.UnitLogLevelError, client.UnitLogLevelInfo},
+		},
+	}
+
+	for _, tc := range testcases {
+		t.Run(tc.name, func(t *testing.T) {
+			result := applyLogLevelOverrides(comp, tc.overrides)
+			for i, unit := range result.Units {
+				assert.Equal(t, tc.expected[i], unit.LogLevel, "unit %s", unit.ID)
+			}
+			// the original component is never modified
+			for _, unit := range comp.Units {
+				assert.Equal(t, client.UnitLogLevelInfo, unit.LogLevel)
+			}
+		})
+	}
+}
+
+func TestLogLevelOverrideExpiry(t *testing.T) {
+	m := &Manager{
+		logger:            newDebugLogger(t),
+		reapplyChan:       make(chan struct{}, 1),
+		doneChan:          make(chan struct{}),
+		logLevelOverrides: make(map[string]*logLevelOverride),
+	}
+	requireReapplied := func() {
+		t.Helper()
+		select {
+		case <-m.reapplyChan:
+		case <-time.After(5 * time.Second):
+			t.Fatal("component model was not reapplied")
+		}
+	}
+
+	require.NoError(t, m.SetLogLevelOverride("", "", client.UnitLogLevelDebug, 100*time.Millisecond))
+	requireReapplied()
+	require.Len(t, m.activeLogLevelOverrides(), 1)
+
+	// the expiry reverts the level by reapplying the model without the override
+	requireReapplied()
+	assert.Empty(t, m.activeLogLevelOverrides())
+	m.logLevelMx.Lock()
+	assert.Empty(t, m.logLevelOverrides)
+	m.logLevelMx.Unlock()
+
+	// an override replaced before its expiry is not reverted by the expiry of the previous one
+	require.NoError(t, m.SetLogLevelOverride("", "", client.UnitLogLevelDebug, 100*time.Millisecond))
+	requireReapplied()
+	require.NoError(t, m.SetLogLevelOverride("", "", client.UnitLogLevelTrace, time.Hour))
+	requireReapplied()
+	time.Sleep(300 * time.Millisecond)
+	select {
+	case <-m.reapplyChan:
+		t.Fatal("expiry of a replaced override must not reapply the model")
+	default:
+	}
+	overrides := m.activeLogLevelOverrides()
+	require.Len(t, overrides, 1)
+	assert.Equal(t, client.UnitLogLevelTrace, overrides[0].level)
+
+	assert.Error(t, m.SetLogLevelOverride("", "", client.UnitLogLevelDebug, 0), "ttl must be positive")
+}
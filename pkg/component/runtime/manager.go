@@ -31,7 +31,9 @@ import (
 	"github.com/elastic/elastic-agent-client/v7/pkg/proto"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/core/authority"
 	"github.com/elastic/elastic-agent/pkg/component"
 	"github.com/elastic/elastic-agent/pkg/control"
@@ -70,6 +72,8 @@ type ComponentComponentState struct {
 	Component component.Component `yaml:"component"`
 	State     ComponentState      `yaml:"state"`
 	LegacyPID string              `yaml:"-"` // To propagate PID for the /processes, and yes, it was a string
+	// Paused is true when the component is stopped because it was paused over the control protocol.
+	Paused bool `yaml:"paused,omitempty"`
 }
 
 // ComponentUnitDiagnosticRequest used to request diagnostics from specific unit.
@@ -115,6 +119,15 @@ type Manager struct {
 	// to the internal run loop.
 	updateChan chan component.Model
 
+	// reapplyChan notifies the internal run loop that the most recent component
+	// model must be applied again, because a component was paused, resumed or
+	// restarted.
+	reapplyChan chan struct{}
+
+	// Most recent component model received by the run loop.
+	// Only access from the main runtime manager goroutine.
+	lastModel *component.Model
+
 	// Next component model update that will be applied, in case we get one
 	// while a previous update is still in progress. If we get more than one,
 	// keep only the most recent.
@@ -130,6 +143,20 @@ type Manager struct {
 	subAllMx      sync.RWMutex
 	subscribeAll  []*SubscriptionAll
 
+	// pausedMx protects access to paused and restarts
+	pausedMx    sync.Mutex
+	paused      map[string]struct{}
+	pausedStore storage.Storage
+	restarts    map[string]struct{}
+
+	// logLevelMx protects access to logLevelOverrides
+	logLevelMx        sync.Mutex
+	logLevelOverrides map[string]*logLevelOverride
+
+	// Components reported as paused by the last update.
+	// Only access from the goroutine running update.
+	pausedReported map[string]component.Component
+
 	errCh chan error
 
 	// doneChan is closed when Manager is shutting down to signal that any
@@ -163,6 +190,15 @@ func NewManager(
 	}
 	logger.With("address", listenAddr).Infof("GRPC comms socket listening at %s", listenAddr)
 
+	pausedStore, err := storage.NewDiskStore(paths.PausedComponentsFile())
+	if err != nil {
+		return nil, fmt.Errorf("failed to create paused components store: %w", err)
+	}
+	paused, err := loadPausedComponents(pausedStore)
+	if err != nil {
+		logger.Errorf("failed to load paused components, all components will be started: %s", err)
+	}
+
 	m := &Manager{
 		logger:        logger,
 		baseLogger:    baseLogger,
@@ -174,11 +210,19 @@ func NewManager(
 		current:       make(map[string]*componentRuntimeState),
 		subscriptions: make(map[string][]*Subscription),
 		updateChan:    make(chan component.Model),
+		reapplyChan:   make(chan struct{}),
 		errCh:         make(chan error),
 		monitor:       monitor,
 		grpcConfig:    grpcConfig,
 		serverReady:   make(chan struct{}),
 		doneChan:      make(chan struct{}),
+
+		paused:         paused,
+		pausedStore:    pausedStore,
+		restarts:       make(map[string]struct{}),
+		pausedReported: make(map[string]component.Component),
+
+		logLevelOverrides: make(map[string]*logLevelOverride),
 	}
 	return m, nil
 }
@@ -280,6 +324,14 @@ LOOP:
 			// We got a new component model from m.Update(), mark it as the
 			// next update to apply, overwriting any previous pending value.
 			m.nextUpdate = &model
+			m.lastModel = &model
+		case <-m.reapplyChan:
+			// A component was paused, resumed or restarted; apply the most
+			// recent model again unless a newer one is already pending.
+			if m.nextUpdate == nil && m.lastModel != nil {
+				model := *m.lastModel
+				m.nextUpdate = &model
+			}
 		case <-updateDoneChan:
 			// An update call has finished, we can initiate another when available.
 			updateInProgress = false
@@ -356,6 +408,185 @@ func (m *Manager) Update(model component.Model) {
 	}
 }
 
+// PauseComponent stops the running component while keeping it in the component model.
+//
+// The component stays stopped, even across restarts of the Elastic Agent, until
+// ResumeComponent is called.
+func (m *Manager) PauseComponent(componentID string) error {
+	m.pausedMx.Lock()
+	if _, ok := m.paused[componentID]; ok {
+		m.pausedMx.Unlock()
+		return nil
+	}
+	if m.getRuntimeFromComponent(component.Component{ID: componentID}) == nil {
+		m.pausedMx.Unlock()
+		return ErrNoComponent
+	}
+	m.paused[componentID] = struct{}{}
+	if err := savePausedComponents(m.pausedStore, m.paused); err != nil {
+		delete(m.paused, componentID)
+		m.pausedMx.Unlock()
+		return fmt.Errorf("failed to persist paused components: %w", err)
+	}
+	m.pausedMx.Unlock()
+
+	m.logger.Infof("Pausing component %q", componentID)
+	m.reapply()
+	return nil
+}
+
+// ResumeComponent starts a component previously stopped by PauseComponent.
+func (m *Manager) ResumeComponent(componentID string) error {
+	m.pausedMx.Lock()
+	if _, ok := m.paused[componentID]; !ok {
+		m.pausedMx.Unlock()
+		return fmt.Errorf("component %s is not paused", componentID)
+	}
+	delete(m.paused, componentID)
+	if err := savePausedComponents(m.pausedStore, m.paused); err != nil {
+		m.paused[componentID] = struct{}{}
+		m.pausedMx.Unlock()
+		return fmt.Errorf("failed to persist paused components: %w", err)
+	}
+	m.pausedMx.Unlock()
+
+	m.logger.Infof("Resuming component %q", componentID)
+	m.reapply()
+	return nil
+}
+
+// RestartComponent stops and starts the running component.
+func (m *Manager) RestartComponent(componentID string) error {
+	m.pausedMx.Lock()
+	if _, ok := m.paused[componentID]; ok {
+		m.pausedMx.Unlock()
+		return fmt.Errorf("component %s is paused", componentID)
+	}
+	if m.getRuntimeFromComponent(component.Component{ID: componentID}) == nil {
+		m.pausedMx.Unlock()
+		return ErrNoComponent
+	}
+	m.restarts[componentID] = struct{}{}
+	m.pausedMx.Unlock()
+
+	m.logger.Infof("Restarting component %q", componentID)
+	m.reapply()
+	return nil
+}
+
+// PausedComponents returns the IDs of the paused components.
+func (m *Manager) PausedComponents() []string {
+	m.pausedMx.Lock()
+	defer m.pausedMx.Unlock()
+	ids := make([]string, 0, len(m.paused))
+	for id := range m.paused {
+		ids = append(ids, id)
+	}
+	return ids
+}
+
+// SetLogLevelOverride temporarily overrides the log level of the units of the running components.
+//
+// An empty componentID matches all components and an empty unitID matches all units of the
+// matching components. The override is reverted once ttl has elapsed.
+func (m *Manager) SetLogLevelOverride(componentID string, unitID string, level client.UnitLogLevel, ttl time.Duration) error {
+	if ttl <= 0 {
+		return errors.New("log level override requires a positive ttl")
+	}
+	if err := m.checkLogLevelOverrideTarget(componentID, unitID); err != nil {
+		return err
+	}
+
+	o := &logLevelOverride{
+		componentID: componentID,
+		unitID:      unitID,
+		level:       level,
+		expires:     time.Now().Add(ttl),
+	}
+	m.logLevelMx.Lock()
+	m.logLevelOverrides[o.key()] = o
+	m.logLevelMx.Unlock()
+	time.AfterFunc(ttl, func() {
+		m.expireLogLevelOverride(o)
+	})
+
+	m.logger.Infof("Overriding log level of component %q unit %q to %s for %s", componentID, unitID, level, ttl)
+	m.reapply()
+	return nil
+}
+
+// checkLogLevelOverrideTarget returns ErrNoComponent or ErrNoUnit when no running component
+// matches componentID and unitID.
+func (m *Manager) checkLogLevelOverrideTarget(componentID string, unitID string) error {
+	if componentID != "" {
+		r := m.getRuntimeFromComponent(component.Component{ID: componentID})
+		if r == nil {
+			return ErrNoComponent
+		}
+		if unitID != "" && !hasUnit(r.getCurrent(), unitID) {
+			return fmt.Errorf("unit %q of component %q: %w", unitID, componentID, ErrNoUnit)
+		}
+		return nil
+	}
+	if unitID == "" {
+		return nil
+	}
+	m.currentMx.RLock()
+	defer m.currentMx.RUnlock()
+	for _, r := range m.current {
+		if hasUnit(r.getCurrent(), unitID) {
+			return nil
+		}
+	}
+	return fmt.Errorf("unit %q: %w", unitID, ErrNoUnit)
+}
+
+func hasUnit(comp component.Component, unitID string) bool {
+	for _, unit := range comp.Units {
+		if unit.ID == unitID {
+			return true
+		}
+	}
+	return false
+}
+
+// expireLogLevelOverride removes the log level override, unless it has since been replaced.
+func (m *Manager) expireLogLevelOverride(o *logLevelOverride) {
+	m.logLevelMx.Lock()
+	if m.logLevelOverrides[o.key()] != o {
+		m.logLevelMx.Unlock()
+		return
+	}
+	delete(m.logLevelOverrides, o.key())
+	m.logLevelMx.Unlock()
+
+	m.logger.Infof("Reverting log level override of component %q unit %q", o.componentID, o.unitID)
+	m.reapply()
+}
+
+// activeLogLevelOverrides returns the log level overrides that have not expired.
+func (m *Manager) activeLogLevelOverrides() []*logLevelOverride {
+	now := time.Now()
+	m.logLevelMx.Lock()
+	defer m.logLevelMx.Unlock()
+	overrides := make([]*logLevelOverride, 0, len(m.logLevelOverrides))
+	for _, o := range m.logLevelOverrides {
+		if now.Before(o.expires) {
+			overrides = append(overrides, o)
+		}
+	}
+	return overrides
+}
+
+// reapply asks the run loop to apply the most recent component model again.
+func (m *Manager) reapply() {
+	select {
+	case m.reapplyChan <- struct{}{}:
+	case <-m.doneChan:
+		// Manager is shutting down, ignore the request
+	}
+}
+
 // PerformAction executes an action on a unit.
 func (m *Manager) PerformAction(ctx context.Context, comp component.Component, unit component.Unit, name string, params map[string]interface{}) (map[string]interface{}, error) {
 	id, err := uuid.NewV4()
@@ -749,13 +980,38 @@ func (m *Manager) Actions(server proto.ElasticAgent_ActionsServer) error {
 //
 // This returns as soon as possible, work is performed in the background.
 func (m *Manager) update(model component.Model, teardown bool) error {
+	m.pausedMx.Lock()
+	paused := make(map[string]struct{}, len(m.paused))
+	for id := range m.paused {
+		paused[id] = struct{}{}
+	}
+	restarts := m.restarts
+	m.restarts = make(map[string]struct{})
+	m.pausedMx.Unlock()
+
+	logLevelOverrides := m.activeLogLevelOverrides()
+
 	touched := make(map[string]bool)
 	newComponents := make([]component.Component, 0, len(model.Components))
+	var pausedComponents []component.Component
+	var restart []*componentRuntimeState
 	for _, comp := range model.Components {
+		if _, ok := paused[comp.ID]; ok {
+			// paused component; kept in the model but not running
+			pausedComponents = append(pausedComponents, comp)
+			continue
+		}
+		comp = applyLogLevelOverrides(comp, logLevelOverrides)
 		touched[comp.ID] = true
 		m.currentMx.RLock()
 		existing, ok := m.current[comp.ID]
 		m.currentMx.RUnlock()
+		if _, ok := restarts[comp.ID]; ok && existing != nil {
+			// restart requested; stop the existing runtime and start a new one
+			restart = append(restart, existing)
+			newComponents = append(newComponents, comp)
+			continue
+		}
 		if ok {
 			// existing component; send runtime updated value
 			existing.setCurrent(comp)
@@ -780,7 +1036,7 @@ func (m *Manager) update(model component.Model, teardown bool) error {
 	m.currentMx.RUnlock()
 
 	var stoppedWg sync.WaitGroup
-	stoppedWg.Add(len(stop))
+	stoppedWg.Add(len(stop) + len(restart))
 	for _, existing := range stop {
 		m.logger.Debugf("Stopping component %q", existing.id)
 		_ = existing.stop(teardown, model.Signed)
@@ -793,11 +1049,31 @@ func (m *Manager) update(model component.Model, teardown bool) error {
 				m.logger.Errorf("updating components: failed waiting %s stop",
 					state.id)
 			}
+			// wait for the stopped state to be reported, so it is not
+			// reported after the paused state
+			m.waitForRemoved(state)
+			stoppedWg.Done()
+		}(existing)
+	}
+	for _, existing := range restart {
+		m.logger.Debugf("Stopping component %q for restart", existing.id)
+		// not a teardown, the component is started again right away
+		_ = existing.stop(false, model.Signed)
+		go func(state *componentRuntimeState) {
+			err := m.waitForStopped(state)
+			if err != nil {
+				m.logger.Errorf("updating components: failed waiting %s stop",
+					state.id)
+			}
+			// the new runtime state replaces this one, wait until it is removed
+			m.waitForRemoved(state)
 			stoppedWg.Done()
 		}(existing)
 	}
 	stoppedWg.Wait()
 
+	m.reportPaused(pausedComponents, touched)
+
 	// start new components
 	for _, comp := range newComponents {
 		// new component; create its runtime
@@ -818,6 +1094,55 @@ func (m *Manager) update(model component.Model, teardown bool) error {
 	return nil
 }
 
+// waitForRemoved waits until the stopped component has been removed from the
+// current components, bounded by the default stop timeout.
+func (m *Manager) waitForRemoved(comp *componentRuntimeState) {
+	timeoutCh := time.After(defaultStopTimeout)
+	for {
+		m.currentMx.RLock()
+		current, exists := m.current[comp.id]
+		m.currentMx.RUnlock()
+		if !exists || current != comp {
+			return
+		}
+		select {
+		case <-timeoutCh:
+			return
+		case <-time.After(stopCheckRetryPeriod):
+		}
+	}
+}
+
+// reportPaused notifies the subscribers of the paused components, and of the
+// previously paused components that are no longer part of the model.
+// Only called from the goroutine running update.
+func (m *Manager) reportPaused(pausedComponents []component.Component, touched map[string]bool) {
+	reported := make(map[string]component.Component, len(pausedComponents))
+	for _, comp := range pausedComponents {
+		reported[comp.ID] = comp
+		m.broadcastAll(ComponentComponentState{
+			Component: comp,
+			State:     newPausedComponentState(comp),
+			Paused:    true,
+		})
+	}
+	for id, comp := range m.pausedReported {
+		if _, ok := reported[id]; ok || touched[id] {
+			// still paused, or resumed and running again
+			continue
+		}
+		// paused component removed from the model
+		m.broadcastAll(ComponentComponentState{
+			Component: comp,
+			State: ComponentState{
+				State:   client.UnitStateStopped,
+				Message: stoppedMsg,
+			},
+		})
+	}
+	m.pausedReported = reported
+}
+
 func (m *Manager) waitForStopped(comp *componentRuntimeState) error {
 	if comp == nil {
 		return nil
@@ -881,17 +1206,10 @@ func (m *Manager) shutdown() {
 
 // stateChanged notifies of the state change and returns true if the state is final (stopped)
 func (m *Manager) stateChanged(state *componentRuntimeState, latest ComponentState) (exit bool) {
-	m.subAllMx.RLock()
-	for _, sub := range m.subscribeAll {
-		select {
-		case <-sub.ctx.Done():
-		case sub.ch <- ComponentComponentState{
-			Component: state.getCurrent(),
-			State:     latest,
-		}:
-		}
-	}
-	m.subAllMx.RUnlock()
+	m.broadcastAll(ComponentComponentState{
+		Component: state.getCurrent(),
+		State:     latest,
+	})
 
 	m.subMx.RLock()
 	subs := m.subscriptions[state.id]
@@ -915,6 +1233,18 @@ func (m *Manager) stateChanged(state *componentRuntimeState, latest ComponentSta
 	return exit
 }
 
+// broadcastAll sends the component state to all the subscribers of all components.
+func (m *Manager) broadcastAll(state ComponentComponentState) {
+	m.subAllMx.RLock()
+	defer m.subAllMx.RUnlock()
+	for _, sub := range m.subscribeAll {
+		select {
+		case <-sub.ctx.Done():
+		case sub.ch <- state:
+		}
+	}
+}
+
 func (m *Manager) getCertificate(chi *tls.ClientHelloInfo) (*tls.Certificate, error) {
 	var cert *tls.Certificate
 
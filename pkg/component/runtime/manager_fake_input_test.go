@@ -2753,6 +2753,207 @@ func (suite *FakeInputSuite) TestManager_StartStopComponent() {
 	}
 }
 
+func (suite *FakeInputSuite) TestManager_PauseResumeRestartComponent() {
+	t := suite.T()
+	t.Cleanup(func() {
+		// later tests of the suite share the data path, they must not load the paused component
+		_ = os.Remove(paths.PausedComponentsFile())
+	})
+
+	const compID = "fake-default"
+	comp := component.Component{
+		ID: compID,
+		InputSpec: &component.InputRuntimeSpec{
+			InputType:  "fake",
+			BinaryName: "",
+			BinaryPath: testBinary(t, "component"),
+			Spec:       fakeInputSpec,
+		},
+		Units: []component.Unit{
+			{
+				ID:   "fake-input",
+				Type: client.UnitTypeInput,
+				Config: component.MustExpectedConfig(map[string]interface{}{
+					"type":    "fake",
+					"state":   int(client.UnitStateHealthy),
+					"message": "Fake Healthy",
+				}),
+			},
+		},
+	}
+	healthy := func(s ComponentComponentState) bool {
+		return s.Component.ID == compID && !s.Paused && s.State.State == client.UnitStateHealthy
+	}
+	paused := func(s ComponentComponentState) bool {
+		return s.Component.ID == compID && s.Paused && s.State.State == client.UnitStateStopped
+	}
+
+	m, sub, stop := runPauseTestManager(t)
+	m.Update(component.Model{Components: []component.Component{comp}})
+	waitForComponentState(t, sub, healthy, "component did not become healthy")
+
+	t.Log("Unknown component")
+	assert.ErrorIs(t, m.PauseComponent("unknown"), ErrNoComponent)
+	assert.ErrorIs(t, m.RestartComponent("unknown"), ErrNoComponent)
+	assert.Error(t, m.ResumeComponent("unknown"), "resuming a component that is not paused must fail")
+	assert.Error(t, m.ResumeComponent(compID), "resuming a running component must fail")
+
+	t.Log("Restart")
+	require.NoError(t, m.RestartComponent(compID))
+	waitForComponentState(t, sub, func(s ComponentComponentState) bool {
+		return s.Component.ID == compID && !s.Paused && s.State.State == client.UnitStateStopped
+	}, "component was not stopped by the restart")
+	waitForComponentState(t, sub, healthy, "component did not become healthy after the restart")
+
+	t.Log("Pause")
+	require.NoError(t, m.PauseComponent(compID))
+	waitForComponentState(t, sub, paused, "component was not reported as paused")
+	assert.Equal(t, []string{compID}, m.PausedComponents())
+	assert.Error(t, m.RestartComponent(compID), "restarting a paused component must fail")
+	assert.NoError(t, m.PauseComponent(compID), "pausing a paused component must be a no-op")
+	stop()
+
+	t.Log("Paused state survives a restart of the manager")
+	m, sub, stop = runPauseTestManager(t)
+	defer stop()
+	assert.Equal(t, []string{compID}, m.PausedComponents())
+	m.Update(component.Model{Components: []component.Component{comp}})
+	waitForComponentState(t, sub, paused, "component was not reported as paused after the restart of the manager")
+	m.currentMx.RLock()
+	_, running := m.current[compID]
+	m.currentMx.RUnlock()
+	assert.False(t, running, "paused component must not be started")
+
+	t.Log("Resume")
+	require.NoError(t, m.ResumeComponent(compID))
+	waitForComponentState(t, sub, healthy, "component did not become healthy after being resumed")
+	assert.Empty(t, m.PausedComponents())
+}
+
+func (suite *FakeInputSuite) TestManager_LogLevelOverride() {
+	t := suite.T()
+
+	const compID = "fake-default"
+	comp := component.Component{
+		ID: compID,
+		InputSpec: &component.InputRuntimeSpec{
+			InputType:  "fake",
+			BinaryName: "",
+			BinaryPath: testBinary(t, "component"),
+			Spec:       fakeInputSpec,
+		},
+		Units: []component.Unit{
+			{
+				ID:       "fake-input",
+				Type:     client.UnitTypeInput,
+				LogLevel: client.UnitLogLevelInfo,
+				Config: component.MustExpectedConfig(map[string]interface{}{
+					"type":    "fake",
+					"state":   int(client.UnitStateHealthy),
+					"message": "Fake Healthy",
+				}),
+			},
+		},
+	}
+
+	m, sub, stop := runPauseTestManager(t)
+	defer stop()
+	m.Update(component.Model{Components: []component.Component{comp}})
+	waitForComponentState(t, sub, func(s ComponentComponentState) bool {
+		return s.Component.ID == compID && s.State.State == client.UnitStateHealthy
+	}, "component did not become healthy")
+
+	unitLogLevel := func() client.UnitLogLevel {
+		r := m.getRuntimeFromComponent(comp)
+		if r == nil {
+			return client.UnitLogLevelInfo
+		}
+		return r.getCurrent().Units[0].LogLevel
+	}
+
+	t.Log("Unknown component or unit")
+	assert.ErrorIs(t, m.SetLogLevelOverride("unknown", "", client.UnitLogLevelDebug, time.Minute), ErrNoComponent)
+	assert.ErrorIs(t, m.SetLogLevelOverride(compID, "unknown", client.UnitLogLevelDebug, time.Minute), ErrNoUnit)
+	assert.ErrorIs(t, m.SetLogLevelOverride("", "unknown", client.UnitLogLevelDebug, time.Minute), ErrNoUnit)
+	assert.Equal(t, client.UnitLogLevelInfo, unitLogLevel())
+
+	t.Log("Override is applied to the running unit")
+	require.NoError(t, m.SetLogLevelOverride(compID, "fake-input", client.UnitLogLevelDebug, 2*time.Second))
+	require.Eventually(t, func() bool {
+		return unitLogLevel() == client.UnitLogLevelDebug
+	}, 10*time.Second, 50*time.Millisecond, "log level override was not applied")
+
+	t.Log("Override is kept when the model is updated")
+	m.Update(component.Model{Components: []component.Component{comp}})
+	assert.Never(t, func() bool {
+		return unitLogLevel() != client.UnitLogLevelDebug
+	}, 500*time.Millisecond, 50*time.Millisecond, "log level override was lost on update")
+
+	t.Log("Override is reverted once the ttl has elapsed")
+	require.Eventually(t, func() bool {
+		return unitLogLevel() == client.UnitLogLevelInfo
+	}, 10*time.Second, 50*time.Millisecond, "log level override was not reverted")
+}
+
+// runPauseTestManager runs a Manager until the returned stop function is called, the results
+// of its updates are logged.
+func runPauseTestManager(t *testing.T) (*Manager, *SubscriptionAll, func()) {
+	t.Helper()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	m, err := NewManager(newDebugLogger(t), newDebugLogger(t), &info.AgentInfo{}, apmtest.DiscardTracer, newTestMonitoringMgr(), testGrpcConfig())
+	require.NoError(t, err)
+
+	managerErrCh := make(chan error, 1)
+	go func() {
+		err := m.Run(ctx)
+		if errors.Is(err, context.Canceled) {
+			err = nil
+		}
+		managerErrCh <- err
+	}()
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case err := <-m.Errors():
+				if err != nil {
+					t.Logf("failed to update components: %v", err)
+				}
+			}
+		}
+	}()
+
+	waitCtx, waitCancel := context.WithTimeout(ctx, 1*time.Second)
+	defer waitCancel()
+	require.NoError(t, waitForReady(waitCtx, m))
+
+	sub := m.SubscribeAll(ctx)
+	return m, sub, func() {
+		cancel()
+		require.NoError(t, <-managerErrCh)
+	}
+}
+
+// waitForComponentState waits until the subscription receives a state for which match is true.
+func waitForComponentState(t *testing.T, sub *SubscriptionAll, match func(ComponentComponentState) bool, msg string) {
+	t.Helper()
+	timeout := time.NewTimer(30 * time.Second)
+	defer timeout.Stop()
+	for {
+		select {
+		case <-timeout.C:
+			t.Fatal(msg)
+		case s := <-sub.Ch():
+			t.Logf("component %s state: %s (paused: %t)", s.Component.ID, s.State.State, s.Paused)
+			if match(s) {
+				return
+			}
+		}
+	}
+}
+
 func (suite *FakeInputSuite) TestManager_Chunk() {
 	t := suite.T()
 
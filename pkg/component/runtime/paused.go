@@ -0,0 +1,88 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package runtime
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"sort"
+
+	"gopkg.in/yaml.v2"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/pkg/component"
+)
+
+const pausedMsg = "Paused"
+
+// pausedComponents is the persisted set of components that have been paused
+// over the control protocol.
+type pausedComponents struct {
+	Components []string `yaml:"components"`
+}
+
+// loadPausedComponents reads the set of paused components from the store.
+func loadPausedComponents(store storage.Storage) (map[string]struct{}, error) {
+	paused := make(map[string]struct{})
+	if store == nil {
+		return paused, nil
+	}
+	exists, err := store.Exists()
+	if err != nil || !exists {
+		return paused, err
+	}
+	reader, err := store.Load()
+	if err != nil {
+		return paused, err
+	}
+	defer reader.Close()
+	data, err := io.ReadAll(reader)
+	if err != nil {
+		return paused, fmt.Errorf("failed to read paused components: %w", err)
+	}
+	var p pausedComponents
+	if err := yaml.Unmarshal(data, &p); err != nil {
+		return paused, fmt.Errorf("failed to parse paused components: %w", err)
+	}
+	for _, id := range p.Components {
+		paused[id] = struct{}{}
+	}
+	return paused, nil
+}
+
+// savePausedComponents writes the set of paused components to the store.
+func savePausedComponents(store storage.Storage, paused map[string]struct{}) error {
+	if store == nil {
+		return nil
+	}
+	p := pausedComponents{Components: make([]string, 0, len(paused))}
+	for id := range paused {
+		p.Components = append(p.Components, id)
+	}
+	sort.Strings(p.Components)
+	data, err := yaml.Marshal(p)
+	if err != nil {
+		return fmt.Errorf("failed to marshal paused components: %w", err)
+	}
+	return store.Save(bytes.NewReader(data))
+}
+
+// newPausedComponentState returns the state reported for a paused component.
+func newPausedComponentState(comp component.Component) ComponentState {
+	s := ComponentState{
+		State:   client.UnitStateStopped,
+		Message: pausedMsg,
+		Units:   make(map[ComponentUnitKey]ComponentUnitState, len(comp.Units)),
+	}
+	for _, unit := range comp.Units {
+		s.Units[ComponentUnitKey{UnitType: unit.Type, UnitID: unit.ID}] = ComponentUnitState{
+			State:   client.UnitStateStopped,
+			Message: pausedMsg,
+		}
+	}
+	return s
+}
@@ -0,0 +1,62 @@
+// Copyright Elasticsearch B.V. and/or licensed to Elasticsearch B.V. under one
+// or more contributor license agreements. Licensed under the Elastic License 2.0;
+// you may not use this file except in compliance with the Elastic License 2.0.
+
+package runtime
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/elastic/elastic-agent-client/v7/pkg/client"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
+	"github.com/elastic/elastic-agent/pkg/component"
+)
+
+func TestPausedComponentsRoundTrip(t *testing.T) {
+	store, err := storage.NewDiskStore(filepath.Join(t.TempDir(), "paused_components.yml"))
+	require.NoError(t, err)
+
+	paused, err := loadPausedComponents(store)
+	require.NoError(t, err)
+	assert.Empty(t, paused)
+
+	require.NoError(t, savePausedComponents(store, map[string]struct{}{
+		"filestream-default":   {},
+		"beat/metrics-default": {},
+	}))
+
+	paused, err = loadPausedComponents(store)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]struct{}{
+		"filestream-default":   {},
+		"beat/metrics-default": {},
+	}, paused)
+
+	require.NoError(t, savePausedComponents(store, map[string]struct{}{}))
+	paused, err = loadPausedComponents(store)
+	require.NoError(t, err)
+	assert.Empty(t, paused)
+}
+
+func TestNewPausedComponentState(t *testing.T) {
+	comp := component.Component{
+		ID: "filestream-default",
+		Units: []component.Unit{
+			{ID: "filestream-default", Type: client.UnitTypeOutput},
+			{ID: "filestream-default-input", Type: client.UnitTypeInput},
+		},
+	}
+
+	state := newPausedComponentState(comp)
+	assert.Equal(t, client.UnitStateStopped, state.State)
+	assert.Equal(t, pausedMsg, state.Message)
+	require.Len(t, state.Units, 2)
+	for _, unit := range state.Units {
+		assert.Equal(t, client.UnitStateStopped, unit.State)
+		assert.Equal(t, pausedMsg, unit.Message)
+	}
+}
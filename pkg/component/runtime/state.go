@@ -80,6 +80,9 @@ type ComponentState struct {
 	// of the endpoint service. If you need the PID for beats, use the coordinator/communicator
 	Pid uint64
 
+	// Restarts is the number of times the component process exited unexpectedly and was restarted.
+	Restarts uint64 `yaml:"restarts,omitempty"`
+
 	// internal
 	expectedUnits map[ComponentUnitKey]expectedUnitState
 
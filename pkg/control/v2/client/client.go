@@ -14,6 +14,7 @@ import (
 	"time"
 
 	"google.golang.org/grpc"
+	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
 	"github.com/elastic/elastic-agent/pkg/control"
@@ -29,6 +30,9 @@ type State = cproto.State
 // CollectorComponentStatus is the status of a collector component
 type CollectorComponentStatus = cproto.CollectorComponentStatus
 
+// EventSeverity is the severity of a state transition event
+type EventSeverity = cproto.EventSeverity
+
 // AdditionalMetrics is the type for additional diagnostic requests
 type AdditionalMetrics = cproto.AdditionalDiagnosticRequest
 
@@ -79,6 +83,15 @@ const (
 	CollectorComponentStatusStopped CollectorComponentStatus = cproto.CollectorComponentStatus_StatusStopped
 )
 
+const (
+	// SeverityInfo is the severity of transitions to starting, configuring, healthy, stopping and stopped.
+	SeverityInfo EventSeverity = cproto.EventSeverity_INFO
+	// SeverityWarning is the severity of transitions to degraded.
+	SeverityWarning EventSeverity = cproto.EventSeverity_WARNING
+	// SeverityError is the severity of transitions to failed.
+	SeverityError EventSeverity = cproto.EventSeverity_ERROR
+)
+
 const (
 	// CPU requests additional CPU diagnostics
 	CPU AdditionalMetrics = cproto.AdditionalDiagnosticRequest_CPU
@@ -118,6 +131,7 @@ type ComponentState struct {
 	Message     string               `json:"message" yaml:"message"`
 	Units       []ComponentUnitState `json:"units" yaml:"units"`
 	VersionInfo ComponentVersionInfo `json:"version_info" yaml:"version_info"`
+	Paused      bool                 `json:"paused,omitempty" yaml:"paused,omitempty"`
 }
 
 // CollectorComponent is a state of a collector component managed by the Elastic Agent.
@@ -152,6 +166,57 @@ type AgentState struct {
 	Collector      *CollectorComponent    `json:"collector,omitempty" yaml:"collector,omitempty"`
 }
 
+// Event is a component or unit state transition.
+type Event struct {
+	Time        time.Time     `json:"time" yaml:"time"`
+	ComponentID string        `json:"component_id" yaml:"component_id"`
+	UnitID      string        `json:"unit_id,omitempty" yaml:"unit_id,omitempty"`
+	UnitType    UnitType      `json:"unit_type" yaml:"unit_type"`
+	OldState    State         `json:"old_state" yaml:"old_state"`
+	State       State         `json:"state" yaml:"state"`
+	Message     string        `json:"message" yaml:"message"`
+	Severity    EventSeverity `json:"severity" yaml:"severity"`
+}
+
+// EventsRequest filters the state transition events.
+type EventsRequest struct {
+	// ComponentID only returns the events of this component, all components when empty.
+	ComponentID string
+	// MinSeverity only returns the events with at least this severity.
+	MinSeverity EventSeverity
+	// Since only returns the events that happened after this time, all recorded events when zero.
+	Since time.Time
+	// Follow keeps streaming new events once the recorded ones have been received.
+	Follow bool
+}
+
+// QueuedAction is a scheduled Fleet action waiting in the queue.
+type QueuedAction struct {
+	ID           string    `json:"id" yaml:"id"`
+	Type         string    `json:"type" yaml:"type"`
+	StartTime    time.Time `json:"start_time" yaml:"start_time"`
+	Expiration   time.Time `json:"expiration,omitempty" yaml:"expiration,omitempty"`
+	RetryAttempt int       `json:"retry_attempt,omitempty" yaml:"retry_attempt,omitempty"`
+}
+
+// HandledAction is a Fleet action handled by the Elastic Agent.
+type HandledAction struct {
+	ID     string    `json:"id" yaml:"id"`
+	Type   string    `json:"type" yaml:"type"`
+	Time   time.Time `json:"time" yaml:"time"`
+	Result string    `json:"result" yaml:"result"`
+	Error  string    `json:"error,omitempty" yaml:"error,omitempty"`
+	// ErrorCode is the machine-readable code of the error.
+	ErrorCode string `json:"error_code,omitempty" yaml:"error_code,omitempty"`
+}
+
+// UpgradePreflightCheck is the result of a check run before an upgrade modifies the installation.
+type UpgradePreflightCheck struct {
+	Name    string `json:"name" yaml:"name"`
+	Status  string `json:"status" yaml:"status"`
+	Message string `json:"message,omitempty" yaml:"message,omitempty"`
+}
+
 // DiagnosticFileResult is a diagnostic file result.
 type DiagnosticFileResult struct {
 	Name        string
@@ -206,6 +271,9 @@ type Client interface {
 	Restart(ctx context.Context) error
 	// Upgrade triggers upgrade of the current running daemon.
 	Upgrade(ctx context.Context, version string, sourceURI string, skipVerify bool, skipDefaultPgp bool, pgpBytes ...string) (string, error)
+	// UpgradeDryRun runs the pre-flight checks of an upgrade of the running daemon without upgrading it.
+	// The results of the checks are returned with an error when one of them failed.
+	UpgradeDryRun(ctx context.Context, version string, sourceURI string, skipVerify bool, skipDefaultPgp bool, pgpBytes []string) ([]UpgradePreflightCheck, error)
 	// DiagnosticAgent gathers diagnostics information for the running Elastic Agent.
 	DiagnosticAgent(ctx context.Context, additionalDiags []AdditionalMetrics) ([]DiagnosticFileResult, error)
 	// DiagnosticUnits gathers diagnostics information from specific units (or all if non are provided).
@@ -216,6 +284,27 @@ type Client interface {
 	// Configure sends a new configuration to the Elastic Agent.
 	// Only works in the case that Elastic Agent is started in testing mode.
 	Configure(ctx context.Context, config string) error
+	// PauseComponent stops a running component until ResumeComponent is called.
+	PauseComponent(ctx context.Context, componentID string) error
+	// ResumeComponent starts a previously paused component.
+	ResumeComponent(ctx context.Context, componentID string) error
+	// RestartComponent stops and starts a running component.
+	RestartComponent(ctx context.Context, componentID string) error
+	// ComponentAction performs an action on a unit of a running component and returns its result.
+	ComponentAction(ctx context.Context, componentID string, unitID string, name string, params map[string]interface{}) (map[string]interface{}, error)
+	// SetComponentLogLevel temporarily overrides the log level of components and units until ttl has elapsed.
+	// Empty componentID or unitID match all components or units.
+	SetComponentLogLevel(ctx context.Context, componentID string, unitID string, level string, ttl time.Duration) error
+	// Events streams the component and unit state transitions.
+	Events(ctx context.Context, req EventsRequest) (ClientEvents, error)
+	// ListActions lists the scheduled Fleet actions waiting in the queue.
+	ListActions(ctx context.Context) ([]QueuedAction, error)
+	// CancelAction removes a scheduled Fleet action from the queue and returns the number of entries cancelled.
+	CancelAction(ctx context.Context, actionID string) (int, error)
+	// ActionHistory returns the last Fleet actions handled, oldest first.
+	ActionHistory(ctx context.Context) ([]HandledAction, error)
+	// RotateVaultKey replaces the agent key of the vault and re-encrypts the encrypted stores with it.
+	RotateVaultKey(ctx context.Context) error
 }
 
 // ClientStateWatch allows the state of the running Elastic Agent to be watched.
@@ -224,6 +313,12 @@ type ClientStateWatch interface {
 	Recv() (*AgentState, error)
 }
 
+// ClientEvents allows the state transition events of the running Elastic Agent to be received.
+type ClientEvents interface {
+	// Recv receives the next event, io.EOF is returned once all events have been received.
+	Recv() (*Event, error)
+}
+
 // Option is an option to adjust how the client operates.
 type Option func(c *client)
 
@@ -352,6 +447,33 @@ func (c *client) Upgrade(ctx context.Context, version string, sourceURI string,
 	return res.Version, nil
 }
 
+// UpgradeDryRun runs the pre-flight checks of an upgrade of the running daemon without upgrading it.
+func (c *client) UpgradeDryRun(ctx context.Context, version string, sourceURI string, skipVerify bool, skipDefaultPgp bool, pgpBytes []string) ([]UpgradePreflightCheck, error) {
+	res, err := c.client.Upgrade(ctx, &cproto.UpgradeRequest{
+		Version:        version,
+		SourceURI:      sourceURI,
+		SkipVerify:     skipVerify,
+		PgpBytes:       pgpBytes,
+		SkipDefaultPgp: skipDefaultPgp,
+		DryRun:         true,
+	})
+	if err != nil {
+		return nil, err
+	}
+	checks := make([]UpgradePreflightCheck, 0, len(res.Checks))
+	for _, check := range res.Checks {
+		checks = append(checks, UpgradePreflightCheck{
+			Name:    check.Name,
+			Status:  check.Status,
+			Message: check.Message,
+		})
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return checks, errors.New(res.Error)
+	}
+	return checks, nil
+}
+
 // DiagnosticAgent gathers diagnostics information for the running Elastic Agent.
 func (c *client) DiagnosticAgent(ctx context.Context, additionalMetrics []AdditionalMetrics) ([]DiagnosticFileResult, error) {
 	resp, err := c.client.DiagnosticAgent(ctx, &cproto.DiagnosticAgentRequest{AdditionalMetrics: additionalMetrics})
@@ -483,6 +605,199 @@ func (c *client) Configure(ctx context.Context, config string) error {
 	return err
 }
 
+// PauseComponent stops a running component until ResumeComponent is called.
+func (c *client) PauseComponent(ctx context.Context, componentID string) error {
+	res, err := c.client.PauseComponent(ctx, &cproto.ComponentControlRequest{ComponentId: componentID})
+	if err != nil {
+		return err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// ResumeComponent starts a previously paused component.
+func (c *client) ResumeComponent(ctx context.Context, componentID string) error {
+	res, err := c.client.ResumeComponent(ctx, &cproto.ComponentControlRequest{ComponentId: componentID})
+	if err != nil {
+		return err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// RestartComponent stops and starts a running component.
+func (c *client) RestartComponent(ctx context.Context, componentID string) error {
+	res, err := c.client.RestartComponent(ctx, &cproto.ComponentControlRequest{ComponentId: componentID})
+	if err != nil {
+		return err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// ComponentAction performs an action on a unit of a running component and returns its result.
+func (c *client) ComponentAction(ctx context.Context, componentID string, unitID string, name string, params map[string]interface{}) (map[string]interface{}, error) {
+	paramBytes := []byte("{}")
+	if params != nil {
+		var err error
+		paramBytes, err = json.Marshal(params)
+		if err != nil {
+			return nil, fmt.Errorf("failed to marshal action params: %w", err)
+		}
+	}
+	res, err := c.client.ComponentAction(ctx, &cproto.ComponentActionRequest{
+		ComponentId: componentID,
+		UnitId:      unitID,
+		Name:        name,
+		Params:      paramBytes,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return nil, errors.New(res.Error)
+	}
+	var result map[string]interface{}
+	if len(res.Result) > 0 {
+		if err := json.Unmarshal(res.Result, &result); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal action result: %w", err)
+		}
+	}
+	return result, nil
+}
+
+// SetComponentLogLevel temporarily overrides the log level of components and units until ttl has elapsed.
+func (c *client) SetComponentLogLevel(ctx context.Context, componentID string, unitID string, level string, ttl time.Duration) error {
+	res, err := c.client.SetComponentLogLevel(ctx, &cproto.ComponentLogLevelRequest{
+		ComponentId: componentID,
+		UnitId:      unitID,
+		Level:       level,
+		TtlSeconds:  int64(ttl / time.Second),
+	})
+	if err != nil {
+		return err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return errors.New(res.Error)
+	}
+	return nil
+}
+
+// Events streams the component and unit state transitions.
+func (c *client) Events(ctx context.Context, req EventsRequest) (ClientEvents, error) {
+	r := &cproto.EventsRequest{
+		ComponentId: req.ComponentID,
+		MinSeverity: req.MinSeverity,
+		Follow:      req.Follow,
+	}
+	if !req.Since.IsZero() {
+		r.Since = timestamppb.New(req.Since)
+	}
+	cli, err := c.client.Events(ctx, r)
+	if err != nil {
+		return nil, err
+	}
+	return &eventsReceiver{cli}, nil
+}
+
+// ListActions lists the scheduled Fleet actions waiting in the queue.
+func (c *client) ListActions(ctx context.Context) ([]QueuedAction, error) {
+	res, err := c.client.ListActions(ctx, &cproto.ActionsListRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return nil, errors.New(res.Error)
+	}
+	actions := make([]QueuedAction, 0, len(res.Actions))
+	for _, a := range res.Actions {
+		qa := QueuedAction{
+			ID:           a.Id,
+			Type:         a.Type,
+			RetryAttempt: int(a.RetryAttempt),
+		}
+		if a.StartTime != nil {
+			qa.StartTime = a.StartTime.AsTime()
+		}
+		if a.Expiration != nil {
+			qa.Expiration = a.Expiration.AsTime()
+		}
+		actions = append(actions, qa)
+	}
+	return actions, nil
+}
+
+// CancelAction removes a scheduled Fleet action from the queue and returns the number of entries cancelled.
+func (c *client) CancelAction(ctx context.Context, actionID string) (int, error) {
+	res, err := c.client.CancelAction(ctx, &cproto.ActionCancelRequest{
+		Id: actionID,
+	})
+	if err != nil {
+		return 0, err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return 0, errors.New(res.Error)
+	}
+	return int(res.Cancelled), nil
+}
+
+// ActionHistory returns the last Fleet actions handled, oldest first.
+func (c *client) ActionHistory(ctx context.Context) ([]HandledAction, error) {
+	res, err := c.client.ActionHistory(ctx, &cproto.ActionHistoryRequest{})
+	if err != nil {
+		return nil, err
+	}
+	if res.Status == cproto.ActionStatus_FAILURE {
+		return nil, errors.New(res.Error)
+	}
+	actions := make([]HandledAction, 0, len(res.Actions))
+	for _, a := range res.Actions {
+		actions = append(actions, HandledAction{
+			ID:        a.Id,
+			Type:      a.Type,
+			Time:      a.Time.AsTime(),
+			Result:    a.Result,
+			Error:     a.Error,
+			ErrorCode: a.ErrorCode,
+		})
+	}
+	return actions, nil
+}
+
+// RotateVaultKey replaces the agent key of the vault and re-encrypts the encrypted stores with it.
+func (c *client) RotateVaultKey(ctx context.Context) error {
+	_, err := c.client.RotateVaultKey(ctx, &cproto.Empty{})
+	return err
+}
+
+type eventsReceiver struct {
+	client cproto.ElasticAgentControl_EventsClient
+}
+
+// Recv receives the next event.
+func (er *eventsReceiver) Recv() (*Event, error) {
+	e, err := er.client.Recv()
+	if err != nil {
+		return nil, err
+	}
+	return &Event{
+		Time:        e.Time.AsTime(),
+		ComponentID: e.ComponentId,
+		UnitID:      e.UnitId,
+		UnitType:    e.UnitType,
+		OldState:    e.OldState,
+		State:       e.State,
+		Message:     e.Message,
+		Severity:    e.Severity,
+	}, nil
+}
+
 type stateWatcher struct {
 	client cproto.ElasticAgentControl_StateWatchClient
 }
@@ -540,6 +855,7 @@ func toState(res *cproto.StateResponse) (*AgentState, error) {
 			State:   comp.State,
 			Message: comp.Message,
 			Units:   units,
+			Paused:  comp.Paused,
 		}
 		if comp.VersionInfo != nil {
 			cs.VersionInfo = ComponentVersionInfo{
@@ -33,6 +33,16 @@ const (
 	ElasticAgentControl_DiagnosticUnits_FullMethodName      = "/cproto.ElasticAgentControl/DiagnosticUnits"
 	ElasticAgentControl_DiagnosticComponents_FullMethodName = "/cproto.ElasticAgentControl/DiagnosticComponents"
 	ElasticAgentControl_Configure_FullMethodName            = "/cproto.ElasticAgentControl/Configure"
+	ElasticAgentControl_PauseComponent_FullMethodName       = "/cproto.ElasticAgentControl/PauseComponent"
+	ElasticAgentControl_ResumeComponent_FullMethodName      = "/cproto.ElasticAgentControl/ResumeComponent"
+	ElasticAgentControl_RestartComponent_FullMethodName     = "/cproto.ElasticAgentControl/RestartComponent"
+	ElasticAgentControl_ComponentAction_FullMethodName      = "/cproto.ElasticAgentControl/ComponentAction"
+	ElasticAgentControl_SetComponentLogLevel_FullMethodName = "/cproto.ElasticAgentControl/SetComponentLogLevel"
+	ElasticAgentControl_Events_FullMethodName               = "/cproto.ElasticAgentControl/Events"
+	ElasticAgentControl_ListActions_FullMethodName          = "/cproto.ElasticAgentControl/ListActions"
+	ElasticAgentControl_CancelAction_FullMethodName         = "/cproto.ElasticAgentControl/CancelAction"
+	ElasticAgentControl_ActionHistory_FullMethodName        = "/cproto.ElasticAgentControl/ActionHistory"
+	ElasticAgentControl_RotateVaultKey_FullMethodName       = "/cproto.ElasticAgentControl/RotateVaultKey"
 )
 
 // ElasticAgentControlClient is the client API for ElasticAgentControl service.
@@ -65,6 +75,36 @@ type ElasticAgentControlClient interface {
 	// on any Elastic Agent that is not in TESTING_MODE will result in an error being
 	// returned and nothing occurring.
 	Configure(ctx context.Context, in *ConfigureRequest, opts ...grpc.CallOption) (*Empty, error)
+	// PauseComponent stops a running component while keeping it in the component model.
+	//
+	// The component stays stopped, even across restarts of the Elastic Agent, until
+	// ResumeComponent is called.
+	PauseComponent(ctx context.Context, in *ComponentControlRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error)
+	// ResumeComponent starts a previously paused component.
+	ResumeComponent(ctx context.Context, in *ComponentControlRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error)
+	// RestartComponent stops and starts a running component.
+	RestartComponent(ctx context.Context, in *ComponentControlRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error)
+	// ComponentAction performs an action on a unit of a running component.
+	ComponentAction(ctx context.Context, in *ComponentActionRequest, opts ...grpc.CallOption) (*ComponentActionResponse, error)
+	// SetComponentLogLevel temporarily overrides the log level of components and units.
+	//
+	// The override is reverted once its TTL has elapsed.
+	SetComponentLogLevel(ctx context.Context, in *ComponentLogLevelRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error)
+	// Events streams the recorded component and unit state transitions.
+	//
+	// When follow is set the new transitions are streamed as they happen.
+	Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error)
+	// ListActions lists the scheduled Fleet actions waiting in the queue.
+	ListActions(ctx context.Context, in *ActionsListRequest, opts ...grpc.CallOption) (*ActionsListResponse, error)
+	// CancelAction removes a scheduled Fleet action from the queue.
+	//
+	// The cancellation is local to the Elastic Agent; Fleet is not notified.
+	CancelAction(ctx context.Context, in *ActionCancelRequest, opts ...grpc.CallOption) (*ActionCancelResponse, error)
+	// ActionHistory returns the last Fleet actions handled by the Elastic Agent.
+	ActionHistory(ctx context.Context, in *ActionHistoryRequest, opts ...grpc.CallOption) (*ActionHistoryResponse, error)
+	// RotateVaultKey replaces the agent key of the vault and re-encrypts the encrypted
+	// stores with the new key.
+	RotateVaultKey(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error)
 }
 
 type elasticAgentControlClient struct {
@@ -192,6 +232,115 @@ func (c *elasticAgentControlClient) Configure(ctx context.Context, in *Configure
 	return out, nil
 }
 
+func (c *elasticAgentControlClient) PauseComponent(ctx context.Context, in *ComponentControlRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComponentControlResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_PauseComponent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) ResumeComponent(ctx context.Context, in *ComponentControlRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComponentControlResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_ResumeComponent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) RestartComponent(ctx context.Context, in *ComponentControlRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComponentControlResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_RestartComponent_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) ComponentAction(ctx context.Context, in *ComponentActionRequest, opts ...grpc.CallOption) (*ComponentActionResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComponentActionResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_ComponentAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) SetComponentLogLevel(ctx context.Context, in *ComponentLogLevelRequest, opts ...grpc.CallOption) (*ComponentControlResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ComponentControlResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_SetComponentLogLevel_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) Events(ctx context.Context, in *EventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[Event], error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	stream, err := c.cc.NewStream(ctx, &ElasticAgentControl_ServiceDesc.Streams[3], ElasticAgentControl_Events_FullMethodName, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	x := &grpc.GenericClientStream[EventsRequest, Event]{ClientStream: stream}
+	if err := x.ClientStream.SendMsg(in); err != nil {
+		return nil, err
+	}
+	if err := x.ClientStream.CloseSend(); err != nil {
+		return nil, err
+	}
+	return x, nil
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ElasticAgentControl_EventsClient = grpc.ServerStreamingClient[Event]
+
+func (c *elasticAgentControlClient) ListActions(ctx context.Context, in *ActionsListRequest, opts ...grpc.CallOption) (*ActionsListResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActionsListResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_ListActions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) CancelAction(ctx context.Context, in *ActionCancelRequest, opts ...grpc.CallOption) (*ActionCancelResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActionCancelResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_CancelAction_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) ActionHistory(ctx context.Context, in *ActionHistoryRequest, opts ...grpc.CallOption) (*ActionHistoryResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(ActionHistoryResponse)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_ActionHistory_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *elasticAgentControlClient) RotateVaultKey(ctx context.Context, in *Empty, opts ...grpc.CallOption) (*Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(Empty)
+	err := c.cc.Invoke(ctx, ElasticAgentControl_RotateVaultKey_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // ElasticAgentControlServer is the server API for ElasticAgentControl service.
 // All implementations must embed UnimplementedElasticAgentControlServer
 // for forward compatibility.
@@ -222,6 +371,36 @@ type ElasticAgentControlServer interface {
 	// on any Elastic Agent that is not in TESTING_MODE will result in an error being
 	// returned and nothing occurring.
 	Configure(context.Context, *ConfigureRequest) (*Empty, error)
+	// PauseComponent stops a running component while keeping it in the component model.
+	//
+	// The component stays stopped, even across restarts of the Elastic Agent, until
+	// ResumeComponent is called.
+	PauseComponent(context.Context, *ComponentControlRequest) (*ComponentControlResponse, error)
+	// ResumeComponent starts a previously paused component.
+	ResumeComponent(context.Context, *ComponentControlRequest) (*ComponentControlResponse, error)
+	// RestartComponent stops and starts a running component.
+	RestartComponent(context.Context, *ComponentControlRequest) (*ComponentControlResponse, error)
+	// ComponentAction performs an action on a unit of a running component.
+	ComponentAction(context.Context, *ComponentActionRequest) (*ComponentActionResponse, error)
+	// SetComponentLogLevel temporarily overrides the log level of components and units.
+	//
+	// The override is reverted once its TTL has elapsed.
+	SetComponentLogLevel(context.Context, *ComponentLogLevelRequest) (*ComponentControlResponse, error)
+	// Events streams the recorded component and unit state transitions.
+	//
+	// When follow is set the new transitions are streamed as they happen.
+	Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error
+	// ListActions lists the scheduled Fleet actions waiting in the queue.
+	ListActions(context.Context, *ActionsListRequest) (*ActionsListResponse, error)
+	// CancelAction removes a scheduled Fleet action from the queue.
+	//
+	// The cancellation is local to the Elastic Agent; Fleet is not notified.
+	CancelAction(context.Context, *ActionCancelRequest) (*ActionCancelResponse, error)
+	// ActionHistory returns the last Fleet actions handled by the Elastic Agent.
+	ActionHistory(context.Context, *ActionHistoryRequest) (*ActionHistoryResponse, error)
+	// RotateVaultKey replaces the agent key of the vault and re-encrypts the encrypted
+	// stores with the new key.
+	RotateVaultKey(context.Context, *Empty) (*Empty, error)
 	mustEmbedUnimplementedElasticAgentControlServer()
 }
 
@@ -259,6 +438,36 @@ func (UnimplementedElasticAgentControlServer) DiagnosticComponents(*DiagnosticCo
 func (UnimplementedElasticAgentControlServer) Configure(context.Context, *ConfigureRequest) (*Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method Configure not implemented")
 }
+func (UnimplementedElasticAgentControlServer) PauseComponent(context.Context, *ComponentControlRequest) (*ComponentControlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method PauseComponent not implemented")
+}
+func (UnimplementedElasticAgentControlServer) ResumeComponent(context.Context, *ComponentControlRequest) (*ComponentControlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ResumeComponent not implemented")
+}
+func (UnimplementedElasticAgentControlServer) RestartComponent(context.Context, *ComponentControlRequest) (*ComponentControlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RestartComponent not implemented")
+}
+func (UnimplementedElasticAgentControlServer) ComponentAction(context.Context, *ComponentActionRequest) (*ComponentActionResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ComponentAction not implemented")
+}
+func (UnimplementedElasticAgentControlServer) SetComponentLogLevel(context.Context, *ComponentLogLevelRequest) (*ComponentControlResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method SetComponentLogLevel not implemented")
+}
+func (UnimplementedElasticAgentControlServer) Events(*EventsRequest, grpc.ServerStreamingServer[Event]) error {
+	return status.Errorf(codes.Unimplemented, "method Events not implemented")
+}
+func (UnimplementedElasticAgentControlServer) ListActions(context.Context, *ActionsListRequest) (*ActionsListResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ListActions not implemented")
+}
+func (UnimplementedElasticAgentControlServer) CancelAction(context.Context, *ActionCancelRequest) (*ActionCancelResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CancelAction not implemented")
+}
+func (UnimplementedElasticAgentControlServer) ActionHistory(context.Context, *ActionHistoryRequest) (*ActionHistoryResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method ActionHistory not implemented")
+}
+func (UnimplementedElasticAgentControlServer) RotateVaultKey(context.Context, *Empty) (*Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RotateVaultKey not implemented")
+}
 func (UnimplementedElasticAgentControlServer) mustEmbedUnimplementedElasticAgentControlServer() {}
 func (UnimplementedElasticAgentControlServer) testEmbeddedByValue()                             {}
 
@@ -421,6 +630,179 @@ func _ElasticAgentControl_Configure_Handler(srv interface{}, ctx context.Context
 	return interceptor(ctx, in, info, handler)
 }
 
+func _ElasticAgentControl_PauseComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComponentControlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).PauseComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_PauseComponent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).PauseComponent(ctx, req.(*ComponentControlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_ResumeComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComponentControlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).ResumeComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_ResumeComponent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).ResumeComponent(ctx, req.(*ComponentControlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_RestartComponent_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComponentControlRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).RestartComponent(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_RestartComponent_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).RestartComponent(ctx, req.(*ComponentControlRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_ComponentAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComponentActionRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).ComponentAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_ComponentAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).ComponentAction(ctx, req.(*ComponentActionRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_SetComponentLogLevel_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ComponentLogLevelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).SetComponentLogLevel(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_SetComponentLogLevel_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).SetComponentLogLevel(ctx, req.(*ComponentLogLevelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_Events_Handler(srv interface{}, stream grpc.ServerStream) error {
+	m := new(EventsRequest)
+	if err := stream.RecvMsg(m); err != nil {
+		return err
+	}
+	return srv.(ElasticAgentControlServer).Events(m, &grpc.GenericServerStream[EventsRequest, Event]{ServerStream: stream})
+}
+
+// This type alias is provided for backwards compatibility with existing code that references the prior non-generic stream type by name.
+type ElasticAgentControl_EventsServer = grpc.ServerStreamingServer[Event]
+
+func _ElasticAgentControl_ListActions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActionsListRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).ListActions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_ListActions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).ListActions(ctx, req.(*ActionsListRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_CancelAction_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActionCancelRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).CancelAction(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_CancelAction_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).CancelAction(ctx, req.(*ActionCancelRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_ActionHistory_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(ActionHistoryRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).ActionHistory(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_ActionHistory_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).ActionHistory(ctx, req.(*ActionHistoryRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _ElasticAgentControl_RotateVaultKey_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(ElasticAgentControlServer).RotateVaultKey(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: ElasticAgentControl_RotateVaultKey_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(ElasticAgentControlServer).RotateVaultKey(ctx, req.(*Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // ElasticAgentControl_ServiceDesc is the grpc.ServiceDesc for ElasticAgentControl service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -452,6 +834,42 @@ var ElasticAgentControl_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "Configure",
 			Handler:    _ElasticAgentControl_Configure_Handler,
 		},
+		{
+			MethodName: "PauseComponent",
+			Handler:    _ElasticAgentControl_PauseComponent_Handler,
+		},
+		{
+			MethodName: "ResumeComponent",
+			Handler:    _ElasticAgentControl_ResumeComponent_Handler,
+		},
+		{
+			MethodName: "RestartComponent",
+			Handler:    _ElasticAgentControl_RestartComponent_Handler,
+		},
+		{
+			MethodName: "ComponentAction",
+			Handler:    _ElasticAgentControl_ComponentAction_Handler,
+		},
+		{
+			MethodName: "SetComponentLogLevel",
+			Handler:    _ElasticAgentControl_SetComponentLogLevel_Handler,
+		},
+		{
+			MethodName: "ListActions",
+			Handler:    _ElasticAgentControl_ListActions_Handler,
+		},
+		{
+			MethodName: "CancelAction",
+			Handler:    _ElasticAgentControl_CancelAction_Handler,
+		},
+		{
+			MethodName: "ActionHistory",
+			Handler:    _ElasticAgentControl_ActionHistory_Handler,
+		},
+		{
+			MethodName: "RotateVaultKey",
+			Handler:    _ElasticAgentControl_RotateVaultKey_Handler,
+		},
 	},
 	Streams: []grpc.StreamDesc{
 		{
@@ -469,6 +887,11 @@ var ElasticAgentControl_ServiceDesc = grpc.ServiceDesc{
 			Handler:       _ElasticAgentControl_DiagnosticComponents_Handler,
 			ServerStreams: true,
 		},
+		{
+			StreamName:    "Events",
+			Handler:       _ElasticAgentControl_Events_Handler,
+			ServerStreams: true,
+		},
 	},
 	Metadata: "control_v2.proto",
 }
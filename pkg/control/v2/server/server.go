@@ -24,13 +24,21 @@ import (
 	"go.elastic.co/apm/module/apmgrpc/v2"
 	"go.elastic.co/apm/v2"
 	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	grpcstatus "google.golang.org/grpc/status"
 	"google.golang.org/protobuf/types/known/timestamppb"
 
 	"github.com/elastic/elastic-agent-client/v7/pkg/client"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/coordinator"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/dispatcher"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/application/info"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/paths"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/reexec"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/application/upgrade"
 	"github.com/elastic/elastic-agent/internal/pkg/agent/configuration"
+	"github.com/elastic/elastic-agent/internal/pkg/agent/storage"
 	"github.com/elastic/elastic-agent/internal/pkg/diagnostics"
+	"github.com/elastic/elastic-agent/internal/pkg/fleetapi"
 	"github.com/elastic/elastic-agent/internal/pkg/release"
 	"github.com/elastic/elastic-agent/pkg/component"
 	"github.com/elastic/elastic-agent/pkg/component/runtime"
@@ -43,13 +51,34 @@ type TestModeConfigSetter interface {
 	SetConfig(ctx context.Context, cfg string) error
 }
 
+// agentCoordinator is the part of the coordinator the control protocol server uses.
+type agentCoordinator interface {
+	State() coordinator.State
+	StateSubscribe(ctx context.Context, bufferLen int) chan coordinator.State
+	ReExec(callback reexec.ShutdownCallbackFn, argOverrides ...string)
+	Upgrade(ctx context.Context, version string, sourceURI string, action *fleetapi.ActionUpgrade, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) error
+	UpgradePreflight(ctx context.Context, version string, sourceURI string, skipVerifyOverride bool, skipDefaultPgp bool, pgpBytes ...string) *upgrade.PreflightReport
+	PerformAction(ctx context.Context, comp component.Component, unit component.Unit, name string, params map[string]interface{}) (map[string]interface{}, error)
+	PerformDiagnostics(ctx context.Context, req ...runtime.ComponentUnitDiagnosticRequest) []runtime.ComponentUnitDiagnostic
+	PerformComponentDiagnostics(ctx context.Context, additionalMetrics []cproto.AdditionalDiagnosticRequest, req ...component.Component) ([]runtime.ComponentDiagnostic, error)
+	PauseComponent(componentID string) error
+	ResumeComponent(componentID string) error
+	RestartComponent(componentID string) error
+	StateTransitions(since time.Time) []coordinator.StateTransition
+	SubscribeStateTransitions(ctx context.Context, since time.Time) ([]coordinator.StateTransition, <-chan coordinator.StateTransition)
+	SetComponentLogLevel(componentID string, unitID string, level client.UnitLogLevel, ttl time.Duration) error
+	QueuedActions() ([]fleetapi.ScheduledAction, error)
+	CancelQueuedAction(ctx context.Context, actionID string) (int, error)
+	ActionHistory() ([]dispatcher.HandledAction, error)
+}
+
 // Server is the daemon side of the control protocol.
 type Server struct {
 	cproto.UnimplementedElasticAgentControlServer
 
 	logger     *logger.Logger
 	agentInfo  info.Agent
-	coord      *coordinator.Coordinator
+	coord      agentCoordinator
 	listener   net.Listener
 	server     *grpc.Server
 	tracer     *apm.Tracer
@@ -174,6 +203,10 @@ func (s *Server) Restart(_ context.Context, _ *cproto.Empty) (*cproto.RestartRes
 
 // Upgrade performs the upgrade operation.
 func (s *Server) Upgrade(ctx context.Context, request *cproto.UpgradeRequest) (*cproto.UpgradeResponse, error) {
+	if request.DryRun {
+		return s.upgradeDryRun(ctx, request), nil
+	}
+
 	err := s.coord.Upgrade(ctx, request.Version, request.SourceURI, nil, request.SkipVerify, request.SkipDefaultPgp, request.PgpBytes...)
 	if err != nil {
 		//nolint:nilerr // ignore the error, return a failure upgrade response
@@ -188,6 +221,32 @@ func (s *Server) Upgrade(ctx context.Context, request *cproto.UpgradeRequest) (*
 	}, nil
 }
 
+// upgradeDryRun runs the pre-flight checks of the upgrade without upgrading.
+func (s *Server) upgradeDryRun(ctx context.Context, request *cproto.UpgradeRequest) *cproto.UpgradeResponse {
+	report := s.coord.UpgradePreflight(ctx, request.Version, request.SourceURI, request.SkipVerify, request.SkipDefaultPgp, request.PgpBytes...)
+	checks := make([]*cproto.UpgradePreflightCheck, 0, len(report.Checks))
+	for _, check := range report.Checks {
+		checks = append(checks, &cproto.UpgradePreflightCheck{
+			Name:    check.Name,
+			Status:  string(check.Status),
+			Message: check.Message,
+		})
+	}
+	if err := report.Err(); err != nil {
+		return &cproto.UpgradeResponse{
+			Status:  cproto.ActionStatus_FAILURE,
+			Version: request.Version,
+			Error:   err.Error(),
+			Checks:  checks,
+		}
+	}
+	return &cproto.UpgradeResponse{
+		Status:  cproto.ActionStatus_SUCCESS,
+		Version: request.Version,
+		Checks:  checks,
+	}
+}
+
 // DiagnosticAgent returns diagnostic information for this running Elastic Agent.
 func (s *Server) DiagnosticAgent(ctx context.Context, req *cproto.DiagnosticAgentRequest) (*cproto.DiagnosticAgentResponse, error) {
 	res := make([]*cproto.DiagnosticFileResult, 0, len(s.diagHooks))
@@ -201,7 +260,7 @@ func (s *Server) DiagnosticAgent(ctx context.Context, req *cproto.DiagnosticAgen
 			Filename:    h.Filename,
 			Description: h.Description,
 			ContentType: h.ContentType,
-			Content:     r,
+			Content:     redactSecretValues(h.ContentType, r),
 			Generated:   timestamppb.New(time.Now().UTC()),
 		})
 	}
@@ -251,7 +310,7 @@ func (s *Server) DiagnosticComponents(req *cproto.DiagnosticComponentsRequest, r
 				Filename:    file.Filename,
 				Description: file.Description,
 				ContentType: file.ContentType,
-				Content:     file.Content,
+				Content:     redactSecretValues(file.ContentType, file.Content),
 				Generated:   file.Generated,
 			})
 		}
@@ -304,7 +363,7 @@ func (s *Server) DiagnosticUnits(req *cproto.DiagnosticUnitsRequest, srv cproto.
 					Filename:    fr.Filename,
 					Description: fr.Description,
 					ContentType: fr.ContentType,
-					Content:     fr.Content,
+					Content:     redactSecretValues(fr.ContentType, fr.Content),
 					Generated:   fr.Generated,
 				})
 			}
@@ -333,6 +392,249 @@ func (s *Server) Configure(ctx context.Context, req *cproto.ConfigureRequest) (*
 	return &cproto.Empty{}, nil
 }
 
+// PauseComponent stops a running component until ResumeComponent is called.
+func (s *Server) PauseComponent(_ context.Context, req *cproto.ComponentControlRequest) (*cproto.ComponentControlResponse, error) {
+	return componentControlResponse(s.coord.PauseComponent(req.ComponentId)), nil
+}
+
+// ResumeComponent starts a previously paused component.
+func (s *Server) ResumeComponent(_ context.Context, req *cproto.ComponentControlRequest) (*cproto.ComponentControlResponse, error) {
+	return componentControlResponse(s.coord.ResumeComponent(req.ComponentId)), nil
+}
+
+// RestartComponent stops and starts a running component.
+func (s *Server) RestartComponent(_ context.Context, req *cproto.ComponentControlRequest) (*cproto.ComponentControlResponse, error) {
+	return componentControlResponse(s.coord.RestartComponent(req.ComponentId)), nil
+}
+
+// ComponentAction performs an action on a unit of a running component.
+func (s *Server) ComponentAction(ctx context.Context, req *cproto.ComponentActionRequest) (*cproto.ComponentActionResponse, error) {
+	comp, unit, ok := findComponentUnit(s.coord.State(), req.ComponentId, req.UnitId)
+	if !ok {
+		return componentActionFailure(fmt.Errorf("unit %s of component %s not found", req.UnitId, req.ComponentId)), nil
+	}
+
+	var params map[string]interface{}
+	if len(req.Params) > 0 {
+		if err := json.Unmarshal(req.Params, &params); err != nil {
+			return componentActionFailure(fmt.Errorf("failed to parse action params: %w", err)), nil
+		}
+	}
+
+	res, err := s.coord.PerformAction(ctx, comp, unit, req.Name, params)
+	if err != nil {
+		return componentActionFailure(err), nil
+	}
+	result, err := json.Marshal(res)
+	if err != nil {
+		return componentActionFailure(fmt.Errorf("failed to marshal action result: %w", err)), nil
+	}
+	return &cproto.ComponentActionResponse{
+		Status: cproto.ActionStatus_SUCCESS,
+		Result: result,
+	}, nil
+}
+
+// SetComponentLogLevel temporarily overrides the log level of components and units.
+func (s *Server) SetComponentLogLevel(_ context.Context, req *cproto.ComponentLogLevelRequest) (*cproto.ComponentControlResponse, error) {
+	level, err := component.StringToLogLevel(req.Level)
+	if err != nil {
+		return componentControlResponse(err), nil
+	}
+	ttl := time.Duration(req.TtlSeconds) * time.Second
+	err = s.coord.SetComponentLogLevel(req.ComponentId, req.UnitId, level, ttl)
+	if errors.Is(err, runtime.ErrNoComponent) || errors.Is(err, runtime.ErrNoUnit) {
+		return nil, grpcstatus.Error(codes.NotFound, err.Error())
+	}
+	return componentControlResponse(err), nil
+}
+
+// Events streams the recorded component and unit state transitions, and the following
+// transitions when follow is requested.
+func (s *Server) Events(req *cproto.EventsRequest, srv cproto.ElasticAgentControl_EventsServer) error {
+	var since time.Time
+	if req.Since != nil {
+		since = req.Since.AsTime()
+	}
+	send := func(t coordinator.StateTransition) error {
+		if !eventMatches(req, t) {
+			return nil
+		}
+		return srv.Send(stateTransitionToProto(t))
+	}
+
+	if !req.Follow {
+		for _, t := range s.coord.StateTransitions(since) {
+			if err := send(t); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	history, ch := s.coord.SubscribeStateTransitions(srv.Context(), since)
+	for _, t := range history {
+		if err := send(t); err != nil {
+			return err
+		}
+	}
+	for {
+		select {
+		case <-srv.Context().Done():
+			return nil
+		case t, ok := <-ch:
+			if !ok {
+				if srv.Context().Err() != nil {
+					return nil
+				}
+				return errors.New("events stream closed because the client is not keeping up")
+			}
+			if err := send(t); err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// ListActions lists the scheduled Fleet actions waiting in the queue.
+func (s *Server) ListActions(_ context.Context, _ *cproto.ActionsListRequest) (*cproto.ActionsListResponse, error) {
+	queued, err := s.coord.QueuedActions()
+	if err != nil {
+		return &cproto.ActionsListResponse{
+			Status: cproto.ActionStatus_FAILURE,
+			Error:  err.Error(),
+		}, nil
+	}
+	actions := make([]*cproto.QueuedAction, 0, len(queued))
+	for _, a := range queued {
+		actions = append(actions, queuedActionToProto(a))
+	}
+	return &cproto.ActionsListResponse{
+		Status:  cproto.ActionStatus_SUCCESS,
+		Actions: actions,
+	}, nil
+}
+
+// CancelAction removes a scheduled Fleet action from the queue.
+func (s *Server) CancelAction(ctx context.Context, req *cproto.ActionCancelRequest) (*cproto.ActionCancelResponse, error) {
+	n, err := s.coord.CancelQueuedAction(ctx, req.Id)
+	if err != nil {
+		return &cproto.ActionCancelResponse{
+			Status: cproto.ActionStatus_FAILURE,
+			Error:  err.Error(),
+		}, nil
+	}
+	return &cproto.ActionCancelResponse{
+		Status:    cproto.ActionStatus_SUCCESS,
+		Cancelled: int32(n), //nolint:gosec // bounded by the size of the queue
+	}, nil
+}
+
+// ActionHistory returns the last Fleet actions handled.
+func (s *Server) ActionHistory(_ context.Context, _ *cproto.ActionHistoryRequest) (*cproto.ActionHistoryResponse, error) {
+	handled, err := s.coord.ActionHistory()
+	if err != nil {
+		return &cproto.ActionHistoryResponse{
+			Status: cproto.ActionStatus_FAILURE,
+			Error:  err.Error(),
+		}, nil
+	}
+	actions := make([]*cproto.HandledAction, 0, len(handled))
+	for _, a := range handled {
+		actions = append(actions, &cproto.HandledAction{
+			Id:        a.ID,
+			Type:      a.Type,
+			Time:      timestamppb.New(a.Time),
+			Result:    string(a.Result),
+			Error:     a.Error,
+			ErrorCode: a.ErrorCode,
+		})
+	}
+	return &cproto.ActionHistoryResponse{
+		Status:  cproto.ActionStatus_SUCCESS,
+		Actions: actions,
+	}, nil
+}
+
+// RotateVaultKey replaces the agent key of the vault and re-encrypts the encrypted stores with it.
+func (s *Server) RotateVaultKey(ctx context.Context, _ *cproto.Empty) (*cproto.Empty, error) {
+	if err := storage.RotateKey(ctx, paths.EncryptedStoreFiles()); err != nil {
+		return nil, err
+	}
+	s.logger.Info("Rotated the agent key of the vault")
+	return &cproto.Empty{}, nil
+}
+
+func queuedActionToProto(a fleetapi.ScheduledAction) *cproto.QueuedAction {
+	qa := &cproto.QueuedAction{
+		Id:   a.ID(),
+		Type: a.Type(),
+	}
+	if start, err := a.StartTime(); err == nil {
+		qa.StartTime = timestamppb.New(start)
+	}
+	if exp, err := a.Expiration(); err == nil {
+		qa.Expiration = timestamppb.New(exp)
+	}
+	if ra, ok := a.(fleetapi.RetryableAction); ok {
+		qa.RetryAttempt = int32(ra.RetryAttempt()) //nolint:gosec // retry attempts are small
+	}
+	return qa
+}
+
+func eventMatches(req *cproto.EventsRequest, t coordinator.StateTransition) bool {
+	if req.ComponentId != "" && req.ComponentId != t.ComponentID {
+		return false
+	}
+	return int(t.Severity()) >= int(req.MinSeverity)
+}
+
+func stateTransitionToProto(t coordinator.StateTransition) *cproto.Event {
+	return &cproto.Event{
+		Time:        timestamppb.New(t.Timestamp),
+		ComponentId: t.ComponentID,
+		UnitId:      t.UnitID,
+		UnitType:    cproto.UnitType(t.UnitType),
+		OldState:    cproto.State(t.OldState),
+		State:       cproto.State(t.State),
+		Message:     t.Message,
+		Severity:    cproto.EventSeverity(t.Severity()),
+	}
+}
+
+func findComponentUnit(state coordinator.State, componentID string, unitID string) (component.Component, component.Unit, bool) {
+	for _, comp := range state.Components {
+		if comp.Component.ID != componentID {
+			continue
+		}
+		for _, unit := range comp.Component.Units {
+			if unit.ID == unitID {
+				return comp.Component, unit, true
+			}
+		}
+	}
+	return component.Component{}, component.Unit{}, false
+}
+
+func componentActionFailure(err error) *cproto.ComponentActionResponse {
+	return &cproto.ComponentActionResponse{
+		Status: cproto.ActionStatus_FAILURE,
+		Error:  err.Error(),
+	}
+}
+
+func componentControlResponse(err error) *cproto.ComponentControlResponse {
+	if err != nil {
+		return &cproto.ComponentControlResponse{
+			Status: cproto.ActionStatus_FAILURE,
+			Error:  err.Error(),
+		}
+	}
+	return &cproto.ComponentControlResponse{
+		Status: cproto.ActionStatus_SUCCESS,
+	}
+}
+
 func stateToProto(state *coordinator.State, agentInfo info.Agent) (*cproto.StateResponse, error) {
 	var err error
 	components := make([]*cproto.ComponentState, 0, len(state.Components))
@@ -364,6 +666,7 @@ func stateToProto(state *coordinator.State, agentInfo info.Agent) (*cproto.State
 				Name: comp.State.VersionInfo.Name,
 				Meta: comp.State.VersionInfo.Meta,
 			},
+			Paused: comp.Paused,
 		})
 	}
 
@@ -379,6 +682,11 @@ func stateToProto(state *coordinator.State, agentInfo info.Agent) (*cproto.State
 				ErrorMsg:        state.UpgradeDetails.Metadata.ErrorMsg,
 				RetryErrorMsg:   state.UpgradeDetails.Metadata.RetryErrorMsg,
 				Reason:          state.UpgradeDetails.Metadata.Reason,
+				ErrorCode:       state.UpgradeDetails.Metadata.ErrorCode,
+
+				RollbackErrorMsg: state.UpgradeDetails.Metadata.RollbackErrorMsg,
+
+				DownloadRateLimit: float64(state.UpgradeDetails.Metadata.DownloadRateLimit),
 			},
 		}
 
@@ -391,6 +699,11 @@ func stateToProto(state *coordinator.State, agentInfo info.Agent) (*cproto.State
 			!state.UpgradeDetails.Metadata.RetryUntil.IsZero() {
 			upgradeDetails.Metadata.RetryUntil = state.UpgradeDetails.Metadata.RetryUntil.Format(control.TimeFormat())
 		}
+
+		if state.UpgradeDetails.Metadata.DownloadWindowOpensAt != nil &&
+			!state.UpgradeDetails.Metadata.DownloadWindowOpensAt.IsZero() {
+			upgradeDetails.Metadata.DownloadWindowOpensAt = state.UpgradeDetails.Metadata.DownloadWindowOpensAt.Format(control.TimeFormat())
+		}
 	}
 
 	return &cproto.StateResponse{
@@ -455,3 +768,12 @@ func otelComponentStatusToProto(s componentstatus.Status) cproto.CollectorCompon
 	}
 	return cproto.CollectorComponentStatus_StatusNone
 }
+
+// redactSecretValues redacts the values resolved from the secret stores from the diagnostic
+// files, binary files are left untouched.
+func redactSecretValues(contentType string, content []byte) []byte {
+	if contentType == "application/octet-stream" {
+		return content
+	}
+	return diagnostics.RedactSecretValues(content)
+}
@@ -5,6 +5,8 @@
 package server
 
 import (
+	"context"
+	"errors"
 	"testing"
 	"time"
 
@@ -220,3 +222,165 @@ func TestStateMapping(t *testing.T) {
 		})
 	}
 }
+
+func TestFindComponentUnit(t *testing.T) {
+	state := coordinator.State{
+		Components: []runtime.ComponentComponentState{
+			{
+				Component: component.Component{
+					ID: "osquery-default",
+					Units: []component.Unit{
+						{ID: "osquery-default", Type: client.UnitTypeOutput},
+						{ID: "osquery-default-osquery", Type: client.UnitTypeInput},
+					},
+				},
+			},
+		},
+	}
+
+	comp, unit, ok := findComponentUnit(state, "osquery-default", "osquery-default-osquery")
+	require.True(t, ok)
+	assert.Equal(t, "osquery-default", comp.ID)
+	assert.Equal(t, client.UnitTypeInput, unit.Type)
+
+	_, _, ok = findComponentUnit(state, "osquery-default", "missing")
+	assert.False(t, ok)
+	_, _, ok = findComponentUnit(state, "missing", "osquery-default-osquery")
+	assert.False(t, ok)
+}
+
+// fakeActionCoordinator performs the component actions of the control protocol server.
+type fakeActionCoordinator struct {
+	agentCoordinator
+
+	state coordinator.State
+
+	result map[string]interface{}
+	err    error
+
+	performed []performedAction
+}
+
+type performedAction struct {
+	componentID string
+	unitID      string
+	name        string
+	params      map[string]interface{}
+}
+
+func (c *fakeActionCoordinator) State() coordinator.State {
+	return c.state
+}
+
+func (c *fakeActionCoordinator) PerformAction(_ context.Context, comp component.Component, unit component.Unit, name string, params map[string]interface{}) (map[string]interface{}, error) {
+	c.performed = append(c.performed, performedAction{componentID: comp.ID, unitID: unit.ID, name: name, params: params})
+	return c.result, c.err
+}
+
+func TestServer_ComponentAction(t *testing.T) {
+	state := coordinator.State{
+		Components: []runtime.ComponentComponentState{
+			{
+				Component: component.Component{
+					ID: "osquery-default",
+					Units: []component.Unit{
+						{ID: "osquery-default-osquery", Type: client.UnitTypeInput},
+					},
+				},
+			},
+		},
+	}
+	req := &cproto.ComponentActionRequest{
+		ComponentId: "osquery-default",
+		UnitId:      "osquery-default-osquery",
+		Name:        "query",
+		Params:      []byte(`{"query": "select 1", "limit": 10}`),
+	}
+
+	t.Run("success", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state, result: map[string]interface{}{"rows": []interface{}{"1"}, "count": 1}}
+		s := &Server{coord: coord}
+
+		res, err := s.ComponentAction(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, cproto.ActionStatus_SUCCESS, res.Status)
+		assert.Empty(t, res.Error)
+		assert.JSONEq(t, `{"rows": ["1"], "count": 1}`, string(res.Result))
+
+		require.Len(t, coord.performed, 1)
+		assert.Equal(t, performedAction{
+			componentID: "osquery-default",
+			unitID:      "osquery-default-osquery",
+			name:        "query",
+			params:      map[string]interface{}{"query": "select 1", "limit": float64(10)},
+		}, coord.performed[0])
+	})
+
+	t.Run("no params", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state}
+		s := &Server{coord: coord}
+
+		res, err := s.ComponentAction(context.Background(), &cproto.ComponentActionRequest{
+			ComponentId: req.ComponentId,
+			UnitId:      req.UnitId,
+			Name:        req.Name,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, cproto.ActionStatus_SUCCESS, res.Status)
+		assert.JSONEq(t, `null`, string(res.Result))
+		require.Len(t, coord.performed, 1)
+		assert.Nil(t, coord.performed[0].params)
+	})
+
+	t.Run("invalid params", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state}
+		s := &Server{coord: coord}
+
+		res, err := s.ComponentAction(context.Background(), &cproto.ComponentActionRequest{
+			ComponentId: req.ComponentId,
+			UnitId:      req.UnitId,
+			Name:        req.Name,
+			Params:      []byte(`["not", "an", "object"]`),
+		})
+		require.NoError(t, err)
+		assert.Equal(t, cproto.ActionStatus_FAILURE, res.Status)
+		assert.Contains(t, res.Error, "failed to parse action params")
+		assert.Empty(t, coord.performed, "the action should not be performed")
+	})
+
+	t.Run("unknown unit", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state}
+		s := &Server{coord: coord}
+
+		res, err := s.ComponentAction(context.Background(), &cproto.ComponentActionRequest{
+			ComponentId: req.ComponentId,
+			UnitId:      "missing",
+			Name:        req.Name,
+		})
+		require.NoError(t, err)
+		assert.Equal(t, cproto.ActionStatus_FAILURE, res.Status)
+		assert.Equal(t, "unit missing of component osquery-default not found", res.Error)
+		assert.Empty(t, coord.performed)
+	})
+
+	t.Run("action failed", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state, err: errors.New("query failed")}
+		s := &Server{coord: coord}
+
+		res, err := s.ComponentAction(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, cproto.ActionStatus_FAILURE, res.Status)
+		assert.Equal(t, "query failed", res.Error)
+		assert.Empty(t, res.Result)
+	})
+
+	t.Run("result cannot be marshalled", func(t *testing.T) {
+		coord := &fakeActionCoordinator{state: state, result: map[string]interface{}{"invalid": make(chan int)}}
+		s := &Server{coord: coord}
+
+		res, err := s.ComponentAction(context.Background(), req)
+		require.NoError(t, err)
+		assert.Equal(t, cproto.ActionStatus_FAILURE, res.Status)
+		assert.Contains(t, res.Error, "failed to marshal action result")
+	})
+}
@@ -16,6 +16,8 @@ import (
 	grpc "google.golang.org/grpc"
 
 	mock "github.com/stretchr/testify/mock"
+
+	time "time"
 )
 
 // Client is an autogenerated mock type for the Client type
@@ -31,6 +33,183 @@ func (_m *Client) EXPECT() *Client_Expecter {
 	return &Client_Expecter{mock: &_m.Mock}
 }
 
+// ActionHistory provides a mock function with given fields: ctx
+func (_m *Client) ActionHistory(ctx context.Context) ([]client.HandledAction, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ActionHistory")
+	}
+
+	var r0 []client.HandledAction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]client.HandledAction, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []client.HandledAction); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]client.HandledAction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_ActionHistory_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ActionHistory'
+type Client_ActionHistory_Call struct {
+	*mock.Call
+}
+
+// ActionHistory is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Client_Expecter) ActionHistory(ctx interface{}) *Client_ActionHistory_Call {
+	return &Client_ActionHistory_Call{Call: _e.mock.On("ActionHistory", ctx)}
+}
+
+func (_c *Client_ActionHistory_Call) Run(run func(ctx context.Context)) *Client_ActionHistory_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Client_ActionHistory_Call) Return(_a0 []client.HandledAction, _a1 error) *Client_ActionHistory_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_ActionHistory_Call) RunAndReturn(run func(context.Context) ([]client.HandledAction, error)) *Client_ActionHistory_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// CancelAction provides a mock function with given fields: ctx, actionID
+func (_m *Client) CancelAction(ctx context.Context, actionID string) (int, error) {
+	ret := _m.Called(ctx, actionID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for CancelAction")
+	}
+
+	var r0 int
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) (int, error)); ok {
+		return rf(ctx, actionID)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string) int); ok {
+		r0 = rf(ctx, actionID)
+	} else {
+		r0 = ret.Get(0).(int)
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string) error); ok {
+		r1 = rf(ctx, actionID)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_CancelAction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'CancelAction'
+type Client_CancelAction_Call struct {
+	*mock.Call
+}
+
+// CancelAction is a helper method to define mock.On call
+//   - ctx context.Context
+//   - actionID string
+func (_e *Client_Expecter) CancelAction(ctx interface{}, actionID interface{}) *Client_CancelAction_Call {
+	return &Client_CancelAction_Call{Call: _e.mock.On("CancelAction", ctx, actionID)}
+}
+
+func (_c *Client_CancelAction_Call) Run(run func(ctx context.Context, actionID string)) *Client_CancelAction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_CancelAction_Call) Return(_a0 int, _a1 error) *Client_CancelAction_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_CancelAction_Call) RunAndReturn(run func(context.Context, string) (int, error)) *Client_CancelAction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ComponentAction provides a mock function with given fields: ctx, componentID, unitID, name, params
+func (_m *Client) ComponentAction(ctx context.Context, componentID string, unitID string, name string, params map[string]interface{}) (map[string]interface{}, error) {
+	ret := _m.Called(ctx, componentID, unitID, name, params)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ComponentAction")
+	}
+
+	var r0 map[string]interface{}
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]interface{}) (map[string]interface{}, error)); ok {
+		return rf(ctx, componentID, unitID, name, params)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, map[string]interface{}) map[string]interface{}); ok {
+		r0 = rf(ctx, componentID, unitID, name, params)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(map[string]interface{})
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, string, map[string]interface{}) error); ok {
+		r1 = rf(ctx, componentID, unitID, name, params)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_ComponentAction_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ComponentAction'
+type Client_ComponentAction_Call struct {
+	*mock.Call
+}
+
+// ComponentAction is a helper method to define mock.On call
+//   - ctx context.Context
+//   - componentID string
+//   - unitID string
+//   - name string
+//   - params map[string]interface{}
+func (_e *Client_Expecter) ComponentAction(ctx interface{}, componentID interface{}, unitID interface{}, name interface{}, params interface{}) *Client_ComponentAction_Call {
+	return &Client_ComponentAction_Call{Call: _e.mock.On("ComponentAction", ctx, componentID, unitID, name, params)}
+}
+
+func (_c *Client_ComponentAction_Call) Run(run func(ctx context.Context, componentID string, unitID string, name string, params map[string]interface{})) *Client_ComponentAction_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(map[string]interface{}))
+	})
+	return _c
+}
+
+func (_c *Client_ComponentAction_Call) Return(_a0 map[string]interface{}, _a1 error) *Client_ComponentAction_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_ComponentAction_Call) RunAndReturn(run func(context.Context, string, string, string, map[string]interface{}) (map[string]interface{}, error)) *Client_ComponentAction_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Configure provides a mock function with given fields: ctx, config
 func (_m *Client) Configure(ctx context.Context, config string) error {
 	ret := _m.Called(ctx, config)
@@ -377,6 +556,170 @@ func (_c *Client_Disconnect_Call) RunAndReturn(run func()) *Client_Disconnect_Ca
 	return _c
 }
 
+// Events provides a mock function with given fields: ctx, req
+func (_m *Client) Events(ctx context.Context, req client.EventsRequest) (client.ClientEvents, error) {
+	ret := _m.Called(ctx, req)
+
+	if len(ret) == 0 {
+		panic("no return value specified for Events")
+	}
+
+	var r0 client.ClientEvents
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, client.EventsRequest) (client.ClientEvents, error)); ok {
+		return rf(ctx, req)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, client.EventsRequest) client.ClientEvents); ok {
+		r0 = rf(ctx, req)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).(client.ClientEvents)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, client.EventsRequest) error); ok {
+		r1 = rf(ctx, req)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_Events_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'Events'
+type Client_Events_Call struct {
+	*mock.Call
+}
+
+// Events is a helper method to define mock.On call
+//   - ctx context.Context
+//   - req client.EventsRequest
+func (_e *Client_Expecter) Events(ctx interface{}, req interface{}) *Client_Events_Call {
+	return &Client_Events_Call{Call: _e.mock.On("Events", ctx, req)}
+}
+
+func (_c *Client_Events_Call) Run(run func(ctx context.Context, req client.EventsRequest)) *Client_Events_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(client.EventsRequest))
+	})
+	return _c
+}
+
+func (_c *Client_Events_Call) Return(_a0 client.ClientEvents, _a1 error) *Client_Events_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_Events_Call) RunAndReturn(run func(context.Context, client.EventsRequest) (client.ClientEvents, error)) *Client_Events_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ListActions provides a mock function with given fields: ctx
+func (_m *Client) ListActions(ctx context.Context) ([]client.QueuedAction, error) {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ListActions")
+	}
+
+	var r0 []client.QueuedAction
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context) ([]client.QueuedAction, error)); ok {
+		return rf(ctx)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context) []client.QueuedAction); ok {
+		r0 = rf(ctx)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]client.QueuedAction)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context) error); ok {
+		r1 = rf(ctx)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_ListActions_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ListActions'
+type Client_ListActions_Call struct {
+	*mock.Call
+}
+
+// ListActions is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Client_Expecter) ListActions(ctx interface{}) *Client_ListActions_Call {
+	return &Client_ListActions_Call{Call: _e.mock.On("ListActions", ctx)}
+}
+
+func (_c *Client_ListActions_Call) Run(run func(ctx context.Context)) *Client_ListActions_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Client_ListActions_Call) Return(_a0 []client.QueuedAction, _a1 error) *Client_ListActions_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_ListActions_Call) RunAndReturn(run func(context.Context) ([]client.QueuedAction, error)) *Client_ListActions_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// PauseComponent provides a mock function with given fields: ctx, componentID
+func (_m *Client) PauseComponent(ctx context.Context, componentID string) error {
+	ret := _m.Called(ctx, componentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for PauseComponent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, componentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_PauseComponent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'PauseComponent'
+type Client_PauseComponent_Call struct {
+	*mock.Call
+}
+
+// PauseComponent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - componentID string
+func (_e *Client_Expecter) PauseComponent(ctx interface{}, componentID interface{}) *Client_PauseComponent_Call {
+	return &Client_PauseComponent_Call{Call: _e.mock.On("PauseComponent", ctx, componentID)}
+}
+
+func (_c *Client_PauseComponent_Call) Run(run func(ctx context.Context, componentID string)) *Client_PauseComponent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_PauseComponent_Call) Return(_a0 error) *Client_PauseComponent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_PauseComponent_Call) RunAndReturn(run func(context.Context, string) error) *Client_PauseComponent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Restart provides a mock function with given fields: ctx
 func (_m *Client) Restart(ctx context.Context) error {
 	ret := _m.Called(ctx)
@@ -423,6 +766,196 @@ func (_c *Client_Restart_Call) RunAndReturn(run func(context.Context) error) *Cl
 	return _c
 }
 
+// RestartComponent provides a mock function with given fields: ctx, componentID
+func (_m *Client) RestartComponent(ctx context.Context, componentID string) error {
+	ret := _m.Called(ctx, componentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RestartComponent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, componentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_RestartComponent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RestartComponent'
+type Client_RestartComponent_Call struct {
+	*mock.Call
+}
+
+// RestartComponent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - componentID string
+func (_e *Client_Expecter) RestartComponent(ctx interface{}, componentID interface{}) *Client_RestartComponent_Call {
+	return &Client_RestartComponent_Call{Call: _e.mock.On("RestartComponent", ctx, componentID)}
+}
+
+func (_c *Client_RestartComponent_Call) Run(run func(ctx context.Context, componentID string)) *Client_RestartComponent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_RestartComponent_Call) Return(_a0 error) *Client_RestartComponent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_RestartComponent_Call) RunAndReturn(run func(context.Context, string) error) *Client_RestartComponent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// ResumeComponent provides a mock function with given fields: ctx, componentID
+func (_m *Client) ResumeComponent(ctx context.Context, componentID string) error {
+	ret := _m.Called(ctx, componentID)
+
+	if len(ret) == 0 {
+		panic("no return value specified for ResumeComponent")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string) error); ok {
+		r0 = rf(ctx, componentID)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_ResumeComponent_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'ResumeComponent'
+type Client_ResumeComponent_Call struct {
+	*mock.Call
+}
+
+// ResumeComponent is a helper method to define mock.On call
+//   - ctx context.Context
+//   - componentID string
+func (_e *Client_Expecter) ResumeComponent(ctx interface{}, componentID interface{}) *Client_ResumeComponent_Call {
+	return &Client_ResumeComponent_Call{Call: _e.mock.On("ResumeComponent", ctx, componentID)}
+}
+
+func (_c *Client_ResumeComponent_Call) Run(run func(ctx context.Context, componentID string)) *Client_ResumeComponent_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string))
+	})
+	return _c
+}
+
+func (_c *Client_ResumeComponent_Call) Return(_a0 error) *Client_ResumeComponent_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_ResumeComponent_Call) RunAndReturn(run func(context.Context, string) error) *Client_ResumeComponent_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// RotateVaultKey provides a mock function with given fields: ctx
+func (_m *Client) RotateVaultKey(ctx context.Context) error {
+	ret := _m.Called(ctx)
+
+	if len(ret) == 0 {
+		panic("no return value specified for RotateVaultKey")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context) error); ok {
+		r0 = rf(ctx)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_RotateVaultKey_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'RotateVaultKey'
+type Client_RotateVaultKey_Call struct {
+	*mock.Call
+}
+
+// RotateVaultKey is a helper method to define mock.On call
+//   - ctx context.Context
+func (_e *Client_Expecter) RotateVaultKey(ctx interface{}) *Client_RotateVaultKey_Call {
+	return &Client_RotateVaultKey_Call{Call: _e.mock.On("RotateVaultKey", ctx)}
+}
+
+func (_c *Client_RotateVaultKey_Call) Run(run func(ctx context.Context)) *Client_RotateVaultKey_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context))
+	})
+	return _c
+}
+
+func (_c *Client_RotateVaultKey_Call) Return(_a0 error) *Client_RotateVaultKey_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_RotateVaultKey_Call) RunAndReturn(run func(context.Context) error) *Client_RotateVaultKey_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
+// SetComponentLogLevel provides a mock function with given fields: ctx, componentID, unitID, level, ttl
+func (_m *Client) SetComponentLogLevel(ctx context.Context, componentID string, unitID string, level string, ttl time.Duration) error {
+	ret := _m.Called(ctx, componentID, unitID, level, ttl)
+
+	if len(ret) == 0 {
+		panic("no return value specified for SetComponentLogLevel")
+	}
+
+	var r0 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, string, time.Duration) error); ok {
+		r0 = rf(ctx, componentID, unitID, level, ttl)
+	} else {
+		r0 = ret.Error(0)
+	}
+
+	return r0
+}
+
+// Client_SetComponentLogLevel_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'SetComponentLogLevel'
+type Client_SetComponentLogLevel_Call struct {
+	*mock.Call
+}
+
+// SetComponentLogLevel is a helper method to define mock.On call
+//   - ctx context.Context
+//   - componentID string
+//   - unitID string
+//   - level string
+//   - ttl time.Duration
+func (_e *Client_Expecter) SetComponentLogLevel(ctx interface{}, componentID interface{}, unitID interface{}, level interface{}, ttl interface{}) *Client_SetComponentLogLevel_Call {
+	return &Client_SetComponentLogLevel_Call{Call: _e.mock.On("SetComponentLogLevel", ctx, componentID, unitID, level, ttl)}
+}
+
+func (_c *Client_SetComponentLogLevel_Call) Run(run func(ctx context.Context, componentID string, unitID string, level string, ttl time.Duration)) *Client_SetComponentLogLevel_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(string), args[4].(time.Duration))
+	})
+	return _c
+}
+
+func (_c *Client_SetComponentLogLevel_Call) Return(_a0 error) *Client_SetComponentLogLevel_Call {
+	_c.Call.Return(_a0)
+	return _c
+}
+
+func (_c *Client_SetComponentLogLevel_Call) RunAndReturn(run func(context.Context, string, string, string, time.Duration) error) *Client_SetComponentLogLevel_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // State provides a mock function with given fields: ctx
 func (_m *Client) State(ctx context.Context) (*client.AgentState, error) {
 	ret := _m.Called(ctx)
@@ -614,6 +1147,69 @@ func (_c *Client_Upgrade_Call) RunAndReturn(run func(context.Context, string, st
 	return _c
 }
 
+// UpgradeDryRun provides a mock function with given fields: ctx, version, sourceURI, skipVerify, skipDefaultPgp, pgpBytes
+func (_m *Client) UpgradeDryRun(ctx context.Context, version string, sourceURI string, skipVerify bool, skipDefaultPgp bool, pgpBytes []string) ([]client.UpgradePreflightCheck, error) {
+	ret := _m.Called(ctx, version, sourceURI, skipVerify, skipDefaultPgp, pgpBytes)
+
+	if len(ret) == 0 {
+		panic("no return value specified for UpgradeDryRun")
+	}
+
+	var r0 []client.UpgradePreflightCheck
+	var r1 error
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, bool, []string) ([]client.UpgradePreflightCheck, error)); ok {
+		return rf(ctx, version, sourceURI, skipVerify, skipDefaultPgp, pgpBytes)
+	}
+	if rf, ok := ret.Get(0).(func(context.Context, string, string, bool, bool, []string) []client.UpgradePreflightCheck); ok {
+		r0 = rf(ctx, version, sourceURI, skipVerify, skipDefaultPgp, pgpBytes)
+	} else {
+		if ret.Get(0) != nil {
+			r0 = ret.Get(0).([]client.UpgradePreflightCheck)
+		}
+	}
+
+	if rf, ok := ret.Get(1).(func(context.Context, string, string, bool, bool, []string) error); ok {
+		r1 = rf(ctx, version, sourceURI, skipVerify, skipDefaultPgp, pgpBytes)
+	} else {
+		r1 = ret.Error(1)
+	}
+
+	return r0, r1
+}
+
+// Client_UpgradeDryRun_Call is a *mock.Call that shadows Run/Return methods with type explicit version for method 'UpgradeDryRun'
+type Client_UpgradeDryRun_Call struct {
+	*mock.Call
+}
+
+// UpgradeDryRun is a helper method to define mock.On call
+//   - ctx context.Context
+//   - version string
+//   - sourceURI string
+//   - skipVerify bool
+//   - skipDefaultPgp bool
+//   - pgpBytes []string
+func (_e *Client_Expecter) UpgradeDryRun(ctx interface{}, version interface{}, sourceURI interface{}, skipVerify interface{}, skipDefaultPgp interface{}, pgpBytes interface{}) *Client_UpgradeDryRun_Call {
+	return &Client_UpgradeDryRun_Call{Call: _e.mock.On("UpgradeDryRun", ctx, version, sourceURI, skipVerify, skipDefaultPgp, pgpBytes)}
+}
+
+func (_c *Client_UpgradeDryRun_Call) Run(run func(ctx context.Context, version string, sourceURI string, skipVerify bool, skipDefaultPgp bool, pgpBytes []string)) *Client_UpgradeDryRun_Call {
+	_c.Call.Run(func(args mock.Arguments) {
+		run(args[0].(context.Context), args[1].(string), args[2].(string), args[3].(bool), args[4].(bool), args[5].([]string))
+	})
+	return _c
+}
+
+func (_c *Client_UpgradeDryRun_Call) Return(_a0 []client.UpgradePreflightCheck, _a1 error) *Client_UpgradeDryRun_Call {
+	_c.Call.Return(_a0, _a1)
+	return _c
+}
+
+func (_c *Client_UpgradeDryRun_Call) RunAndReturn(run func(context.Context, string, string, bool, bool, []string) ([]client.UpgradePreflightCheck, error)) *Client_UpgradeDryRun_Call {
+	_c.Call.Return(run)
+	return _c
+}
+
 // Version provides a mock function with given fields: ctx
 func (_m *Client) Version(ctx context.Context) (client.Version, error) {
 	ret := _m.Called(ctx)